@@ -120,10 +120,73 @@ func (c *CartController) AddItem(ctx *gin.Context) {
 	}
 
 	// 调用service添加或更新
-	if err := c.service.AddItem(ctx.Request.Context(), userID, req.ProductID, int32(req.Quantity)); err != nil {
+	if err := c.service.AddItem(ctx.Request.Context(), userID, req.ProductID, req.SkuID, int32(req.Quantity)); err != nil {
 		_ = ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, response.Success("添加(或更新)商品成功"))
 }
+
+// RemoveItem 从购物车中移除商品接口
+func (c *CartController) RemoveItem(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.RemoveCartItemReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	if err := c.service.RemoveItems(ctx.Request.Context(), userID, req.SkuIDs); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Success("商品已移除"))
+}
+
+// SetQuantity 设置购物车商品数量接口
+func (c *CartController) SetQuantity(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.SetCartItemQuantityReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	if err := c.service.SetQuantity(ctx.Request.Context(), userID, req.ProductID, req.SkuID, int32(req.Quantity)); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Success("商品数量已更新"))
+}
+
+// SelectItems 勾选/取消勾选购物车商品接口
+func (c *CartController) SelectItems(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.SelectCartItemsReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	if err := c.service.SelectItems(ctx.Request.Context(), userID, req.SkuIDs, req.Selected); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Success("勾选状态已更新"))
+}
@@ -0,0 +1,153 @@
+package v1
+
+import (
+	"douyin/pkg/utils/response"
+	"douyin/service"
+	"douyin/types"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"log"
+	"net/http"
+)
+
+// CategoryControllerType 封装商品分类操作
+type CategoryControllerType struct {
+	service *service.CategoryService
+}
+
+// CategoryController 是全局商品分类控制器实例，商品列表接口也通过它按分类筛选
+var CategoryController *CategoryControllerType
+
+// SetCategoryController 初始化商品分类控制器
+func SetCategoryController(db *gorm.DB) {
+	CategoryController = &CategoryControllerType{
+		service: service.NewCategoryService(db),
+	}
+	log.Println("CategoryController 初始化成功")
+}
+
+// categoryHandler 包装分类处理函数，控制器在路由注册之后才初始化，因此在请求时检查
+func categoryHandler(handle func(c *CategoryControllerType, ctx *gin.Context)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if CategoryController == nil || CategoryController.service == nil {
+			log.Println("CategoryController 或 CategoryService 未正确初始化")
+			_ = ctx.Error(errors.New("服务内部错误：分类服务未就绪"))
+			return
+		}
+		handle(CategoryController, ctx)
+	}
+}
+
+// CategoryTreeHandler 查询分类树的处理函数
+func CategoryTreeHandler() gin.HandlerFunc {
+	return categoryHandler((*CategoryControllerType).Tree)
+}
+
+// AdminCategoryCreateHandler 管理员创建分类的处理函数
+func AdminCategoryCreateHandler() gin.HandlerFunc {
+	return categoryHandler((*CategoryControllerType).CreateCategory)
+}
+
+// AdminCategoryUpdateHandler 管理员修改分类的处理函数
+func AdminCategoryUpdateHandler() gin.HandlerFunc {
+	return categoryHandler((*CategoryControllerType).UpdateCategory)
+}
+
+// AdminCategoryDeleteHandler 管理员删除分类的处理函数
+func AdminCategoryDeleteHandler() gin.HandlerFunc {
+	return categoryHandler((*CategoryControllerType).DeleteCategory)
+}
+
+// AdminProductCategoryAssignHandler 管理员设置商品分类的处理函数
+func AdminProductCategoryAssignHandler() gin.HandlerFunc {
+	return categoryHandler((*CategoryControllerType).AssignProduct)
+}
+
+// Tree 查询完整的分类树
+func (c *CategoryControllerType) Tree(ctx *gin.Context) {
+	resp, err := c.service.Tree(ctx.Request.Context())
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// CreateCategory 管理员创建分类
+func (c *CategoryControllerType) CreateCategory(ctx *gin.Context) {
+	var req types.CategoryCreateReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.CreateCategory(ctx.Request.Context(), &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// UpdateCategory 管理员修改分类名称、排序或父分类
+func (c *CategoryControllerType) UpdateCategory(ctx *gin.Context) {
+	var req types.CategoryUpdateReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.UpdateCategory(ctx.Request.Context(), &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// DeleteCategory 管理员删除分类
+func (c *CategoryControllerType) DeleteCategory(ctx *gin.Context) {
+	var req types.CategoryDeleteReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	if err := c.service.DeleteCategory(ctx.Request.Context(), req.ID); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(nil))
+}
+
+// AssignProduct 管理员设置商品所属的分类
+func (c *CategoryControllerType) AssignProduct(ctx *gin.Context) {
+	var req types.ProductCategoryAssignReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	if err := c.service.AssignProduct(ctx.Request.Context(), &req); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(nil))
+}
+
+// productCategoryScope 返回按分类筛选商品时使用的分类及其子孙分类ID，categoryID 为 0 或分类控制器未初始化时不筛选
+func productCategoryScope(ctx *gin.Context, categoryID uint) ([]uint, error) {
+	if categoryID == 0 || CategoryController == nil {
+		return nil, nil
+	}
+	return CategoryController.service.Descendants(ctx.Request.Context(), categoryID)
+}
+
+// fillProductCategories 为商品填充分类名称，分类控制器未初始化时跳过
+func fillProductCategories(ctx *gin.Context, products []types.Product) {
+	if CategoryController == nil {
+		return
+	}
+	CategoryController.service.FillProductCategories(ctx.Request.Context(), products)
+}
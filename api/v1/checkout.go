@@ -2,7 +2,7 @@
 package v1
 
 import (
-	"douyin/pkg/utils/jwt"
+	"douyin/pkg/error_code"
 	"douyin/pkg/utils/response"
 	"douyin/service"
 	"douyin/types"
@@ -29,28 +29,37 @@ func NewCheckoutController(db *gorm.DB) *CheckoutController {
 
 // CheckoutOrder 订单结算接口
 func (c *CheckoutController) CheckoutOrder(ctx *gin.Context) {
-	// 验证JWT token
-	userID, err := jwt.ValidateJWT(ctx)
-	if err != nil {
-		_ = ctx.Error(errors.New("未授权"))
+	userIDVal, exists := ctx.Get("user_id")
+	if !exists {
+		_ = ctx.Error(errors.New("用户未授权或user_id未在context中设置"))
+		return
+	}
+	userID, ok := userIDVal.(uint)
+	if !ok {
+		_ = ctx.Error(errors.New("user_id在context中的类型错误"))
 		return
 	}
 
-	// 修改处：使用 types.CreateOrderReq 代替 types.CheckoutReq
-	var req types.CreateOrderReq
+	var req types.CheckoutReq
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
 		return
 	}
 
-	// 进行结算
-	transactionID, err := c.service.CheckoutOrder(userID, &req)
+	// 进行结算：购物车商品、订单、库存与支付单在同一事务中处理
+	resp, err := c.service.CheckoutOrder(ctx.Request.Context(), userID, &req)
 	if err != nil {
+		// 购物车商品已变更时返回 409 与变动明细，客户端提示用户确认后携带 confirm_changes 重新结算；
+		// 仍记录 error，使幂等中间件丢弃本次结果，允许用同一幂等键确认后重试
+		var changed *service.CartChangedError
+		if errors.As(err, &changed) {
+			ctx.AbortWithStatusJSON(http.StatusConflict, response.FailWithData(error_code.ErrorCartChanged, changed.Error(), changed.Warnings))
+		}
 		_ = ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, response.Success(gin.H{"transaction_id": transactionID}))
+	ctx.JSON(http.StatusOK, response.Success(resp))
 }
 
 // CheckoutOrderHandler
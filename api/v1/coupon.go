@@ -0,0 +1,191 @@
+package v1
+
+import (
+	"douyin/consts"
+	"douyin/pkg/utils/response"
+	"douyin/service"
+	"douyin/types"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"log"
+	"net/http"
+)
+
+// CouponControllerType 封装优惠券操作
+type CouponControllerType struct {
+	service *service.CouponService
+}
+
+// CouponController 是全局优惠券控制器实例
+var CouponController *CouponControllerType
+
+// SetCouponController 初始化优惠券控制器
+func SetCouponController(db *gorm.DB) {
+	CouponController = &CouponControllerType{
+		service: service.NewCouponService(db),
+	}
+	log.Println("CouponController 初始化成功")
+}
+
+// couponHandler 包装优惠券处理函数，控制器在路由注册之后才初始化，因此在请求时检查
+func couponHandler(handle func(c *CouponControllerType, ctx *gin.Context)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if CouponController == nil || CouponController.service == nil {
+			log.Println("CouponController 或 CouponService 未正确初始化")
+			_ = ctx.Error(errors.New("服务内部错误：优惠券服务未就绪"))
+			return
+		}
+		handle(CouponController, ctx)
+	}
+}
+
+// CouponListHandler 查询可领取优惠券的处理函数
+func CouponListHandler() gin.HandlerFunc {
+	return couponHandler((*CouponControllerType).ListClaimable)
+}
+
+// CouponClaimHandler 领取优惠券的处理函数
+func CouponClaimHandler() gin.HandlerFunc {
+	return couponHandler((*CouponControllerType).Claim)
+}
+
+// CouponMineHandler 查询我的优惠券的处理函数
+func CouponMineHandler() gin.HandlerFunc {
+	return couponHandler((*CouponControllerType).ListMine)
+}
+
+// AdminCouponCreateHandler 管理员创建优惠券模板的处理函数
+func AdminCouponCreateHandler() gin.HandlerFunc {
+	return couponHandler((*CouponControllerType).CreateTemplate)
+}
+
+// AdminCouponListHandler 管理员查询优惠券模板列表的处理函数
+func AdminCouponListHandler() gin.HandlerFunc {
+	return couponHandler((*CouponControllerType).AdminListTemplates)
+}
+
+// AdminCouponStatusHandler 管理员启用/停用优惠券模板的处理函数
+func AdminCouponStatusHandler() gin.HandlerFunc {
+	return couponHandler((*CouponControllerType).SetTemplateEnabled)
+}
+
+// ListClaimable 分页查询当前可领取的优惠券
+func (c *CouponControllerType) ListClaimable(ctx *gin.Context) {
+	c.listTemplates(ctx, true)
+}
+
+// AdminListTemplates 管理员分页查询全部优惠券模板
+func (c *CouponControllerType) AdminListTemplates(ctx *gin.Context) {
+	c.listTemplates(ctx, false)
+}
+
+func (c *CouponControllerType) listTemplates(ctx *gin.Context, claimableOnly bool) {
+	var req types.CouponTemplateListReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+	if req.PageNum <= 0 {
+		req.PageNum = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = consts.BasePageSize
+	}
+
+	resp, err := c.service.ListTemplates(ctx.Request.Context(), &req, claimableOnly)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// Claim 用户领取优惠券
+func (c *CouponControllerType) Claim(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.CouponClaimReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.Claim(ctx.Request.Context(), userID, req.TemplateID)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// ListMine 分页查询当前用户的优惠券
+func (c *CouponControllerType) ListMine(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.UserCouponListReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+	if req.PageNum <= 0 {
+		req.PageNum = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = consts.BasePageSize
+	}
+
+	resp, err := c.service.ListUserCoupons(ctx.Request.Context(), userID, &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// CreateTemplate 管理员创建优惠券模板
+func (c *CouponControllerType) CreateTemplate(ctx *gin.Context) {
+	adminID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.CouponTemplateCreateReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.CreateTemplate(ctx.Request.Context(), adminID, &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// SetTemplateEnabled 管理员启用或停用优惠券模板
+func (c *CouponControllerType) SetTemplateEnabled(ctx *gin.Context) {
+	adminID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.CouponTemplateStatusReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	if err := c.service.SetTemplateEnabled(ctx.Request.Context(), adminID, &req); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(nil))
+}
@@ -0,0 +1,109 @@
+package v1
+
+import (
+	"douyin/config"
+	"douyin/pkg/utils/response"
+	"douyin/service"
+	"douyin/types"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+// 声明全局汇率控制器变量，供路由包装函数和商品接口调用
+var exchangeRateController *ExchangeRateController
+
+// ExchangeRateController 汇率控制器
+type ExchangeRateController struct {
+	service *service.ExchangeRateService
+}
+
+// NewExchangeRateController 创建新的 ExchangeRateController 实例
+func NewExchangeRateController(db *gorm.DB) *ExchangeRateController {
+	return &ExchangeRateController{
+		service: service.NewExchangeRateService(db),
+	}
+}
+
+// ListRates 查询基础币种及全部汇率
+func (c *ExchangeRateController) ListRates(ctx *gin.Context) {
+	resp, err := c.service.ListRates(ctx.Request.Context())
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// UpdateRates 管理员批量更新汇率
+func (c *ExchangeRateController) UpdateRates(ctx *gin.Context) {
+	adminID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.ExchangeRateUpdateReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	if err := c.service.UpdateRates(ctx.Request.Context(), adminID, &req); err != nil {
+		if errors.Is(err, service.ErrUnsupportedCurrency) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, err.Error()))
+			return
+		}
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(nil))
+}
+
+// ReloadRates 管理员从配置的本地文件重新导入汇率
+func (c *ExchangeRateController) ReloadRates(ctx *gin.Context) {
+	path := config.GetExchangeRatesFile()
+	if path == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "未配置汇率文件"))
+		return
+	}
+	count, err := c.service.ImportFile(ctx.Request.Context(), path)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(gin.H{"imported": count}))
+}
+
+// displayQuote 确定商品价格的展示汇率：请求参数指定的币种优先，其次为登录用户的偏好币种
+// 控制器未初始化时返回 nil，即按基础币种展示
+func displayQuote(ctx *gin.Context, requested string) (*service.ExchangeQuote, error) {
+	if exchangeRateController == nil {
+		return nil, nil
+	}
+	var userID uint
+	if v, exists := ctx.Get("user_id"); exists {
+		userID, _ = v.(uint)
+	}
+	return exchangeRateController.service.DisplayQuote(ctx.Request.Context(), userID, requested)
+}
+
+// ExchangeRateListHandler
+func ExchangeRateListHandler() gin.HandlerFunc {
+	return exchangeRateController.ListRates
+}
+
+// AdminExchangeRateUpdateHandler
+func AdminExchangeRateUpdateHandler() gin.HandlerFunc {
+	return exchangeRateController.UpdateRates
+}
+
+// AdminExchangeRateReloadHandler
+func AdminExchangeRateReloadHandler() gin.HandlerFunc {
+	return exchangeRateController.ReloadRates
+}
+
+// SetExchangeRateController
+func SetExchangeRateController(db *gorm.DB) {
+	exchangeRateController = NewExchangeRateController(db)
+}
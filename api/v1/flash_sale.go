@@ -0,0 +1,185 @@
+package v1
+
+import (
+	"douyin/consts"
+	"douyin/pkg/utils/response"
+	"douyin/service"
+	"douyin/types"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"log"
+	"net/http"
+)
+
+// FlashSaleControllerType 封装秒杀操作
+type FlashSaleControllerType struct {
+	service *service.FlashSaleService
+}
+
+// FlashSaleController 是全局秒杀控制器实例
+var FlashSaleController *FlashSaleControllerType
+
+// SetFlashSaleController 初始化秒杀控制器
+func SetFlashSaleController(db *gorm.DB) {
+	FlashSaleController = &FlashSaleControllerType{
+		service: service.NewFlashSaleService(db),
+	}
+	log.Println("FlashSaleController 初始化成功")
+}
+
+// flashSaleHandler 包装秒杀处理函数，控制器在路由注册之后才初始化，因此在请求时检查
+func flashSaleHandler(handle func(c *FlashSaleControllerType, ctx *gin.Context)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if FlashSaleController == nil || FlashSaleController.service == nil {
+			log.Println("FlashSaleController 或 FlashSaleService 未正确初始化")
+			_ = ctx.Error(errors.New("服务内部错误：秒杀服务未就绪"))
+			return
+		}
+		handle(FlashSaleController, ctx)
+	}
+}
+
+// FlashSaleListHandler 查询进行中及即将开始的秒杀场次的处理函数
+func FlashSaleListHandler() gin.HandlerFunc {
+	return flashSaleHandler((*FlashSaleControllerType).ListActive)
+}
+
+// FlashSaleBuyHandler 秒杀抢购的处理函数
+func FlashSaleBuyHandler() gin.HandlerFunc {
+	return flashSaleHandler((*FlashSaleControllerType).Buy)
+}
+
+// FlashSaleResultHandler 查询秒杀结果的处理函数
+func FlashSaleResultHandler() gin.HandlerFunc {
+	return flashSaleHandler((*FlashSaleControllerType).Result)
+}
+
+// AdminFlashSaleCreateHandler 管理员创建秒杀场次的处理函数
+func AdminFlashSaleCreateHandler() gin.HandlerFunc {
+	return flashSaleHandler((*FlashSaleControllerType).CreateSession)
+}
+
+// AdminFlashSaleListHandler 管理员查询秒杀场次列表的处理函数
+func AdminFlashSaleListHandler() gin.HandlerFunc {
+	return flashSaleHandler((*FlashSaleControllerType).AdminListSessions)
+}
+
+// AdminFlashSaleStatusHandler 管理员启用/停用秒杀场次的处理函数
+func AdminFlashSaleStatusHandler() gin.HandlerFunc {
+	return flashSaleHandler((*FlashSaleControllerType).SetSessionEnabled)
+}
+
+// ListActive 分页查询进行中及即将开始的秒杀场次
+func (c *FlashSaleControllerType) ListActive(ctx *gin.Context) {
+	c.listSessions(ctx, true)
+}
+
+// AdminListSessions 管理员分页查询全部秒杀场次
+func (c *FlashSaleControllerType) AdminListSessions(ctx *gin.Context) {
+	c.listSessions(ctx, false)
+}
+
+func (c *FlashSaleControllerType) listSessions(ctx *gin.Context, activeOnly bool) {
+	var req types.FlashSaleListReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+	if req.PageNum <= 0 {
+		req.PageNum = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = consts.BasePageSize
+	}
+
+	resp, err := c.service.ListSessions(ctx.Request.Context(), &req, activeOnly)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// Buy 用户抢购，抢到名额后返回 PENDING，订单异步创建
+func (c *FlashSaleControllerType) Buy(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.FlashSaleBuyReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.Buy(ctx.Request.Context(), userID, &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// Result 用户轮询抢购结果
+func (c *FlashSaleControllerType) Result(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.FlashSaleResultReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.Result(ctx.Request.Context(), userID, req.SessionID)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// CreateSession 管理员创建秒杀场次
+func (c *FlashSaleControllerType) CreateSession(ctx *gin.Context) {
+	adminID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.FlashSaleCreateReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.CreateSession(ctx.Request.Context(), adminID, &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// SetSessionEnabled 管理员启用或停用秒杀场次
+func (c *FlashSaleControllerType) SetSessionEnabled(ctx *gin.Context) {
+	adminID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.FlashSaleStatusReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	if err := c.service.SetSessionEnabled(ctx.Request.Context(), adminID, &req); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(nil))
+}
@@ -0,0 +1,188 @@
+package v1
+
+import (
+	"douyin/config"
+	"douyin/consts"
+	"douyin/middleware"
+	"douyin/pkg/utils/log"
+	"douyin/pkg/utils/response"
+	"douyin/service"
+	"douyin/types"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 声明全局游客购物车控制器变量，供路由包装函数及登录接口调用
+var guestCartController *GuestCartController
+
+// GuestCartController 游客购物车控制器，购物车令牌保存在签名 Cookie 中
+type GuestCartController struct {
+	service *service.GuestCartService
+}
+
+// NewGuestCartController 创建新的 GuestCartController 实例
+func NewGuestCartController(db *gorm.DB) *GuestCartController {
+	return &GuestCartController{
+		service: service.NewGuestCartService(db),
+	}
+}
+
+// GetCart 获取游客购物车接口，没有 Cookie 时返回空购物车
+func (c *GuestCartController) GetCart(ctx *gin.Context) {
+	var req types.GuestCartGetReq
+	if err := ctx.ShouldBindJSON(&req); err != nil && ctx.Request.ContentLength > 0 {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	token, _ := guestCartToken(ctx)
+	cart, err := c.service.GetCart(ctx.Request.Context(), token, req.Currency)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Success(cart))
+}
+
+// AddItem 往游客购物车中添加商品接口，首次加购时签发购物车 Cookie
+func (c *GuestCartController) AddItem(ctx *gin.Context) {
+	var req types.AddItemReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	token, ok := guestCartToken(ctx)
+	if !ok {
+		if token, ok = issueGuestCartToken(ctx); !ok {
+			return
+		}
+	}
+	if err := c.service.AddItem(ctx.Request.Context(), token, req.ProductID, req.SkuID, int32(req.Quantity)); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Success("添加(或更新)商品成功"))
+}
+
+// SetQuantity 设置游客购物车商品数量接口
+func (c *GuestCartController) SetQuantity(ctx *gin.Context) {
+	var req types.SetCartItemQuantityReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	token, ok := guestCartToken(ctx)
+	if !ok {
+		if token, ok = issueGuestCartToken(ctx); !ok {
+			return
+		}
+	}
+	if err := c.service.SetQuantity(ctx.Request.Context(), token, req.ProductID, req.SkuID, int32(req.Quantity)); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Success("商品数量已更新"))
+}
+
+// RemoveItem 从游客购物车中移除商品接口
+func (c *GuestCartController) RemoveItem(ctx *gin.Context) {
+	var req types.RemoveCartItemReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	if token, ok := guestCartToken(ctx); ok {
+		if err := c.service.RemoveItems(ctx.Request.Context(), token, req.SkuIDs); err != nil {
+			_ = ctx.Error(err)
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, response.Success("商品已移除"))
+}
+
+// EmptyCart 清空游客购物车接口
+func (c *GuestCartController) EmptyCart(ctx *gin.Context) {
+	if token, ok := guestCartToken(ctx); ok {
+		if err := c.service.EmptyCart(ctx.Request.Context(), token); err != nil {
+			_ = ctx.Error(err)
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, response.Success("购物车已清空"))
+}
+
+// mergeGuestCart 登录成功后合并游客购物车并清除 Cookie；合并失败只记录日志，不影响登录，Cookie 保留以便下次登录重试
+func mergeGuestCart(ctx *gin.Context, userID uint) *types.CartMergeResp {
+	token, ok := guestCartToken(ctx)
+	if !ok || guestCartController == nil {
+		return nil
+	}
+	resp, err := guestCartController.service.MergeIntoUser(ctx.Request.Context(), token, userID)
+	if err != nil {
+		log.Errorf("合并游客购物车失败 (userID: %d): %v", userID, err)
+		return nil
+	}
+	ctx.SetCookie(consts.GuestCartCookie, "", -1, "/", "", middleware.IsHttps(ctx), true)
+	return resp
+}
+
+// guestCartToken 从 Cookie 中读取并校验游客购物车令牌
+func guestCartToken(ctx *gin.Context) (string, bool) {
+	cookie, err := ctx.Cookie(consts.GuestCartCookie)
+	if err != nil || cookie == "" {
+		return "", false
+	}
+	return service.ParseGuestCartToken(cookie)
+}
+
+// issueGuestCartToken 签发新的游客购物车令牌并写入 Cookie，有效期与 Redis 中的保留时长一致
+func issueGuestCartToken(ctx *gin.Context) (string, bool) {
+	token, cookie, err := service.NewGuestCartToken()
+	if err != nil {
+		_ = ctx.Error(err)
+		return "", false
+	}
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(consts.GuestCartCookie, cookie, int(config.GetGuestCartTTL().Seconds()), "/", "", middleware.IsHttps(ctx), true)
+	return token, true
+}
+
+// GuestCartGetHandler
+func GuestCartGetHandler() gin.HandlerFunc {
+	return guestCartController.GetCart
+}
+
+// GuestCartAddHandler
+func GuestCartAddHandler() gin.HandlerFunc {
+	return guestCartController.AddItem
+}
+
+// GuestCartSetQuantityHandler
+func GuestCartSetQuantityHandler() gin.HandlerFunc {
+	return guestCartController.SetQuantity
+}
+
+// GuestCartRemoveHandler
+func GuestCartRemoveHandler() gin.HandlerFunc {
+	return guestCartController.RemoveItem
+}
+
+// GuestCartEmptyHandler
+func GuestCartEmptyHandler() gin.HandlerFunc {
+	return guestCartController.EmptyCart
+}
+
+// SetGuestCartController
+func SetGuestCartController(db *gorm.DB) {
+	guestCartController = NewGuestCartController(db)
+}
@@ -0,0 +1,225 @@
+package v1
+
+import (
+	"douyin/consts"
+	"douyin/pkg/utils/response"
+	"douyin/service"
+	"douyin/types"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"log"
+	"net/http"
+)
+
+// InventoryControllerType 封装仓库与库存管理操作
+type InventoryControllerType struct {
+	service *service.InventoryService
+}
+
+// InventoryController 是全局库存控制器实例
+var InventoryController *InventoryControllerType
+
+// SetInventoryController 初始化库存控制器
+func SetInventoryController(db *gorm.DB) {
+	InventoryController = &InventoryControllerType{
+		service: service.NewInventoryService(db),
+	}
+	log.Println("InventoryController 初始化成功")
+}
+
+// inventoryHandler 包装库存处理函数，控制器在路由注册之后才初始化，因此在请求时检查
+func inventoryHandler(handle func(c *InventoryControllerType, ctx *gin.Context)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if InventoryController == nil || InventoryController.service == nil {
+			log.Println("InventoryController 或 InventoryService 未正确初始化")
+			_ = ctx.Error(errors.New("服务内部错误：库存服务未就绪"))
+			return
+		}
+		handle(InventoryController, ctx)
+	}
+}
+
+// AdminWarehouseCreateHandler 管理员创建仓库的处理函数
+func AdminWarehouseCreateHandler() gin.HandlerFunc {
+	return inventoryHandler((*InventoryControllerType).CreateWarehouse)
+}
+
+// AdminWarehouseUpdateHandler 管理员修改仓库的处理函数
+func AdminWarehouseUpdateHandler() gin.HandlerFunc {
+	return inventoryHandler((*InventoryControllerType).UpdateWarehouse)
+}
+
+// AdminWarehouseListHandler 管理员查询仓库列表的处理函数
+func AdminWarehouseListHandler() gin.HandlerFunc {
+	return inventoryHandler((*InventoryControllerType).ListWarehouses)
+}
+
+// AdminInventoryReceiveHandler 管理员入库的处理函数
+func AdminInventoryReceiveHandler() gin.HandlerFunc {
+	return inventoryHandler((*InventoryControllerType).Receive)
+}
+
+// AdminInventoryAdjustHandler 管理员盘点调整的处理函数
+func AdminInventoryAdjustHandler() gin.HandlerFunc {
+	return inventoryHandler((*InventoryControllerType).Adjust)
+}
+
+// AdminInventoryLevelListHandler 管理员查询库存水位的处理函数
+func AdminInventoryLevelListHandler() gin.HandlerFunc {
+	return inventoryHandler((*InventoryControllerType).ListLevels)
+}
+
+// AdminInventoryMovementListHandler 管理员查询库存流水的处理函数
+func AdminInventoryMovementListHandler() gin.HandlerFunc {
+	return inventoryHandler((*InventoryControllerType).ListMovements)
+}
+
+// AdminInventoryReconcileHandler 管理员库存对账的处理函数
+func AdminInventoryReconcileHandler() gin.HandlerFunc {
+	return inventoryHandler((*InventoryControllerType).Reconcile)
+}
+
+// CreateWarehouse 管理员创建仓库
+func (c *InventoryControllerType) CreateWarehouse(ctx *gin.Context) {
+	var req types.WarehouseCreateReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.CreateWarehouse(ctx.Request.Context(), &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// UpdateWarehouse 管理员修改仓库名称、地址、优先级或启用状态
+func (c *InventoryControllerType) UpdateWarehouse(ctx *gin.Context) {
+	var req types.WarehouseUpdateReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.UpdateWarehouse(ctx.Request.Context(), &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// ListWarehouses 管理员查询全部仓库
+func (c *InventoryControllerType) ListWarehouses(ctx *gin.Context) {
+	resp, err := c.service.ListWarehouses(ctx.Request.Context())
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// Receive 管理员办理入库
+func (c *InventoryControllerType) Receive(ctx *gin.Context) {
+	adminID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.InventoryReceiveReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.Receive(ctx.Request.Context(), adminID, &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// Adjust 管理员盘点调整在库数量
+func (c *InventoryControllerType) Adjust(ctx *gin.Context) {
+	adminID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.InventoryAdjustReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.Adjust(ctx.Request.Context(), adminID, &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// ListLevels 管理员分页查询库存水位
+func (c *InventoryControllerType) ListLevels(ctx *gin.Context) {
+	var req types.InventoryLevelListReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+	if req.PageNum <= 0 {
+		req.PageNum = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = consts.BasePageSize
+	}
+
+	resp, err := c.service.ListLevels(ctx.Request.Context(), &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// ListMovements 管理员分页查询库存流水
+func (c *InventoryControllerType) ListMovements(ctx *gin.Context) {
+	var req types.InventoryMovementListReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+	if req.PageNum <= 0 {
+		req.PageNum = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = consts.BasePageSize
+	}
+
+	resp, err := c.service.ListMovements(ctx.Request.Context(), &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// Reconcile 管理员发起库存对账
+func (c *InventoryControllerType) Reconcile(ctx *gin.Context) {
+	var req types.InventoryReconcileReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.Reconcile(ctx.Request.Context(), req.FixSkuStock)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
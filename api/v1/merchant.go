@@ -0,0 +1,299 @@
+package v1
+
+import (
+	"douyin/consts"
+	"douyin/pkg/utils/response"
+	"douyin/service"
+	"douyin/types"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"log"
+	"net/http"
+)
+
+// MerchantControllerType 封装商家店铺与结算相关操作
+type MerchantControllerType struct {
+	service *service.MerchantService
+}
+
+// MerchantController 是全局商家控制器实例
+var MerchantController *MerchantControllerType
+
+// SetMerchantController 初始化商家控制器
+func SetMerchantController(db *gorm.DB) {
+	MerchantController = &MerchantControllerType{
+		service: service.NewMerchantService(db),
+	}
+	log.Println("MerchantController 初始化成功")
+}
+
+// merchantHandler 包装商家处理函数，控制器在路由注册之后才初始化，因此在请求时检查
+func merchantHandler(handle func(c *MerchantControllerType, ctx *gin.Context)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if MerchantController == nil || MerchantController.service == nil {
+			log.Println("MerchantController 或 MerchantService 未正确初始化")
+			_ = ctx.Error(errors.New("服务内部错误：商家服务未就绪"))
+			return
+		}
+		handle(MerchantController, ctx)
+	}
+}
+
+// MerchantRegisterHandler 开设店铺的处理函数
+func MerchantRegisterHandler() gin.HandlerFunc {
+	return merchantHandler((*MerchantControllerType).Register)
+}
+
+// MerchantProfileHandler 查询店铺资料的处理函数
+func MerchantProfileHandler() gin.HandlerFunc {
+	return merchantHandler((*MerchantControllerType).Profile)
+}
+
+// MerchantUpdateHandler 修改店铺资料的处理函数
+func MerchantUpdateHandler() gin.HandlerFunc {
+	return merchantHandler((*MerchantControllerType).Update)
+}
+
+// MerchantProductListHandler 查询店铺商品的处理函数
+func MerchantProductListHandler() gin.HandlerFunc {
+	return merchantHandler((*MerchantControllerType).ProductList)
+}
+
+// MerchantOrderListHandler 查询店铺订单的处理函数
+func MerchantOrderListHandler() gin.HandlerFunc {
+	return merchantHandler((*MerchantControllerType).OrderList)
+}
+
+// MerchantOrderDetailHandler 查询店铺订单详情的处理函数
+func MerchantOrderDetailHandler() gin.HandlerFunc {
+	return merchantHandler((*MerchantControllerType).OrderDetail)
+}
+
+// MerchantSettlementListHandler 查询店铺结算明细的处理函数
+func MerchantSettlementListHandler() gin.HandlerFunc {
+	return merchantHandler((*MerchantControllerType).SettlementList)
+}
+
+// AdminMerchantListHandler 管理员查询商家列表的处理函数
+func AdminMerchantListHandler() gin.HandlerFunc {
+	return merchantHandler((*MerchantControllerType).AdminList)
+}
+
+// AdminMerchantStatusHandler 管理员启用/停用商家的处理函数
+func AdminMerchantStatusHandler() gin.HandlerFunc {
+	return merchantHandler((*MerchantControllerType).AdminStatus)
+}
+
+// AdminMerchantSettleHandler 管理员发起货款结算的处理函数
+func AdminMerchantSettleHandler() gin.HandlerFunc {
+	return merchantHandler((*MerchantControllerType).AdminSettle)
+}
+
+// Register 当前用户开设店铺
+func (c *MerchantControllerType) Register(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.MerchantRegisterReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.Register(ctx.Request.Context(), userID, &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// Profile 查询当前用户的店铺资料
+func (c *MerchantControllerType) Profile(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	resp, err := c.service.GetProfile(ctx.Request.Context(), userID)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// Update 修改当前用户的店铺资料
+func (c *MerchantControllerType) Update(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.MerchantUpdateReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.UpdateProfile(ctx.Request.Context(), userID, &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// ProductList 分页查询当前用户店铺的商品
+func (c *MerchantControllerType) ProductList(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.BasePage
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+	normalizePage(&req)
+
+	resp, err := c.service.ListProducts(ctx.Request.Context(), userID, &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// OrderList 分页查询包含当前用户店铺商品的订单
+func (c *MerchantControllerType) OrderList(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.MerchantOrderListReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+	normalizePage(&req.BasePage)
+
+	resp, err := c.service.ListOrders(ctx.Request.Context(), userID, &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// OrderDetail 查询包含当前用户店铺商品的订单详情
+func (c *MerchantControllerType) OrderDetail(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	orderID := ctx.Param("id")
+	if orderID == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "订单ID不能为空"))
+		return
+	}
+
+	resp, err := c.service.GetOrder(ctx.Request.Context(), userID, orderID)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// SettlementList 分页查询当前用户店铺的结算明细
+func (c *MerchantControllerType) SettlementList(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.MerchantSettlementListReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+	normalizePage(&req.BasePage)
+
+	resp, err := c.service.ListSettlements(ctx.Request.Context(), userID, &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// AdminList 管理员分页查询商家
+func (c *MerchantControllerType) AdminList(ctx *gin.Context) {
+	var req types.MerchantListReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+	normalizePage(&req.BasePage)
+
+	resp, err := c.service.ListMerchants(ctx.Request.Context(), &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// AdminStatus 管理员启用或停用商家
+func (c *MerchantControllerType) AdminStatus(ctx *gin.Context) {
+	adminID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.MerchantStatusReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.UpdateStatus(ctx.Request.Context(), adminID, &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// AdminSettle 管理员发起货款结算，返回每个商家的结算结果
+func (c *MerchantControllerType) AdminSettle(ctx *gin.Context) {
+	var req types.MerchantSettleReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.Settle(ctx.Request.Context(), req.MerchantID)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// normalizePage 为未传或非法的分页参数设置默认值
+func normalizePage(page *types.BasePage) {
+	if page.PageNum <= 0 {
+		page.PageNum = 1
+	}
+	if page.PageSize <= 0 {
+		page.PageSize = consts.BasePageSize
+	}
+}
@@ -2,6 +2,7 @@
 package v1
 
 import (
+	"douyin/consts"
 	"douyin/pkg/utils/response"
 	"douyin/service"
 	"douyin/types"
@@ -69,6 +70,54 @@ func OrderUpdateHandler() gin.HandlerFunc {
 	}
 }
 
+// OrderConfirmReceiptHandler 买家确认收货的处理函数
+func OrderConfirmReceiptHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if OrderController == nil || OrderController.service == nil {
+			log.Println("OrderController 或 OrderService 未正确初始化")
+			_ = ctx.Error(errors.New("服务内部错误：订单服务未就绪"))
+			return
+		}
+		OrderController.ConfirmReceipt(ctx)
+	}
+}
+
+// OrderHistoryHandler 查询订单状态流转记录的处理函数
+func OrderHistoryHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if OrderController == nil || OrderController.service == nil {
+			log.Println("OrderController 或 OrderService 未正确初始化")
+			_ = ctx.Error(errors.New("服务内部错误：订单服务未就绪"))
+			return
+		}
+		OrderController.ListOrderHistory(ctx)
+	}
+}
+
+// OrderListHandler 查询订单列表的处理函数
+func OrderListHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if OrderController == nil || OrderController.service == nil {
+			log.Println("OrderController 或 OrderService 未正确初始化")
+			_ = ctx.Error(errors.New("服务内部错误：订单服务未就绪"))
+			return
+		}
+		OrderController.ListOrders(ctx)
+	}
+}
+
+// OrderDetailHandler 查询订单详情的处理函数
+func OrderDetailHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if OrderController == nil || OrderController.service == nil {
+			log.Println("OrderController 或 OrderService 未正确初始化")
+			_ = ctx.Error(errors.New("服务内部错误：订单服务未就绪"))
+			return
+		}
+		OrderController.GetOrderDetail(ctx)
+	}
+}
+
 // CreateOrder 调用服务层创建订单
 func (c *OrderControllerType) CreateOrder(ctx *gin.Context) {
 	userIDVal, exists := ctx.Get("user_id") // Key "user_id" from AuthMiddleware
@@ -89,14 +138,14 @@ func (c *OrderControllerType) CreateOrder(ctx *gin.Context) {
 	}
 
 	// 调用服务层创建订单, using the new service signature
-	orderID, err := c.service.CreateOrder(ctx.Request.Context(), userID, req.AddressID, req.Items)
+	resp, err := c.service.CreateOrder(ctx.Request.Context(), userID, &req)
 	if err != nil {
 		// log.Printf("创建订单时出错: %v", err) // Service/DAO layer should log specifics
 		_ = ctx.Error(err) // Pass to global error handler
 		return
 	}
 
-	ctx.JSON(http.StatusOK, response.Success(gin.H{"order_id": orderID}))
+	ctx.JSON(http.StatusOK, response.Success(resp))
 }
 
 // UpdateOrder 调用服务层更新订单
@@ -127,3 +176,123 @@ func (c *OrderControllerType) UpdateOrder(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, response.Success("订单更新成功"))
 }
+
+// ConfirmReceipt 调用服务层确认收货
+func (c *OrderControllerType) ConfirmReceipt(ctx *gin.Context) {
+	userIDVal, exists := ctx.Get("user_id")
+	if !exists {
+		_ = ctx.Error(errors.New("用户未授权或user_id未在context中设置"))
+		return
+	}
+	userID, ok := userIDVal.(uint)
+	if !ok {
+		_ = ctx.Error(errors.New("user_id在context中的类型错误"))
+		return
+	}
+
+	var req types.ConfirmReceiptReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	if err := c.service.ConfirmReceipt(ctx.Request.Context(), userID, &req); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Success("确认收货成功"))
+}
+
+// ListOrderHistory 调用服务层查询订单状态流转记录
+func (c *OrderControllerType) ListOrderHistory(ctx *gin.Context) {
+	userIDVal, exists := ctx.Get("user_id")
+	if !exists {
+		_ = ctx.Error(errors.New("用户未授权或user_id未在context中设置"))
+		return
+	}
+	userID, ok := userIDVal.(uint)
+	if !ok {
+		_ = ctx.Error(errors.New("user_id在context中的类型错误"))
+		return
+	}
+
+	orderID := ctx.Param("id")
+	if orderID == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法：缺少订单ID"))
+		return
+	}
+
+	histories, err := c.service.ListOrderHistory(ctx.Request.Context(), userID, orderID)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Success(histories))
+}
+
+// ListOrders 调用服务层分页查询订单
+func (c *OrderControllerType) ListOrders(ctx *gin.Context) {
+	userIDVal, exists := ctx.Get("user_id")
+	if !exists {
+		_ = ctx.Error(errors.New("用户未授权或user_id未在context中设置"))
+		return
+	}
+	userID, ok := userIDVal.(uint)
+	if !ok {
+		_ = ctx.Error(errors.New("user_id在context中的类型错误"))
+		return
+	}
+
+	var req types.OrderListReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+	if req.PageNum <= 0 {
+		req.PageNum = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = consts.BasePageSize
+	}
+	if req.PageSize > consts.MaxPageSize {
+		req.PageSize = consts.MaxPageSize
+	}
+
+	resp, err := c.service.ListOrders(ctx.Request.Context(), userID, &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// GetOrderDetail 调用服务层查询订单详情
+func (c *OrderControllerType) GetOrderDetail(ctx *gin.Context) {
+	userIDVal, exists := ctx.Get("user_id")
+	if !exists {
+		_ = ctx.Error(errors.New("用户未授权或user_id未在context中设置"))
+		return
+	}
+	userID, ok := userIDVal.(uint)
+	if !ok {
+		_ = ctx.Error(errors.New("user_id在context中的类型错误"))
+		return
+	}
+
+	orderID := ctx.Param("id")
+	if orderID == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法：缺少订单ID"))
+		return
+	}
+
+	order, err := c.service.GetOrderDetail(ctx.Request.Context(), userID, orderID)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Success(order))
+}
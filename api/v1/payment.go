@@ -0,0 +1,115 @@
+package v1
+
+import (
+	"douyin/pkg/utils/response"
+	"douyin/service"
+	"douyin/types"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+// 声明全局支付控制器变量，供路由包装函数调用
+var paymentController *PaymentController
+
+// PaymentController 支付控制器
+type PaymentController struct {
+	service *service.PaymentService
+}
+
+// NewPaymentController 创建新的 PaymentController 实例
+func NewPaymentController(db *gorm.DB) *PaymentController {
+	return &PaymentController{
+		service: service.NewPaymentService(db),
+	}
+}
+
+// Pay 发起订单支付
+func (c *PaymentController) Pay(ctx *gin.Context) {
+	userIDVal, exists := ctx.Get("user_id")
+	if !exists {
+		_ = ctx.Error(errors.New("用户未授权或user_id未在context中设置"))
+		return
+	}
+	userID, ok := userIDVal.(uint)
+	if !ok {
+		_ = ctx.Error(errors.New("user_id在context中的类型错误"))
+		return
+	}
+
+	var req types.PayReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.Pay(ctx.Request.Context(), userID, &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// GetPayment 查询支付单状态
+func (c *PaymentController) GetPayment(ctx *gin.Context) {
+	userIDVal, exists := ctx.Get("user_id")
+	if !exists {
+		_ = ctx.Error(errors.New("用户未授权或user_id未在context中设置"))
+		return
+	}
+	userID, ok := userIDVal.(uint)
+	if !ok {
+		_ = ctx.Error(errors.New("user_id在context中的类型错误"))
+		return
+	}
+
+	resp, err := c.service.GetPayment(ctx.Request.Context(), userID, ctx.Param("transaction_id"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// PaymentCallback 接收支付渠道的异步通知（无需登录，依靠签名校验）
+func (c *PaymentController) PaymentCallback(ctx *gin.Context) {
+	body, err := ctx.GetRawData()
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	err = c.service.HandleCallback(ctx.Request.Context(), ctx.Param("provider"), ctx.Request.Header, body)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, response.Success(nil))
+	case errors.Is(err, service.ErrInvalidCallbackSignature):
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Fail(http.StatusUnauthorized, err.Error()))
+	case errors.Is(err, service.ErrPaymentProviderNotFound):
+		ctx.AbortWithStatusJSON(http.StatusNotFound, response.Fail(http.StatusNotFound, err.Error()))
+	default:
+		_ = ctx.Error(err)
+	}
+}
+
+// PaymentPayHandler
+func PaymentPayHandler() gin.HandlerFunc {
+	return paymentController.Pay
+}
+
+// PaymentGetHandler
+func PaymentGetHandler() gin.HandlerFunc {
+	return paymentController.GetPayment
+}
+
+// PaymentCallbackHandler
+func PaymentCallbackHandler() gin.HandlerFunc {
+	return paymentController.PaymentCallback
+}
+
+// SetPaymentController
+func SetPaymentController(db *gorm.DB) {
+	paymentController = NewPaymentController(db)
+}
@@ -69,9 +69,14 @@ func GetProduct(c *gin.Context) {
 		return
 	}
 
-	// 查询商品
+	// 查询商品，价格按请求指定币种或用户偏好币种展示
+	quote, err := displayQuote(c, req.Currency)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	ctx := c.Request.Context()
-	product, err := service.GetProductByID(ctx, req.ID) // req.ID is uint32
+	product, err := service.GetProductByID(ctx, req.ID, quote) // req.ID is uint32
 	if err != nil {
 		// Handle specific errors like not found if service.GetProductByID provides them
 		// For example: if errors.Is(err, gorm.ErrRecordNotFound) { ... }
@@ -84,6 +89,9 @@ func GetProduct(c *gin.Context) {
 		response.Fail(c, http.StatusNotFound, "商品未找到")
         return
 	}
+	detail := []types.Product{*product}
+	fillProductCategories(c, detail)
+	product = &detail[0]
 
 	response.Success(c, product)
 }
@@ -171,12 +179,14 @@ func DeleteProduct(c *gin.Context) {
 // @Produce      json
 // @Param        pageNum   query     int                  false "页码 (Page Number)" default(1)
 // @Param        pageSize  query     int                  false "每页数量 (Page Size)" default(10)
+// @Param        currency  query     string               false "展示币种 (Display Currency)"
+// @Param        category_id query   int                  false "分类ID，包含全部子孙分类 (Category ID, descendants included)"
 // @Success      200   {object}  response.APIResponse{data=object{products=[]types.Product,total=int}} "返回商品列表和总数"
 // @Failure      400   {object}  response.APIResponse "参数错误 (Bad Request)"
 // @Failure      500   {object}  response.APIResponse "服务器内部错误 (Internal Server Error)"
 // @Router       /product/list [get] // Or /product [get] if it's the standard list endpoint
 func ListProducts(c *gin.Context) {
-	var req types.BasePage
+	var req types.ProductListReq
 	if err := c.ShouldBindQuery(&req); err != nil { // Query params for pagination
 		response.Fail(c, http.StatusBadRequest, "参数非法: "+err.Error())
 		return
@@ -191,14 +201,82 @@ func ListProducts(c *gin.Context) {
 	}
 
 
-	// 查询商品列表
+	// 查询商品列表，价格按请求指定币种或用户偏好币种展示
+	quote, err := displayQuote(c, req.Currency)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// 按分类筛选时包含该分类的全部子孙分类
+	categoryIDs, err := productCategoryScope(c, req.CategoryID)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	ctx := c.Request.Context()
-	products, total, err := service.ListProducts(ctx, req.PageNum, req.PageSize)
+	products, total, err := service.ListProducts(ctx, req.PageNum, req.PageSize, req.CategoryID, categoryIDs, quote)
 	if err != nil {
 		// response.Fail(c, http.StatusInternalServerError, "查询商品列表失败: "+err.Error()) // Example for Swaggo
 		_ = c.Error(err)
 		return
 	}
+	fillProductCategories(c, products)
+
+	response.Success(c, gin.H{
+		"products": products,
+		"total":    total,
+	})
+}
+
+// @Summary      搜索商品
+// @Description  按关键词、价格区间（基础币种）、分类和是否有货搜索商品，支持按价格、销量、上架时间排序.
+// @Tags         商品 (Product)
+// @Accept       json
+// @Produce      json
+// @Param        keyword     query     string               false "关键词 (Keyword)"
+// @Param        min_price   query     string               false "最低价格，基础币种 (Min Price)"
+// @Param        max_price   query     string               false "最高价格，基础币种 (Max Price)"
+// @Param        category_id query     int                  false "分类ID，包含全部子孙分类 (Category ID, descendants included)"
+// @Param        in_stock    query     bool                 false "只看有货 (In Stock Only)"
+// @Param        sort        query     string               false "排序：price_asc / price_desc / sales / newest，默认相关度或最新 (Sort)"
+// @Param        page_num    query     int                  false "页码 (Page Number)" default(1)
+// @Param        page_size   query     int                  false "每页数量 (Page Size)" default(10)
+// @Param        currency    query     string               false "展示币种 (Display Currency)"
+// @Success      200   {object}  response.APIResponse{data=object{products=[]types.Product,total=int}} "返回商品列表和总数"
+// @Failure      400   {object}  response.APIResponse "参数错误 (Bad Request)"
+// @Failure      500   {object}  response.APIResponse "服务器内部错误 (Internal Server Error)"
+// @Router       /product/search [get]
+func SearchProducts(c *gin.Context) {
+	var req types.ProductSearchReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Fail(c, http.StatusBadRequest, "参数非法: "+err.Error())
+		return
+	}
+	if req.PageNum <= 0 {
+		req.PageNum = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	// 价格按请求指定币种或用户偏好币种展示，筛选区间始终为基础币种
+	quote, err := displayQuote(c, req.Currency)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	categoryIDs, err := productCategoryScope(c, req.CategoryID)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	ctx := c.Request.Context()
+	products, total, err := service.SearchProducts(ctx, &req, categoryIDs, quote)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	fillProductCategories(c, products)
 
 	response.Success(c, gin.H{
 		"products": products,
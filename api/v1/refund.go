@@ -0,0 +1,187 @@
+package v1
+
+import (
+	"douyin/consts"
+	"douyin/pkg/utils/response"
+	"douyin/pkg/utils/upload"
+	"douyin/service"
+	"douyin/types"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"log"
+	"mime/multipart"
+	"net/http"
+)
+
+// RefundControllerType 封装售后操作
+type RefundControllerType struct {
+	service *service.RefundService
+}
+
+// RefundController 是全局售后控制器实例
+var RefundController *RefundControllerType
+
+// SetRefundController 初始化售后控制器，uploader 为凭证上传使用的对象存储客户端，可为 nil
+func SetRefundController(db *gorm.DB, uploader *upload.Client) {
+	RefundController = &RefundControllerType{
+		service: service.NewRefundService(db, uploader),
+	}
+	log.Println("RefundController 初始化成功")
+}
+
+// refundHandler 包装售后处理函数，控制器在路由注册之后才初始化，因此在请求时检查
+func refundHandler(handle func(c *RefundControllerType, ctx *gin.Context)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if RefundController == nil || RefundController.service == nil {
+			log.Println("RefundController 或 RefundService 未正确初始化")
+			_ = ctx.Error(errors.New("服务内部错误：售后服务未就绪"))
+			return
+		}
+		handle(RefundController, ctx)
+	}
+}
+
+// RefundApplyHandler 申请售后的处理函数
+func RefundApplyHandler() gin.HandlerFunc {
+	return refundHandler((*RefundControllerType).ApplyRefund)
+}
+
+// RefundDetailHandler 查询售后单的处理函数
+func RefundDetailHandler() gin.HandlerFunc {
+	return refundHandler((*RefundControllerType).GetRefund)
+}
+
+// RefundListHandler 查询买家售后单列表的处理函数
+func RefundListHandler() gin.HandlerFunc {
+	return refundHandler((*RefundControllerType).ListRefunds)
+}
+
+// AdminRefundListHandler 管理员查询售后单列表的处理函数
+func AdminRefundListHandler() gin.HandlerFunc {
+	return refundHandler((*RefundControllerType).AdminListRefunds)
+}
+
+// AdminRefundReviewHandler 管理员审核售后单的处理函数
+func AdminRefundReviewHandler() gin.HandlerFunc {
+	return refundHandler((*RefundControllerType).ReviewRefund)
+}
+
+// currentUserID 从上下文中获取 AuthMiddleware 设置的用户ID
+func currentUserID(ctx *gin.Context) (uint, bool) {
+	userIDVal, exists := ctx.Get("user_id")
+	if !exists {
+		_ = ctx.Error(errors.New("用户未授权或user_id未在context中设置"))
+		return 0, false
+	}
+	userID, ok := userIDVal.(uint)
+	if !ok {
+		_ = ctx.Error(errors.New("user_id在context中的类型错误"))
+		return 0, false
+	}
+	return userID, true
+}
+
+// ApplyRefund 买家申请售后（multipart/form-data，凭证图片字段为 evidence）
+func (c *RefundControllerType) ApplyRefund(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.RefundApplyReq
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+	if req.Items != "" {
+		if err := json.Unmarshal([]byte(req.Items), &req.ItemReq); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法：items 格式错误"))
+			return
+		}
+	}
+
+	var evidence []*multipart.FileHeader
+	if form := ctx.Request.MultipartForm; form != nil {
+		evidence = form.File["evidence"]
+	}
+
+	resp, err := c.service.ApplyRefund(ctx.Request.Context(), userID, &req, evidence)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// GetRefund 买家查询售后单详情
+func (c *RefundControllerType) GetRefund(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	resp, err := c.service.GetRefund(ctx.Request.Context(), userID, ctx.Param("refund_no"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// ListRefunds 买家分页查询自己的售后单
+func (c *RefundControllerType) ListRefunds(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+	c.listRefunds(ctx, userID)
+}
+
+// AdminListRefunds 管理员分页查询全部售后单
+func (c *RefundControllerType) AdminListRefunds(ctx *gin.Context) {
+	c.listRefunds(ctx, 0)
+}
+
+func (c *RefundControllerType) listRefunds(ctx *gin.Context, userID uint) {
+	var req types.RefundListReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+	if req.PageNum <= 0 {
+		req.PageNum = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = consts.BasePageSize
+	}
+
+	resp, err := c.service.ListRefunds(ctx.Request.Context(), userID, &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// ReviewRefund 管理员审核售后单
+func (c *RefundControllerType) ReviewRefund(ctx *gin.Context) {
+	reviewerID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.RefundReviewReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.ReviewRefund(ctx.Request.Context(), reviewerID, &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
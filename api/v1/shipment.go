@@ -0,0 +1,118 @@
+package v1
+
+import (
+	"douyin/pkg/utils/response"
+	"douyin/service"
+	"douyin/types"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"log"
+	"net/http"
+)
+
+// ShipmentControllerType 封装发货与物流查询相关操作
+type ShipmentControllerType struct {
+	service *service.ShipmentService
+}
+
+// ShipmentController 是全局发货控制器实例
+var ShipmentController *ShipmentControllerType
+
+// SetShipmentController 初始化发货控制器
+func SetShipmentController(db *gorm.DB) {
+	ShipmentController = &ShipmentControllerType{
+		service: service.NewShipmentService(db),
+	}
+	log.Println("ShipmentController 初始化成功")
+}
+
+// shipmentHandler 包装发货处理函数，控制器在路由注册之后才初始化，因此在请求时检查
+func shipmentHandler(handle func(c *ShipmentControllerType, ctx *gin.Context)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ShipmentController == nil || ShipmentController.service == nil {
+			log.Println("ShipmentController 或 ShipmentService 未正确初始化")
+			_ = ctx.Error(errors.New("服务内部错误：发货服务未就绪"))
+			return
+		}
+		handle(ShipmentController, ctx)
+	}
+}
+
+// MerchantOrderShipHandler 商家发货的处理函数
+func MerchantOrderShipHandler() gin.HandlerFunc {
+	return shipmentHandler((*ShipmentControllerType).MerchantShip)
+}
+
+// AdminOrderShipHandler 管理员发货的处理函数
+func AdminOrderShipHandler() gin.HandlerFunc {
+	return shipmentHandler((*ShipmentControllerType).AdminShip)
+}
+
+// OrderShipmentListHandler 买家查询订单物流的处理函数
+func OrderShipmentListHandler() gin.HandlerFunc {
+	return shipmentHandler((*ShipmentControllerType).OrderShipments)
+}
+
+// MerchantShip 当前用户的店铺为订单中属于该店铺的子订单发货
+func (c *ShipmentControllerType) MerchantShip(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.ShipOrderReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.ShipMerchantOrder(ctx.Request.Context(), userID, &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// AdminShip 管理员为子订单发货
+func (c *ShipmentControllerType) AdminShip(ctx *gin.Context) {
+	adminID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.AdminShipOrderReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.AdminShipOrder(ctx.Request.Context(), adminID, &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// OrderShipments 查询当前用户订单的发货单与物流轨迹
+func (c *ShipmentControllerType) OrderShipments(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	orderID := ctx.Param("id")
+	if orderID == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法：缺少订单ID"))
+		return
+	}
+
+	resp, err := c.service.ListOrderShipments(ctx.Request.Context(), userID, orderID)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
@@ -0,0 +1,124 @@
+package v1
+
+import (
+	"douyin/consts"
+	"douyin/pkg/utils/response"
+	"douyin/service"
+	"douyin/types"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"log"
+	"net/http"
+)
+
+// RestockControllerType 封装到货提醒订阅操作
+type RestockControllerType struct {
+	service *service.StockNotificationService
+}
+
+// RestockController 是全局到货提醒控制器实例
+var RestockController *RestockControllerType
+
+// SetRestockController 初始化到货提醒控制器
+func SetRestockController(db *gorm.DB) {
+	RestockController = &RestockControllerType{
+		service: service.NewStockNotificationService(db),
+	}
+	log.Println("RestockController 初始化成功")
+}
+
+// restockHandler 包装到货提醒处理函数，控制器在路由注册之后才初始化，因此在请求时检查
+func restockHandler(handle func(c *RestockControllerType, ctx *gin.Context)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if RestockController == nil || RestockController.service == nil {
+			log.Println("RestockController 或 StockNotificationService 未正确初始化")
+			_ = ctx.Error(errors.New("服务内部错误：到货提醒服务未就绪"))
+			return
+		}
+		handle(RestockController, ctx)
+	}
+}
+
+// RestockSubscribeHandler 订阅到货提醒的处理函数
+func RestockSubscribeHandler() gin.HandlerFunc {
+	return restockHandler((*RestockControllerType).Subscribe)
+}
+
+// RestockUnsubscribeHandler 取消到货提醒的处理函数
+func RestockUnsubscribeHandler() gin.HandlerFunc {
+	return restockHandler((*RestockControllerType).Unsubscribe)
+}
+
+// RestockListHandler 查询我的到货提醒的处理函数
+func RestockListHandler() gin.HandlerFunc {
+	return restockHandler((*RestockControllerType).List)
+}
+
+// Subscribe 用户订阅缺货商品的到货提醒
+func (c *RestockControllerType) Subscribe(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.RestockSubscribeReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.Subscribe(ctx.Request.Context(), userID, &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// Unsubscribe 用户取消到货提醒
+func (c *RestockControllerType) Unsubscribe(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.RestockUnsubscribeReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	if err := c.service.Unsubscribe(ctx.Request.Context(), userID, req.SkuID); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(nil))
+}
+
+// List 用户分页查询自己的到货提醒
+func (c *RestockControllerType) List(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.BasePage
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+	if req.PageNum <= 0 {
+		req.PageNum = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = consts.BasePageSize
+	}
+
+	resp, err := c.service.ListSubscriptions(ctx.Request.Context(), userID, &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
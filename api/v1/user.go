@@ -53,6 +53,13 @@ func UserLoginHandler() gin.HandlerFunc {
 			_ = ctx.Error(err)
 			return
 		}
+		// 携带游客购物车 Cookie 登录时，将游客购物车合并到用户购物车
+		if tokenData, ok := resp.(types.UserTokenData); ok {
+			if user, ok := tokenData.User.(*types.UserInfoResp); ok {
+				tokenData.CartMerge = mergeGuestCart(ctx, user.ID)
+				resp = tokenData
+			}
+		}
 		log.LogrusObj.Info("登录成功，生成令牌")
 		ctx.JSON(http.StatusOK, response.Success(resp))
 	}
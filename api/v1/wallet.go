@@ -0,0 +1,125 @@
+package v1
+
+import (
+	"douyin/consts"
+	"douyin/pkg/utils/response"
+	"douyin/repository/db/dao"
+	"douyin/service"
+	"douyin/types"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+// 声明全局钱包控制器变量，供路由包装函数调用
+var walletController *WalletController
+
+// WalletController 钱包控制器
+type WalletController struct {
+	service *service.WalletService
+}
+
+// NewWalletController 创建新的 WalletController 实例
+func NewWalletController(db *gorm.DB) *WalletController {
+	return &WalletController{
+		service: service.NewWalletService(db),
+	}
+}
+
+// ListTransactions 分页查询当前用户的钱包流水
+func (c *WalletController) ListTransactions(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.WalletTransactionListReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+	if req.PageNum <= 0 {
+		req.PageNum = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = consts.BasePageSize
+	}
+
+	resp, err := c.service.ListTransactions(ctx.Request.Context(), userID, &req)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// TopUp 登记外部资金到账，为指定用户充值
+func (c *WalletController) TopUp(ctx *gin.Context) {
+	operatorID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.WalletTopUpReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.TopUp(ctx.Request.Context(), operatorID, &req)
+	if err != nil {
+		if errors.Is(err, dao.ErrWalletDuplicateRef) {
+			ctx.AbortWithStatusJSON(http.StatusConflict, response.Fail(1002, err.Error()))
+			return
+		}
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// Adjust 管理员人工调整指定用户的余额
+func (c *WalletController) Adjust(ctx *gin.Context) {
+	adminID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req types.WalletAdjustReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法："+err.Error()))
+		return
+	}
+
+	resp, err := c.service.Adjust(ctx.Request.Context(), adminID, &req)
+	if err != nil {
+		if errors.Is(err, dao.ErrInsufficientBalance) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1002, err.Error()))
+			return
+		}
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(resp))
+}
+
+// WalletTransactionsHandler
+func WalletTransactionsHandler() gin.HandlerFunc {
+	return walletController.ListTransactions
+}
+
+// AdminWalletTopUpHandler
+func AdminWalletTopUpHandler() gin.HandlerFunc {
+	return walletController.TopUp
+}
+
+// AdminWalletAdjustHandler
+func AdminWalletAdjustHandler() gin.HandlerFunc {
+	return walletController.Adjust
+}
+
+// SetWalletController
+func SetWalletController(db *gorm.DB) {
+	walletController = NewWalletController(db)
+}
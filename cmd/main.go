@@ -28,6 +28,7 @@ import (
 	"douyin/global" // For global.DB
 	mylog "douyin/pkg/utils/log"
 	"douyin/repository/cache"
+	"douyin/repository/db/dao"
 	"douyin/repository/db/model" // For RBAC models
 	"douyin/pkg/utils/upload"    // For OSS client
 	i18nUtils "douyin/pkg/utils/i18n" // For i18n
@@ -115,11 +116,101 @@ func main() {
 	}
 	mylog.Info("RBAC tables migrated successfully")
 
+	// 订单状态由字符串改为整数：AutoMigrate 修改列类型之前先把旧的 'pending'、'paid' 等取值改写为 consts.OrderType*
+	if migrated, err := dao.NewOrderDao(db).MigrateLegacyOrderStatus(context.Background()); err != nil {
+		mylog.Fatalf("迁移旧订单状态失败: %v", err)
+	} else if migrated > 0 {
+		mylog.Infof("已将 %d 个订单的旧状态迁移为数字状态", migrated)
+	}
+
+	// 业务表迁移
+	// 金额列（商品价格、订单项单价、支付/退款金额）使用 money.Amount，迁移时会由 DOUBLE 转换为 DECIMAL(20,2)
+	bizModels := []interface{}{
+		&model.Product{},
+		&model.ProductSpec{},
+		&model.ProductSku{},
+		&model.Order{},
+		&model.SubOrder{},
+		&model.OrderItem{},
+		&model.OrderStatusHistory{},
+		&model.Payment{},
+		&model.PaymentStatusHistory{},
+		&model.PaymentNotification{},
+		&model.Refund{},
+		&model.RefundItem{},
+		&model.Wallet{},
+		&model.WalletTransaction{},
+		&model.ExchangeRate{},
+		&model.StockReservation{},
+		&model.Category{},
+		&model.ProductCategory{},
+		&model.CouponTemplate{},
+		&model.UserCoupon{},
+		&model.FlashSaleSession{},
+		&model.FlashSaleOrder{},
+		&model.Warehouse{},
+		&model.InventoryLevel{},
+		&model.InventoryMovement{},
+		&model.StockEvent{},
+		&model.RestockSubscription{},
+		&model.Merchant{},
+		&model.MerchantSettlement{},
+		&model.Shipment{},
+		&model.ShipmentItem{},
+	}
+	if err = global.DB.AutoMigrate(bizModels...); err != nil {
+		mylog.Fatalf("GORM AutoMigrate business tables failed: %v", err)
+	}
+	mylog.Info("Business tables migrated successfully")
+
+	// 为引入 SKU 之前的商品补建默认 SKU，并回填购物车、订单等记录的 sku_id
+	if created, err := dao.NewProductSkuDao(db).EnsureDefaultSkus(context.Background()); err != nil {
+		mylog.Errorf("补建商品默认 SKU 失败: %v", err)
+	} else if created > 0 {
+		mylog.Infof("已为 %d 个商品补建默认 SKU", created)
+	}
+	// 为尚未建立库存水位的 SKU 在默认仓库写入期初库存
+	if created, err := dao.NewInventoryDao(db).EnsureInventoryLevels(context.Background()); err != nil {
+		mylog.Errorf("建立期初库存失败: %v", err)
+	} else if created > 0 {
+		mylog.Infof("已为 %d 个 SKU 建立期初库存", created)
+	}
+
+	// 为引入子订单之前创建的订单按商家补建子订单
+	if created, err := dao.NewSubOrderDao(db).EnsureSubOrders(context.Background()); err != nil {
+		mylog.Errorf("补建子订单失败: %v", err)
+	} else if created > 0 {
+		mylog.Infof("已为 %d 个订单补建子订单", created)
+	}
+
+	// 从本地文件导入汇率，失败时保留数据库中已有的汇率
+	if ratesFile := conf.GetExchangeRatesFile(); ratesFile != "" {
+		if _, err := service.NewExchangeRateService(db).ImportFile(context.Background(), ratesFile); err != nil {
+			mylog.Errorf("导入汇率文件失败: %v", err)
+		}
+	}
+
+
+	// 初始化商品搜索后端（search.backend），商品变更时增量同步索引
+	if err := service.InitSearchService(context.Background(), db); err != nil {
+		mylog.Errorf("商品搜索后端初始化失败，搜索接口不可用: %v", err)
+	}
 
 	v1.SetDB(db) // This function might also set global.DB or uses the passed db.
 	             // If v1.SetDB already sets global.DB, the line global.DB = db above might be redundant
 	             // but explicit assignment is safer for clarity.
 	v1.SetCheckoutController(db)
+	v1.SetPaymentController(db)
+	v1.SetWalletController(db)
+	v1.SetExchangeRateController(db)
+	v1.SetGuestCartController(db)
+	v1.SetCouponController(db)
+	v1.SetFlashSaleController(db)
+	v1.SetCategoryController(db)
+	v1.SetInventoryController(db)
+	v1.SetRestockController(db)
+	v1.SetMerchantController(db)
+	v1.SetShipmentController(db)
 
 	// Initialize HealthController
 	// Assuming cache.GetClient() returns the *redis.Client initialized by cache.InitCache()
@@ -218,6 +309,7 @@ func main() {
 	// Initialize OSS Client and Upload Controller
 	// Assuming conf.GlobalConfig.OSS has fields like Type, Endpoint, Region, Bucket, AccessKeyID, SecretAccessKey
 	// These fields should match the structure expected by upload.Config
+	var ossClient *upload.Client
 	if conf.GlobalConfig.OSS.Type == "s3" { // Check if OSS type is S3
 		ossCfg := upload.Config{
 			Type:            conf.GlobalConfig.OSS.Type,
@@ -228,7 +320,7 @@ func main() {
 			SecretAccessKey: conf.GlobalConfig.OSS.SecretAccessKey,
 		}
 
-		ossClient, err := upload.NewClient(ossCfg)
+		ossClient, err = upload.NewClient(ossCfg)
 		if err != nil {
 			// Use the project's logger mylog (which is douyin/pkg/utils/log)
 			mylog.Fatalf("Failed to initialize OSS client: %v", err)
@@ -243,6 +335,8 @@ func main() {
 	} else {
 		mylog.Info("OSS Type not configured to 's3' or not set in config. Skipping OSS client and UploadController initialization.")
 	}
+	// 售后控制器依赖 OSS 客户端上传凭证，未配置 OSS 时仍可申请售后，但不能上传凭证
+	v1.SetRefundController(db, ossClient)
 
 	// Initialize Email Client and Notification Service
 	// cancelWorker needs to be declared here to be accessible by the shutdown logic.
@@ -295,6 +389,58 @@ func main() {
 	}
 
 
+	// Start the unpaid-order timeout worker (Redis sorted-set delay queue)
+	var cancelOrderWorker context.CancelFunc
+	if redisClient != nil {
+		orderSvc, err := service.NewOrderService(db)
+		if err != nil {
+			mylog.Fatalf("Failed to initialize OrderService for timeout worker: %v", err)
+		}
+		var orderWorkerCtx context.Context
+		orderWorkerCtx, cancelOrderWorker = context.WithCancel(context.Background())
+		go orderSvc.ListenAndCloseUnpaid(orderWorkerCtx)
+		mylog.Info("Unpaid order timeout worker started.")
+	} else {
+		mylog.Warn("Redis client is nil. Unpaid order timeout worker not started.")
+	}
+
+	// Start the flash-sale order worker (Redis list queue filled by the Lua acquire script)
+	var cancelFlashSaleWorker context.CancelFunc
+	if redisClient != nil {
+		var flashSaleWorkerCtx context.Context
+		flashSaleWorkerCtx, cancelFlashSaleWorker = context.WithCancel(context.Background())
+		go service.NewFlashSaleService(db).ListenAndCreateOrders(flashSaleWorkerCtx)
+		mylog.Info("Flash-sale order worker started.")
+	} else {
+		mylog.Warn("Redis client is nil. Flash-sale order worker not started.")
+	}
+
+	// Start the stock notification worker (low-stock alerts and back-in-stock emails via the email queue)
+	var cancelStockWorker context.CancelFunc
+	if redisClient != nil {
+		var stockWorkerCtx context.Context
+		stockWorkerCtx, cancelStockWorker = context.WithCancel(context.Background())
+		go service.NewStockNotificationService(db).ListenAndNotify(stockWorkerCtx)
+		mylog.Info("Stock notification worker started.")
+	} else {
+		mylog.Warn("Redis client is nil. Stock notification worker not started.")
+	}
+
+	// Start the expired cart reservation cleanup worker
+	cartWorkerCtx, cancelCartWorker := context.WithCancel(context.Background())
+	go service.NewCartService(db).ListenAndReleaseExpired(cartWorkerCtx)
+	mylog.Info("Cart reservation cleanup worker started.")
+
+	// Start the shipment tracking worker (refreshes carrier tracking and auto-confirms receipt after shipping.autoConfirmDays)
+	shipmentWorkerCtx, cancelShipmentWorker := context.WithCancel(context.Background())
+	go service.NewShipmentService(db).ListenAndTrack(shipmentWorkerCtx)
+	mylog.Info("Shipment tracking worker started.")
+
+	// Start the refund retry worker (re-sends PROCESSING refunds to the payment provider with the same refund number)
+	refundWorkerCtx, cancelRefundWorker := context.WithCancel(context.Background())
+	go service.NewRefundService(db, nil).ListenAndRetryRefunds(refundWorkerCtx)
+	mylog.Info("Refund retry worker started.")
+
 	// HTTP Server Setup for Graceful Shutdown
 	srv := &http.Server{
 		Addr:    conf.GlobalConfig.System.HttpPort,
@@ -323,6 +469,30 @@ func main() {
 		// time.Sleep(2 * time.Second)
 	}
 
+	if cancelOrderWorker != nil {
+		mylog.Info("Signaling unpaid order timeout worker to stop...")
+		cancelOrderWorker()
+	}
+
+	if cancelFlashSaleWorker != nil {
+		mylog.Info("Signaling flash-sale order worker to stop...")
+		cancelFlashSaleWorker()
+	}
+
+	if cancelStockWorker != nil {
+		mylog.Info("Signaling stock notification worker to stop...")
+		cancelStockWorker()
+	}
+
+	mylog.Info("Signaling cart reservation cleanup worker to stop...")
+	cancelCartWorker()
+
+	mylog.Info("Signaling shipment tracking worker to stop...")
+	cancelShipmentWorker()
+
+	mylog.Info("Signaling refund retry worker to stop...")
+	cancelRefundWorker()
+
 	ctxShutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
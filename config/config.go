@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"strings" // Added for strings.NewReplacer
+	"time"
 
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v2"
@@ -29,6 +30,13 @@ type Conf struct {
 	RabbitMq      *RabbitMq               `yaml:"rabbitMq"`      // RabbitMQ 配置
 	Es            *Es                     `yaml:"es"`            // ElasticSearch 配置
 	PhotoPath     *LocalPhotoPath         `yaml:"photoPath"`     // 本地图片存储路径配置
+	Order         *Order                  `yaml:"order"`         // 订单配置
+	Payment       *Payment                `yaml:"payment"`       // 支付配置
+	Cart          *Cart                   `yaml:"cart"`          // 购物车配置
+	Inventory     *Inventory              `yaml:"inventory"`     // 库存提醒配置
+	Shipping      *Shipping               `yaml:"shipping"`      // 物流配置
+	Currency      *Currency               `yaml:"currency"`      // 币种与汇率配置
+	Search        *Search                 `yaml:"search"`        // 商品搜索配置
 }
 
 // 以下为各部分配置结构体定义（部分可根据实际需求扩展）
@@ -81,10 +89,12 @@ type Redis struct {
 }
 
 type EncryptSecret struct {
-	JwtSecret   string `yaml:"jwtSecret"`
-	EmailSecret string `yaml:"emailSecret"`
-	PhoneSecret string `yaml:"phoneSecret"`
-	MoneySecret string `yaml:"moneySecret"`
+	JwtSecret     string `yaml:"jwtSecret"`
+	EmailSecret   string `yaml:"emailSecret"`
+	PhoneSecret   string `yaml:"phoneSecret"`
+	MoneySecret   string `yaml:"moneySecret"`
+	PaymentSecret string `yaml:"paymentSecret"` // 支付渠道回调的 HMAC 签名密钥
+	CartSecret    string `yaml:"cartSecret"`    // 游客购物车 Cookie 的 HMAC 签名密钥
 }
 
 type LocalPhotoPath struct {
@@ -101,9 +111,11 @@ type Cache struct {
 }
 
 type Es struct {
-	EsHost  string `yaml:"esHost"`
-	EsPort  string `yaml:"esPort"`
-	EsIndex string `yaml:"esIndex"`
+	EsHost     string `yaml:"esHost"`
+	EsPort     string `yaml:"esPort"`
+	EsIndex    string `yaml:"esIndex"`
+	EsUsername string `yaml:"esUsername"` // 开启安全认证时的用户名（无则留空）
+	EsPassword string `yaml:"esPassword"` // 开启安全认证时的密码（无则留空）
 }
 
 type RabbitMq struct {
@@ -114,6 +126,45 @@ type RabbitMq struct {
 	RabbitMQPort     string `yaml:"rabbitMqPort"`
 }
 
+type Order struct {
+	UnpaidTimeout         int64 `yaml:"unpaidTimeout"`         // 未支付订单自动关闭的超时时间（秒）
+	ScanInterval          int64 `yaml:"scanInterval"`          // 延时队列轮询间隔（秒）
+	PendingPaymentTimeout int64 `yaml:"pendingPaymentTimeout"` // 超时关单时处理中支付等待渠道结果的最长时间（秒），超过后支付单失效并标记待对账
+}
+
+type Cart struct {
+	ReservationTTL  int64 `yaml:"reservationTTL"`  // 加入购物车后库存预占的有效期（秒）
+	CleanupInterval int64 `yaml:"cleanupInterval"` // 过期预占清理间隔（秒）
+	GuestTTL        int64 `yaml:"guestTTL"`        // 游客购物车在 Redis 中的保留时长（秒），每次操作顺延
+}
+
+type Inventory struct {
+	AlertEmails    []string `yaml:"alertEmails"`    // 低库存预警邮件的收件人
+	NotifyInterval int64    `yaml:"notifyInterval"` // 库存事件（低库存预警、到货提醒）轮询间隔（秒）
+}
+
+type Shipping struct {
+	Tracker         string `yaml:"tracker"`         // 物流轨迹查询实现（file），为空时不查询物流轨迹
+	TrackingFile    string `yaml:"trackingFile"`    // file 实现读取的物流轨迹文件路径
+	TrackInterval   int64  `yaml:"trackInterval"`   // 物流轨迹刷新与超时自动确认收货的轮询间隔（秒）
+	AutoConfirmDays int    `yaml:"autoConfirmDays"` // 发货后超过该天数买家仍未确认收货时自动确认收货
+}
+
+type Payment struct {
+	DefaultProvider string `yaml:"defaultProvider"` // 未指定支付渠道时使用的默认渠道（mock / wallet）
+	MockEnabled     bool   `yaml:"mockEnabled"`     // 是否启用本地模拟支付渠道，生产环境应关闭
+	MockAutoCapture bool   `yaml:"mockAutoCapture"` // 模拟渠道是否立即扣款成功；为 false 时需等待支付回调
+}
+
+type Currency struct {
+	Base      string `yaml:"base"`      // 基础币种，商品价格以该币种定价
+	RatesFile string `yaml:"ratesFile"` // 启动时导入的汇率文件（CSV 或 JSON），为空时不导入
+}
+
+type Search struct {
+	Backend string `yaml:"backend"` // 商品搜索后端（mysql / es），默认 mysql
+}
+
 type KafkaConfig struct {
 	DisableConsumer bool   `yaml:"disableConsumer"`
 	Debug           bool   `yaml:"debug"`
@@ -214,3 +265,188 @@ func GetExpiresTime() int64 {
 	}
 	return GlobalConfig.Cache.CacheExpires
 }
+
+// GetOrderUnpaidTimeout 获取未支付订单的超时关闭时间，未配置时默认 30 分钟
+func GetOrderUnpaidTimeout() time.Duration {
+	if GlobalConfig == nil || GlobalConfig.Order == nil || GlobalConfig.Order.UnpaidTimeout <= 0 {
+		return 30 * time.Minute
+	}
+	return time.Duration(GlobalConfig.Order.UnpaidTimeout) * time.Second
+}
+
+// GetOrderScanInterval 获取延时队列轮询间隔，未配置时默认 1 秒
+func GetOrderScanInterval() time.Duration {
+	if GlobalConfig == nil || GlobalConfig.Order == nil || GlobalConfig.Order.ScanInterval <= 0 {
+		return time.Second
+	}
+	return time.Duration(GlobalConfig.Order.ScanInterval) * time.Second
+}
+
+// GetOrderPendingPaymentTimeout 获取超时关单时处理中支付的最长等待时间，未配置时默认 30 分钟
+func GetOrderPendingPaymentTimeout() time.Duration {
+	if GlobalConfig == nil || GlobalConfig.Order == nil || GlobalConfig.Order.PendingPaymentTimeout <= 0 {
+		return 30 * time.Minute
+	}
+	return time.Duration(GlobalConfig.Order.PendingPaymentTimeout) * time.Second
+}
+
+// GetCartReservationTTL 获取购物车库存预占有效期，未配置时默认 30 分钟
+func GetCartReservationTTL() time.Duration {
+	if GlobalConfig == nil || GlobalConfig.Cart == nil || GlobalConfig.Cart.ReservationTTL <= 0 {
+		return 30 * time.Minute
+	}
+	return time.Duration(GlobalConfig.Cart.ReservationTTL) * time.Second
+}
+
+// GetCartCleanupInterval 获取过期库存预占的清理间隔，未配置时默认 1 分钟
+func GetCartCleanupInterval() time.Duration {
+	if GlobalConfig == nil || GlobalConfig.Cart == nil || GlobalConfig.Cart.CleanupInterval <= 0 {
+		return time.Minute
+	}
+	return time.Duration(GlobalConfig.Cart.CleanupInterval) * time.Second
+}
+
+// GetGuestCartTTL 获取游客购物车的保留时长，未配置时默认 7 天
+func GetGuestCartTTL() time.Duration {
+	if GlobalConfig == nil || GlobalConfig.Cart == nil || GlobalConfig.Cart.GuestTTL <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	return time.Duration(GlobalConfig.Cart.GuestTTL) * time.Second
+}
+
+// GetGuestCartSecret 获取游客购物车 Cookie 签名密钥，未配置时返回空字符串（此时所有 Cookie 均校验失败）
+func GetGuestCartSecret() string {
+	if GlobalConfig == nil || GlobalConfig.EncryptSecret == nil {
+		return ""
+	}
+	return GlobalConfig.EncryptSecret.CartSecret
+}
+
+// GetInventoryAlertEmails 获取低库存预警邮件的收件人，未配置时返回空（只记录日志）
+func GetInventoryAlertEmails() []string {
+	if GlobalConfig == nil || GlobalConfig.Inventory == nil {
+		return nil
+	}
+	return GlobalConfig.Inventory.AlertEmails
+}
+
+// GetInventoryNotifyInterval 获取库存事件轮询间隔，未配置时默认 30 秒
+func GetInventoryNotifyInterval() time.Duration {
+	if GlobalConfig == nil || GlobalConfig.Inventory == nil || GlobalConfig.Inventory.NotifyInterval <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(GlobalConfig.Inventory.NotifyInterval) * time.Second
+}
+
+// GetShippingTracker 获取物流轨迹查询实现，未配置时返回空字符串（不查询物流轨迹）
+func GetShippingTracker() string {
+	if GlobalConfig == nil || GlobalConfig.Shipping == nil {
+		return ""
+	}
+	return strings.ToLower(GlobalConfig.Shipping.Tracker)
+}
+
+// GetShippingTrackingFile 获取 file 物流轨迹实现读取的文件路径
+func GetShippingTrackingFile() string {
+	if GlobalConfig == nil || GlobalConfig.Shipping == nil {
+		return ""
+	}
+	return GlobalConfig.Shipping.TrackingFile
+}
+
+// GetShippingTrackInterval 获取物流轨迹刷新与自动确认收货的轮询间隔，未配置时默认 10 分钟
+func GetShippingTrackInterval() time.Duration {
+	if GlobalConfig == nil || GlobalConfig.Shipping == nil || GlobalConfig.Shipping.TrackInterval <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(GlobalConfig.Shipping.TrackInterval) * time.Second
+}
+
+// GetShippingAutoConfirmDays 获取发货后自动确认收货的天数，未配置时默认 10 天
+func GetShippingAutoConfirmDays() int {
+	if GlobalConfig == nil || GlobalConfig.Shipping == nil || GlobalConfig.Shipping.AutoConfirmDays <= 0 {
+		return 10
+	}
+	return GlobalConfig.Shipping.AutoConfirmDays
+}
+
+// GetPaymentDefaultProvider 获取默认支付渠道，未配置时使用余额支付
+func GetPaymentDefaultProvider() string {
+	if GlobalConfig == nil || GlobalConfig.Payment == nil || GlobalConfig.Payment.DefaultProvider == "" {
+		return "wallet"
+	}
+	return GlobalConfig.Payment.DefaultProvider
+}
+
+// GetPaymentCallbackSecret 获取支付回调验签密钥，未配置时返回空字符串（此时拒绝所有回调）
+func GetPaymentCallbackSecret() string {
+	if GlobalConfig == nil || GlobalConfig.EncryptSecret == nil {
+		return ""
+	}
+	return GlobalConfig.EncryptSecret.PaymentSecret
+}
+
+// GetMoneySecret 获取钱包余额加密密钥，为空时余额明文存储
+func GetMoneySecret() string {
+	if GlobalConfig == nil || GlobalConfig.EncryptSecret == nil {
+		return ""
+	}
+	return GlobalConfig.EncryptSecret.MoneySecret
+}
+
+// GetBaseCurrency 获取基础币种，未配置时使用 USD
+func GetBaseCurrency() string {
+	if GlobalConfig == nil || GlobalConfig.Currency == nil || GlobalConfig.Currency.Base == "" {
+		return "USD"
+	}
+	return strings.ToUpper(GlobalConfig.Currency.Base)
+}
+
+// GetExchangeRatesFile 获取启动时导入的汇率文件路径
+func GetExchangeRatesFile() string {
+	if GlobalConfig == nil || GlobalConfig.Currency == nil {
+		return ""
+	}
+	return GlobalConfig.Currency.RatesFile
+}
+
+// GetSearchBackend 获取商品搜索后端，默认使用 MySQL 全文索引
+func GetSearchBackend() string {
+	if GlobalConfig == nil || GlobalConfig.Search == nil || GlobalConfig.Search.Backend == "" {
+		return "mysql"
+	}
+	return strings.ToLower(GlobalConfig.Search.Backend)
+}
+
+// GetEsAddress 获取 ElasticSearch 服务地址，默认 http://127.0.0.1:9200
+func GetEsAddress() string {
+	host, port := "127.0.0.1", "9200"
+	if GlobalConfig != nil && GlobalConfig.Es != nil {
+		if GlobalConfig.Es.EsHost != "" {
+			host = GlobalConfig.Es.EsHost
+		}
+		if GlobalConfig.Es.EsPort != "" {
+			port = GlobalConfig.Es.EsPort
+		}
+	}
+	if strings.HasPrefix(host, "http://") || strings.HasPrefix(host, "https://") {
+		return host + ":" + port
+	}
+	return "http://" + host + ":" + port
+}
+
+// GetEsIndex 获取商品索引名称，默认 products
+func GetEsIndex() string {
+	if GlobalConfig == nil || GlobalConfig.Es == nil || GlobalConfig.Es.EsIndex == "" {
+		return "products"
+	}
+	return GlobalConfig.Es.EsIndex
+}
+
+// GetEsBasicAuth 获取 ElasticSearch 的认证用户名和密码，未配置时均为空
+func GetEsBasicAuth() (username, password string) {
+	if GlobalConfig == nil || GlobalConfig.Es == nil {
+		return "", ""
+	}
+	return GlobalConfig.Es.EsUsername, GlobalConfig.Es.EsPassword
+}
@@ -0,0 +1,16 @@
+package consts
+
+const (
+	// CartItemMaxQuantity 购物车单行商品的最大数量
+	CartItemMaxQuantity = 999
+	// GuestCartCookie 游客购物车令牌所在的 Cookie 名称
+	GuestCartCookie = "guest_cart"
+)
+
+// 购物车行提示类型，商品在加入购物车后发生的变化
+const (
+	CartWarningPriceIncreased    = "price_increased"    // 涨价
+	CartWarningPriceDecreased    = "price_decreased"    // 降价
+	CartWarningInsufficientStock = "insufficient_stock" // 可售数量不足
+	CartWarningProductDeleted    = "product_deleted"    // 商品已下架
+)
@@ -2,4 +2,7 @@ package consts
 
 const (
 	BasePageSize = 15
+	MaxPageSize  = 100 // 分页查询每页最多返回的条数
+
+	DefaultCurrency = "USD" // 未指定结算币种时使用的默认币种
 )
@@ -0,0 +1,18 @@
+package consts
+
+// 优惠券类型，金额均以基础币种配置，下单时按订单汇率快照换算
+const (
+	CouponTypeFixed     = "FIXED"     // 立减：适用商品金额达到门槛（可为 0）后减免 Value
+	CouponTypePercent   = "PERCENT"   // 折扣：减免适用商品金额的 Percent%，可设置最高优惠金额
+	CouponTypeThreshold = "THRESHOLD" // 满减：适用商品金额满 Threshold 减 Value
+)
+
+// 用户优惠券状态
+const (
+	UserCouponStatusUnused  = "UNUSED"  // 未使用
+	UserCouponStatusUsed    = "USED"    // 已使用（订单取消或超时关闭后退回为未使用）
+	UserCouponStatusExpired = "EXPIRED" // 已过期
+)
+
+// CouponMaxPerOrder 单个订单最多可使用的优惠券数量
+const CouponMaxPerOrder = 5
@@ -0,0 +1,7 @@
+package consts
+
+// 汇率数据来源
+const (
+	ExchangeRateSourceFile  = "file"  // 启动时从本地文件导入
+	ExchangeRateSourceAdmin = "admin" // 管理员通过接口维护
+)
@@ -0,0 +1,11 @@
+package consts
+
+// 秒杀抢购结果状态，保存在 Redis 中供客户端轮询
+const (
+	FlashSaleResultPending = "PENDING" // 已抢到名额，订单创建中
+	FlashSaleResultSuccess = "SUCCESS" // 订单已创建，待支付
+	FlashSaleResultFailed  = "FAILED"  // 下单失败，名额已归还，可以重新抢购
+)
+
+// FlashSaleWorkers 秒杀下单队列的消费协程数
+const FlashSaleWorkers = 4
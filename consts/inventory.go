@@ -0,0 +1,31 @@
+package consts
+
+// 库存流水类型
+const (
+	InventoryMoveReceipt     = "RECEIPT"     // 入库：采购到货、新建商品时的初始库存
+	InventoryMoveReservation = "RESERVATION" // 下单占用：未支付订单锁定库存，在库数量不变
+	InventoryMoveRelease     = "RELEASE"     // 释放占用：未支付订单取消或超时关闭
+	InventoryMoveSale        = "SALE"        // 销售出库：订单支付成功，占用转为出库
+	InventoryMoveReturn      = "RETURN"      // 退货入库：退货退款的商品重新入库
+	InventoryMoveAdjustment  = "ADJUSTMENT"  // 盘点调整：人工调整、修改商品库存、期初库存迁移
+)
+
+// 库存流水关联的业务单据类型
+const (
+	InventoryRefOrder   = "order"   // 订单，RefID 为订单ID
+	InventoryRefRefund  = "refund"  // 售后单，RefID 为售后单ID
+	InventoryRefProduct = "product" // 商品维护，RefID 为商品ID
+	InventoryRefManual  = "manual"  // 仓库人工操作，RefID 为操作单号（可为空）
+)
+
+// DefaultWarehouseCode 默认仓库编码，迁移期初库存和修改商品库存时使用，启动时自动创建
+const DefaultWarehouseCode = "DEFAULT"
+
+// 库存事件类型，库存变动时在同一事务中写入，由库存通知 worker 异步发送邮件
+const (
+	StockEventLowStock    = "LOW_STOCK"     // 商品总库存降至预警阈值及以下
+	StockEventBackInStock = "BACK_IN_STOCK" // SKU 可售库存从 0 恢复为正数
+)
+
+// StockEventBatchSize 库存通知 worker 每轮处理的事件数量上限
+const StockEventBatchSize = 100
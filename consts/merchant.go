@@ -0,0 +1,22 @@
+package consts
+
+// 商家状态
+const (
+	MerchantStatusActive    = "ACTIVE"    // 正常营业，可发布与修改商品
+	MerchantStatusSuspended = "SUSPENDED" // 已被管理员停用，不能发布或修改商品，已上架商品照常售卖
+)
+
+// 商家结算明细类型
+const (
+	SettlementTypeSale   = "SALE"   // 订单支付成功，商家应收货款
+	SettlementTypeRefund = "REFUND" // 售后退款，从商家应收中扣回
+)
+
+// 商家结算明细状态
+const (
+	SettlementStatusPending = "PENDING" // 待结算
+	SettlementStatusSettled = "SETTLED" // 已结算到商家钱包
+)
+
+// ProductManagePermission 平台商品管理权限：可发布平台自营商品（不属于任何商家），并修改或删除任意商品
+const ProductManagePermission = "product:manage"
@@ -5,6 +5,9 @@ const (
 	OrderTypePendingShipping
 	OrderTypeShipping
 	OrderTypeReceipt
+	OrderTypeCancelled
+	OrderTypeRefunded
+	OrderTypeClosed
 )
 
 var OrderTypeMap = map[int]string{
@@ -12,4 +15,24 @@ var OrderTypeMap = map[int]string{
 	OrderTypePendingShipping: "已支付，待发货",
 	OrderTypeShipping:        "已发货，待收货",
 	OrderTypeReceipt:         "已收货，交易成功",
+	OrderTypeCancelled:       "已取消",
+	OrderTypeRefunded:        "已退款",
+	OrderTypeClosed:          "已关闭",
 }
+
+// OrderStatusTransitions 订单状态机：key 为当前状态，value 为允许流转到的目标状态
+// 未列出的状态（已取消、已退款、已关闭）为终态，不允许再流转
+var OrderStatusTransitions = map[int][]int{
+	OrderTypeUnPaid:          {OrderTypePendingShipping, OrderTypeCancelled, OrderTypeClosed},
+	OrderTypePendingShipping: {OrderTypeShipping, OrderTypeRefunded},
+	OrderTypeShipping:        {OrderTypeReceipt, OrderTypeRefunded},
+	OrderTypeReceipt:         {OrderTypeRefunded},
+}
+
+// 订单状态变更的操作者类型，写入 order_status_history.actor
+const (
+	OrderActorUser     = "user"
+	OrderActorAdmin    = "admin"
+	OrderActorMerchant = "merchant"
+	OrderActorSystem   = "system"
+)
@@ -0,0 +1,27 @@
+package consts
+
+// 支付单状态
+const (
+	PaymentStatusUnpaid   = "UNPAID"   // 待支付
+	PaymentStatusPending  = "PENDING"  // 已向支付渠道发起扣款，等待渠道确认
+	PaymentStatusPaid     = "PAID"     // 已支付
+	PaymentStatusFailed   = "FAILED"   // 支付失败，可重新发起支付
+	PaymentStatusExpired  = "EXPIRED"  // 订单超时关闭或取消，支付单失效
+	PaymentStatusRefunded = "REFUNDED" // 已退款
+)
+
+// PaymentStatusTransitions 支付单状态机：key 为当前状态，value 为允许流转到的状态
+// 处理中的支付单渠道可能已经扣款，只有超时关单等待渠道结果超过时限后才失效并标记待对账；失效后渠道才通知扣款成功时自动退款，支付单流转为已退款
+var PaymentStatusTransitions = map[string][]string{
+	PaymentStatusUnpaid:  {PaymentStatusPending, PaymentStatusPaid, PaymentStatusFailed, PaymentStatusExpired},
+	PaymentStatusPending: {PaymentStatusPaid, PaymentStatusFailed, PaymentStatusExpired},
+	PaymentStatusFailed:  {PaymentStatusPending, PaymentStatusPaid, PaymentStatusExpired},
+	PaymentStatusPaid:    {PaymentStatusRefunded},
+	PaymentStatusExpired: {PaymentStatusRefunded},
+}
+
+// 支付渠道
+const (
+	PaymentProviderMock   = "mock"   // 本地模拟/沙箱支付渠道，用于开发测试
+	PaymentProviderWallet = "wallet" // 账户余额支付（User.Money）
+)
@@ -2,6 +2,21 @@ package consts
 
 const BaseProductPageSize = 15
 
+// SkillProductQueues 秒杀下单任务队列（Redis 列表）的键名
 const SkillProductQueues = "rabbitmq-skill-product-queues"
 
 const ProductBatchCreate = 1000
+
+// 商品搜索排序方式，未指定时有关键词按相关度排序，否则按上架时间倒序
+const (
+	ProductSortPriceAsc  = "price_asc"  // 价格从低到高
+	ProductSortPriceDesc = "price_desc" // 价格从高到低
+	ProductSortSales     = "sales"      // 销量从高到低
+	ProductSortNewest    = "newest"     // 上架时间从新到旧
+)
+
+// 商品搜索后端
+const (
+	SearchBackendMySQL = "mysql" // MySQL 全文索引，直接查询商品表，无需同步
+	SearchBackendES    = "es"    // ElasticSearch，商品变更时增量同步索引
+)
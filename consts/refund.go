@@ -0,0 +1,34 @@
+package consts
+
+import "time"
+
+// 售后类型
+const (
+	RefundTypeRefundOnly = "REFUND_ONLY" // 仅退款
+	RefundTypeReturn     = "RETURN"      // 退货退款，退款成功后退回商品重新入库
+)
+
+// 售后单状态
+const (
+	RefundStatusPending    = "PENDING"    // 待审核
+	RefundStatusProcessing = "PROCESSING" // 审核通过，已向支付渠道发起退款，等待退款结果
+	RefundStatusRejected   = "REJECTED"   // 审核拒绝
+	RefundStatusRefunded   = "REFUNDED"   // 审核通过且已退款
+)
+
+// RefundableOrderStatus 允许申请售后的订单状态
+var RefundableOrderStatus = map[int]bool{
+	OrderTypePendingShipping: true,
+	OrderTypeShipping:        true,
+	OrderTypeReceipt:         true,
+}
+
+// RefundEvidenceMaxFiles 售后凭证图片数量上限
+const RefundEvidenceMaxFiles = 6
+
+// 渠道退款重试：处理中的售后单在最后更新超过 RefundRetryDelay 后由后台 worker 以相同售后单号重新向渠道发起退款
+const (
+	RefundRetryInterval  = time.Minute     // 重试轮询间隔
+	RefundRetryDelay     = 2 * time.Minute // 处理中超过该时长才重试，避免与审核请求中的渠道调用并发
+	RefundRetryBatchSize = 100             // 每轮重试的售后单数量上限
+)
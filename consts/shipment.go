@@ -0,0 +1,16 @@
+package consts
+
+// 发货单物流状态，由物流轨迹查询结果更新
+const (
+	ShipmentStatusShipped   = "SHIPPED"    // 已发货，快递公司尚未揽收或暂无物流轨迹
+	ShipmentStatusInTransit = "IN_TRANSIT" // 运输中
+	ShipmentStatusDelivered = "DELIVERED"  // 快递已签收，等待买家确认收货
+)
+
+// 物流轨迹查询实现，取值用于配置项 shipping.tracker
+const (
+	CarrierTrackerFile = "file" // 从本地 JSON 文件读取物流轨迹，用于开发与测试
+)
+
+// ShipmentTrackBatchSize 每轮刷新物流轨迹、自动确认收货时处理的发货单数量上限
+const ShipmentTrackBatchSize = 100
@@ -35,7 +35,7 @@ const (
 
 const EncryptMoneyKeyLength = 6
 
-const UserInitMoney = "10000" // 初始金额
+const UserInitMoney = "10000" // 初始金额，开通钱包时作为期初余额记入流水
 
 // MoneyDecryptKey 定义余额解密密钥（本项目余额不加密，不做解密，所以可为空）
 const MoneyDecryptKey = ""
@@ -0,0 +1,18 @@
+package consts
+
+// 钱包流水类型
+const (
+	WalletTxnTopUp      = "TOPUP"      // 充值
+	WalletTxnPurchase   = "PURCHASE"   // 余额支付
+	WalletTxnRefund     = "REFUND"     // 退款退回余额
+	WalletTxnAdjustment = "ADJUSTMENT" // 人工调整、期初余额迁移
+	WalletTxnSettlement = "SETTLEMENT" // 商家货款结算
+)
+
+// 复式记账中与用户钱包相对的系统账户
+const (
+	WalletAccountExternal = "system:external" // 外部资金（充值来源）
+	WalletAccountSales    = "system:sales"    // 平台销售收入（余额支付入账、退款出账）
+	WalletAccountOpening  = "system:opening"  // 期初余额（从 User.Money 迁移）
+	WalletAccountAdjust   = "system:adjust"   // 人工调整
+)
@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"douyin/pkg/utils/log"
+	"douyin/pkg/utils/response"
+	"douyin/repository/cache"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// IdempotencyHeader 客户端携带的幂等键请求头
+	IdempotencyHeader = "Idempotency-Key"
+	// IdempotencyReplayedHeader 标识当前响应是重放的缓存结果
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+	idempotencyMaxKeyLen = 128
+	// idempotencyLockTTL 请求处理中占位记录的过期时间，防止进程崩溃后幂等键被永久占用
+	idempotencyLockTTL = time.Minute
+
+	idempotencyStateProcessing = "processing"
+	idempotencyStateDone       = "done"
+)
+
+// idempotencyRecord Redis 中保存的幂等记录
+type idempotencyRecord struct {
+	State       string `json:"state"`
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	BodyHash    string `json:"body_hash"` // 请求体的 SHA-256（十六进制），同一幂等键的请求体必须一致
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// bodyCaptureWriter 在写出响应的同时缓存响应体
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *bodyCaptureWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency 基于 Idempotency-Key 请求头的幂等中间件，需放在 AuthMiddleware 之后
+// 同一用户 + 幂等键的首个请求结果会在 Redis 中保存 ttl 时长，重复请求直接返回保存的响应；
+// 首个请求尚未完成时的并发重复请求返回 409；同一幂等键用于不同的接口或请求体时返回 422。
+// 请求失败（返回 error 或 5xx）时删除记录，允许客户端重试。
+// 未携带请求头或 Redis 不可用时直接放行。
+func Idempotency(ttl time.Duration) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(IdempotencyHeader)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+		if len(key) > idempotencyMaxKeyLen {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法：Idempotency-Key 过长"))
+			return
+		}
+
+		rdb := cache.RedisClient
+		if rdb == nil {
+			log.Warnf("Redis 未初始化，幂等校验已跳过 (path: %s)", ctx.FullPath())
+			ctx.Next()
+			return
+		}
+
+		userIDVal, exists := ctx.Get("user_id")
+		if !exists {
+			_ = ctx.Error(errors.New("用户未授权或user_id未在context中设置"))
+			ctx.Abort()
+			return
+		}
+		userID, ok := userIDVal.(uint)
+		if !ok {
+			_ = ctx.Error(errors.New("user_id在context中的类型错误"))
+			ctx.Abort()
+			return
+		}
+
+		// 读取请求体计算摘要后放回，供后续处理函数绑定参数
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Fail(1001, "参数非法：读取请求体失败"))
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		bodyHash := hex.EncodeToString(sum[:])
+
+		redisKey := cache.IdempotencyKey(userID, key)
+		reqCtx := ctx.Request.Context()
+		pending, _ := json.Marshal(idempotencyRecord{
+			State:    idempotencyStateProcessing,
+			Method:   ctx.Request.Method,
+			Path:     ctx.FullPath(),
+			BodyHash: bodyHash,
+		})
+
+		acquired, err := rdb.SetNX(reqCtx, redisKey, pending, idempotencyLockTTL).Result()
+		if err != nil {
+			log.Errorf("幂等键占位失败 (key: %s): %v", redisKey, err)
+			ctx.Next()
+			return
+		}
+		if !acquired {
+			replayIdempotentResponse(ctx, rdb, redisKey, bodyHash)
+			return
+		}
+
+		writer := &bodyCaptureWriter{ResponseWriter: ctx.Writer, body: &bytes.Buffer{}}
+		ctx.Writer = writer
+		ctx.Next()
+
+		// 使用独立的 context，避免请求超时或客户端断开导致记录无法写入/清理
+		saveCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		status := writer.Status()
+		if len(ctx.Errors) > 0 || status >= http.StatusInternalServerError {
+			if err := rdb.Del(saveCtx, redisKey).Err(); err != nil {
+				log.Errorf("清理幂等键失败 (key: %s): %v", redisKey, err)
+			}
+			return
+		}
+
+		done, _ := json.Marshal(idempotencyRecord{
+			State:       idempotencyStateDone,
+			Method:      ctx.Request.Method,
+			Path:        ctx.FullPath(),
+			BodyHash:    bodyHash,
+			StatusCode:  status,
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		})
+		if err := rdb.Set(saveCtx, redisKey, done, ttl).Err(); err != nil {
+			log.Errorf("保存幂等响应失败 (key: %s): %v", redisKey, err)
+		}
+	}
+}
+
+// replayIdempotentResponse 处理幂等键已存在的请求：接口或请求体不一致返回 422，处理中返回 409，已完成则重放保存的响应
+func replayIdempotentResponse(ctx *gin.Context, rdb *redis.Client, redisKey, bodyHash string) {
+	raw, err := rdb.Get(ctx.Request.Context(), redisKey).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			// 占位记录恰好过期或被清理，提示客户端重试
+			ctx.AbortWithStatusJSON(http.StatusConflict, response.Fail(http.StatusConflict, "请求正在处理中，请稍后重试"))
+			return
+		}
+		log.Errorf("读取幂等记录失败 (key: %s): %v", redisKey, err)
+		_ = ctx.Error(err)
+		ctx.Abort()
+		return
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		log.Errorf("解析幂等记录失败 (key: %s): %v", redisKey, err)
+		_ = ctx.Error(err)
+		ctx.Abort()
+		return
+	}
+
+	if record.Method != ctx.Request.Method || record.Path != ctx.FullPath() {
+		ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, response.Fail(http.StatusUnprocessableEntity, "Idempotency-Key 已用于其他请求"))
+		return
+	}
+	// 升级前保存的记录没有请求体摘要，不做校验
+	if record.BodyHash != "" && record.BodyHash != bodyHash {
+		ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, response.Fail(http.StatusUnprocessableEntity, "Idempotency-Key 已用于请求内容不同的请求"))
+		return
+	}
+	if record.State == idempotencyStateProcessing {
+		ctx.AbortWithStatusJSON(http.StatusConflict, response.Fail(http.StatusConflict, "相同请求正在处理中，请勿重复提交"))
+		return
+	}
+
+	ctx.Header(IdempotencyReplayedHeader, "true")
+	ctx.Data(record.StatusCode, record.ContentType, record.Body)
+	ctx.Abort()
+}
@@ -89,6 +89,22 @@ func AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// OptionalAuthMiddleware 可选登录中间件：携带有效访问令牌时设置 user_id/user_name，否则按匿名请求继续处理
+// 用于商品详情、列表等公开接口按登录用户的偏好展示内容，不做令牌续期
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader(consts.HeaderAuthorization)
+		const bearerPrefix = "Bearer "
+		if strings.HasPrefix(authHeader, bearerPrefix) {
+			if claims, err := jwt.ParseAccessToken(strings.TrimPrefix(authHeader, bearerPrefix)); err == nil {
+				c.Set("user_id", claims.UserId)
+				c.Set("user_name", claims.Username)
+			}
+		}
+		c.Next()
+	}
+}
+
 // SetToken 将访问令牌和刷新令牌设置到响应头和Cookie中
 // This function might be used by login/register handlers.
 // Note: consts.AccessTokenHeader and consts.RefreshTokenHeader from the original code
@@ -17,7 +17,7 @@ import (
 func RBAC(requiredPerm string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 1. Get userID from context (set by AuthMiddleware)
-		userIDAny, exists := c.Get("userID") // As per issue's AuthMiddleware hint
+		userIDAny, exists := c.Get("user_id") // AuthMiddleware 以 "user_id" 写入上下文
 		if !exists {
 			response.Fail(c, http.StatusUnauthorized, "用户未登录 (User not logged in)")
 			c.Abort()
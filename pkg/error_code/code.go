@@ -30,6 +30,7 @@ const (
 	// 购物车
 	ErrorProductExistCart = 20007
 	ErrorProductMoreCart  = 20008
+	ErrorCartChanged      = 20009
 
 	// 管理员错误
 	ErrorAuthCheckTokenFail        = 30001
@@ -27,6 +27,7 @@ var MsgFlags = map[int]string{
 
 	ErrorProductExistCart: "商品已经在购物车了，数量+1",
 	ErrorProductMoreCart:  "超过最大上限",
+	ErrorCartChanged:      "购物车商品信息已变更",
 
 	ErrorAuthCheckTokenFail:        "Token鉴权失败",
 	ErrorAuthCheckTokenTimeout:     "Token已超时",
@@ -0,0 +1,25 @@
+package encryption
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// SignHMAC 使用 HMAC-SHA256 对各部分数据依次签名，返回十六进制字符串
+func SignHMAC(secret string, parts ...[]byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	for _, part := range parts {
+		mac.Write(part)
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyHMAC 以常量时间比较签名，secret 为空时一律校验失败
+func VerifyHMAC(secret, signature string, parts ...[]byte) bool {
+	if secret == "" || signature == "" {
+		return false
+	}
+	expected := SignHMAC(secret, parts...)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
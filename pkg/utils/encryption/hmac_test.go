@@ -0,0 +1,17 @@
+package encryption
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyHMAC(t *testing.T) {
+	body := []byte(`{"transaction_id":"t1","status":"PAID"}`)
+	sig := SignHMAC("secret", []byte("1700000000."), body)
+
+	assert.True(t, VerifyHMAC("secret", sig, []byte("1700000000."), body))
+	assert.False(t, VerifyHMAC("other", sig, []byte("1700000000."), body), "密钥不同应校验失败")
+	assert.False(t, VerifyHMAC("secret", sig, []byte("1700000001."), body), "时间戳被篡改应校验失败")
+	assert.False(t, VerifyHMAC("", SignHMAC("", body), body), "未配置密钥时应拒绝")
+}
@@ -1 +1,70 @@
 package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// encryptedMoneyPrefix 加密后的金额前缀，用于区分未加密的历史数据
+const encryptedMoneyPrefix = "enc:"
+
+// EncryptMoney 将金额（最小货币单位，如分）序列化为存储字符串
+// secret 为空时以明文十进制存储；否则使用 AES-256-GCM 加密（密钥为 secret 的 SHA-256）
+func EncryptMoney(secret string, minorUnits int64) (string, error) {
+	plain := strconv.FormatInt(minorUnits, 10)
+	if secret == "" {
+		return plain, nil
+	}
+	gcm, err := moneyCipher(secret)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return encryptedMoneyPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptMoney 解析 EncryptMoney 生成的字符串，兼容未加密的明文值
+func DecryptMoney(secret, value string) (int64, error) {
+	if !strings.HasPrefix(value, encryptedMoneyPrefix) {
+		return strconv.ParseInt(value, 10, 64)
+	}
+	if secret == "" {
+		return 0, errors.New("金额已加密，但未配置 MoneySecret")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedMoneyPrefix))
+	if err != nil {
+		return 0, err
+	}
+	gcm, err := moneyCipher(secret)
+	if err != nil {
+		return 0, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return 0, errors.New("加密金额格式错误")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return 0, errors.New("金额解密失败")
+	}
+	return strconv.ParseInt(string(plain), 10, 64)
+}
+
+func moneyCipher(secret string) (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
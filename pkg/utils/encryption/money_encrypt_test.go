@@ -0,0 +1,30 @@
+package encryption
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoneyEncryptRoundTrip(t *testing.T) {
+	plain, err := EncryptMoney("", 1234)
+	require.NoError(t, err)
+	assert.Equal(t, "1234", plain)
+
+	enc, err := EncryptMoney("secret", -1234)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(enc, "enc:"))
+
+	v, err := DecryptMoney("secret", enc)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1234), v)
+
+	_, err = DecryptMoney("wrong", enc)
+	assert.Error(t, err, "密钥错误应解密失败")
+
+	v, err = DecryptMoney("secret", "500")
+	require.NoError(t, err)
+	assert.Equal(t, int64(500), v, "应兼容未加密的历史数据")
+}
@@ -0,0 +1,59 @@
+package money
+
+import (
+	"math/big"
+	"sort"
+)
+
+// Allocate 将 total 按 weights 的比例拆分，各份之和恰好等于 total
+// 先按比例向下取整，剩余的分按小数部分从大到小逐一补齐，小数部分相同时靠前的优先；
+// 权重为负数时按 0 处理，权重之和为 0 时全部分配给第一份
+func Allocate(total Amount, weights []Amount) []Amount {
+	parts := make([]Amount, len(weights))
+	if len(weights) == 0 {
+		return parts
+	}
+	var sum int64
+	for _, w := range weights {
+		if w > 0 {
+			sum += int64(w)
+		}
+	}
+	if sum == 0 {
+		parts[0] = total
+		return parts
+	}
+
+	type remainder struct {
+		index int
+		value *big.Int
+	}
+	var (
+		bigTotal  = big.NewInt(int64(total))
+		bigSum    = big.NewInt(sum)
+		allocated Amount
+		rems      = make([]remainder, 0, len(weights))
+	)
+	for i, w := range weights {
+		if w <= 0 {
+			continue
+		}
+		// total * w / sum，余数用于决定剩余的分补给谁
+		q, r := new(big.Int).QuoRem(new(big.Int).Mul(bigTotal, big.NewInt(int64(w))), bigSum, new(big.Int))
+		parts[i] = Amount(q.Int64())
+		allocated += parts[i]
+		rems = append(rems, remainder{index: i, value: r.Abs(r)})
+	}
+
+	left := total - allocated
+	step := Amount(1)
+	if left < 0 {
+		step = -1
+	}
+	sort.SliceStable(rems, func(i, j int) bool { return rems[i].value.Cmp(rems[j].value) > 0 })
+	for i := 0; left != 0; i++ {
+		parts[rems[i%len(rems)].index] += step
+		left -= step
+	}
+	return parts
+}
@@ -0,0 +1,30 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllocate(t *testing.T) {
+	// 10.00 按 1:1:1 拆分，多出的 1 分给第一份
+	assert.Equal(t, []Amount{334, 333, 333}, Allocate(1000, []Amount{500, 500, 500}))
+
+	// 按金额比例拆分：3.00 分摊到 20.00 和 10.00
+	assert.Equal(t, []Amount{200, 100}, Allocate(300, []Amount{2000, 1000}))
+
+	// 余数较大的一份优先补齐
+	assert.Equal(t, []Amount{33, 67}, Allocate(100, []Amount{1, 2}))
+
+	// 权重为 0 的份不参与分摊
+	assert.Equal(t, []Amount{0, 100}, Allocate(100, []Amount{0, 7}))
+
+	// 权重之和为 0 时全部分给第一份
+	assert.Equal(t, []Amount{100, 0}, Allocate(100, []Amount{0, 0}))
+
+	// 负数金额同样保证总和不变
+	parts := Allocate(-100, []Amount{1, 1, 1})
+	assert.Equal(t, Amount(-100), parts[0]+parts[1]+parts[2])
+
+	assert.Empty(t, Allocate(100, nil))
+}
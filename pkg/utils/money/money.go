@@ -0,0 +1,194 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Scale 每个货币单位包含的最小单位数，金额统一精确到两位小数（分）
+const Scale = 100
+
+// SQLType 金额列在数据库中的类型
+const SQLType = "decimal(20,2)"
+
+var (
+	// ErrInvalidAmount 金额格式非法
+	ErrInvalidAmount = errors.New("金额格式非法")
+	// ErrCurrencyMismatch 不同币种的金额不能直接运算
+	ErrCurrencyMismatch = errors.New("币种不一致")
+)
+
+// Amount 定点金额，以最小货币单位（分）存储，避免浮点运算误差
+// JSON 中序列化为保留两位小数的数字（如 12.34），数据库中存为 DECIMAL(20,2)
+type Amount int64
+
+// FromFloat 将浮点金额四舍五入到分，仅用于兼容历史数据和第三方浮点报文
+func FromFloat(f float64) Amount {
+	return Amount(math.Round(f * Scale))
+}
+
+// Parse 解析十进制金额字符串，如 "12"、"12.3"、"-0.05"，最多两位小数
+func Parse(s string) (Amount, error) {
+	v, err := parseFixed(s, 2)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidAmount, strings.TrimSpace(s))
+	}
+	return Amount(v), nil
+}
+
+// parseFixed 将十进制字符串解析为保留 places 位小数的定点整数，小数位超出时报错
+func parseFixed(s string, places int) (int64, error) {
+	s = strings.TrimSpace(s)
+	negative := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+	intPart, fracPart, hasDot := strings.Cut(s, ".")
+	if !isDigits(intPart) || (hasDot && !isDigits(fracPart)) || len(fracPart) > places {
+		return 0, ErrInvalidAmount
+	}
+	scale := int64(math.Pow10(places))
+	units, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil || units > math.MaxInt64/scale {
+		return 0, ErrInvalidAmount
+	}
+	var minor int64
+	if fracPart != "" {
+		fracPart += strings.Repeat("0", places-len(fracPart))
+		if minor, err = strconv.ParseInt(fracPart, 10, 64); err != nil {
+			return 0, ErrInvalidAmount
+		}
+	}
+	v := units*scale + minor
+	if negative {
+		v = -v
+	}
+	return v, nil
+}
+
+// isDigits 判断字符串是否为非空的纯数字
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// MinorUnits 返回以分为单位的整数金额
+func (a Amount) MinorUnits() int64 {
+	return int64(a)
+}
+
+// Mul 金额乘以数量
+func (a Amount) Mul(n int64) Amount {
+	return a * Amount(n)
+}
+
+// Float64 转换为浮点数，仅用于展示或对接只接受浮点的外部接口
+func (a Amount) Float64() float64 {
+	return float64(a) / Scale
+}
+
+// String 格式化为保留两位小数的字符串，如 -1234 -> "-12.34"
+func (a Amount) String() string {
+	v := int64(a)
+	sign := ""
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/Scale, v%Scale)
+}
+
+// MarshalJSON 序列化为两位小数的 JSON 数字
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON 兼容 JSON 数字和字符串两种写法，按十进制文本解析，不经过浮点数
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	v, err := Parse(strings.Trim(s, `"`))
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// Value 实现 driver.Valuer，写库时使用十进制字符串，由数据库按 DECIMAL 精确存储
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// Scan 实现 sql.Scanner，兼容 DECIMAL（[]byte/string）以及迁移前的浮点列
+func (a *Amount) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = 0
+	case []byte:
+		return a.scanString(string(v))
+	case string:
+		return a.scanString(v)
+	case float64:
+		*a = FromFloat(v)
+	case float32:
+		*a = FromFloat(float64(v))
+	case int64:
+		*a = Amount(v * Scale)
+	default:
+		return fmt.Errorf("无法将 %T 转换为金额", src)
+	}
+	return nil
+}
+
+func (a *Amount) scanString(s string) error {
+	v, err := Parse(s)
+	if err != nil {
+		// 迁移前的 DOUBLE 列可能带有多位小数
+		f, ferr := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if ferr != nil {
+			return err
+		}
+		v = FromFloat(f)
+	}
+	*a = v
+	return nil
+}
+
+// Money 带币种的金额
+type Money struct {
+	Amount   Amount `json:"amount"`   // 金额
+	Currency string `json:"currency"` // 币种，ISO 4217 代码
+}
+
+// New 创建带币种的金额
+func New(amount Amount, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// Add 相同币种的金额相加
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s != %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// String 格式化为 "12.34 USD"
+func (m Money) String() string {
+	return m.Amount.String() + " " + m.Currency
+}
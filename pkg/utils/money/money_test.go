@@ -0,0 +1,83 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]Amount{
+		"12":     1200,
+		"12.3":   1230,
+		"12.34":  1234,
+		"-0.05":  -5,
+		"+1.10":  110,
+		" 0.01 ": 1,
+	}
+	for in, want := range cases {
+		got, err := Parse(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	for _, in := range []string{"", "1.234", "abc", ".5", "1.", "1.2.3", "--1", "1.-5"} {
+		_, err := Parse(in)
+		assert.ErrorIs(t, err, ErrInvalidAmount, in)
+	}
+}
+
+func TestAmountString(t *testing.T) {
+	assert.Equal(t, "12.34", Amount(1234).String())
+	assert.Equal(t, "-0.05", Amount(-5).String())
+	assert.Equal(t, "0.00", Amount(0).String())
+}
+
+func TestAmountArithmeticIsExact(t *testing.T) {
+	// 0.1 + 0.2 在浮点数下不等于 0.3
+	price, err := Parse("0.10")
+	require.NoError(t, err)
+	total := price.Mul(3)
+	assert.Equal(t, "0.30", total.String())
+	assert.Equal(t, FromFloat(19.99).Mul(3), Amount(5997))
+}
+
+func TestAmountJSON(t *testing.T) {
+	var v struct {
+		Price Amount `json:"price"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"price":19.9}`), &v))
+	assert.Equal(t, Amount(1990), v.Price)
+	require.NoError(t, json.Unmarshal([]byte(`{"price":"0.07"}`), &v))
+	assert.Equal(t, Amount(7), v.Price)
+	assert.Error(t, json.Unmarshal([]byte(`{"price":1.005}`), &v))
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"price":0.07}`, string(data))
+}
+
+func TestAmountScan(t *testing.T) {
+	var a Amount
+	require.NoError(t, a.Scan([]byte("12.30")))
+	assert.Equal(t, Amount(1230), a)
+	require.NoError(t, a.Scan(float64(0.1+0.2)))
+	assert.Equal(t, Amount(30), a)
+	require.NoError(t, a.Scan("9.999999"))
+	assert.Equal(t, Amount(1000), a)
+
+	v, err := Amount(-1234).Value()
+	require.NoError(t, err)
+	assert.Equal(t, "-12.34", v)
+}
+
+func TestMoneyAdd(t *testing.T) {
+	sum, err := New(100, "usd").Add(New(250, "USD"))
+	require.NoError(t, err)
+	assert.Equal(t, "3.50 USD", sum.String())
+
+	_, err = New(100, "USD").Add(New(100, "EUR"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+}
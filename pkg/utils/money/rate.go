@@ -0,0 +1,131 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// RatePlaces 汇率保留的小数位数
+const RatePlaces = 8
+
+// RateScale 汇率的定点缩放倍数
+const RateScale = 100000000
+
+// RateSQLType 汇率列在数据库中的类型
+const RateSQLType = "decimal(20,8)"
+
+// OneRate 汇率 1，即基础币种自身
+const OneRate = Rate(RateScale)
+
+// ErrInvalidRate 汇率格式非法或不大于 0
+var ErrInvalidRate = errors.New("汇率格式非法")
+
+// Rate 定点汇率，表示 1 单位基础币种可兑换的目标币种数量，精确到 8 位小数
+type Rate int64
+
+// ParseRate 解析十进制汇率字符串，如 "0.92"、"7.1234"，必须大于 0
+func ParseRate(s string) (Rate, error) {
+	v, err := parseFixed(s, RatePlaces)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidRate, strings.TrimSpace(s))
+	}
+	return Rate(v), nil
+}
+
+// String 格式化为去掉末尾 0 的十进制字符串，如 "0.92"
+func (r Rate) String() string {
+	v := int64(r)
+	sign := ""
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	frac := strings.TrimRight(fmt.Sprintf("%08d", v%RateScale), "0")
+	if frac == "" {
+		return fmt.Sprintf("%s%d", sign, v/RateScale)
+	}
+	return fmt.Sprintf("%s%d.%s", sign, v/RateScale, frac)
+}
+
+// Convert 按汇率将基础币种金额换算为目标币种金额，四舍五入到分
+func (a Amount) Convert(r Rate) Amount {
+	if r == OneRate {
+		return a
+	}
+	product := new(big.Int).Mul(big.NewInt(int64(a)), big.NewInt(int64(r)))
+	half := big.NewInt(RateScale / 2)
+	if product.Sign() < 0 {
+		half.Neg(half)
+	}
+	product.Add(product, half)
+	return Amount(product.Quo(product, big.NewInt(RateScale)).Int64())
+}
+
+// ConvertBack 按汇率将目标币种金额换算回基础币种金额，四舍五入到分，是 Convert 的逆运算
+func (a Amount) ConvertBack(r Rate) Amount {
+	if r == OneRate || r <= 0 {
+		return a
+	}
+	product := new(big.Int).Mul(big.NewInt(int64(a)), big.NewInt(RateScale))
+	half := big.NewInt(int64(r) / 2)
+	if product.Sign() < 0 {
+		half.Neg(half)
+	}
+	product.Add(product, half)
+	return Amount(product.Quo(product, big.NewInt(int64(r))).Int64())
+}
+
+// MarshalJSON 序列化为 JSON 数字
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalJSON 兼容 JSON 数字和字符串两种写法
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	v, err := ParseRate(strings.Trim(s, `"`))
+	if err != nil {
+		return err
+	}
+	*r = v
+	return nil
+}
+
+// Value 实现 driver.Valuer，写库时使用十进制字符串
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// Scan 实现 sql.Scanner，读取 DECIMAL 列
+func (r *Rate) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*r = 0
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case float64:
+		s = strconv.FormatFloat(v, 'f', RatePlaces, 64)
+	case int64:
+		*r = Rate(v * RateScale)
+		return nil
+	default:
+		return fmt.Errorf("无法将 %T 转换为汇率", src)
+	}
+	v, err := parseFixed(s, RatePlaces)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidRate, s)
+	}
+	*r = Rate(v)
+	return nil
+}
@@ -0,0 +1,64 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRate(t *testing.T) {
+	r, err := ParseRate("0.92")
+	require.NoError(t, err)
+	assert.Equal(t, Rate(92000000), r)
+	assert.Equal(t, "0.92", r.String())
+	assert.Equal(t, "1", OneRate.String())
+
+	for _, in := range []string{"0", "-1", "abc", "0.123456789"} {
+		_, err := ParseRate(in)
+		assert.ErrorIs(t, err, ErrInvalidRate, in)
+	}
+}
+
+func TestAmountConvert(t *testing.T) {
+	eur, err := ParseRate("0.92")
+	require.NoError(t, err)
+	assert.Equal(t, Amount(920), Amount(1000).Convert(eur))
+	// 19.99 * 0.92 = 18.3908，四舍五入到分
+	assert.Equal(t, Amount(1839), Amount(1999).Convert(eur))
+	assert.Equal(t, Amount(-1839), Amount(-1999).Convert(eur))
+
+	jpy, err := ParseRate("151.37")
+	require.NoError(t, err)
+	assert.Equal(t, Amount(302589), Amount(1999).Convert(jpy))
+	assert.Equal(t, Amount(1999), Amount(1999).Convert(OneRate))
+}
+
+func TestAmountConvertBack(t *testing.T) {
+	eur, err := ParseRate("0.92")
+	require.NoError(t, err)
+	assert.Equal(t, Amount(1000), Amount(920).ConvertBack(eur))
+	// 18.39 / 0.92 = 19.9891...，四舍五入到分
+	assert.Equal(t, Amount(1999), Amount(1839).ConvertBack(eur))
+	assert.Equal(t, Amount(-1999), Amount(-1839).ConvertBack(eur))
+
+	jpy, err := ParseRate("151.37")
+	require.NoError(t, err)
+	assert.Equal(t, Amount(1999), Amount(302589).ConvertBack(jpy))
+	assert.Equal(t, Amount(1999), Amount(1999).ConvertBack(OneRate))
+}
+
+func TestRateJSONAndScan(t *testing.T) {
+	var v struct {
+		Rate Rate `json:"rate"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"rate":"7.1234"}`), &v))
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"rate":7.1234}`, string(data))
+
+	var r Rate
+	require.NoError(t, r.Scan([]byte("0.92000000")))
+	assert.Equal(t, Rate(92000000), r)
+}
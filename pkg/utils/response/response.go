@@ -31,6 +31,15 @@ func Fail(code int, msg string) APIResponse {
 	}
 }
 
+// FailWithData creates a failed APIResponse that carries details for the client, e.g. the items that caused the failure.
+func FailWithData(code int, msg string, data interface{}) APIResponse {
+	return APIResponse{
+		Code:    code,
+		Message: msg,
+		Data:    data,
+	}
+}
+
 // Result is a generic helper to return JSON response
 func Result(code int, data interface{}, msg string, c HTTPContext) {
 	c.JSON(http.StatusOK, APIResponse{
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	logging "github.com/sirupsen/logrus"
+)
+
+const (
+	delayQueueBatchSize  = 100              // 每次轮询最多取出的到期任务数
+	delayQueueRetryDelay = 30 * time.Second // 任务处理失败后的重试间隔
+)
+
+// DelayQueue 基于 Redis 有序集合的延时任务队列
+// member 为任务标识（如订单ID），score 为任务到期的 Unix 时间戳
+// 多实例同时消费时通过 ZREM 的返回值争抢任务，保证同一任务只会被一个实例取走
+type DelayQueue struct {
+	client *redis.Client
+	key    string
+}
+
+// NewDelayQueue 创建延时队列
+func NewDelayQueue(client *redis.Client, key string) *DelayQueue {
+	return &DelayQueue{
+		client: client,
+		key:    key,
+	}
+}
+
+// Push 添加（或覆盖）一个在 runAt 时刻到期的任务，重复添加同一 member 是幂等的
+func (q *DelayQueue) Push(ctx context.Context, member string, runAt time.Time) error {
+	return q.client.ZAdd(ctx, q.key, redis.Z{
+		Score:  float64(runAt.Unix()),
+		Member: member,
+	}).Err()
+}
+
+// Remove 移除尚未到期的任务
+func (q *DelayQueue) Remove(ctx context.Context, member string) error {
+	return q.client.ZRem(ctx, q.key, member).Err()
+}
+
+// Consume 按 interval 轮询到期任务并交给 handler 处理，直到 ctx 被取消
+// handler 返回错误时任务会在 delayQueueRetryDelay 后重新投递，因此 handler 需要保证幂等
+func (q *DelayQueue) Consume(ctx context.Context, interval time.Duration, handler func(ctx context.Context, member string) error) {
+	logging.Infof("延时队列 %s 开始消费", q.key)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logging.Infof("延时队列 %s 停止消费", q.key)
+			return
+		case <-ticker.C:
+			q.consumeDue(ctx, handler)
+		}
+	}
+}
+
+// consumeDue 取出当前所有到期任务并逐个处理
+func (q *DelayQueue) consumeDue(ctx context.Context, handler func(ctx context.Context, member string) error) {
+	members, err := q.client.ZRangeByScore(ctx, q.key, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().Unix(), 10),
+		Count: delayQueueBatchSize,
+	}).Result()
+	if err != nil {
+		if ctx.Err() == nil {
+			logging.Errorf("读取延时队列 %s 失败：%v", q.key, err)
+		}
+		return
+	}
+
+	for _, member := range members {
+		// ZREM 成功（返回 1）的实例才拥有该任务，其余实例跳过
+		removed, err := q.client.ZRem(ctx, q.key, member).Result()
+		if err != nil {
+			logging.Errorf("领取延时任务 %s 失败：%v", member, err)
+			continue
+		}
+		if removed == 0 {
+			continue
+		}
+		if err := handler(ctx, member); err != nil {
+			logging.Errorf("处理延时任务 %s 失败，%s 后重试：%v", member, delayQueueRetryDelay, err)
+			if pushErr := q.Push(context.Background(), member, time.Now().Add(delayQueueRetryDelay)); pushErr != nil {
+				logging.Errorf("重新投递延时任务 %s 失败：%v", member, pushErr)
+			}
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"douyin/consts"
+	"github.com/redis/go-redis/v9"
+)
+
+// flashSaleRetention 场次结束后库存与抢购结果在 Redis 中的保留时间，供客户端轮询结果
+const flashSaleRetention = 24 * time.Hour
+
+var (
+	// ErrFlashSaleNotFound 场次未预热或已下线
+	ErrFlashSaleNotFound = errors.New("秒杀活动不存在或已下线")
+	// ErrFlashSaleNotStarted 场次尚未开始
+	ErrFlashSaleNotStarted = errors.New("秒杀尚未开始")
+	// ErrFlashSaleEnded 场次已结束
+	ErrFlashSaleEnded = errors.New("秒杀已结束")
+	// ErrFlashSaleRepeated 用户在本场次已抢到名额
+	ErrFlashSaleRepeated = errors.New("每人限抢一件，请勿重复抢购")
+	// ErrFlashSaleSoldOut 场次库存已抢光
+	ErrFlashSaleSoldOut = errors.New("商品已抢光")
+)
+
+// flashSaleAcquireScript 原子地校验场次时间、每人限购和库存，扣减库存、记录抢购结果并投递下单任务
+// KEYS[1] 场次哈希 KEYS[2] 用户结果哈希 KEYS[3] 下单队列
+// ARGV[1] 场次ID ARGV[2] 当前时间戳 ARGV[3] 下单任务 ARGV[4] 用户结果的过期时间戳
+// 抢购结果的取值为 PENDING、SUCCESS:<订单ID>、FAILED:<原因>（见 consts.FlashSaleResult*），上一次抢购失败的用户可以重新抢购
+var flashSaleAcquireScript = redis.NewScript(`
+local session = redis.call('HMGET', KEYS[1], 'stock', 'start_at', 'end_at')
+if not session[1] then
+	return -1
+end
+local now = tonumber(ARGV[2])
+if now < tonumber(session[2]) then
+	return -2
+end
+if now >= tonumber(session[3]) then
+	return -3
+end
+local result = redis.call('HGET', KEYS[2], ARGV[1])
+if result and string.sub(result, 1, 6) ~= 'FAILED' then
+	return -4
+end
+if tonumber(session[1]) <= 0 then
+	return -5
+end
+redis.call('HINCRBY', KEYS[1], 'stock', -1)
+redis.call('HSET', KEYS[2], ARGV[1], 'PENDING')
+redis.call('EXPIREAT', KEYS[2], ARGV[4])
+redis.call('LPUSH', KEYS[3], ARGV[3])
+return 1
+`)
+
+// flashSaleFailScript 下单失败时归还库存并记录失败原因，只处理仍为 PENDING 的结果，重复执行不会多归还库存
+// KEYS[1] 场次哈希 KEYS[2] 用户结果哈希
+// ARGV[1] 场次ID ARGV[2] 失败结果
+var flashSaleFailScript = redis.NewScript(`
+if redis.call('HGET', KEYS[2], ARGV[1]) ~= 'PENDING' then
+	return 0
+end
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	redis.call('HINCRBY', KEYS[1], 'stock', 1)
+end
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+return 1
+`)
+
+// flashSaleReleaseScript 秒杀订单取消或超时关闭后归还库存并将结果置为失败，用户可以重新抢购
+// 只处理仍为该订单成功结果或 PENDING（下单成功但结果尚未写入）的记录，重复执行不会多归还库存
+// KEYS[1] 场次哈希 KEYS[2] 用户结果哈希
+// ARGV[1] 场次ID ARGV[2] 成功结果 ARGV[3] 失败结果
+var flashSaleReleaseScript = redis.NewScript(`
+local result = redis.call('HGET', KEYS[2], ARGV[1])
+if result ~= ARGV[2] and result ~= 'PENDING' then
+	return 0
+end
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	redis.call('HINCRBY', KEYS[1], 'stock', 1)
+end
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[3])
+return 1
+`)
+
+// FlashSaleStock 预热到 Redis 的秒杀场次信息
+type FlashSaleStock struct {
+	SessionID uint
+	Stock     int // 剩余可抢数量
+	StartAt   time.Time
+	EndAt     time.Time
+}
+
+// FlashSaleStore 秒杀库存、抢购结果与下单队列的 Redis 存储
+// 场次库存保存在 SkillProductKey 哈希中，用户的抢购结果保存在 SkillProductUserKey 哈希中，下单任务写入 queueKey 列表
+type FlashSaleStore struct {
+	client   *redis.Client
+	queueKey string
+}
+
+// NewFlashSaleStore 创建秒杀存储，queueKey 为下单任务队列的键名
+func NewFlashSaleStore(client *redis.Client, queueKey string) *FlashSaleStore {
+	return &FlashSaleStore{
+		client:   client,
+		queueKey: queueKey,
+	}
+}
+
+func flashSaleSessionKey(sessionID uint) string {
+	return fmt.Sprintf(SkillProductKey, sessionID)
+}
+
+func flashSaleUserKey(userID uint) string {
+	return fmt.Sprintf(SkillProductUserKey, strconv.FormatUint(uint64(userID), 10))
+}
+
+// Preload 将场次库存预热到 Redis；库存只在键不存在时写入，重复预热（如服务重启）不会覆盖正在扣减的库存
+func (s *FlashSaleStore) Preload(ctx context.Context, stock FlashSaleStock) error {
+	key := flashSaleSessionKey(stock.SessionID)
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSetNX(ctx, key, "stock", stock.Stock)
+		pipe.HSet(ctx, key, "start_at", stock.StartAt.Unix(), "end_at", stock.EndAt.Unix())
+		pipe.ExpireAt(ctx, key, stock.EndAt.Add(flashSaleRetention))
+		pipe.ZAdd(ctx, SkillProductListKey, redis.Z{
+			Score:  float64(stock.StartAt.Unix()),
+			Member: stock.SessionID,
+		})
+		return nil
+	})
+	return err
+}
+
+// Offline 将场次从 Redis 下线，之后的抢购请求返回 ErrFlashSaleNotFound
+func (s *FlashSaleStore) Offline(ctx context.Context, sessionID uint) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, flashSaleSessionKey(sessionID))
+		pipe.ZRem(ctx, SkillProductListKey, sessionID)
+		return nil
+	})
+	return err
+}
+
+// Remaining 批量查询场次在 Redis 中的剩余库存，未预热的场次不在结果中
+func (s *FlashSaleStore) Remaining(ctx context.Context, sessionIDs []uint) (map[uint]int, error) {
+	remaining := make(map[uint]int, len(sessionIDs))
+	if len(sessionIDs) == 0 {
+		return remaining, nil
+	}
+	cmds := make([]*redis.StringCmd, len(sessionIDs))
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range sessionIDs {
+			cmds[i] = pipe.HGet(ctx, flashSaleSessionKey(id), "stock")
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	for i, cmd := range cmds {
+		stock, err := cmd.Int()
+		if err != nil {
+			continue
+		}
+		remaining[sessionIDs[i]] = stock
+	}
+	return remaining, nil
+}
+
+// Acquire 抢购一个名额：成功时扣减库存、将用户结果置为 PENDING 并投递下单任务 job
+func (s *FlashSaleStore) Acquire(ctx context.Context, sessionID, userID uint, now time.Time, job []byte) error {
+	keys := []string{flashSaleSessionKey(sessionID), flashSaleUserKey(userID), s.queueKey}
+	code, err := flashSaleAcquireScript.Run(ctx, s.client, keys,
+		sessionID, now.Unix(), job, now.Add(flashSaleRetention).Unix()).Int()
+	if err != nil {
+		return err
+	}
+	switch code {
+	case -1:
+		return ErrFlashSaleNotFound
+	case -2:
+		return ErrFlashSaleNotStarted
+	case -3:
+		return ErrFlashSaleEnded
+	case -4:
+		return ErrFlashSaleRepeated
+	case -5:
+		return ErrFlashSaleSoldOut
+	}
+	return nil
+}
+
+// Succeed 记录下单成功的订单ID
+func (s *FlashSaleStore) Succeed(ctx context.Context, sessionID, userID uint, orderID string) error {
+	return s.client.HSet(ctx, flashSaleUserKey(userID), strconv.FormatUint(uint64(sessionID), 10),
+		consts.FlashSaleResultSuccess+":"+orderID).Err()
+}
+
+// Fail 下单失败时归还名额并记录失败原因，用户可以重新抢购
+func (s *FlashSaleStore) Fail(ctx context.Context, sessionID, userID uint, reason string) error {
+	keys := []string{flashSaleSessionKey(sessionID), flashSaleUserKey(userID)}
+	return flashSaleFailScript.Run(ctx, s.client, keys, sessionID, consts.FlashSaleResultFailed+":"+reason).Err()
+}
+
+// Release 秒杀订单取消或超时关闭后归还名额，用户结果置为失败并记录原因
+func (s *FlashSaleStore) Release(ctx context.Context, sessionID, userID uint, orderID, reason string) error {
+	keys := []string{flashSaleSessionKey(sessionID), flashSaleUserKey(userID)}
+	return flashSaleReleaseScript.Run(ctx, s.client, keys, sessionID,
+		consts.FlashSaleResultSuccess+":"+orderID, consts.FlashSaleResultFailed+":"+reason).Err()
+}
+
+// Result 查询用户在场次中的抢购结果，返回状态（PENDING/SUCCESS/FAILED）及订单ID或失败原因；未参与时状态为空
+func (s *FlashSaleStore) Result(ctx context.Context, sessionID, userID uint) (status, detail string, err error) {
+	result, err := s.client.HGet(ctx, flashSaleUserKey(userID), strconv.FormatUint(uint64(sessionID), 10)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	status, detail, _ = strings.Cut(result, ":")
+	return status, detail, nil
+}
+
+// processingKey 下单 worker 的处理中列表，任务取出后暂存于此，处理完成并 Ack 后才删除
+func (s *FlashSaleStore) processingKey(worker string) string {
+	return s.queueKey + ":processing:" + worker
+}
+
+// Pop 阻塞地从下单队列取出一个任务并原子地移入 worker 的处理中列表，timeout 内没有任务时返回 nil
+// 任务处理完成后需调用 Ack；服务在 Ack 前退出时任务留在处理中列表，下次启动由 Requeue 放回队列
+func (s *FlashSaleStore) Pop(ctx context.Context, worker string, timeout time.Duration) ([]byte, error) {
+	result, err := s.client.BLMove(ctx, s.queueKey, s.processingKey(worker), "RIGHT", "LEFT", timeout).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(result), nil
+}
+
+// Ack 任务处理完成（下单成功或已记录失败结果）后从 worker 的处理中列表删除
+func (s *FlashSaleStore) Ack(ctx context.Context, worker string, job []byte) error {
+	return s.client.LRem(ctx, s.processingKey(worker), 1, job).Err()
+}
+
+// Retry 将处理失败的任务从 worker 的处理中列表移回队列末尾，排在已有任务之后重新处理
+func (s *FlashSaleStore) Retry(ctx context.Context, worker string, job []byte) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LRem(ctx, s.processingKey(worker), 1, job)
+		pipe.LPush(ctx, s.queueKey, job)
+		return nil
+	})
+	return err
+}
+
+// Requeue 将 worker 处理中列表里未 Ack 的任务放回队列优先处理，返回放回的任务数
+// 需在该 worker 开始消费前调用
+func (s *FlashSaleStore) Requeue(ctx context.Context, worker string) (int, error) {
+	count := 0
+	for {
+		err := s.client.LMove(ctx, s.processingKey(worker), s.queueKey, "RIGHT", "RIGHT").Err()
+		if errors.Is(err, redis.Nil) {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+}
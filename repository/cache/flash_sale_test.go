@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFlashSaleAcquireLoad 对本地 Redis 并发抢购，验证不超卖且每人只能抢到一件
+// 需要设置 FLASH_SALE_REDIS_ADDR（如 127.0.0.1:6379）才会运行，会使用并在结束时清理独立的场次与队列键
+func TestFlashSaleAcquireLoad(t *testing.T) {
+	addr := os.Getenv("FLASH_SALE_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("未设置 FLASH_SALE_REDIS_ADDR，跳过秒杀压测")
+	}
+	ctx := context.Background()
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	require.NoError(t, client.Ping(ctx).Err())
+	defer client.Close()
+
+	const (
+		stock    = 100
+		users    = 500
+		attempts = 3 // 每个用户重复抢购的次数
+	)
+	sessionID := uint(time.Now().UnixNano() % 1_000_000_000)
+	queueKey := fmt.Sprintf("test:flash_sale:queue:%d", sessionID)
+	store := NewFlashSaleStore(client, queueKey)
+	now := time.Now()
+	require.NoError(t, store.Preload(ctx, FlashSaleStock{
+		SessionID: sessionID,
+		Stock:     stock,
+		StartAt:   now.Add(-time.Minute),
+		EndAt:     now.Add(time.Hour),
+	}))
+	defer func() {
+		keys := []string{flashSaleSessionKey(sessionID), queueKey, store.processingKey("test")}
+		for userID := uint(1); userID <= users; userID++ {
+			client.HDel(ctx, flashSaleUserKey(userID), fmt.Sprint(sessionID))
+		}
+		client.Del(ctx, keys...)
+		client.ZRem(ctx, SkillProductListKey, sessionID)
+	}()
+
+	var (
+		wg       sync.WaitGroup
+		acquired int64
+		repeated int64
+		soldOut  int64
+	)
+	start := time.Now()
+	for userID := uint(1); userID <= users; userID++ {
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func(userID uint) {
+				defer wg.Done()
+				err := store.Acquire(ctx, sessionID, userID, time.Now(), []byte(fmt.Sprint(userID)))
+				switch {
+				case err == nil:
+					atomic.AddInt64(&acquired, 1)
+				case errors.Is(err, ErrFlashSaleRepeated):
+					atomic.AddInt64(&repeated, 1)
+				case errors.Is(err, ErrFlashSaleSoldOut):
+					atomic.AddInt64(&soldOut, 1)
+				default:
+					t.Errorf("抢购返回了意外的错误: %v", err)
+				}
+			}(userID)
+		}
+	}
+	wg.Wait()
+	t.Logf("%d 次抢购耗时 %s：成功 %d，重复 %d，抢光 %d", users*attempts, time.Since(start), acquired, repeated, soldOut)
+
+	assert.Equal(t, int64(stock), acquired)
+	queued, err := client.LLen(ctx, queueKey).Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(stock), queued)
+	remaining, err := store.Remaining(ctx, []uint{sessionID})
+	require.NoError(t, err)
+	assert.Equal(t, 0, remaining[sessionID])
+
+	// 取出未 Ack 的任务在 Requeue 后回到队列
+	job, err := store.Pop(ctx, "test", time.Second)
+	require.NoError(t, err)
+	requeued, err := store.Requeue(ctx, "test")
+	require.NoError(t, err)
+	assert.Equal(t, 1, requeued)
+	queued, err = client.LLen(ctx, queueKey).Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(stock), queued)
+
+	// 下单失败归还名额后，该用户可以重新抢到
+	again, err := store.Pop(ctx, "test", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, job, again)
+	require.NoError(t, store.Ack(ctx, "test", job))
+	processing, err := client.LLen(ctx, store.processingKey("test")).Result()
+	require.NoError(t, err)
+	assert.Zero(t, processing)
+	var userID uint
+	_, err = fmt.Sscan(string(job), &userID)
+	require.NoError(t, err)
+	require.NoError(t, store.Fail(ctx, sessionID, userID, "库存不足"))
+	require.NoError(t, store.Fail(ctx, sessionID, userID, "库存不足"))
+	status, detail, err := store.Result(ctx, sessionID, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "FAILED", status)
+	assert.Equal(t, "库存不足", detail)
+	remaining, err = store.Remaining(ctx, []uint{sessionID})
+	require.NoError(t, err)
+	assert.Equal(t, 1, remaining[sessionID])
+	assert.NoError(t, store.Acquire(ctx, sessionID, userID, time.Now(), job))
+
+	// 订单超时关闭后归还名额，重复归还不会多加库存
+	require.NoError(t, store.Succeed(ctx, sessionID, userID, "order-1"))
+	require.NoError(t, store.Release(ctx, sessionID, userID, "order-1", "订单已关闭"))
+	require.NoError(t, store.Release(ctx, sessionID, userID, "order-1", "订单已关闭"))
+	status, detail, err = store.Result(ctx, sessionID, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "FAILED", status)
+	assert.Equal(t, "订单已关闭", detail)
+	remaining, err = store.Remaining(ctx, []uint{sessionID})
+	require.NoError(t, err)
+	assert.Equal(t, 1, remaining[sessionID])
+}
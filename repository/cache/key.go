@@ -8,12 +8,14 @@ import (
 const (
 	// RankKey 每日排名的Redis键名
 	RankKey = "rank"
-	// SkillProductKey 单个商品的Redis键名模板，%d为商品ID占位符
+	// SkillProductKey 秒杀场次的Redis键名模板（哈希：剩余库存、起止时间），%d为场次ID占位符
 	SkillProductKey = "skill:product:%d"
-	// SkillProductListKey 所有商品的Redis键名
+	// SkillProductListKey 已预热的秒杀场次（有序集合，score 为开始时间戳）
 	SkillProductListKey = "skill:product_list"
-	// SkillProductUserKey 用户相关的商品信息Redis键名模板，%s为用户ID占位符
+	// SkillProductUserKey 用户的秒杀抢购结果Redis键名模板（哈希：场次ID -> 结果），%s为用户ID占位符
 	SkillProductUserKey = "skill:user:%s"
+	// OrderUnpaidDelayKey 未支付订单超时关闭的延时队列（有序集合，score 为到期时间戳）
+	OrderUnpaidDelayKey = "delay:order:unpaid"
 )
 
 // ProductViewKey 返回指定商品ID的查看数Redis键名
@@ -33,3 +35,22 @@ func ProductDetailKey(id uint) string {
 func ProductListKey(page, size int) string {
 	return fmt.Sprintf("product:list:%d:%d", page, size)
 }
+
+// ProductCategoryListKey returns the Redis key for a paginated list of products filtered by a category (including descendants).
+// Example: "product:list:category:5:1:10" (category 5, page 1, size 10)
+func ProductCategoryListKey(categoryID uint, page, size int) string {
+	return fmt.Sprintf("product:list:category:%d:%d:%d", categoryID, page, size)
+}
+
+// IdempotencyKey returns the Redis key that stores the response for a user's Idempotency-Key.
+// Example: "idempotency:42:3f1c..."
+func IdempotencyKey(userID uint, key string) string {
+	return fmt.Sprintf("idempotency:%d:%s", userID, key)
+}
+
+// GuestCartKey returns the Redis hash that stores an anonymous visitor's cart (field: SKU ID, value: quantity).
+// The "sku" segment keeps carts written before SKUs existed (keyed by product ID) from being misread; they simply expire.
+// Example: "cart:guest:sku:9f86d081..."
+func GuestCartKey(token string) string {
+	return fmt.Sprintf("cart:guest:sku:%s", token)
+}
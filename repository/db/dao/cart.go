@@ -2,11 +2,24 @@ package dao
 
 import (
 	"context"
+	"douyin/consts"
 	"douyin/repository/db/model"
 	"errors"
 	"fmt"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
+	"time"
+)
+
+var (
+	// ErrCartItemNotFound 购物车中没有该商品
+	ErrCartItemNotFound = errors.New("购物车中没有该商品")
+	// ErrCartItemUnavailable 商品已无可售库存，无法加入购物车
+	ErrCartItemUnavailable = errors.New("商品已无可售库存")
+	// ErrCartProductNotFound 要加入购物车的商品不存在或已下架
+	ErrCartProductNotFound = errors.New("商品不存在")
+
+	// errCartItemUnchanged 由 changeItemQuantity 的 next 返回，表示保持购物车行不变
+	errCartItemUnchanged = errors.New("购物车行无需变更")
 )
 
 // CartDao 购物车数据访问对象，封装对 cart_items 表的增删改查操作
@@ -38,95 +51,164 @@ func (dao *CartDao) GetCart(ctx context.Context, userID uint) ([]model.CartItem,
 	return items, nil
 }
 
-// EmptyCart 清空购物车（删除 cart_items 表里所有匹配 user_id 的记录）
+// EmptyCart 清空购物车（删除 cart_items 表里所有匹配 user_id 的记录），并立即释放该用户的全部库存预占
 func (dao *CartDao) EmptyCart(ctx context.Context, userID uint) error {
-	if err := dao.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.CartItem{}).Error; err != nil {
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.CartItem{}).Error; err != nil {
+			return err
+		}
+		return NewStockReservationDao(tx).Release(ctx, userID, nil)
+	})
+	if err != nil {
 		return err
 	}
 	fmt.Printf("EmptyCart: 已清空用户 %d 的购物车\n", userID)
 	return nil
 }
 
-// AddItem 往购物车中添加(或更新)商品
-// userID: 用户ID
-// productID: 商品ID
-// quantity: 要添加的商品数量（可能为正数，表示往购物车中增加）
-func (dao *CartDao) AddItem(ctx context.Context, userID, productID uint, quantity int32) error {
-	tx := dao.db.WithContext(ctx).Begin()
-	if tx.Error != nil {
-		return tx.Error
+// RemoveItems 从购物车中删除指定 SKU 的购物车行并释放对应的库存预占（例如结算后移除已购买的商品）
+func (dao *CartDao) RemoveItems(ctx context.Context, userID uint, skuIDs []uint) error {
+	if len(skuIDs) == 0 {
+		return nil
 	}
-	defer tx.Rollback()
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND sku_id IN ?", userID, skuIDs).
+			Delete(&model.CartItem{}).Error; err != nil {
+			return err
+		}
+		return NewStockReservationDao(tx).Release(ctx, userID, skuIDs)
+	})
+}
 
-	var product model.Product
-	// Lock product row for update
-	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", productID).First(&product).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("商品不存在")
+// AddItem 往购物车中添加(或更新)商品，并为购物车行预占库存直到 holdUntil
+// 加入购物车不扣减 SKU 库存，库存在结算下单时才真正扣减
+// userID: 用户ID
+// productID, skuID: 商品ID 与 SKU ID，SKU 的确定规则见 ProductSkuDao.ResolveSku
+// quantity: 数量增量（负数表示减少），累加后的数量小于1时拒绝操作，删除商品请使用 RemoveItems 或 SetQuantity
+func (dao *CartDao) AddItem(ctx context.Context, userID, productID, skuID uint, quantity int32, holdUntil time.Time) error {
+	return dao.changeItemQuantity(ctx, userID, productID, skuID, func(current int32, _ int) (int32, error) {
+		if current+quantity < 1 {
+			return 0, fmt.Errorf("AddItem: 最终商品数量小于1，操作非法")
 		}
-		return err
-	}
+		return current + quantity, nil
+	}, holdUntil)
+}
 
-	// Check stock - assuming adding to cart deducts stock
-	if product.Stock < int(quantity) { // quantity is int32, product.Stock is int
-		return errors.New("库存不足: " + product.Name)
-	}
+// SetQuantity 将购物车行数量设置为 quantity，为 0 时删除该行并释放预占；购物车中没有该 SKU 时新增
+func (dao *CartDao) SetQuantity(ctx context.Context, userID, productID, skuID uint, quantity int32, holdUntil time.Time) error {
+	return dao.changeItemQuantity(ctx, userID, productID, skuID, func(int32, int) (int32, error) {
+		if quantity < 0 {
+			return 0, fmt.Errorf("SetQuantity: 商品数量不能为负数")
+		}
+		return quantity, nil
+	}, holdUntil)
+}
 
-	// Update stock using optimistic locking
-	result := tx.Model(&model.Product{}).Where("id = ? AND version = ?", product.ID, product.Version).Updates(map[string]interface{}{
-		"stock":   gorm.Expr("stock - ?", quantity),
-		"version": gorm.Expr("version + 1"),
-	})
+// MergeItem 将游客购物车中的 SKU 合并到用户购物车，返回合并前与合并后的数量
+// 合并规则：数量累加后不超过单行上限与当前可售数量；可售数量不足时保留用户原有数量，不会因合并而减少；
+// 用户购物车中没有该 SKU 且已无可售库存时返回 ErrCartItemUnavailable
+func (dao *CartDao) MergeItem(ctx context.Context, userID, skuID uint, quantity int32, holdUntil time.Time) (previous, merged int32, err error) {
+	err = dao.changeItemQuantity(ctx, userID, 0, skuID, func(current int32, available int) (int32, error) {
+		previous = current
+		merged = current + quantity
+		if merged > consts.CartItemMaxQuantity {
+			merged = consts.CartItemMaxQuantity
+		}
+		if int(merged) > available {
+			merged = int32(available)
+		}
+		if merged <= current {
+			merged = current
+			if current == 0 {
+				return 0, ErrCartItemUnavailable
+			}
+			// 可售数量已不足以增加，保留用户原有的数量与预占
+			return 0, errCartItemUnchanged
+		}
+		return merged, nil
+	}, holdUntil)
+	return previous, merged, err
+}
 
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return errors.New("并发冲突，请重试: " + product.Name)
-	}
+// changeItemQuantity 按 next 计算购物车行的新数量并写入，同时刷新或释放该行的库存预占
+// 锁定 SKU 行后，以「SKU 库存 - 其他用户未过期的预占」作为可售数量传给 next 并校验新数量；新数量不大于 0 时删除该行并释放预占
+func (dao *CartDao) changeItemQuantity(ctx context.Context, userID, productID, skuID uint, next func(current int32, available int) (int32, error), holdUntil time.Time) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 锁定 SKU 行，使同一 SKU 的预占校验串行执行
+		sku, err := NewProductSkuDao(tx).ResolveSku(ctx, productID, skuID, true)
+		if err != nil {
+			if errors.Is(err, ErrSkuNotFound) {
+				return ErrCartProductNotFound
+			}
+			return err
+		}
 
-	var cartItem model.CartItem
-	// 先查询cart_items中是否已有此商品
-	err := tx.Where("user_id = ? AND product_id = ?", userID, productID).
-		First(&cartItem).Error
+		var cartItem model.CartItem
+		// 先查询cart_items中是否已有此 SKU
+		err = tx.Where("user_id = ? AND sku_id = ?", userID, sku.ID).First(&cartItem).Error
+		isNew := errors.Is(err, gorm.ErrRecordNotFound)
+		if err != nil && !isNew {
+			return err
+		}
+		if isNew {
+			cartItem = model.CartItem{UserID: userID, SkuID: sku.ID, ProductID: sku.ProductID, Selected: true}
+		}
 
-	if err != nil {
-		// 如果记录未找到，表示购物车里还没有这个商品，执行插入操作
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			newItem := model.CartItem{
-				UserID:    userID,
-				ProductID: productID,
-				Quantity:  quantity,
-			}
-			if createErr := tx.Create(&newItem).Error; createErr != nil {
-				return createErr
+		reservationDao := NewStockReservationDao(tx)
+		available, err := NewInventoryDao(tx).SellableStock(ctx, sku, userID)
+		if err != nil {
+			return err
+		}
+		if cartItem.Quantity, err = next(cartItem.Quantity, available); err != nil {
+			if errors.Is(err, errCartItemUnchanged) {
+				return nil
 			}
-			fmt.Printf("AddItem: 用户 %d 的购物车中新增商品 %d，数量为 %d\n", userID, productID, quantity)
-		} else {
-			// 如果是其他错误，则直接返回
 			return err
 		}
-	} else {
-		// 如果购物车已存在该商品，则进行数量累加
-		// Note: Stock was already deducted for the new quantity. If the item exists, 
-		// this logic might need adjustment if stock is only deducted on checkout.
-		// For now, assuming stock is deducted when adding to cart.
-		cartItem.Quantity += quantity
-		if cartItem.Quantity < 1 {
-			// If quantity becomes less than 1, consider deleting the item or erroring
-			// For now, let's assume quantity will always be positive when adding.
-			// If logic allows reducing quantity which might make it < 1, handle appropriately.
-			// e.g., tx.Delete(&cartItem) or return error
-			return fmt.Errorf("AddItem: 最终商品数量小于1，操作非法")
+
+		// 用户每次修改购物车行都视为已按当前价格确认，刷新价格快照
+		cartItem.PriceSnapshot = sku.Price
+		if cartItem.Quantity <= 0 {
+			if isNew {
+				return ErrCartItemNotFound
+			}
+			if err := tx.Where("user_id = ? AND sku_id = ?", userID, sku.ID).Delete(&model.CartItem{}).Error; err != nil {
+				return err
+			}
+			return reservationDao.Release(ctx, userID, []uint{sku.ID})
 		}
-		if saveErr := tx.Save(&cartItem).Error; saveErr != nil {
-			return saveErr
+
+		if available < int(cartItem.Quantity) {
+			return errors.New("库存不足: " + sku.Product.Name)
 		}
-		fmt.Printf("AddItem: 用户 %d 的购物车中更新商品 %d，数量已变更为 %d\n", userID, productID, cartItem.Quantity)
-	}
 
-	if err := tx.Commit().Error; err != nil {
-		return err
+		if isNew {
+			// 如果记录未找到，表示购物车里还没有这个商品，执行插入操作
+			if err := tx.Create(&cartItem).Error; err != nil {
+				return err
+			}
+			fmt.Printf("AddItem: 用户 %d 的购物车中新增商品 %d (SKU %d)，数量为 %d\n", userID, sku.ProductID, sku.ID, cartItem.Quantity)
+		} else {
+			if err := tx.Save(&cartItem).Error; err != nil {
+				return err
+			}
+			fmt.Printf("AddItem: 用户 %d 的购物车中更新商品 %d (SKU %d)，数量已变更为 %d\n", userID, sku.ProductID, sku.ID, cartItem.Quantity)
+		}
+		return reservationDao.Reserve(ctx, userID, sku, int(cartItem.Quantity), holdUntil)
+	})
+}
+
+// SetSelected 勾选或取消勾选购物车行，skuIDs 为空时作用于整个购物车，返回受影响的行数
+func (dao *CartDao) SetSelected(ctx context.Context, userID uint, skuIDs []uint, selected bool) (int64, error) {
+	query := dao.db.WithContext(ctx).Model(&model.CartItem{}).Where("user_id = ?", userID)
+	if len(skuIDs) > 0 {
+		query = query.Where("sku_id IN ?", skuIDs)
 	}
-	return nil
+	result := query.Update("selected", selected)
+	return result.RowsAffected, result.Error
+}
+
+// ListCartSkus 查询购物车行对应的 SKU 及商品当前信息，已删除的 SKU 或商品不会出现在结果中
+func (dao *CartDao) ListCartSkus(ctx context.Context, skuIDs []uint) ([]model.ProductSku, error) {
+	return NewProductSkuDao(dao.db).ListSkusByIDs(ctx, skuIDs)
 }
@@ -1 +1,99 @@
 package dao
+
+import (
+	"context"
+	"douyin/repository/db/model"
+	"errors"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCategoryHasChildren 分类下还有子分类，不能删除
+	ErrCategoryHasChildren = errors.New("该分类下还有子分类，请先删除或移动子分类")
+	// ErrCategoryNameExists 分类名称已存在
+	ErrCategoryNameExists = errors.New("分类名称已存在")
+)
+
+// CategoryDao 定义商品分类数据访问对象
+type CategoryDao struct {
+	db *gorm.DB
+}
+
+// NewCategoryDao 根据传入的数据库连接创建新的 CategoryDao 实例
+func NewCategoryDao(db *gorm.DB) *CategoryDao {
+	return &CategoryDao{
+		db: db,
+	}
+}
+
+// CreateCategory 创建分类，名称重复时返回 ErrCategoryNameExists
+func (dao *CategoryDao) CreateCategory(ctx context.Context, category *model.Category) error {
+	if err := dao.checkNameAvailable(ctx, category.Name, 0); err != nil {
+		return err
+	}
+	return dao.db.WithContext(ctx).Create(category).Error
+}
+
+// UpdateCategory 更新分类的名称、父分类和排序，名称与其他分类重复时返回 ErrCategoryNameExists
+func (dao *CategoryDao) UpdateCategory(ctx context.Context, category *model.Category) error {
+	if err := dao.checkNameAvailable(ctx, category.Name, category.ID); err != nil {
+		return err
+	}
+	return dao.db.WithContext(ctx).Model(&model.Category{}).Where("id = ?", category.ID).
+		Select("name", "parent_id", "sort").Updates(category).Error
+}
+
+// checkNameAvailable 检查分类名称是否未被 excludeID 以外的分类使用
+func (dao *CategoryDao) checkNameAvailable(ctx context.Context, name string, excludeID uint) error {
+	var count int64
+	if err := dao.db.WithContext(ctx).Model(&model.Category{}).
+		Where("name = ? AND id <> ?", name, excludeID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrCategoryNameExists
+	}
+	return nil
+}
+
+// GetCategory 查询分类，不存在时返回 gorm.ErrRecordNotFound
+func (dao *CategoryDao) GetCategory(ctx context.Context, categoryID uint) (*model.Category, error) {
+	var category model.Category
+	if err := dao.db.WithContext(ctx).Where("id = ?", categoryID).First(&category).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// ListAllCategories 查询全部分类，按排序与ID升序；分类数量有限，层级关系在内存中组装
+func (dao *CategoryDao) ListAllCategories(ctx context.Context) ([]model.Category, error) {
+	var categories []model.Category
+	if err := dao.db.WithContext(ctx).Order("sort ASC, id ASC").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// DeleteCategory 删除没有子分类的分类，并解除其与商品的关联
+func (dao *CategoryDao) DeleteCategory(ctx context.Context, categoryID uint) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var children int64
+		if err := tx.Model(&model.Category{}).Where("parent_id = ?", categoryID).Count(&children).Error; err != nil {
+			return err
+		}
+		if children > 0 {
+			return ErrCategoryHasChildren
+		}
+		if err := tx.Where("category_id = ?", categoryID).Delete(&model.ProductCategory{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("id = ?", categoryID).Delete(&model.Category{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
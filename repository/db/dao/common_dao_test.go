@@ -104,9 +104,22 @@ func setupTestDB() (func(), error) { // Return error for better handling in Test
 		// &model.Checkout{}, // Assuming Checkout might not be a direct GORM table model based on typical naming
 		&model.Order{},
 		&model.OrderItem{},
+		&model.OrderStatusHistory{},
 		&model.Payment{},
+		&model.PaymentStatusHistory{},
+		&model.PaymentNotification{},
+		&model.Refund{},
+		&model.RefundItem{},
+		&model.Wallet{},
+		&model.WalletTransaction{},
+		&model.ExchangeRate{},
+		&model.StockReservation{},
 		&model.Product{},
 		&model.ProductCategory{},
+		&model.CouponTemplate{},
+		&model.UserCoupon{},
+		&model.FlashSaleSession{},
+		&model.FlashSaleOrder{},
 		// RBAC models are added next
 	}
     // Add RBAC models (Role, Permission, RolePermission, UserRole)
@@ -0,0 +1,199 @@
+package dao
+
+import (
+	"context"
+	"douyin/consts"
+	"douyin/pkg/utils/money"
+	"douyin/repository/db/model"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+var (
+	// ErrCouponNotClaimable 优惠券已停用或不在领取时间内
+	ErrCouponNotClaimable = errors.New("优惠券当前不可领取")
+	// ErrCouponSoldOut 优惠券已领完
+	ErrCouponSoldOut = errors.New("优惠券已领完")
+	// ErrCouponClaimLimit 已达到每人领取上限
+	ErrCouponClaimLimit = errors.New("已达到该优惠券的领取上限")
+	// ErrCouponStatusConflict 优惠券状态在读取后已被其他请求修改
+	ErrCouponStatusConflict = errors.New("优惠券状态已变更，请刷新后重试")
+)
+
+// CouponDao 定义优惠券数据访问对象
+type CouponDao struct {
+	db *gorm.DB
+}
+
+// NewCouponDao 根据传入的数据库连接创建新的 CouponDao 实例
+func NewCouponDao(db *gorm.DB) *CouponDao {
+	return &CouponDao{
+		db: db,
+	}
+}
+
+// CreateTemplate 创建优惠券模板
+func (dao *CouponDao) CreateTemplate(ctx context.Context, template *model.CouponTemplate) error {
+	return dao.db.WithContext(ctx).Create(template).Error
+}
+
+// SetTemplateEnabled 启用或停用优惠券模板，模板不存在时返回 gorm.ErrRecordNotFound
+func (dao *CouponDao) SetTemplateEnabled(ctx context.Context, templateID uint, enabled bool) error {
+	result := dao.db.WithContext(ctx).Model(&model.CouponTemplate{}).Where("id = ?", templateID).Update("enabled", enabled)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := dao.db.WithContext(ctx).Model(&model.CouponTemplate{}).Where("id = ?", templateID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return gorm.ErrRecordNotFound
+		}
+	}
+	return nil
+}
+
+// ListTemplates 分页查询优惠券模板，claimableAt 不为零值时只返回该时刻可领取且未领完的模板
+func (dao *CouponDao) ListTemplates(ctx context.Context, claimableAt time.Time, pageNum, pageSize int) ([]model.CouponTemplate, int64, error) {
+	query := dao.db.WithContext(ctx).Model(&model.CouponTemplate{})
+	if !claimableAt.IsZero() {
+		query = query.Where("enabled = ? AND claim_start_at <= ? AND claim_end_at > ?", true, claimableAt, claimableAt).
+			Where("total_quantity = 0 OR claimed_quantity < total_quantity")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var templates []model.CouponTemplate
+	if err := query.Order("id DESC").Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&templates).Error; err != nil {
+		return nil, 0, err
+	}
+	return templates, total, nil
+}
+
+// ClaimCoupon 用户领取优惠券：锁定模板行后校验启用状态、领取时间、发行总量与每人限领，再生成用户优惠券
+func (dao *CouponDao) ClaimCoupon(ctx context.Context, userID, templateID uint, now time.Time) (*model.UserCoupon, error) {
+	var coupon *model.UserCoupon
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var template model.CouponTemplate
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", templateID).First(&template).Error; err != nil {
+			return err
+		}
+		if !template.Enabled || now.Before(template.ClaimStartAt) || !now.Before(template.ClaimEndAt) {
+			return ErrCouponNotClaimable
+		}
+		if template.TotalQuantity > 0 && template.ClaimedQuantity >= template.TotalQuantity {
+			return ErrCouponSoldOut
+		}
+		var claimed int64
+		if err := tx.Model(&model.UserCoupon{}).Where("user_id = ? AND template_id = ?", userID, templateID).Count(&claimed).Error; err != nil {
+			return err
+		}
+		if template.PerUserLimit > 0 && claimed >= int64(template.PerUserLimit) {
+			return ErrCouponClaimLimit
+		}
+
+		if err := tx.Model(&model.CouponTemplate{}).Where("id = ?", templateID).
+			Update("claimed_quantity", gorm.Expr("claimed_quantity + 1")).Error; err != nil {
+			return err
+		}
+		coupon = &model.UserCoupon{
+			UserID:     userID,
+			TemplateID: templateID,
+			Status:     consts.UserCouponStatusUnused,
+			ClaimedAt:  now,
+			ExpiresAt:  template.ExpiryFor(now),
+			Template:   template,
+		}
+		return tx.Omit("Template").Create(coupon).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return coupon, nil
+}
+
+// ExpireUserCoupons 将用户已过期但仍为未使用状态的优惠券标记为已过期
+func (dao *CouponDao) ExpireUserCoupons(ctx context.Context, userID uint, now time.Time) error {
+	return dao.db.WithContext(ctx).Model(&model.UserCoupon{}).
+		Where("user_id = ? AND status = ? AND expires_at <= ?", userID, consts.UserCouponStatusUnused, now).
+		Update("status", consts.UserCouponStatusExpired).Error
+}
+
+// ListUserCoupons 分页查询用户的优惠券，status 为空时返回全部，按领取时间倒序
+func (dao *CouponDao) ListUserCoupons(ctx context.Context, userID uint, status string, pageNum, pageSize int) ([]model.UserCoupon, int64, error) {
+	query := dao.db.WithContext(ctx).Model(&model.UserCoupon{}).Where("user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var coupons []model.UserCoupon
+	if err := query.Preload("Template").Order("claimed_at DESC").
+		Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&coupons).Error; err != nil {
+		return nil, 0, err
+	}
+	return coupons, total, nil
+}
+
+// LockUserCoupons 加行锁查询属于用户的优惠券并预加载模板，供下单事务使用
+func (dao *CouponDao) LockUserCoupons(ctx context.Context, userID uint, couponIDs []uint) ([]model.UserCoupon, error) {
+	var coupons []model.UserCoupon
+	if err := dao.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Template").
+		Where("user_id = ? AND id IN ?", userID, couponIDs).Order("id ASC").Find(&coupons).Error; err != nil {
+		return nil, err
+	}
+	return coupons, nil
+}
+
+// ListProductCategories 查询商品与指定分类的关联关系
+func (dao *CouponDao) ListProductCategories(ctx context.Context, productIDs, categoryIDs []uint) ([]model.ProductCategory, error) {
+	var relations []model.ProductCategory
+	if len(productIDs) == 0 || len(categoryIDs) == 0 {
+		return relations, nil
+	}
+	if err := dao.db.WithContext(ctx).Where("product_id IN ? AND category_id IN ?", productIDs, categoryIDs).
+		Find(&relations).Error; err != nil {
+		return nil, err
+	}
+	return relations, nil
+}
+
+// MarkUsed 将未使用的优惠券标记为已被订单使用，状态已变化时返回 ErrCouponStatusConflict
+func (dao *CouponDao) MarkUsed(ctx context.Context, couponID uint, orderID string, amount money.Amount, now time.Time) error {
+	result := dao.db.WithContext(ctx).Model(&model.UserCoupon{}).
+		Where("id = ? AND status = ?", couponID, consts.UserCouponStatusUnused).
+		Updates(map[string]interface{}{
+			"status":          consts.UserCouponStatusUsed,
+			"order_id":        orderID,
+			"discount_amount": amount,
+			"used_at":         now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCouponStatusConflict
+	}
+	return nil
+}
+
+// RestoreOrderCoupons 订单取消或关闭后退回其使用的优惠券，已过有效期的直接标记为已过期
+func (dao *CouponDao) RestoreOrderCoupons(ctx context.Context, orderID string, now time.Time) error {
+	return dao.db.WithContext(ctx).Model(&model.UserCoupon{}).
+		Where("order_id = ? AND status = ?", orderID, consts.UserCouponStatusUsed).
+		Updates(map[string]interface{}{
+			"status":          gorm.Expr("CASE WHEN expires_at > ? THEN ? ELSE ? END", now, consts.UserCouponStatusUnused, consts.UserCouponStatusExpired),
+			"order_id":        "",
+			"discount_amount": 0,
+			"used_at":         nil,
+		}).Error
+}
@@ -0,0 +1,49 @@
+package dao
+
+import (
+	"context"
+	"douyin/repository/db/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ExchangeRateDao 定义汇率数据访问对象
+type ExchangeRateDao struct {
+	db *gorm.DB
+}
+
+// NewExchangeRateDao 根据传入的数据库连接创建新的 ExchangeRateDao 实例
+func NewExchangeRateDao(db *gorm.DB) *ExchangeRateDao {
+	return &ExchangeRateDao{
+		db: db,
+	}
+}
+
+// UpsertRates 批量写入汇率，币种已存在时覆盖汇率与来源
+func (dao *ExchangeRateDao) UpsertRates(ctx context.Context, rates []model.ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "updated_by", "updated_at"}),
+	}).Create(&rates).Error
+}
+
+// GetRate 查询指定币种的汇率
+func (dao *ExchangeRateDao) GetRate(ctx context.Context, currency string) (*model.ExchangeRate, error) {
+	var rate model.ExchangeRate
+	if err := dao.db.WithContext(ctx).Where("currency = ?", currency).First(&rate).Error; err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// ListRates 查询全部汇率，按币种排序
+func (dao *ExchangeRateDao) ListRates(ctx context.Context) ([]model.ExchangeRate, error) {
+	var rates []model.ExchangeRate
+	if err := dao.db.WithContext(ctx).Order("currency ASC").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
@@ -0,0 +1,158 @@
+package dao
+
+import (
+	"context"
+	"douyin/repository/db/model"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+var (
+	// ErrFlashSaleClosed 秒杀场次已停用
+	ErrFlashSaleClosed = errors.New("秒杀活动已下线")
+	// ErrFlashSaleExhausted 秒杀场次已全部成交
+	ErrFlashSaleExhausted = errors.New("秒杀商品已售罄")
+	// ErrFlashSaleDuplicated 用户在该场次已成交过
+	ErrFlashSaleDuplicated = errors.New("每人限购一件，您已抢购成功")
+)
+
+// FlashSaleDao 定义秒杀数据访问对象
+type FlashSaleDao struct {
+	db *gorm.DB
+}
+
+// NewFlashSaleDao 根据传入的数据库连接创建新的 FlashSaleDao 实例
+func NewFlashSaleDao(db *gorm.DB) *FlashSaleDao {
+	return &FlashSaleDao{
+		db: db,
+	}
+}
+
+// CreateSession 创建秒杀场次
+func (dao *FlashSaleDao) CreateSession(ctx context.Context, session *model.FlashSaleSession) error {
+	return dao.db.WithContext(ctx).Create(session).Error
+}
+
+// GetSession 查询秒杀场次，不存在时返回 gorm.ErrRecordNotFound
+func (dao *FlashSaleDao) GetSession(ctx context.Context, sessionID uint) (*model.FlashSaleSession, error) {
+	var session model.FlashSaleSession
+	if err := dao.db.WithContext(ctx).Where("id = ?", sessionID).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetSku 查询秒杀 SKU 及其商品，SKU 的确定规则与返回的错误见 ProductSkuDao.ResolveSku
+func (dao *FlashSaleDao) GetSku(ctx context.Context, productID, skuID uint) (*model.ProductSku, error) {
+	return NewProductSkuDao(dao.db).ResolveSku(ctx, productID, skuID, false)
+}
+
+// SetSessionEnabled 启用或停用秒杀场次并返回更新后的场次，场次不存在时返回 gorm.ErrRecordNotFound
+func (dao *FlashSaleDao) SetSessionEnabled(ctx context.Context, sessionID uint, enabled bool) (*model.FlashSaleSession, error) {
+	if err := dao.db.WithContext(ctx).Model(&model.FlashSaleSession{}).Where("id = ?", sessionID).
+		Update("enabled", enabled).Error; err != nil {
+		return nil, err
+	}
+	return dao.GetSession(ctx, sessionID)
+}
+
+// ListSessions 分页查询秒杀场次并预加载商品与 SKU，按开始时间排序；activeAt 不为零值时只返回该时刻进行中或未开始的已启用场次
+func (dao *FlashSaleDao) ListSessions(ctx context.Context, activeAt time.Time, pageNum, pageSize int) ([]model.FlashSaleSession, int64, error) {
+	query := dao.db.WithContext(ctx).Model(&model.FlashSaleSession{})
+	if !activeAt.IsZero() {
+		query = query.Where("enabled = ? AND end_at > ?", true, activeAt)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var sessions []model.FlashSaleSession
+	if err := query.Preload("Product").Preload("Sku").Order("start_at ASC, id ASC").
+		Offset((pageNum - 1) * pageSize).Limit(pageSize).
+		Find(&sessions).Error; err != nil {
+		return nil, 0, err
+	}
+	return sessions, total, nil
+}
+
+// ListUnfinishedSessions 查询 now 时刻尚未结束的已启用场次，用于预热库存
+func (dao *FlashSaleDao) ListUnfinishedSessions(ctx context.Context, now time.Time) ([]model.FlashSaleSession, error) {
+	var sessions []model.FlashSaleSession
+	if err := dao.db.WithContext(ctx).Where("enabled = ? AND end_at > ?", true, now).Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RecordOrder 在下单事务中锁定场次、累加成交数量并写入成交记录，返回场次（用于获取秒杀价）
+// Redis 中的库存与限购是第一道防线，这里在数据库层面再次保证不超卖、每人每场只成交一单
+func (dao *FlashSaleDao) RecordOrder(ctx context.Context, sessionID, userID uint, orderID string) (*model.FlashSaleSession, error) {
+	var session model.FlashSaleSession
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", sessionID).First(&session).Error; err != nil {
+			return err
+		}
+		if !session.Enabled {
+			return ErrFlashSaleClosed
+		}
+		if session.Sold >= session.Stock {
+			return ErrFlashSaleExhausted
+		}
+		var count int64
+		if err := tx.Model(&model.FlashSaleOrder{}).Where("session_id = ? AND user_id = ?", sessionID, userID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrFlashSaleDuplicated
+		}
+		if err := tx.Model(&session).Update("sold", gorm.Expr("sold + 1")).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.FlashSaleOrder{
+			SessionID: sessionID,
+			UserID:    userID,
+			OrderID:   orderID,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ReleaseOrder 订单取消或关闭时回退成交：删除订单的秒杀成交记录并扣回场次成交数量，需在事务中调用
+// 返回被删除的成交记录，非秒杀订单返回 nil
+func (dao *FlashSaleDao) ReleaseOrder(ctx context.Context, orderID string) (*model.FlashSaleOrder, error) {
+	var record model.FlashSaleOrder
+	err := dao.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ?", orderID).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := dao.db.WithContext(ctx).Model(&model.FlashSaleSession{}).
+		Where("id = ? AND sold > 0", record.SessionID).
+		Update("sold", gorm.Expr("sold - 1")).Error; err != nil {
+		return nil, err
+	}
+	if err := dao.db.WithContext(ctx).Delete(&record).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// GetUserOrder 查询用户在场次中的成交记录，不存在时返回 gorm.ErrRecordNotFound
+func (dao *FlashSaleDao) GetUserOrder(ctx context.Context, sessionID, userID uint) (*model.FlashSaleOrder, error) {
+	var record model.FlashSaleOrder
+	if err := dao.db.WithContext(ctx).Where("session_id = ? AND user_id = ?", sessionID, userID).First(&record).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
@@ -0,0 +1,656 @@
+package dao
+
+import (
+	"context"
+	"douyin/consts"
+	"douyin/repository/db/model"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sort"
+	"strconv"
+)
+
+var (
+	// ErrWarehouseNotFound 仓库不存在
+	ErrWarehouseNotFound = errors.New("仓库不存在")
+	// ErrWarehouseCodeExists 仓库编码已被使用
+	ErrWarehouseCodeExists = errors.New("仓库编码已存在")
+	// ErrInsufficientInventory 启用仓库的可售数量之和不足
+	ErrInsufficientInventory = errors.New("库存不足")
+	// ErrInventoryBelowReserved 调整后在库数量低于已被订单占用的数量
+	ErrInventoryBelowReserved = errors.New("在库数量不能低于已被订单占用的数量")
+)
+
+// InventoryMoveRef 库存流水关联的业务单据与操作人
+type InventoryMoveRef struct {
+	RefType string // 取值见 consts.InventoryRef*
+	RefID   string
+	Actor   string // 取值见 consts.OrderActor*
+	ActorID uint
+	Remark  string
+}
+
+// InventoryMovementFilter 库存流水查询条件，零值表示不限
+type InventoryMovementFilter struct {
+	WarehouseID uint
+	SkuID       uint
+	Type        string
+	RefType     string
+	RefID       string
+}
+
+// InventoryLevelCheck 库存水位与其流水合计，用于对账
+type InventoryLevelCheck struct {
+	LevelID        uint
+	WarehouseID    uint
+	SkuID          uint
+	OnHand         int
+	Reserved       int
+	LedgerOnHand   int // 流水在库变动之和
+	LedgerReserved int // 流水占用变动之和
+}
+
+// SkuStockCheck SKU 缓存库存与各仓库可售数量之和，用于对账
+type SkuStockCheck struct {
+	SkuID          uint
+	ProductID      uint
+	Stock          int // ProductSku.Stock
+	LevelAvailable int // 各仓库 OnHand - Reserved 之和
+}
+
+// orderReservation 订单在某仓库对某 SKU 尚未出库或释放的占用
+type orderReservation struct {
+	WarehouseID uint
+	SkuID       uint
+	ProductID   uint
+	Quantity    int
+}
+
+// InventoryDao 库存数据访问对象：仓库、库存水位与库存流水
+// 所有库存变动（下单占用、支付出库、取消释放、退货入库、入库与盘点调整）都经由此处，
+// 在同一事务中锁定库存水位、写入流水，并同步 ProductSku.Stock 与 Product.Stock
+type InventoryDao struct {
+	db *gorm.DB
+}
+
+// NewInventoryDao 根据传入的数据库连接创建新的 InventoryDao 实例
+func NewInventoryDao(db *gorm.DB) *InventoryDao {
+	return &InventoryDao{
+		db: db,
+	}
+}
+
+// CreateWarehouse 创建仓库，编码已被使用时返回 ErrWarehouseCodeExists
+func (dao *InventoryDao) CreateWarehouse(ctx context.Context, warehouse *model.Warehouse) error {
+	var count int64
+	if err := dao.db.WithContext(ctx).Model(&model.Warehouse{}).Where("code = ?", warehouse.Code).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrWarehouseCodeExists
+	}
+	return dao.db.WithContext(ctx).Create(warehouse).Error
+}
+
+// UpdateWarehouse 保存仓库的名称、地址、优先级与启用状态
+func (dao *InventoryDao) UpdateWarehouse(ctx context.Context, warehouse *model.Warehouse) error {
+	return dao.db.WithContext(ctx).Model(warehouse).
+		Select("name", "address", "priority", "enabled").
+		Updates(warehouse).Error
+}
+
+// GetWarehouse 按ID查询仓库，不存在时返回 ErrWarehouseNotFound
+func (dao *InventoryDao) GetWarehouse(ctx context.Context, id uint) (*model.Warehouse, error) {
+	var warehouse model.Warehouse
+	if err := dao.db.WithContext(ctx).Where("id = ?", id).First(&warehouse).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWarehouseNotFound
+		}
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+// ListWarehouses 按分配优先级列出全部仓库
+func (dao *InventoryDao) ListWarehouses(ctx context.Context) ([]model.Warehouse, error) {
+	var warehouses []model.Warehouse
+	if err := dao.db.WithContext(ctx).Order("priority ASC, id ASC").Find(&warehouses).Error; err != nil {
+		return nil, err
+	}
+	return warehouses, nil
+}
+
+// DefaultWarehouse 返回默认仓库，不存在时创建
+func (dao *InventoryDao) DefaultWarehouse(ctx context.Context) (*model.Warehouse, error) {
+	warehouse := model.Warehouse{Code: consts.DefaultWarehouseCode}
+	if err := dao.db.WithContext(ctx).Where("code = ?", consts.DefaultWarehouseCode).
+		Attrs(model.Warehouse{Name: "默认仓库", Enabled: true}).
+		FirstOrCreate(&warehouse).Error; err != nil {
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+// SellableStock 返回 SKU 对指定用户的可售数量：各仓库可售数量之和减去其他用户购物车中未过期的预占
+// sku 应为调用方在当前事务中锁定读取的记录
+func (dao *InventoryDao) SellableStock(ctx context.Context, sku *model.ProductSku, userID uint) (int, error) {
+	reserved, err := NewStockReservationDao(dao.db).SumReservedByOthers(ctx, sku.ID, userID)
+	if err != nil {
+		return 0, err
+	}
+	return sku.Stock - reserved, nil
+}
+
+// ReserveForOrder 下单时按仓库优先级从启用的仓库占用库存，一个 SKU 可拆分到多个仓库，每个仓库写一条占用流水
+// sku 须为调用方在当前事务中锁定读取的记录；可售数量不足时返回 ErrInsufficientInventory
+func (dao *InventoryDao) ReserveForOrder(ctx context.Context, orderID string, sku *model.ProductSku, quantity int, userID uint) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var enabled []model.Warehouse
+		if err := tx.Where("enabled = ?", true).Order("priority ASC, id ASC").Find(&enabled).Error; err != nil {
+			return err
+		}
+		var locked []model.InventoryLevel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("sku_id = ?", sku.ID).Find(&locked).Error; err != nil {
+			return err
+		}
+		byWarehouse := make(map[uint]model.InventoryLevel, len(locked))
+		for _, level := range locked {
+			byWarehouse[level.WarehouseID] = level
+		}
+		levels := make([]model.InventoryLevel, 0, len(enabled))
+		for _, warehouse := range enabled {
+			if level, ok := byWarehouse[warehouse.ID]; ok {
+				levels = append(levels, level)
+			}
+		}
+
+		allocations := model.AllocateInventory(levels, quantity)
+		if allocations == nil {
+			return ErrInsufficientInventory
+		}
+		ref := InventoryMoveRef{RefType: consts.InventoryRefOrder, RefID: orderID, Actor: consts.OrderActorUser, ActorID: userID, Remark: "下单占用"}
+		for _, allocation := range allocations {
+			level := byWarehouse[allocation.WarehouseID]
+			if err := recordMovement(tx, &level, consts.InventoryMoveReservation, 0, allocation.Quantity, ref); err != nil {
+				return err
+			}
+		}
+		return changeSkuStock(tx, sku.ID, sku.ProductID, -quantity)
+	})
+}
+
+// CommitOrderSale 订单支付成功后将其占用转为销售出库，在支付成功的事务中调用；可售数量不变，SKU 库存无需同步
+// 库存台账启用之前创建的订单没有占用记录（下单时已直接扣减库存），此时不做任何处理
+func (dao *InventoryDao) CommitOrderSale(ctx context.Context, orderID string) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		reservations, err := listOrderReservations(tx, orderID)
+		if err != nil {
+			return err
+		}
+		ref := InventoryMoveRef{RefType: consts.InventoryRefOrder, RefID: orderID, Actor: consts.OrderActorSystem, Remark: "支付出库"}
+		for _, r := range reservations {
+			level, err := lockInventoryLevel(tx, r.WarehouseID, r.SkuID, r.ProductID)
+			if err != nil {
+				return err
+			}
+			if err := recordMovement(tx, level, consts.InventoryMoveSale, -r.Quantity, -r.Quantity, ref); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ReleaseOrder 未支付订单取消或关闭时释放其占用，并归还 SKU 可售库存
+// 库存台账启用之前创建的订单没有占用记录，按订单项数量调整回默认仓库
+func (dao *InventoryDao) ReleaseOrder(ctx context.Context, orderID string, actor string, actorID uint, reason string) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		reservations, err := listOrderReservations(tx, orderID)
+		if err != nil {
+			return err
+		}
+		ref := InventoryMoveRef{RefType: consts.InventoryRefOrder, RefID: orderID, Actor: actor, ActorID: actorID, Remark: reason}
+		for _, r := range reservations {
+			// 与下单时一致，先锁 SKU 再锁库存水位；SKU 已被删除时仍释放仓库占用，但不再归还 SKU 库存
+			_, err := lockSku(tx, r.SkuID)
+			skuDeleted := errors.Is(err, ErrSkuNotFound)
+			if err != nil && !skuDeleted {
+				return err
+			}
+			level, err := lockInventoryLevel(tx, r.WarehouseID, r.SkuID, r.ProductID)
+			if err != nil {
+				return err
+			}
+			if err := recordMovement(tx, level, consts.InventoryMoveRelease, 0, -r.Quantity, ref); err != nil {
+				return err
+			}
+			if skuDeleted {
+				continue
+			}
+			if err := changeSkuStock(tx, r.SkuID, r.ProductID, r.Quantity); err != nil {
+				return err
+			}
+		}
+
+		if len(reservations) > 0 {
+			return nil
+		}
+		var count int64
+		if err := tx.Model(&model.InventoryMovement{}).
+			Where("ref_type = ? AND ref_id = ?", consts.InventoryRefOrder, orderID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil // 占用已全部出库或释放
+		}
+		var items []model.OrderItem
+		if err := tx.Where("order_id = ?", orderID).Find(&items).Error; err != nil {
+			return err
+		}
+		ref.Remark = reason + "（台账启用前的订单）"
+		for _, item := range items {
+			if err := adjustDefaultWarehouse(ctx, tx, item.SkuID, int(item.Quantity), consts.InventoryMoveAdjustment, ref); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ReturnRefundItems 退货退款完成后将退回的商品入库：优先退回该订单销售出库的仓库，找不到时退回默认仓库
+// SKU 已被删除的商品不再入库
+func (dao *InventoryDao) ReturnRefundItems(ctx context.Context, refund *model.Refund, reviewerID uint) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ref := InventoryMoveRef{
+			RefType: consts.InventoryRefRefund,
+			RefID:   strconv.FormatUint(uint64(refund.ID), 10),
+			Actor:   consts.OrderActorAdmin,
+			ActorID: reviewerID,
+			Remark:  "退货入库 " + refund.RefundNo,
+		}
+		for _, item := range refund.Items {
+			sku, err := lockSku(tx, item.SkuID)
+			if err != nil {
+				if errors.Is(err, ErrSkuNotFound) {
+					continue
+				}
+				return err
+			}
+
+			var warehouseIDs []uint
+			if err := tx.Model(&model.InventoryMovement{}).
+				Where("ref_type = ? AND ref_id = ? AND sku_id = ? AND type = ?", consts.InventoryRefOrder, refund.OrderID, sku.ID, consts.InventoryMoveSale).
+				Order("id ASC").Limit(1).Pluck("warehouse_id", &warehouseIDs).Error; err != nil {
+				return err
+			}
+			if len(warehouseIDs) == 0 {
+				if err := adjustDefaultWarehouse(ctx, tx, sku.ID, int(item.Quantity), consts.InventoryMoveReturn, ref); err != nil {
+					return err
+				}
+				continue
+			}
+			level, err := lockInventoryLevel(tx, warehouseIDs[0], sku.ID, sku.ProductID)
+			if err != nil {
+				return err
+			}
+			if err := recordMovement(tx, level, consts.InventoryMoveReturn, int(item.Quantity), 0, ref); err != nil {
+				return err
+			}
+			if err := changeSkuStock(tx, sku.ID, sku.ProductID, int(item.Quantity)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Receive 仓库入库
+func (dao *InventoryDao) Receive(ctx context.Context, warehouseID, skuID uint, quantity int, ref InventoryMoveRef) (*model.InventoryLevel, error) {
+	return dao.changeOnHand(ctx, warehouseID, skuID, quantity, consts.InventoryMoveReceipt, ref)
+}
+
+// Adjust 盘点调整在库数量，delta 为正表示盘盈、为负表示盘亏；调整后不能低于已占用数量
+func (dao *InventoryDao) Adjust(ctx context.Context, warehouseID, skuID uint, delta int, ref InventoryMoveRef) (*model.InventoryLevel, error) {
+	return dao.changeOnHand(ctx, warehouseID, skuID, delta, consts.InventoryMoveAdjustment, ref)
+}
+
+// SetSkuStock 将 SKU 的可售库存调整为 target，用于商品维护时直接修改库存
+// 增加的部分计入默认仓库；减少的部分按仓库优先级从有可售数量的仓库扣除；sku 须为当前事务中锁定读取的记录
+// sku.Stock 为 0 且没有任何库存水位（新建的 SKU）时记为入库，否则记为盘点调整
+func (dao *InventoryDao) SetSkuStock(ctx context.Context, sku *model.ProductSku, target int, ref InventoryMoveRef) error {
+	delta := target - sku.Stock
+	if delta == 0 {
+		return nil
+	}
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var levels []model.InventoryLevel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("sku_id = ?", sku.ID).Find(&levels).Error; err != nil {
+			return err
+		}
+		if delta > 0 {
+			moveType := consts.InventoryMoveAdjustment
+			if len(levels) == 0 {
+				moveType = consts.InventoryMoveReceipt
+			}
+			return adjustDefaultWarehouse(ctx, tx, sku.ID, delta, moveType, ref)
+		}
+
+		var warehouses []model.Warehouse
+		if err := tx.Order("priority ASC, id ASC").Find(&warehouses).Error; err != nil {
+			return err
+		}
+		rank := make(map[uint]int, len(warehouses))
+		for i, warehouse := range warehouses {
+			rank[warehouse.ID] = i
+		}
+		sort.SliceStable(levels, func(i, j int) bool { return rank[levels[i].WarehouseID] < rank[levels[j].WarehouseID] })
+
+		allocations := model.AllocateInventory(levels, -delta)
+		if allocations == nil {
+			return ErrInsufficientInventory
+		}
+		for _, allocation := range allocations {
+			for i := range levels {
+				if levels[i].WarehouseID != allocation.WarehouseID {
+					continue
+				}
+				if err := recordMovement(tx, &levels[i], consts.InventoryMoveAdjustment, -allocation.Quantity, 0, ref); err != nil {
+					return err
+				}
+			}
+		}
+		return changeSkuStock(tx, sku.ID, sku.ProductID, delta)
+	})
+}
+
+// ListLevels 分页查询库存水位，warehouseID、skuID 为 0 时不限
+func (dao *InventoryDao) ListLevels(ctx context.Context, warehouseID, skuID uint, pageNum, pageSize int) ([]model.InventoryLevel, int64, error) {
+	query := dao.db.WithContext(ctx).Model(&model.InventoryLevel{})
+	if warehouseID != 0 {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+	if skuID != 0 {
+		query = query.Where("sku_id = ?", skuID)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var levels []model.InventoryLevel
+	if err := query.Order("sku_id ASC, warehouse_id ASC").Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&levels).Error; err != nil {
+		return nil, 0, err
+	}
+	return levels, total, nil
+}
+
+// ListMovements 按时间倒序分页查询库存流水
+func (dao *InventoryDao) ListMovements(ctx context.Context, filter *InventoryMovementFilter, pageNum, pageSize int) ([]model.InventoryMovement, int64, error) {
+	query := dao.db.WithContext(ctx).Model(&model.InventoryMovement{})
+	if filter.WarehouseID != 0 {
+		query = query.Where("warehouse_id = ?", filter.WarehouseID)
+	}
+	if filter.SkuID != 0 {
+		query = query.Where("sku_id = ?", filter.SkuID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.RefType != "" {
+		query = query.Where("ref_type = ?", filter.RefType)
+	}
+	if filter.RefID != "" {
+		query = query.Where("ref_id = ?", filter.RefID)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var movements []model.InventoryMovement
+	if err := query.Order("id DESC").Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&movements).Error; err != nil {
+		return nil, 0, err
+	}
+	return movements, total, nil
+}
+
+// ListLevelChecks 返回每个库存水位及其流水合计
+func (dao *InventoryDao) ListLevelChecks(ctx context.Context) ([]InventoryLevelCheck, error) {
+	ledger := dao.db.Model(&model.InventoryMovement{}).
+		Select("warehouse_id, sku_id, SUM(on_hand_change) AS on_hand_sum, SUM(reserved_change) AS reserved_sum").
+		Group("warehouse_id, sku_id")
+	var checks []InventoryLevelCheck
+	if err := dao.db.WithContext(ctx).Table("inventory_levels AS l").
+		Select("l.id AS level_id, l.warehouse_id, l.sku_id, l.on_hand, l.reserved, "+
+			"COALESCE(m.on_hand_sum, 0) AS ledger_on_hand, COALESCE(m.reserved_sum, 0) AS ledger_reserved").
+		Joins("LEFT JOIN (?) AS m ON m.warehouse_id = l.warehouse_id AND m.sku_id = l.sku_id", ledger).
+		Order("l.sku_id ASC, l.warehouse_id ASC").
+		Scan(&checks).Error; err != nil {
+		return nil, err
+	}
+	return checks, nil
+}
+
+// ListSkuStockChecks 返回每个 SKU 的缓存库存及其各仓库可售数量之和
+func (dao *InventoryDao) ListSkuStockChecks(ctx context.Context) ([]SkuStockCheck, error) {
+	var checks []SkuStockCheck
+	if err := dao.db.WithContext(ctx).Table("product_skus AS s").
+		Select("s.id AS sku_id, s.product_id, s.stock, COALESCE(SUM(l.on_hand - l.reserved), 0) AS level_available").
+		Joins("LEFT JOIN inventory_levels AS l ON l.sku_id = s.id").
+		Group("s.id, s.product_id, s.stock").
+		Order("s.id ASC").
+		Scan(&checks).Error; err != nil {
+		return nil, err
+	}
+	return checks, nil
+}
+
+// SyncSkuStock 按各仓库可售数量之和重写 SKU 缓存库存，并重新计算商品的最低价与总库存
+func (dao *InventoryDao) SyncSkuStock(ctx context.Context, skuID uint) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sku, err := lockSku(tx, skuID)
+		if err != nil {
+			return err
+		}
+		var available int
+		if err := tx.Model(&model.InventoryLevel{}).Select("COALESCE(SUM(on_hand - reserved), 0)").
+			Where("sku_id = ?", skuID).Scan(&available).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.ProductSku{}).Where("id = ?", skuID).Updates(map[string]interface{}{
+			"stock":   available,
+			"version": gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		if err := syncProductSkuSummary(tx, sku.ProductID); err != nil {
+			return err
+		}
+		return recordStockEvents(tx, skuID, sku.ProductID, available-sku.Stock)
+	})
+}
+
+// EnsureInventoryLevels 为还没有库存水位的 SKU 在默认仓库建立期初库存（数量取 SKU 当前库存）并写入调整流水
+// 可重复执行，返回建立期初库存的 SKU 数量
+func (dao *InventoryDao) EnsureInventoryLevels(ctx context.Context) (int, error) {
+	warehouse, err := dao.DefaultWarehouse(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var skus []model.ProductSku
+	if err := dao.db.WithContext(ctx).
+		Where("id NOT IN (?)", dao.db.Model(&model.InventoryLevel{}).Select("sku_id")).
+		Find(&skus).Error; err != nil {
+		return 0, err
+	}
+	for _, sku := range skus {
+		err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			level, err := lockInventoryLevel(tx, warehouse.ID, sku.ID, sku.ProductID)
+			if err != nil {
+				return err
+			}
+			if sku.Stock <= 0 {
+				return nil
+			}
+			return recordMovement(tx, level, consts.InventoryMoveAdjustment, sku.Stock, 0, InventoryMoveRef{
+				RefType: consts.InventoryRefProduct,
+				RefID:   strconv.FormatUint(uint64(sku.ProductID), 10),
+				Actor:   consts.OrderActorSystem,
+				Remark:  "期初库存",
+			})
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(skus), nil
+}
+
+// changeOnHand 锁定 SKU 与库存水位后变动在库数量并写入流水，同步 SKU 库存
+func (dao *InventoryDao) changeOnHand(ctx context.Context, warehouseID, skuID uint, delta int, moveType string, ref InventoryMoveRef) (*model.InventoryLevel, error) {
+	var level *model.InventoryLevel
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sku, err := lockSku(tx, skuID)
+		if err != nil {
+			return err
+		}
+		if _, err := NewInventoryDao(tx).GetWarehouse(ctx, warehouseID); err != nil {
+			return err
+		}
+		if level, err = lockInventoryLevel(tx, warehouseID, sku.ID, sku.ProductID); err != nil {
+			return err
+		}
+		if err := recordMovement(tx, level, moveType, delta, 0, ref); err != nil {
+			return err
+		}
+		return changeSkuStock(tx, sku.ID, sku.ProductID, delta)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return level, nil
+}
+
+// adjustDefaultWarehouse 在默认仓库增加 SKU 的在库数量并同步 SKU 库存，需在事务中调用
+func adjustDefaultWarehouse(ctx context.Context, tx *gorm.DB, skuID uint, quantity int, moveType string, ref InventoryMoveRef) error {
+	warehouse, err := NewInventoryDao(tx).DefaultWarehouse(ctx)
+	if err != nil {
+		return err
+	}
+	sku, err := lockSku(tx, skuID)
+	if err != nil {
+		if errors.Is(err, ErrSkuNotFound) {
+			return nil // SKU 已被删除，无需归还
+		}
+		return err
+	}
+	level, err := lockInventoryLevel(tx, warehouse.ID, sku.ID, sku.ProductID)
+	if err != nil {
+		return err
+	}
+	if err := recordMovement(tx, level, moveType, quantity, 0, ref); err != nil {
+		return err
+	}
+	return changeSkuStock(tx, sku.ID, sku.ProductID, quantity)
+}
+
+// listOrderReservations 汇总订单在各仓库对各 SKU 尚未出库或释放的占用数量
+func listOrderReservations(tx *gorm.DB, orderID string) ([]orderReservation, error) {
+	var reservations []orderReservation
+	if err := tx.Model(&model.InventoryMovement{}).
+		Select("warehouse_id, sku_id, product_id, SUM(reserved_change) AS quantity").
+		Where("ref_type = ? AND ref_id = ?", consts.InventoryRefOrder, orderID).
+		Group("warehouse_id, sku_id, product_id").
+		Having("SUM(reserved_change) > 0").
+		Order("sku_id ASC, warehouse_id ASC").
+		Scan(&reservations).Error; err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// lockSku 锁定读取 SKU 行，不存在时返回 ErrSkuNotFound
+func lockSku(tx *gorm.DB, skuID uint) (*model.ProductSku, error) {
+	var sku model.ProductSku
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", skuID).First(&sku).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSkuNotFound
+		}
+		return nil, err
+	}
+	return &sku, nil
+}
+
+// lockInventoryLevel 锁定读取仓库中 SKU 的库存水位，不存在时创建数量为 0 的水位
+func lockInventoryLevel(tx *gorm.DB, warehouseID, skuID, productID uint) (*model.InventoryLevel, error) {
+	var level model.InventoryLevel
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("warehouse_id = ? AND sku_id = ?", warehouseID, skuID).First(&level).Error
+	if err == nil {
+		return &level, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	level = model.InventoryLevel{WarehouseID: warehouseID, SkuID: skuID, ProductID: productID}
+	if err := tx.Create(&level).Error; err != nil {
+		return nil, err
+	}
+	return &level, nil
+}
+
+// recordMovement 变动已锁定的库存水位并追加一条流水，变动后须满足 0 <= 占用 <= 在库
+func recordMovement(tx *gorm.DB, level *model.InventoryLevel, moveType string, onHandChange, reservedChange int, ref InventoryMoveRef) error {
+	onHand := level.OnHand + onHandChange
+	reserved := level.Reserved + reservedChange
+	if reserved < 0 {
+		return fmt.Errorf("库存占用数量不足 (warehouseID: %d, skuID: %d)", level.WarehouseID, level.SkuID)
+	}
+	if onHand < reserved {
+		return ErrInventoryBelowReserved
+	}
+	if err := tx.Model(&model.InventoryLevel{}).Where("id = ?", level.ID).Updates(map[string]interface{}{
+		"on_hand":  onHand,
+		"reserved": reserved,
+	}).Error; err != nil {
+		return err
+	}
+	level.OnHand = onHand
+	level.Reserved = reserved
+
+	return tx.Create(&model.InventoryMovement{
+		WarehouseID:    level.WarehouseID,
+		SkuID:          level.SkuID,
+		ProductID:      level.ProductID,
+		Type:           moveType,
+		OnHandChange:   onHandChange,
+		ReservedChange: reservedChange,
+		OnHandAfter:    onHand,
+		ReservedAfter:  reserved,
+		RefType:        ref.RefType,
+		RefID:          ref.RefID,
+		Actor:          ref.Actor,
+		ActorID:        ref.ActorID,
+		Remark:         ref.Remark,
+	}).Error
+}
+
+// changeSkuStock 按可售数量的变化同步 SKU 库存与商品总库存，并写入低库存与到货库存事件
+func changeSkuStock(tx *gorm.DB, skuID, productID uint, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+	if err := tx.Model(&model.ProductSku{}).Where("id = ?", skuID).Updates(map[string]interface{}{
+		"stock":   gorm.Expr("stock + ?", delta),
+		"version": gorm.Expr("version + 1"),
+	}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&model.Product{}).Where("id = ?", productID).Updates(map[string]interface{}{
+		"stock":   gorm.Expr("stock + ?", delta),
+		"version": gorm.Expr("version + 1"),
+	}).Error; err != nil {
+		return err
+	}
+	return recordStockEvents(tx, skuID, productID, delta)
+}
@@ -0,0 +1,333 @@
+package dao
+
+import (
+	"context"
+	"douyin/consts"
+	"douyin/pkg/utils/money"
+	"douyin/repository/db/model"
+	"douyin/types"
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrMerchantNotFound 商家不存在
+	ErrMerchantNotFound = errors.New("商家不存在")
+	// ErrMerchantExists 用户已开设店铺
+	ErrMerchantExists = errors.New("您已开设店铺")
+	// ErrMerchantNameExists 店铺名称已被使用
+	ErrMerchantNameExists = errors.New("店铺名称已被使用")
+)
+
+// MerchantSettleResult 一个商家一次结算的结果
+type MerchantSettleResult struct {
+	MerchantID uint
+	BatchNo    string
+	Entries    int          // 本次结算的明细条数
+	Amount     money.Amount // 本次结算记入商家钱包的基础币种金额，扣回多于货款时为负数
+}
+
+// MerchantDao 商家、商家订单视图与结算明细数据访问对象
+type MerchantDao struct {
+	db *gorm.DB
+}
+
+// NewMerchantDao 根据传入的数据库连接创建新的 MerchantDao 实例
+func NewMerchantDao(db *gorm.DB) *MerchantDao {
+	return &MerchantDao{
+		db: db,
+	}
+}
+
+// CreateMerchant 为用户开设店铺，用户已有店铺或店铺名称重复时返回错误
+func (dao *MerchantDao) CreateMerchant(ctx context.Context, merchant *model.Merchant) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.Merchant{}).Where("user_id = ?", merchant.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrMerchantExists
+		}
+		if err := checkMerchantName(tx, merchant.Name, 0); err != nil {
+			return err
+		}
+		return tx.Create(merchant).Error
+	})
+}
+
+// UpdateMerchant 保存商家的名称、简介、联系邮箱与状态，店铺名称不能与其他商家重复
+func (dao *MerchantDao) UpdateMerchant(ctx context.Context, merchant *model.Merchant) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkMerchantName(tx, merchant.Name, merchant.ID); err != nil {
+			return err
+		}
+		return tx.Model(merchant).Select("name", "description", "contact_email", "status").Updates(merchant).Error
+	})
+}
+
+// GetMerchant 根据ID查询商家
+func (dao *MerchantDao) GetMerchant(ctx context.Context, id uint) (*model.Merchant, error) {
+	var merchant model.Merchant
+	if err := dao.db.WithContext(ctx).Where("id = ?", id).First(&merchant).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMerchantNotFound
+		}
+		return nil, err
+	}
+	return &merchant, nil
+}
+
+// GetMerchantByUserID 查询用户开设的店铺，未开设时返回 ErrMerchantNotFound
+func (dao *MerchantDao) GetMerchantByUserID(ctx context.Context, userID uint) (*model.Merchant, error) {
+	var merchant model.Merchant
+	if err := dao.db.WithContext(ctx).Where("user_id = ?", userID).First(&merchant).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMerchantNotFound
+		}
+		return nil, err
+	}
+	return &merchant, nil
+}
+
+// GetMerchantByUserID 使用全局数据库连接查询用户开设的店铺
+func GetMerchantByUserID(ctx context.Context, userID uint) (*model.Merchant, error) {
+	return NewMerchantDao(db).GetMerchantByUserID(ctx, userID)
+}
+
+// ListMerchants 分页查询商家，status 为空时不限状态
+func (dao *MerchantDao) ListMerchants(ctx context.Context, status string, pageNum, pageSize int) ([]model.Merchant, int64, error) {
+	query := dao.db.WithContext(ctx).Model(&model.Merchant{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var merchants []model.Merchant
+	if err := query.Order("id ASC").Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&merchants).Error; err != nil {
+		return nil, 0, err
+	}
+	return merchants, total, nil
+}
+
+// ListMerchantProducts 分页查询商家的商品，按上架时间倒序
+func (dao *MerchantDao) ListMerchantProducts(ctx context.Context, merchantID uint, pageNum, pageSize int) ([]model.Product, int64, error) {
+	query := dao.db.WithContext(ctx).Model(&model.Product{}).Where("merchant_id = ?", merchantID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var products []model.Product
+	if err := query.Order("id DESC").Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&products).Error; err != nil {
+		return nil, 0, err
+	}
+	return products, total, nil
+}
+
+// ListMerchantOrders 分页查询商家的子订单（含订单项与父订单），按下单时间倒序
+func (dao *MerchantDao) ListMerchantOrders(ctx context.Context, merchantID uint, req *types.MerchantOrderListReq) ([]model.SubOrder, int64, error) {
+	query := dao.db.WithContext(ctx).Model(&model.SubOrder{}).Where("merchant_id = ?", merchantID)
+	if req.Status != 0 {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.StartTime > 0 {
+		query = query.Where("created_at >= ?", time.Unix(req.StartTime, 0))
+	}
+	if req.EndTime > 0 {
+		query = query.Where("created_at < ?", time.Unix(req.EndTime, 0))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var subOrders []model.SubOrder
+	if err := query.Preload("OrderItems").Preload("Order").Order("created_at DESC").
+		Offset((req.PageNum - 1) * req.PageSize).Limit(req.PageSize).
+		Find(&subOrders).Error; err != nil {
+		return nil, 0, err
+	}
+	return subOrders, total, nil
+}
+
+// GetMerchantOrder 查询父订单中属于该商家的子订单（含订单项与父订单）；订单不包含该商家的商品时返回 gorm.ErrRecordNotFound
+func (dao *MerchantDao) GetMerchantOrder(ctx context.Context, merchantID uint, orderID string) (*model.SubOrder, error) {
+	var subOrder model.SubOrder
+	if err := dao.db.WithContext(ctx).Preload("OrderItems").Preload("Order").
+		Where("order_id = ? AND merchant_id = ?", orderID, merchantID).First(&subOrder).Error; err != nil {
+		return nil, err
+	}
+	return &subOrder, nil
+}
+
+// CreateOrderSettlements 订单支付成功后按商家写入待结算的应收货款，在支付成功的事务中调用；已写入过的订单不会重复写入
+func (dao *MerchantDao) CreateOrderSettlements(ctx context.Context, orderID string) error {
+	var count int64
+	if err := dao.db.WithContext(ctx).Model(&model.MerchantSettlement{}).
+		Where("order_id = ? AND type = ?", orderID, consts.SettlementTypeSale).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	var order model.Order
+	if err := dao.db.WithContext(ctx).Preload("OrderItems").Where("order_id = ?", orderID).First(&order).Error; err != nil {
+		return err
+	}
+	settlements := buildSettlements(&order, model.MerchantOrderAmounts(order.OrderItems), consts.SettlementTypeSale, "")
+	if len(settlements) == 0 {
+		return nil
+	}
+	return dao.db.WithContext(ctx).Create(&settlements).Error
+}
+
+// CreateRefundSettlements 售后退款成功后按退款项所属商家写入负数的扣回明细，在退款事务中调用
+func (dao *MerchantDao) CreateRefundSettlements(ctx context.Context, refund *model.Refund) error {
+	var order model.Order
+	if err := dao.db.WithContext(ctx).Preload("OrderItems").Where("order_id = ?", refund.OrderID).First(&order).Error; err != nil {
+		return err
+	}
+	merchantOf := make(map[uint]uint, len(order.OrderItems))
+	for _, item := range order.OrderItems {
+		merchantOf[item.ID] = item.MerchantID
+	}
+	amounts := make(map[uint]money.Amount)
+	for _, item := range refund.Items {
+		if merchantID := merchantOf[item.OrderItemID]; merchantID != 0 {
+			amounts[merchantID] -= item.Amount
+		}
+	}
+	settlements := buildSettlements(&order, amounts, consts.SettlementTypeRefund, refund.RefundNo)
+	if len(settlements) == 0 {
+		return nil
+	}
+	return dao.db.WithContext(ctx).Create(&settlements).Error
+}
+
+// ListSettlements 按时间倒序分页查询商家的结算明细，status 为空时不限状态
+func (dao *MerchantDao) ListSettlements(ctx context.Context, merchantID uint, status string, pageNum, pageSize int) ([]model.MerchantSettlement, int64, error) {
+	query := dao.db.WithContext(ctx).Model(&model.MerchantSettlement{}).Where("merchant_id = ?", merchantID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var settlements []model.MerchantSettlement
+	if err := query.Order("id DESC").Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&settlements).Error; err != nil {
+		return nil, 0, err
+	}
+	return settlements, total, nil
+}
+
+// ListPendingSettlementMerchantIDs 查询有待结算明细的商家
+func (dao *MerchantDao) ListPendingSettlementMerchantIDs(ctx context.Context) ([]uint, error) {
+	var merchantIDs []uint
+	err := dao.db.WithContext(ctx).Model(&model.MerchantSettlement{}).Distinct("merchant_id").
+		Where("status = ?", consts.SettlementStatusPending).
+		Order("merchant_id ASC").
+		Pluck("merchant_id", &merchantIDs).Error
+	return merchantIDs, err
+}
+
+// SettleMerchant 在一个事务内锁定商家的全部待结算明细，将基础币种金额合计记入店主钱包（对方账户为平台销售收入），并将明细标记为已结算
+// 合计为负数（扣回多于货款）时从店主钱包扣除，余额不足返回 ErrInsufficientBalance，明细保持待结算；没有待结算明细时返回 nil
+func (dao *MerchantDao) SettleMerchant(ctx context.Context, merchant *model.Merchant) (*MerchantSettleResult, error) {
+	var result *MerchantSettleResult
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var settlements []model.MerchantSettlement
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("merchant_id = ? AND status = ?", merchant.ID, consts.SettlementStatusPending).
+			Order("id ASC").
+			Find(&settlements).Error; err != nil {
+			return err
+		}
+		if len(settlements) == 0 {
+			return nil
+		}
+
+		result = &MerchantSettleResult{
+			MerchantID: merchant.ID,
+			BatchNo:    uuid.New().String(),
+			Entries:    len(settlements),
+		}
+		ids := make([]uint, 0, len(settlements))
+		for _, settlement := range settlements {
+			result.Amount += settlement.BaseAmount
+			ids = append(ids, settlement.ID)
+		}
+		if result.Amount != 0 {
+			if _, err := NewWalletDao(tx).Post(ctx, &WalletPosting{
+				UserID:         merchant.UserID,
+				Type:           consts.WalletTxnSettlement,
+				Amount:         result.Amount.MinorUnits(),
+				CounterAccount: consts.WalletAccountSales,
+				RefID:          result.BatchNo,
+				Remark:         "商家货款结算 " + merchant.Name,
+			}); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&model.MerchantSettlement{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":     consts.SettlementStatusSettled,
+			"batch_no":   result.BatchNo,
+			"settled_at": time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// checkMerchantName 校验店铺名称未被其他商家使用，excludeID 为当前商家ID（新建时为 0）
+func checkMerchantName(tx *gorm.DB, name string, excludeID uint) error {
+	var count int64
+	if err := tx.Model(&model.Merchant{}).Where("name = ? AND id <> ?", name, excludeID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrMerchantNameExists
+	}
+	return nil
+}
+
+// buildSettlements 按商家ID顺序生成结算明细，金额为订单币种，按订单汇率快照换算出基础币种金额
+func buildSettlements(order *model.Order, amounts map[uint]money.Amount, settlementType, refundNo string) []model.MerchantSettlement {
+	merchantIDs := make([]uint, 0, len(amounts))
+	for merchantID, amount := range amounts {
+		if amount != 0 {
+			merchantIDs = append(merchantIDs, merchantID)
+		}
+	}
+	sort.Slice(merchantIDs, func(i, j int) bool { return merchantIDs[i] < merchantIDs[j] })
+
+	rate := order.ExchangeRate
+	if rate == 0 {
+		rate = money.OneRate
+	}
+	settlements := make([]model.MerchantSettlement, 0, len(merchantIDs))
+	for _, merchantID := range merchantIDs {
+		amount := amounts[merchantID]
+		settlements = append(settlements, model.MerchantSettlement{
+			MerchantID: merchantID,
+			Status:     consts.SettlementStatusPending,
+			Type:       settlementType,
+			OrderID:    order.OrderID,
+			RefundNo:   refundNo,
+			Amount:     amount,
+			Currency:   order.UserCurrency,
+			BaseAmount: amount.ConvertBack(rate),
+		})
+	}
+	return settlements
+}
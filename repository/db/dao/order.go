@@ -2,15 +2,23 @@ package dao
 
 import (
 	"context"
+	"douyin/consts"
+	"douyin/pkg/utils/money"
 	"douyin/repository/db/model"
 	"douyin/types"
 	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// ErrOrderStatusConflict 订单状态在读取后已被其他请求修改
+var ErrOrderStatusConflict = errors.New("订单状态已变更，请刷新后重试")
+
 // OrderDao 定义订单数据访问对象
 type OrderDao struct {
 	db *gorm.DB
@@ -23,110 +31,420 @@ func NewOrderDao(db *gorm.DB) *OrderDao {
 	}
 }
 
-// CreateOrder 创建订单
-func (dao *OrderDao) CreateOrder(ctx context.Context, userID uint, req *types.CreateOrderReq) (string, error) {
-	orderID := uuid.New().String()
-	order := model.Order{
-		OrderID:       orderID,
-		UserID:        userID,
-		UserCurrency:  req.UserCurrency,
-		Email:         req.Email,
-		FirstName:     req.FirstName,
-		LastName:      req.LastName,
-		StreetAddress: req.StreetAddress,
-		City:          req.City,
-		State:         req.State,
-		Country:       req.Country,
-		ZipCode:       req.ZipCode,
-		CreatedAt:     time.Now(),
-		Status:        "pending", // Initial status
-	}
+// OrderPricer 下单优惠计算，在订单项成本确定之后、订单写入之前于同一事务内调用
+// 实现方需将各订单项分摊到的优惠写入 items[i].Discount 并返回优惠总额，也可以改写 items[i].Cost（如秒杀价）；返回错误时整个下单事务回滚
+type OrderPricer interface {
+	Price(ctx context.Context, tx *gorm.DB, order *model.Order, items []model.OrderItem) (money.Amount, error)
+}
 
-	tx := dao.db.WithContext(ctx).Begin()
-	if tx.Error != nil {
-		return "", tx.Error
+// CreateOrder 在一个事务内创建订单：逐个 SKU 加锁校验并占用仓库库存（InventoryDao.ReserveForOrder）、生成订单项快照，计算优惠后写入订单、状态记录、
+// 按商家拆分的子订单与订单项，最后为父订单创建一张待支付的支付单
+// order 由 service 层填充用户、币种、汇率快照和收货地址信息，订单ID、状态和创建时间在此生成
+// 订单项的 SKU 按 ProductSkuDao.ResolveSku 确定，同一 SKU 的多行合并为一个订单项，并按 SKU ID 升序加锁，避免并发下单交叉锁定而死锁；可售库存为 SKU 库存减去其他用户购物车中未过期的预占
+// SKU 价格以基础币种定价，按 order.ExchangeRate 换算为订单币种后写入订单项；支付金额为（经 pricer 调整后的）订单项金额减去优惠
+// pricer 为 nil 时不计算优惠
+// 可在外层事务中调用（NewOrderDao(tx)），此时以 SavePoint 方式嵌套
+func (dao *OrderDao) CreateOrder(ctx context.Context, order *model.Order, items []types.OrderItemReq, pricer OrderPricer) (*model.Payment, error) {
+	if len(items) == 0 {
+		return nil, errors.New("订单中没有商品")
+	}
+	order.OrderID = uuid.New().String()
+	order.Status = consts.OrderTypeUnPaid
+	order.CreatedAt = time.Now()
+	if order.ExchangeRate == 0 {
+		order.ExchangeRate = money.OneRate
 	}
-	defer tx.Rollback() // Rollback if not committed
 
-	if err := tx.Create(&order).Error; err != nil {
-		return "", err
+	payment := &model.Payment{
+		TransactionID: uuid.New().String(),
+		OrderID:       order.OrderID,
+		UserID:        order.UserID,
+		Currency:      order.UserCurrency,
+		Status:        consts.PaymentStatusUnpaid,
+		CreatedAt:     order.CreatedAt,
 	}
 
-	var totalAmount float64 = 0 // Calculate total amount for payment
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		skuDao := NewProductSkuDao(tx)
+		lines, err := resolveOrderLines(ctx, skuDao, items)
+		if err != nil {
+			if errors.Is(err, ErrSkuNotFound) {
+				return errors.New("商品不存在")
+			}
+			return err
+		}
+		orderItems := make([]model.OrderItem, 0, len(lines))
+		inventory := NewInventoryDao(tx)
+		for _, item := range lines {
+			// 锁定 SKU 行，与购物车预占校验串行执行
+			sku, err := skuDao.ResolveSku(ctx, item.ProductID, item.SkuID, true)
+			if err != nil {
+				if errors.Is(err, ErrSkuNotFound) {
+					return errors.New("商品不存在")
+				}
+				return err
+			}
+			product := sku.Product
+
+			// Check stock：其他用户购物车中未过期的预占不可售
+			available, err := inventory.SellableStock(ctx, sku, order.UserID)
+			if err != nil {
+				return err
+			}
+			if available < item.Quantity {
+				return errors.New("库存不足: " + product.Name)
+			}
+
+			// 按仓库优先级占用库存并写入库存流水，支付成功后转为销售出库
+			if err := inventory.ReserveForOrder(ctx, order.OrderID, sku, item.Quantity, order.UserID); err != nil {
+				if errors.Is(err, ErrInsufficientInventory) {
+					return errors.New("库存不足: " + product.Name)
+				}
+				return err
+			}
+
+			cost := sku.Price.Convert(order.ExchangeRate)
+			orderItems = append(orderItems, model.OrderItem{
+				OrderID:        order.OrderID,
+				ProductID:      product.ID,
+				MerchantID:     product.MerchantID,
+				SkuID:          sku.ID,
+				Quantity:       int32(item.Quantity),
+				Cost:           cost, // 下单时价格
+				ProductName:    product.Name,
+				ProductPicture: sku.DisplayPicture(),
+				SkuSpecs:       sku.SpecKey,
+			})
+		}
 
-	for _, item := range req.OrderItems {
-		var product model.Product
-		// Lock product row for update
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", item.ProductID).First(&product).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return "", errors.New("商品不存在")
+		if pricer != nil {
+			discount, err := pricer.Price(ctx, tx, order, orderItems)
+			if err != nil {
+				return err
 			}
-			return "", err
+			order.DiscountAmount = discount
 		}
+		for _, item := range orderItems {
+			payment.Amount += item.Cost.Mul(int64(item.Quantity))
+		}
+		payment.Amount -= order.DiscountAmount
 
-		// Check stock
-		if product.Stock < item.Quantity {
-			return "", errors.New("库存不足: " + product.Name)
+		subOrders := model.SplitSubOrders(order, orderItems)
+		if err := tx.Omit("OrderItems", "SubOrders").Create(order).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&model.OrderStatusHistory{
+			OrderID:  order.OrderID,
+			ToStatus: consts.OrderTypeUnPaid,
+			Actor:    consts.OrderActorUser,
+			ActorID:  order.UserID,
+			Reason:   "创建订单",
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit("OrderItems", "Order").Create(&subOrders).Error; err != nil {
+			return err
 		}
+		if err := tx.Create(&orderItems).Error; err != nil {
+			return err
+		}
+		order.OrderItems = orderItems
+		order.SubOrders = subOrders
+
+		return NewPaymentDao(tx).CreatePayment(ctx, payment, "创建订单")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// GetOrderByID 查询属于指定用户的订单
+func (dao *OrderDao) GetOrderByID(ctx context.Context, userID uint, orderID string) (*model.Order, error) {
+	var order model.Order
+	if err := dao.db.WithContext(ctx).Where("user_id = ? AND order_id = ?", userID, orderID).First(&order).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// GetOrderByOrderID 根据订单ID查询订单（不校验所属用户，供系统任务使用）
+func (dao *OrderDao) GetOrderByOrderID(ctx context.Context, orderID string) (*model.Order, error) {
+	var order model.Order
+	if err := dao.db.WithContext(ctx).Where("order_id = ?", orderID).First(&order).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// ListUnpaidOrders 查询所有未支付订单，仅返回订单ID和创建时间
+func (dao *OrderDao) ListUnpaidOrders(ctx context.Context) ([]model.Order, error) {
+	var orders []model.Order
+	if err := dao.db.WithContext(ctx).Select("order_id", "created_at").
+		Where("status = ?", consts.OrderTypeUnPaid).Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// GetOrderDetail 查询属于指定用户的订单，并预加载订单项与子订单
+func (dao *OrderDao) GetOrderDetail(ctx context.Context, userID uint, orderID string) (*model.Order, error) {
+	var order model.Order
+	if err := dao.db.WithContext(ctx).Preload("OrderItems").Preload("SubOrders").
+		Where("user_id = ? AND order_id = ?", userID, orderID).First(&order).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// ListOrders 分页查询用户订单，支持按状态、下单时间范围和关键字（订单号或商品名称）筛选，按下单时间倒序
+func (dao *OrderDao) ListOrders(ctx context.Context, userID uint, req *types.OrderListReq) ([]model.Order, int64, error) {
+	query := dao.db.WithContext(ctx).Model(&model.Order{}).Where("user_id = ?", userID)
+	if req.Status != 0 {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.StartTime > 0 {
+		query = query.Where("created_at >= ?", time.Unix(req.StartTime, 0))
+	}
+	if req.EndTime > 0 {
+		query = query.Where("created_at < ?", time.Unix(req.EndTime, 0))
+	}
+	if req.Keyword != "" {
+		itemQuery := dao.db.Model(&model.OrderItem{}).Select("order_id").Where("product_name LIKE ?", "%"+escapeLike(req.Keyword)+"%")
+		query = query.Where("order_id = ? OR order_id IN (?)", req.Keyword, itemQuery)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var orders []model.Order
+	if err := query.Preload("OrderItems").Preload("SubOrders").Order("created_at DESC").
+		Offset((req.PageNum - 1) * req.PageSize).Limit(req.PageSize).
+		Find(&orders).Error; err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
 
-		// Update stock using optimistic locking
-		result := tx.Model(&model.Product{}).Where("id = ? AND version = ?", product.ID, product.Version).Updates(map[string]interface{}{
-			"stock":   gorm.Expr("stock - ?", item.Quantity),
-			"version": gorm.Expr("version + 1"),
-		})
+// UpdateOrder 更新订单收货地址（状态变更统一走 UpdateOrderStatus）
+func (dao *OrderDao) UpdateOrder(ctx context.Context, userID uint, req *types.UpdateOrderReq) error {
+	return dao.db.WithContext(ctx).Model(&model.Order{}).
+		Where("user_id = ? AND order_id = ?", userID, req.OrderID).
+		Updates(model.Order{
+			StreetAddress: req.StreetAddress,
+			City:          req.City,
+			State:         req.State,
+			Country:       req.Country,
+			ZipCode:       req.ZipCode,
+		}).Error
+}
 
+// UpdateOrderStatus 将订单从 from 状态流转到 to 状态，并写入状态流转记录；处于 from 状态的子订单随父订单一起流转
+// 用于支付成功、取消与超时关闭等整单流转，子订单各自的流转走 SubOrderDao.UpdateSubOrderStatus
+// 以 from 作为更新条件，若订单状态已被并发修改则返回 ErrOrderStatusConflict
+// 合法性校验由 service 层负责，dao 只保证更新与记录在同一事务中完成
+func (dao *OrderDao) UpdateOrderStatus(ctx context.Context, orderID string, from, to int, actor string, actorID uint, reason string) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Order{}).
+			Where("order_id = ? AND status = ?", orderID, from).
+			Update("status", to)
 		if result.Error != nil {
-			return "", result.Error
+			return result.Error
 		}
 		if result.RowsAffected == 0 {
-			return "", errors.New("并发冲突，请重试: " + product.Name)
+			return ErrOrderStatusConflict
 		}
-		
-		totalAmount += product.Price * float64(item.Quantity) // Assume item.Cost should be product.Price
-
-		orderItem := model.OrderItem{
-			OrderID:   orderID,
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-			Cost:      product.Price, // Use actual product price for cost
+		if err := tx.Model(&model.SubOrder{}).
+			Where("order_id = ? AND status = ?", orderID, from).
+			Update("status", to).Error; err != nil {
+			return err
 		}
+		return tx.Create(&model.OrderStatusHistory{
+			OrderID:    orderID,
+			FromStatus: from,
+			ToStatus:   to,
+			Actor:      actor,
+			ActorID:    actorID,
+			Reason:     reason,
+		}).Error
+	})
+}
 
-		if err := tx.Create(&orderItem).Error; err != nil {
-			return "", err
+// AddOrderProductSales 按订单项数量累加商品销量，在支付成功的事务中调用
+func (dao *OrderDao) AddOrderProductSales(ctx context.Context, orderID string) error {
+	var items []model.OrderItem
+	if err := dao.db.WithContext(ctx).Where("order_id = ?", orderID).Find(&items).Error; err != nil {
+		return err
+	}
+	for _, item := range items {
+		// 销量不参与乐观锁，不修改 version，避免与并发下单的库存扣减冲突
+		if err := dao.db.WithContext(ctx).Model(&model.Product{}).Where("id = ?", item.ProductID).
+			UpdateColumn("sales", gorm.Expr("sales + ?", item.Quantity)).Error; err != nil {
+			return err
 		}
 	}
+	return nil
+}
+
+// ListOrderProductIDs 返回订单涉及的商品ID（去重）
+func (dao *OrderDao) ListOrderProductIDs(ctx context.Context, orderID string) ([]uint, error) {
+	var productIDs []uint
+	if err := dao.db.WithContext(ctx).Model(&model.OrderItem{}).Distinct("product_id").
+		Where("order_id = ?", orderID).Pluck("product_id", &productIDs).Error; err != nil {
+		return nil, err
+	}
+	return productIDs, nil
+}
 
-	// Create a placeholder payment record
-	payment := model.Payment{
-		OrderID:   orderID,
-		Amount:    totalAmount, // This should be calculated based on items
-		Status:    "UNPAID",
-		CreatedAt: time.Now(),
+// ListOrderStatusHistory 按时间顺序返回订单的状态流转记录
+func (dao *OrderDao) ListOrderStatusHistory(ctx context.Context, orderID string) ([]model.OrderStatusHistory, error) {
+	var histories []model.OrderStatusHistory
+	if err := dao.db.WithContext(ctx).Where("order_id = ?", orderID).Order("id ASC").Find(&histories).Error; err != nil {
+		return nil, err
 	}
-	if err := tx.Create(&payment).Error; err != nil {
-		return "", err
+	return histories, nil
+}
+
+// ReleaseUnpaidOrder 取消或关闭未支付订单：流转订单状态、释放占用的库存、退回使用的优惠券、将待支付的支付单置为失效，
+// 秒杀订单同时删除成交记录并扣回场次成交数量
+// 各步骤在同一事务中完成；订单已不处于未支付状态时返回 ErrOrderStatusConflict，调用方可据此判定为重复处理
+// 返回被删除的秒杀成交记录（非秒杀订单为 nil），供调用方在提交后归还 Redis 中的名额
+func (dao *OrderDao) ReleaseUnpaidOrder(ctx context.Context, orderID string, to int, actor string, actorID uint, reason string) (*model.FlashSaleOrder, error) {
+	var flashSale *model.FlashSaleOrder
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := NewOrderDao(tx).UpdateOrderStatus(ctx, orderID, consts.OrderTypeUnPaid, to, actor, actorID, reason); err != nil {
+			return err
+		}
+
+		if err := NewInventoryDao(tx).ReleaseOrder(ctx, orderID, actor, actorID, reason); err != nil {
+			return err
+		}
+		if err := NewCouponDao(tx).RestoreOrderCoupons(ctx, orderID, time.Now()); err != nil {
+			return err
+		}
+		if err := NewPaymentDao(tx).ExpireOrderPayments(ctx, orderID, reason); err != nil {
+			return err
+		}
+
+		var err error
+		flashSale, err = NewFlashSaleDao(tx).ReleaseOrder(ctx, orderID)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
+	return flashSale, nil
+}
 
-	if err := tx.Commit().Error; err != nil {
-		return "", err
+// resolveOrderLines 确定每个订单项的 SKU（不加锁），合并同一 SKU 的多行数量，按 SKU ID 升序返回
+// 下单按返回顺序锁定 SKU 行，所有事务以相同顺序加锁
+func resolveOrderLines(ctx context.Context, skuDao *ProductSkuDao, items []types.OrderItemReq) ([]types.OrderItemReq, error) {
+	quantities := make(map[uint]int, len(items))
+	lines := make([]types.OrderItemReq, 0, len(items))
+	for _, item := range items {
+		sku, err := skuDao.ResolveSku(ctx, item.ProductID, item.SkuID, false)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := quantities[sku.ID]; !ok {
+			lines = append(lines, types.OrderItemReq{ProductID: sku.ProductID, SkuID: sku.ID})
+		}
+		quantities[sku.ID] += item.Quantity
+	}
+	for i := range lines {
+		lines[i].Quantity = quantities[lines[i].SkuID]
 	}
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i].SkuID < lines[j].SkuID
+	})
+	return lines, nil
+}
 
-	return orderID, nil
+// legacyOrderStatus 订单状态改为整数之前 orders.status 列中的字符串取值与 consts.OrderType* 的对应关系
+var legacyOrderStatus = map[string]int{
+	"":          consts.OrderTypeUnPaid, // 空字符串与 NULL 一样按未支付处理
+	"pending":   consts.OrderTypeUnPaid,
+	"unpaid":    consts.OrderTypeUnPaid,
+	"paid":      consts.OrderTypePendingShipping,
+	"shipped":   consts.OrderTypeShipping,
+	"shipping":  consts.OrderTypeShipping,
+	"received":  consts.OrderTypeReceipt,
+	"completed": consts.OrderTypeReceipt,
+	"cancelled": consts.OrderTypeCancelled,
+	"canceled":  consts.OrderTypeCancelled,
+	"refunded":  consts.OrderTypeRefunded,
+	"closed":    consts.OrderTypeClosed,
 }
 
-// UpdateOrder 更新订单
-func (dao *OrderDao) UpdateOrder(userID uint, req *types.UpdateOrderReq) error {
-	var order model.Order
-	if err := dao.db.Where("user_id = ? AND order_id = ?", userID, req.OrderID).First(&order).Error; err != nil {
-		return err
+// MigrateLegacyOrderStatus 将 orders.status 中的旧字符串状态（'pending'、'paid' 等）改写为 consts.OrderType* 的数字，
+// 需在 AutoMigrate 把该列改为整数类型之前执行；空值（NULL 或空字符串）按未支付处理。
+// 表不存在或该列已是数字类型时直接返回；存在无法识别的取值时返回错误且不做任何修改，返回改写的订单数量
+func (dao *OrderDao) MigrateLegacyOrderStatus(ctx context.Context) (int64, error) {
+	db := dao.db.WithContext(ctx)
+	if !db.Migrator().HasTable(&model.Order{}) {
+		return 0, nil
+	}
+	columnTypes, err := db.Migrator().ColumnTypes(&model.Order{})
+	if err != nil {
+		return 0, err
+	}
+	legacy := false
+	for _, column := range columnTypes {
+		if column.Name() == "status" {
+			switch strings.ToLower(column.DatabaseTypeName()) {
+			case "varchar", "char", "text":
+				legacy = true
+			}
+		}
+	}
+	if !legacy {
+		return 0, nil
+	}
+
+	var values []string
+	if err := db.Model(&model.Order{}).Where("status IS NOT NULL").Distinct("status").Pluck("status", &values).Error; err != nil {
+		return 0, err
+	}
+	mapping := make(map[string]int, len(values))
+	for _, value := range values {
+		if _, err := strconv.Atoi(value); err == nil {
+			continue
+		}
+		status, ok := legacyOrderStatus[strings.ToLower(strings.TrimSpace(value))]
+		if !ok {
+			return 0, fmt.Errorf("无法识别的旧订单状态 %q，请手动处理后再启动", value)
+		}
+		mapping[value] = status
 	}
 
-	order.StreetAddress = req.StreetAddress
-	order.City = req.City
-	order.State = req.State
-	order.Country = req.Country
-	order.ZipCode = req.ZipCode
-	order.Status = req.Status
+	var migrated int64
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for value, status := range mapping {
+			result := tx.Model(&model.Order{}).Where("status = ?", value).Update("status", strconv.Itoa(status))
+			if result.Error != nil {
+				return result.Error
+			}
+			migrated += result.RowsAffected
+		}
+		result := tx.Model(&model.Order{}).Where("status IS NULL").Update("status", strconv.Itoa(consts.OrderTypeUnPaid))
+		if result.Error != nil {
+			return result.Error
+		}
+		migrated += result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return migrated, nil
+}
+
+// likeEscaper 转义 LIKE 模式中的通配符，使关键词按字面匹配（MySQL 默认转义字符为反斜杠）
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
 
-	return dao.db.Save(&order).Error
+// escapeLike 转义关键词中的 %、_ 与反斜杠，用于拼接 LIKE 条件
+func escapeLike(keyword string) string {
+	return likeEscaper.Replace(keyword)
 }
@@ -1 +1,267 @@
 package dao
+
+import (
+	"context"
+	"douyin/consts"
+	"douyin/pkg/utils/money"
+	"douyin/repository/db/model"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrPaymentStatusConflict 支付单状态在读取后已被其他请求修改
+	ErrPaymentStatusConflict = errors.New("支付单状态已变更，请刷新后重试")
+	// ErrPaymentPending 订单有已向渠道发起、尚未得到结果的支付，此时不能取消或关闭订单
+	ErrPaymentPending = errors.New("订单有处理中的支付，请等待支付结果后再操作")
+)
+
+// PaymentDao 定义支付单数据访问对象
+type PaymentDao struct {
+	db *gorm.DB
+}
+
+// NewPaymentDao 根据传入的数据库连接创建新的 PaymentDao 实例
+func NewPaymentDao(db *gorm.DB) *PaymentDao {
+	return &PaymentDao{
+		db: db,
+	}
+}
+
+// CreatePayment 创建支付单并记录初始状态
+func (dao *PaymentDao) CreatePayment(ctx context.Context, payment *model.Payment, reason string) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(payment).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.PaymentStatusHistory{
+			TransactionID: payment.TransactionID,
+			ToStatus:      payment.Status,
+			Provider:      payment.Provider,
+			ProviderRef:   payment.ProviderRef,
+			Reason:        reason,
+		}).Error
+	})
+}
+
+// GetPaymentByTransactionID 根据交易ID查询支付单
+func (dao *PaymentDao) GetPaymentByTransactionID(ctx context.Context, transactionID string) (*model.Payment, error) {
+	var payment model.Payment
+	if err := dao.db.WithContext(ctx).Where("transaction_id = ?", transactionID).First(&payment).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetPaymentByTransactionIDForUpdate 加行锁查询支付单，需在事务中调用
+func (dao *PaymentDao) GetPaymentByTransactionIDForUpdate(ctx context.Context, transactionID string) (*model.Payment, error) {
+	var payment model.Payment
+	if err := dao.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("transaction_id = ?", transactionID).
+		First(&payment).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// RecordNotification 记录支付回调通知，同一渠道、交易和状态的通知已存在时返回 false
+func (dao *PaymentDao) RecordNotification(ctx context.Context, notification *model.PaymentNotification) (bool, error) {
+	result := dao.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(notification)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetPaymentByProviderRef 根据支付渠道及渠道单号查询支付单
+func (dao *PaymentDao) GetPaymentByProviderRef(ctx context.Context, provider, providerRef string) (*model.Payment, error) {
+	var payment model.Payment
+	if err := dao.db.WithContext(ctx).
+		Where("provider = ? AND provider_ref = ?", provider, providerRef).
+		First(&payment).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetPaymentByOrderIDForUpdate 加行锁查询订单最近的一笔支付单，需在事务中调用
+func (dao *PaymentDao) GetPaymentByOrderIDForUpdate(ctx context.Context, userID uint, orderID string) (*model.Payment, error) {
+	var payment model.Payment
+	if err := dao.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ? AND user_id = ?", orderID, userID).
+		Order("created_at DESC").
+		First(&payment).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// UpdatePaymentProvider 更新支付单的渠道与渠道单号，不改变支付状态
+func (dao *PaymentDao) UpdatePaymentProvider(ctx context.Context, transactionID, provider, providerRef string) error {
+	return dao.db.WithContext(ctx).Model(&model.Payment{}).
+		Where("transaction_id = ?", transactionID).
+		Updates(map[string]interface{}{"provider": provider, "provider_ref": providerRef}).Error
+}
+
+// UpdatePaymentStatus 以 from 状态为条件更新支付单状态并写入流转记录
+// updates 为需要同时更新的其他字段（provider、provider_ref、paid_at 等），可为 nil
+// 状态已被其他请求修改时返回 ErrPaymentStatusConflict
+func (dao *PaymentDao) UpdatePaymentStatus(ctx context.Context, payment *model.Payment, to string, updates map[string]interface{}, reason string) error {
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+	updates["status"] = to
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Payment{}).
+			Where("transaction_id = ? AND status = ?", payment.TransactionID, payment.Status).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPaymentStatusConflict
+		}
+
+		history := &model.PaymentStatusHistory{
+			TransactionID: payment.TransactionID,
+			FromStatus:    payment.Status,
+			ToStatus:      to,
+			Provider:      payment.Provider,
+			ProviderRef:   payment.ProviderRef,
+			Reason:        reason,
+		}
+		if provider, ok := updates["provider"].(string); ok {
+			history.Provider = provider
+		}
+		if providerRef, ok := updates["provider_ref"].(string); ok {
+			history.ProviderRef = providerRef
+		}
+		return tx.Create(history).Error
+	})
+}
+
+// GetPaidPaymentByOrderID 查询订单已支付（含部分退款）的支付单
+func (dao *PaymentDao) GetPaidPaymentByOrderID(ctx context.Context, orderID string) (*model.Payment, error) {
+	var payment model.Payment
+	if err := dao.db.WithContext(ctx).
+		Where("order_id = ? AND status = ?", orderID, consts.PaymentStatusPaid).
+		First(&payment).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetPaidPaymentByOrderIDForUpdate 加行锁查询订单已支付（含部分退款）的支付单，需在事务中调用
+func (dao *PaymentDao) GetPaidPaymentByOrderIDForUpdate(ctx context.Context, orderID string) (*model.Payment, error) {
+	var payment model.Payment
+	if err := dao.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ? AND status = ?", orderID, consts.PaymentStatusPaid).
+		First(&payment).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// AddRefundedAmount 累加支付单的已退款金额，退款累计达到支付金额时将支付单置为已退款
+func (dao *PaymentDao) AddRefundedAmount(ctx context.Context, payment *model.Payment, amount money.Amount, reason string) error {
+	refunded := payment.RefundedAmount + amount
+	if refunded > payment.Amount {
+		return errors.New("累计退款金额超出支付金额")
+	}
+	if refunded == payment.Amount {
+		if err := dao.UpdatePaymentStatus(ctx, payment, consts.PaymentStatusRefunded, map[string]interface{}{
+			"refunded_amount": payment.Amount,
+		}, reason); err != nil {
+			return err
+		}
+		payment.Status = consts.PaymentStatusRefunded
+		payment.RefundedAmount = payment.Amount
+		return nil
+	}
+	if err := dao.db.WithContext(ctx).Model(&model.Payment{}).
+		Where("transaction_id = ?", payment.TransactionID).
+		Update("refunded_amount", refunded).Error; err != nil {
+		return err
+	}
+	payment.RefundedAmount = refunded
+	return nil
+}
+
+// ListPendingPaymentsByOrderID 查询订单下处理中的支付单
+func (dao *PaymentDao) ListPendingPaymentsByOrderID(ctx context.Context, orderID string) ([]model.Payment, error) {
+	var payments []model.Payment
+	if err := dao.db.WithContext(ctx).
+		Where("order_id = ? AND status = ?", orderID, consts.PaymentStatusPending).
+		Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// ExpireOrderPayments 订单取消或关闭时，将订单下待支付或支付失败的支付单置为失效，需在事务中调用
+// 支付单加行锁读取，与发起支付互斥；存在处理中的支付单时返回 ErrPaymentPending，
+// 渠道可能已经扣款，订单需等待扣款结果（回调或查询）后再关闭，超时关单见 PaymentService.ResolvePendingPayments
+func (dao *PaymentDao) ExpireOrderPayments(ctx context.Context, orderID string, reason string) error {
+	var payments []model.Payment
+	if err := dao.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ? AND status IN ?", orderID, []string{consts.PaymentStatusUnpaid, consts.PaymentStatusPending, consts.PaymentStatusFailed}).
+		Find(&payments).Error; err != nil {
+		return err
+	}
+	for i := range payments {
+		if payments[i].Status == consts.PaymentStatusPending {
+			return ErrPaymentPending
+		}
+	}
+	for i := range payments {
+		if err := dao.UpdatePaymentStatus(ctx, &payments[i], consts.PaymentStatusExpired, nil, reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FlagReconcile 标记支付单需要对账并记录渠道单号，支付状态不变，同时写入一条流转记录说明原因
+func (dao *PaymentDao) FlagReconcile(ctx context.Context, payment *model.Payment, provider, providerRef, reason string) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Payment{}).
+			Where("transaction_id = ?", payment.TransactionID).
+			Updates(map[string]interface{}{
+				"provider":        provider,
+				"provider_ref":    providerRef,
+				"needs_reconcile": true,
+			}).Error; err != nil {
+			return err
+		}
+		payment.Provider = provider
+		payment.ProviderRef = providerRef
+		payment.NeedsReconcile = true
+		return tx.Create(&model.PaymentStatusHistory{
+			TransactionID: payment.TransactionID,
+			FromStatus:    payment.Status,
+			ToStatus:      payment.Status,
+			Provider:      provider,
+			ProviderRef:   providerRef,
+			Reason:        reason,
+		}).Error
+	})
+}
+
+// ListPaymentStatusHistory 查询支付单的状态流转记录，按时间正序
+func (dao *PaymentDao) ListPaymentStatusHistory(ctx context.Context, transactionID string) ([]model.PaymentStatusHistory, error) {
+	var histories []model.PaymentStatusHistory
+	if err := dao.db.WithContext(ctx).
+		Where("transaction_id = ?", transactionID).
+		Order("id ASC").
+		Find(&histories).Error; err != nil {
+		return nil, err
+	}
+	return histories, nil
+}
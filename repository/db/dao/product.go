@@ -59,6 +59,38 @@ func CreateProduct(product *model.Product) error {
 	return nil
 }
 
+// CreateProductWithSkus 在一个事务内创建商品及其规格与 SKU，商品的价格与库存由 SKU 汇总后回填到 product
+func CreateProductWithSkus(ctx context.Context, product *model.Product, specs []model.ProductSpec, skus []model.ProductSku) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		if err := NewProductSkuDao(tx).ReplaceSkus(ctx, product.ID, specs, skus); err != nil {
+			return err
+		}
+		return tx.Where("id = ?", product.ID).First(product).Error
+	})
+}
+
+// ReplaceProductSkus 整体替换商品的规格与 SKU，规则见 ProductSkuDao.ReplaceSkus
+func ReplaceProductSkus(ctx context.Context, productID uint, specs []model.ProductSpec, skus []model.ProductSku) error {
+	return NewProductSkuDao(db).ReplaceSkus(ctx, productID, specs, skus)
+}
+
+// ListProductSkus 查询商品的规格与 SKU
+func ListProductSkus(ctx context.Context, productID uint) ([]model.ProductSpec, []model.ProductSku, error) {
+	skuDao := NewProductSkuDao(db)
+	specs, err := skuDao.ListSpecs(ctx, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	skus, err := skuDao.ListSkus(ctx, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return specs, skus, nil
+}
+
 // 获取单个商品信息
 func GetProduct(id uint32) (*model.Product, error) {
 	var dbProduct model.Product
@@ -70,19 +102,25 @@ func GetProduct(id uint32) (*model.Product, error) {
 	return &dbProduct, nil
 }
 
-// 获取商品列表（带分页）
-func ListProducts(pageNum, pageSize int) ([]model.Product, int64, error) {
+// 获取商品列表（带分页），categoryIDs 不为空时只返回属于其中任一分类的商品
+func ListProducts(pageNum, pageSize int, categoryIDs []uint) ([]model.Product, int64, error) {
 	var products []model.Product
 	var total int64
 
+	query := db.Model(&model.Product{})
+	if len(categoryIDs) > 0 {
+		productIDs := db.Model(&model.ProductCategory{}).Select("product_id").Where("category_id IN ?", categoryIDs)
+		query = query.Where("id IN (?)", productIDs)
+	}
+
 	// 获取商品总数
-	if err := db.Model(&model.Product{}).Count(&total).Error; err != nil {
+	if err := query.Count(&total).Error; err != nil {
 		fmt.Printf("获取商品总数时出错：%v\n", err)
 		return nil, 0, err
 	}
 
 	// 查询商品列表（分页）
-	if err := db.Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&products).Error; err != nil {
+	if err := query.Order("id ASC").Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&products).Error; err != nil {
 		fmt.Printf("查询商品列表时出错：%v\n", err)
 		return nil, 0, err
 	}
@@ -101,10 +139,25 @@ func UpdateProduct(product *model.Product) error {
 	return nil
 }
 
-// 删除商品
+// UpdateProductLowStockThreshold 修改商品的低库存预警阈值，0 表示不预警
+func UpdateProductLowStockThreshold(ctx context.Context, productID uint, threshold int) error {
+	return db.WithContext(ctx).Model(&model.Product{}).Where("id = ?", productID).
+		Update("low_stock_threshold", threshold).Error
+}
+
+// 删除商品及其规格与 SKU，引用这些 SKU 的购物车行在展示时标记为无效
 func DeleteProduct(id uint32) error {
 	// 执行删除操作
-	if err := db.Where("id = ?", id).Delete(&model.Product{}).Error; err != nil {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", id).Delete(&model.ProductSku{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", id).Delete(&model.ProductSpec{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&model.Product{}).Error
+	})
+	if err != nil {
 		fmt.Printf("删除商品时出错：%v\n", err)
 		return err
 	}
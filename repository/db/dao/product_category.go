@@ -1 +1,61 @@
 package dao
+
+import (
+	"context"
+	"douyin/repository/db/model"
+	"gorm.io/gorm"
+)
+
+// ProductCategoryDao 定义商品与分类关联的数据访问对象
+type ProductCategoryDao struct {
+	db *gorm.DB
+}
+
+// NewProductCategoryDao 根据传入的数据库连接创建新的 ProductCategoryDao 实例
+func NewProductCategoryDao(db *gorm.DB) *ProductCategoryDao {
+	return &ProductCategoryDao{
+		db: db,
+	}
+}
+
+// SetProductCategories 将商品的分类整体替换为 categoryIDs，为空时清除商品的全部分类
+func (dao *ProductCategoryDao) SetProductCategories(ctx context.Context, productID uint, categoryIDs []uint) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", productID).Delete(&model.ProductCategory{}).Error; err != nil {
+			return err
+		}
+		if len(categoryIDs) == 0 {
+			return nil
+		}
+		relations := make([]model.ProductCategory, 0, len(categoryIDs))
+		for _, categoryID := range categoryIDs {
+			relations = append(relations, model.ProductCategory{
+				ProductID:  productID,
+				CategoryID: categoryID,
+			})
+		}
+		return tx.Create(&relations).Error
+	})
+}
+
+// ListByProducts 查询多个商品的分类关联
+func (dao *ProductCategoryDao) ListByProducts(ctx context.Context, productIDs []uint) ([]model.ProductCategory, error) {
+	var relations []model.ProductCategory
+	if len(productIDs) == 0 {
+		return relations, nil
+	}
+	if err := dao.db.WithContext(ctx).Where("product_id IN ?", productIDs).
+		Order("product_id ASC, category_id ASC").Find(&relations).Error; err != nil {
+		return nil, err
+	}
+	return relations, nil
+}
+
+// ProductExists 判断商品是否存在
+func (dao *ProductCategoryDao) ProductExists(ctx context.Context, productID uint) (bool, error) {
+	var count int64
+	if err := dao.db.WithContext(ctx).Model(&model.Product{}).Where("id = ?", productID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,103 @@
+package dao
+
+import (
+	"context"
+
+	"douyin/consts"
+	"douyin/pkg/utils/money"
+	"douyin/repository/db/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProductSearchFilter 商品搜索条件，各搜索后端共用
+type ProductSearchFilter struct {
+	Keyword     string       // 关键词，匹配商品名称和描述
+	MinPrice    money.Amount // 最低价格（基础币种），0 表示不限
+	MaxPrice    money.Amount // 最高价格（基础币种），0 表示不限
+	CategoryIDs []uint       // 属于其中任一分类，为空表示不限
+	InStock     bool         // 只返回有库存的商品
+	Sort        string       // 排序方式，取值见 consts.ProductSort*
+	PageNum     int
+	PageSize    int
+}
+
+// ProductSearchDao 定义商品搜索的数据访问对象，基于商品表的 FULLTEXT 索引
+type ProductSearchDao struct {
+	db *gorm.DB
+}
+
+// NewProductSearchDao 根据传入的数据库连接创建新的 ProductSearchDao 实例
+func NewProductSearchDao(db *gorm.DB) *ProductSearchDao {
+	return &ProductSearchDao{
+		db: db,
+	}
+}
+
+// Search 按条件分页搜索商品，关键词使用自然语言模式的全文检索
+func (dao *ProductSearchDao) Search(ctx context.Context, filter *ProductSearchFilter) ([]model.Product, int64, error) {
+	query := dao.db.WithContext(ctx).Model(&model.Product{})
+	if filter.Keyword != "" {
+		query = query.Where("MATCH(name, description) AGAINST (? IN NATURAL LANGUAGE MODE)", filter.Keyword)
+	}
+	if filter.MinPrice > 0 {
+		query = query.Where("price >= ?", filter.MinPrice)
+	}
+	if filter.MaxPrice > 0 {
+		query = query.Where("price <= ?", filter.MaxPrice)
+	}
+	if len(filter.CategoryIDs) > 0 {
+		productIDs := dao.db.Model(&model.ProductCategory{}).Select("product_id").Where("category_id IN ?", filter.CategoryIDs)
+		query = query.Where("id IN (?)", productIDs)
+	}
+	if filter.InStock {
+		query = query.Where("stock > 0")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var products []model.Product
+	err := query.Clauses(clause.OrderBy{Expression: productSearchOrder(filter)}).
+		Offset((filter.PageNum - 1) * filter.PageSize).Limit(filter.PageSize).
+		Find(&products).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return products, total, nil
+}
+
+// GetProducts 按ID批量查询商品，用于同步搜索索引；已删除的商品不在结果中
+func (dao *ProductSearchDao) GetProducts(ctx context.Context, productIDs []uint) ([]model.Product, error) {
+	var products []model.Product
+	if len(productIDs) == 0 {
+		return products, nil
+	}
+	if err := dao.db.WithContext(ctx).Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// productSearchOrder 返回搜索结果的排序规则，相同时按商品ID倒序保证分页稳定；未指定排序时有关键词按相关度，否则按上架时间倒序
+func productSearchOrder(filter *ProductSearchFilter) clause.Expression {
+	switch filter.Sort {
+	case consts.ProductSortPriceAsc:
+		return clause.Expr{SQL: "price ASC, id DESC"}
+	case consts.ProductSortPriceDesc:
+		return clause.Expr{SQL: "price DESC, id DESC"}
+	case consts.ProductSortSales:
+		return clause.Expr{SQL: "sales DESC, id DESC"}
+	case consts.ProductSortNewest:
+		return clause.Expr{SQL: "created_at DESC, id DESC"}
+	}
+	if filter.Keyword != "" {
+		return clause.Expr{
+			SQL:  "MATCH(name, description) AGAINST (? IN NATURAL LANGUAGE MODE) DESC, id DESC",
+			Vars: []interface{}{filter.Keyword},
+		}
+	}
+	return clause.Expr{SQL: "created_at DESC, id DESC"}
+}
@@ -0,0 +1,243 @@
+package dao
+
+import (
+	"context"
+	"douyin/consts"
+	"douyin/pkg/utils/money"
+	"douyin/repository/db/model"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strconv"
+)
+
+var (
+	// ErrSkuNotFound SKU 不存在、不属于指定商品或商品已删除
+	ErrSkuNotFound = errors.New("商品规格不存在")
+	// ErrSkuRequired 商品有多个 SKU 时必须指定 SKU
+	ErrSkuRequired = errors.New("请选择商品规格")
+)
+
+// ProductSkuDao 定义商品规格与 SKU 数据访问对象
+type ProductSkuDao struct {
+	db *gorm.DB
+}
+
+// NewProductSkuDao 根据传入的数据库连接创建新的 ProductSkuDao 实例
+func NewProductSkuDao(db *gorm.DB) *ProductSkuDao {
+	return &ProductSkuDao{
+		db: db,
+	}
+}
+
+// ListSpecs 按展示顺序查询商品的规格
+func (dao *ProductSkuDao) ListSpecs(ctx context.Context, productID uint) ([]model.ProductSpec, error) {
+	var specs []model.ProductSpec
+	if err := dao.db.WithContext(ctx).Where("product_id = ?", productID).
+		Order("sort ASC, id ASC").Find(&specs).Error; err != nil {
+		return nil, err
+	}
+	return specs, nil
+}
+
+// ListSkus 查询商品的全部 SKU
+func (dao *ProductSkuDao) ListSkus(ctx context.Context, productID uint) ([]model.ProductSku, error) {
+	var skus []model.ProductSku
+	if err := dao.db.WithContext(ctx).Where("product_id = ?", productID).Order("id ASC").Find(&skus).Error; err != nil {
+		return nil, err
+	}
+	return skus, nil
+}
+
+// ListSkusByIDs 查询 SKU 及其所属商品，SKU 或商品已删除的不会出现在结果中
+func (dao *ProductSkuDao) ListSkusByIDs(ctx context.Context, skuIDs []uint) ([]model.ProductSku, error) {
+	var skus []model.ProductSku
+	if len(skuIDs) == 0 {
+		return skus, nil
+	}
+	if err := dao.db.WithContext(ctx).Preload("Product").Where("id IN ?", skuIDs).Find(&skus).Error; err != nil {
+		return nil, err
+	}
+	result := skus[:0]
+	for _, sku := range skus {
+		if sku.Product.ID != 0 {
+			result = append(result, sku)
+		}
+	}
+	return result, nil
+}
+
+// ResolveSku 确定下单或加购的 SKU 并加载所属商品：skuID 不为 0 时按ID查询（productID 不为 0 时还需属于该商品），
+// 否则使用商品唯一的 SKU；商品有多个 SKU 时返回 ErrSkuRequired，找不到时返回 ErrSkuNotFound
+// lock 为 true 时锁定 SKU 行，需在事务中调用，同一 SKU 的库存校验与扣减由此串行执行
+func (dao *ProductSkuDao) ResolveSku(ctx context.Context, productID, skuID uint, lock bool) (*model.ProductSku, error) {
+	query := dao.db.WithContext(ctx)
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	switch {
+	case skuID != 0:
+		query = query.Where("id = ?", skuID)
+		if productID != 0 {
+			query = query.Where("product_id = ?", productID)
+		}
+	case productID != 0:
+		query = query.Where("product_id = ?", productID).Limit(2)
+	default:
+		return nil, ErrSkuNotFound
+	}
+
+	var skus []model.ProductSku
+	if err := query.Order("id ASC").Find(&skus).Error; err != nil {
+		return nil, err
+	}
+	switch len(skus) {
+	case 0:
+		return nil, ErrSkuNotFound
+	case 1:
+	default:
+		return nil, ErrSkuRequired
+	}
+
+	sku := &skus[0]
+	if err := dao.db.WithContext(ctx).Where("id = ?", sku.ProductID).First(&sku.Product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSkuNotFound
+		}
+		return nil, err
+	}
+	return sku, nil
+}
+
+// ReplaceSkus 整体替换商品的规格与 SKU，并同步商品的最低价与总库存；SKU 库存为目标可售数量，差额通过库存台账调整
+// 按 SpecKey 匹配已有 SKU 并保留其ID，购物车、预占与订单中的引用不受影响；不再出现的 SKU 被删除，引用它的购物车行在展示时标记为无效
+func (dao *ProductSkuDao) ReplaceSkus(ctx context.Context, productID uint, specs []model.ProductSpec, skus []model.ProductSku) error {
+	if len(skus) == 0 {
+		return errors.New("商品至少需要一个 SKU")
+	}
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", productID).Delete(&model.ProductSpec{}).Error; err != nil {
+			return err
+		}
+		if len(specs) > 0 {
+			for i := range specs {
+				specs[i].ID = 0
+				specs[i].ProductID = productID
+			}
+			if err := tx.Create(&specs).Error; err != nil {
+				return err
+			}
+		}
+
+		var existing []model.ProductSku
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("product_id = ?", productID).Find(&existing).Error; err != nil {
+			return err
+		}
+		byKey := make(map[string]model.ProductSku, len(existing))
+		for _, sku := range existing {
+			byKey[sku.SpecKey] = sku
+		}
+
+		// 库存经由库存台账修改：新 SKU 以 0 库存创建后入库，已有 SKU 按差额调整
+		inventory := NewInventoryDao(tx)
+		ref := InventoryMoveRef{
+			RefType: consts.InventoryRefProduct,
+			RefID:   strconv.FormatUint(uint64(productID), 10),
+			Actor:   consts.OrderActorAdmin,
+			Remark:  "修改商品库存",
+		}
+		kept := make(map[uint]bool, len(skus))
+		for i := range skus {
+			skus[i].ProductID = productID
+			target := skus[i].Stock
+			old, ok := byKey[skus[i].SpecKey]
+			if !ok {
+				skus[i].ID = 0
+				skus[i].Version = 1
+				skus[i].Stock = 0
+				if err := tx.Create(&skus[i]).Error; err != nil {
+					return err
+				}
+				if err := inventory.SetSkuStock(ctx, &skus[i], target, ref); err != nil {
+					return err
+				}
+				skus[i].Stock = target
+				continue
+			}
+			skus[i].ID = old.ID
+			skus[i].Version = old.Version + 1
+			kept[old.ID] = true
+			if err := tx.Model(&model.ProductSku{}).Where("id = ?", old.ID).
+				Select("specs", "price", "picture", "version").
+				Updates(&skus[i]).Error; err != nil {
+				return err
+			}
+			if err := inventory.SetSkuStock(ctx, &old, target, ref); err != nil {
+				return err
+			}
+		}
+
+		var removed []uint
+		for _, sku := range existing {
+			if !kept[sku.ID] {
+				removed = append(removed, sku.ID)
+			}
+		}
+		if len(removed) > 0 {
+			if err := tx.Where("id IN ?", removed).Delete(&model.ProductSku{}).Error; err != nil {
+				return err
+			}
+		}
+		return syncProductSkuSummary(tx, productID)
+	})
+}
+
+// EnsureDefaultSkus 为还没有 SKU 的商品按其价格和库存创建默认 SKU，并把引入 SKU 之前的购物车、预占、订单项、
+// 售后项与秒杀场次指向该默认 SKU；可重复执行，返回新建的默认 SKU 数量
+func (dao *ProductSkuDao) EnsureDefaultSkus(ctx context.Context) (int, error) {
+	var products []model.Product
+	if err := dao.db.WithContext(ctx).
+		Where("id NOT IN (?)", dao.db.Model(&model.ProductSku{}).Select("product_id")).
+		Find(&products).Error; err != nil {
+		return 0, err
+	}
+	for _, product := range products {
+		sku := model.ProductSku{
+			ProductID: product.ID,
+			Price:     product.Price,
+			Stock:     product.Stock,
+			Version:   1,
+		}
+		if err := dao.db.WithContext(ctx).Create(&sku).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	defaultSku := dao.db.Model(&model.ProductSku{}).Select("MIN(id)").Where("product_skus.product_id = t.product_id")
+	for _, table := range []string{"cart_items", "stock_reservations", "order_items", "refund_items", "flash_sale_sessions"} {
+		if err := dao.db.WithContext(ctx).Table(table+" AS t").Where("t.sku_id = 0").
+			Update("sku_id", gorm.Expr("(?)", defaultSku)).Error; err != nil {
+			return 0, err
+		}
+	}
+	return len(products), nil
+}
+
+// syncProductSkuSummary 按 SKU 重新计算商品的最低价与总库存
+func syncProductSkuSummary(tx *gorm.DB, productID uint) error {
+	var summary struct {
+		MinPrice   money.Amount
+		TotalStock int
+	}
+	if err := tx.Model(&model.ProductSku{}).
+		Select("COALESCE(MIN(price), 0) AS min_price, COALESCE(SUM(stock), 0) AS total_stock").
+		Where("product_id = ?", productID).
+		Scan(&summary).Error; err != nil {
+		return err
+	}
+	return tx.Model(&model.Product{}).Where("id = ?", productID).Updates(map[string]interface{}{
+		"price":   summary.MinPrice,
+		"stock":   summary.TotalStock,
+		"version": gorm.Expr("version + 1"),
+	}).Error
+}
@@ -0,0 +1,157 @@
+package dao
+
+import (
+	"context"
+	"douyin/consts"
+	"douyin/pkg/utils/money"
+	"douyin/repository/db/model"
+	"douyin/types"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrRefundStatusConflict 售后单状态在读取后已被其他请求修改
+var ErrRefundStatusConflict = errors.New("售后单状态已变更，请刷新后重试")
+
+// RefundDao 定义售后单数据访问对象
+type RefundDao struct {
+	db *gorm.DB
+}
+
+// NewRefundDao 根据传入的数据库连接创建新的 RefundDao 实例
+func NewRefundDao(db *gorm.DB) *RefundDao {
+	return &RefundDao{
+		db: db,
+	}
+}
+
+// CreateRefund 创建售后单及其退款项
+func (dao *RefundDao) CreateRefund(ctx context.Context, refund *model.Refund) error {
+	return dao.db.WithContext(ctx).Create(refund).Error
+}
+
+// GetRefundByNo 根据售后单号查询售后单（含退款项）
+func (dao *RefundDao) GetRefundByNo(ctx context.Context, refundNo string) (*model.Refund, error) {
+	var refund model.Refund
+	if err := dao.db.WithContext(ctx).Preload("Items").Where("refund_no = ?", refundNo).First(&refund).Error; err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// GetRefundByNoForUpdate 加行锁查询售后单，需在事务中调用
+func (dao *RefundDao) GetRefundByNoForUpdate(ctx context.Context, refundNo string) (*model.Refund, error) {
+	var refund model.Refund
+	if err := dao.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("refund_no = ?", refundNo).
+		First(&refund).Error; err != nil {
+		return nil, err
+	}
+	if err := dao.db.WithContext(ctx).Where("refund_id = ?", refund.ID).Find(&refund.Items).Error; err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// ListRefunds 分页查询售后单，userID 为 0 时查询全部用户（管理端）
+func (dao *RefundDao) ListRefunds(ctx context.Context, userID uint, req *types.RefundListReq) ([]model.Refund, int64, error) {
+	query := dao.db.WithContext(ctx).Model(&model.Refund{})
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.OrderID != "" {
+		query = query.Where("order_id = ?", req.OrderID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var refunds []model.Refund
+	if err := query.Preload("Items").Order("created_at DESC").
+		Offset((req.PageNum - 1) * req.PageSize).Limit(req.PageSize).
+		Find(&refunds).Error; err != nil {
+		return nil, 0, err
+	}
+	return refunds, total, nil
+}
+
+// SumActiveRefundQuantity 统计订单下各订单项在待审核、退款处理中或已退款售后单中的数量，key 为订单项ID
+func (dao *RefundDao) SumActiveRefundQuantity(ctx context.Context, orderID string) (map[uint]int32, error) {
+	var rows []struct {
+		OrderItemID uint
+		Quantity    int32
+	}
+	err := dao.db.WithContext(ctx).Model(&model.RefundItem{}).
+		Select("refund_items.order_item_id, SUM(refund_items.quantity) AS quantity").
+		Joins("JOIN refunds ON refunds.id = refund_items.refund_id").
+		Where("refunds.order_id = ? AND refunds.status IN ?", orderID, []string{consts.RefundStatusPending, consts.RefundStatusProcessing, consts.RefundStatusRefunded}).
+		Group("refund_items.order_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint]int32, len(rows))
+	for _, row := range rows {
+		result[row.OrderItemID] = row.Quantity
+	}
+	return result, nil
+}
+
+// ListProcessingRefunds 查询最后更新早于 before、仍在等待渠道退款结果的售后单，按更新时间升序
+func (dao *RefundDao) ListProcessingRefunds(ctx context.Context, before time.Time, limit int) ([]model.Refund, error) {
+	var refunds []model.Refund
+	err := dao.db.WithContext(ctx).
+		Where("status = ? AND updated_at < ?", consts.RefundStatusProcessing, before).
+		Order("updated_at ASC").
+		Limit(limit).
+		Find(&refunds).Error
+	if err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+// SumRefundedAmount 统计子订单已退款的售后单金额合计
+func (dao *RefundDao) SumRefundedAmount(ctx context.Context, subOrderID string) (money.Amount, error) {
+	var amount money.Amount
+	err := dao.db.WithContext(ctx).Model(&model.Refund{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("sub_order_id = ? AND status = ?", subOrderID, consts.RefundStatusRefunded).
+		Scan(&amount).Error
+	if err != nil {
+		return 0, err
+	}
+	return amount, nil
+}
+
+// UpdateRefundStatus 以 from 状态为条件更新售后单，状态已变化时返回 ErrRefundStatusConflict
+func (dao *RefundDao) UpdateRefundStatus(ctx context.Context, refundID uint, from, to string, updates map[string]interface{}) error {
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+	updates["status"] = to
+	result := dao.db.WithContext(ctx).Model(&model.Refund{}).
+		Where("id = ? AND status = ?", refundID, from).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRefundStatusConflict
+	}
+	return nil
+}
+
+// RestockRefundItems 退货商品重新入库，写入退货入库流水
+func (dao *RefundDao) RestockRefundItems(ctx context.Context, refund *model.Refund, reviewerID uint) error {
+	return NewInventoryDao(dao.db).ReturnRefundItems(ctx, refund, reviewerID)
+}
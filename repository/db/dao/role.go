@@ -0,0 +1,35 @@
+package dao
+
+import (
+	"context"
+	"douyin/repository/db/model"
+	"gorm.io/gorm"
+)
+
+// RoleDao 角色与权限数据访问对象
+type RoleDao struct {
+	db *gorm.DB
+}
+
+// NewRoleDao 根据传入的数据库连接创建新的 RoleDao 实例
+func NewRoleDao(db *gorm.DB) *RoleDao {
+	return &RoleDao{
+		db: db,
+	}
+}
+
+// HasPermission 判断用户的任一角色是否拥有指定权限，权限名称不区分大小写，与 middleware.RBAC 的判定一致
+func (dao *RoleDao) HasPermission(ctx context.Context, userID uint, permission string) (bool, error) {
+	var count int64
+	err := dao.db.WithContext(ctx).Model(&model.UserRole{}).
+		Joins("JOIN role_permissions ON role_permissions.role_id = user_roles.role_id").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+		Where("user_roles.user_id = ? AND LOWER(permissions.name) = LOWER(?)", userID, permission).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// UserHasPermission 使用全局数据库连接判断用户是否拥有指定权限
+func UserHasPermission(ctx context.Context, userID uint, permission string) (bool, error) {
+	return NewRoleDao(db).HasPermission(ctx, userID, permission)
+}
@@ -0,0 +1,89 @@
+package dao
+
+import (
+	"context"
+	"douyin/consts"
+	"douyin/repository/db/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ShipmentDao 定义发货单数据访问对象
+type ShipmentDao struct {
+	db *gorm.DB
+}
+
+// NewShipmentDao 根据传入的数据库连接创建新的 ShipmentDao 实例
+func NewShipmentDao(db *gorm.DB) *ShipmentDao {
+	return &ShipmentDao{
+		db: db,
+	}
+}
+
+// CreateShipment 在一个事务内将子订单从待发货流转为已发货并创建发货单（含发货项）
+// 子订单状态已被并发修改时返回 ErrOrderStatusConflict，发货单不会创建
+func (dao *ShipmentDao) CreateShipment(ctx context.Context, shipment *model.Shipment, subOrder *model.SubOrder, actor string, actorID uint, reason string) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := NewSubOrderDao(tx).UpdateSubOrderStatus(ctx, subOrder, consts.OrderTypePendingShipping, consts.OrderTypeShipping, actor, actorID, reason); err != nil {
+			return err
+		}
+		return tx.Create(shipment).Error
+	})
+}
+
+// ConfirmReceipt 在一个事务内将子订单从已发货流转为已收货并记录发货单的确认收货时间
+// 在引入发货单之前发货的子订单没有发货单，此时只流转子订单状态
+func (dao *ShipmentDao) ConfirmReceipt(ctx context.Context, subOrder *model.SubOrder, actor string, actorID uint, reason string, receivedAt time.Time) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := NewSubOrderDao(tx).UpdateSubOrderStatus(ctx, subOrder, consts.OrderTypeShipping, consts.OrderTypeReceipt, actor, actorID, reason); err != nil {
+			return err
+		}
+		return tx.Model(&model.Shipment{}).
+			Where("sub_order_id = ? AND received_at IS NULL", subOrder.SubOrderID).
+			Update("received_at", receivedAt).Error
+	})
+}
+
+// ListOrderShipments 查询父订单的全部发货单（含发货项），按发货时间排序
+func (dao *ShipmentDao) ListOrderShipments(ctx context.Context, orderID string) ([]model.Shipment, error) {
+	var shipments []model.Shipment
+	if err := dao.db.WithContext(ctx).Preload("Items").
+		Where("order_id = ?", orderID).Order("shipped_at ASC, id ASC").
+		Find(&shipments).Error; err != nil {
+		return nil, err
+	}
+	return shipments, nil
+}
+
+// ListTrackingShipments 查询需要刷新物流轨迹的发货单：子订单仍待收货且快递尚未签收，最久未查询的优先
+func (dao *ShipmentDao) ListTrackingShipments(ctx context.Context, limit int) ([]model.Shipment, error) {
+	var shipments []model.Shipment
+	if err := dao.db.WithContext(ctx).
+		Joins("JOIN sub_orders ON sub_orders.sub_order_id = shipments.sub_order_id").
+		Where("sub_orders.status = ? AND shipments.status <> ?", consts.OrderTypeShipping, consts.ShipmentStatusDelivered).
+		Order("shipments.last_tracked_at ASC, shipments.id ASC").
+		Limit(limit).Find(&shipments).Error; err != nil {
+		return nil, err
+	}
+	return shipments, nil
+}
+
+// UpdateTracking 保存发货单的物流状态、轨迹、签收时间与查询时间
+func (dao *ShipmentDao) UpdateTracking(ctx context.Context, shipment *model.Shipment) error {
+	return dao.db.WithContext(ctx).Model(shipment).
+		Select("status", "events", "delivered_at", "last_tracked_at").
+		Updates(shipment).Error
+}
+
+// ListAutoConfirmDue 查询已到自动确认收货时间、子订单仍待收货的发货单
+func (dao *ShipmentDao) ListAutoConfirmDue(ctx context.Context, now time.Time, limit int) ([]model.Shipment, error) {
+	var shipments []model.Shipment
+	if err := dao.db.WithContext(ctx).
+		Joins("JOIN sub_orders ON sub_orders.sub_order_id = shipments.sub_order_id").
+		Where("sub_orders.status = ? AND shipments.received_at IS NULL AND shipments.auto_confirm_at <= ?", consts.OrderTypeShipping, now).
+		Order("shipments.auto_confirm_at ASC").
+		Limit(limit).Find(&shipments).Error; err != nil {
+		return nil, err
+	}
+	return shipments, nil
+}
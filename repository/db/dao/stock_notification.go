@@ -0,0 +1,141 @@
+package dao
+
+import (
+	"context"
+	"douyin/consts"
+	"douyin/repository/db/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// StockNotificationDao 库存事件与到货提醒订阅数据访问对象
+type StockNotificationDao struct {
+	db *gorm.DB
+}
+
+// NewStockNotificationDao 根据传入的数据库连接创建新的 StockNotificationDao 实例
+func NewStockNotificationDao(db *gorm.DB) *StockNotificationDao {
+	return &StockNotificationDao{
+		db: db,
+	}
+}
+
+// ListPendingEvents 按写入顺序查询未处理的库存事件
+func (dao *StockNotificationDao) ListPendingEvents(ctx context.Context, limit int) ([]model.StockEvent, error) {
+	var events []model.StockEvent
+	err := dao.db.WithContext(ctx).
+		Where("processed_at IS NULL").
+		Order("id ASC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+// ClaimEvent 将库存事件标记为已处理，事件已被其他实例处理时返回 false
+func (dao *StockNotificationDao) ClaimEvent(ctx context.Context, eventID uint) (bool, error) {
+	result := dao.db.WithContext(ctx).Model(&model.StockEvent{}).
+		Where("id = ? AND processed_at IS NULL", eventID).
+		Update("processed_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Subscribe 写入或恢复用户对某 SKU 的到货提醒，已提醒过的订阅会重新进入等待状态
+func (dao *StockNotificationDao) Subscribe(ctx context.Context, subscription *model.RestockSubscription) error {
+	subscription.NotifiedAt = nil
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "sku_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"product_id", "email", "notified_at", "updated_at"}),
+	}).Create(subscription).Error
+}
+
+// Unsubscribe 取消用户对某 SKU 的到货提醒，返回是否存在该订阅
+func (dao *StockNotificationDao) Unsubscribe(ctx context.Context, userID, skuID uint) (bool, error) {
+	result := dao.db.WithContext(ctx).
+		Where("user_id = ? AND sku_id = ?", userID, skuID).
+		Delete(&model.RestockSubscription{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListUserSubscriptions 按订阅时间倒序分页查询用户的到货提醒
+func (dao *StockNotificationDao) ListUserSubscriptions(ctx context.Context, userID uint, pageNum, pageSize int) ([]model.RestockSubscription, int64, error) {
+	var subscriptions []model.RestockSubscription
+	var total int64
+	query := dao.db.WithContext(ctx).Model(&model.RestockSubscription{}).Where("user_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("id DESC").Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&subscriptions).Error; err != nil {
+		return nil, 0, err
+	}
+	return subscriptions, total, nil
+}
+
+// ListPendingSubscriptions 查询某 SKU 尚未发送到货提醒的订阅
+func (dao *StockNotificationDao) ListPendingSubscriptions(ctx context.Context, skuID uint) ([]model.RestockSubscription, error) {
+	var subscriptions []model.RestockSubscription
+	err := dao.db.WithContext(ctx).
+		Where("sku_id = ? AND notified_at IS NULL", skuID).
+		Order("id ASC").
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// MarkNotified 记录订阅的到货提醒已发送
+func (dao *StockNotificationDao) MarkNotified(ctx context.Context, subscriptionID uint) error {
+	return dao.db.WithContext(ctx).Model(&model.RestockSubscription{}).
+		Where("id = ?", subscriptionID).
+		Update("notified_at", time.Now()).Error
+}
+
+// recordStockEvents 在 SKU 可售库存变动 delta 之后检查 SKU 与商品库存是否跨越提醒条件，并在同一事务中写入库存事件
+// 商品总库存降至低库存阈值及以下时写入低库存事件；SKU 库存从 0 恢复且有等待提醒的订阅时写入到货事件
+func recordStockEvents(tx *gorm.DB, skuID, productID uint, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+	var product model.Product
+	if err := tx.Select("id, stock, low_stock_threshold").Where("id = ?", productID).First(&product).Error; err != nil {
+		return err
+	}
+	if model.CrossedLowStock(product.Stock-delta, product.Stock, product.LowStockThreshold) {
+		if err := tx.Create(&model.StockEvent{
+			Type:      consts.StockEventLowStock,
+			ProductID: productID,
+			SkuID:     skuID,
+			Stock:     product.Stock,
+			Threshold: product.LowStockThreshold,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	var skuStock int
+	if err := tx.Model(&model.ProductSku{}).Select("stock").Where("id = ?", skuID).Scan(&skuStock).Error; err != nil {
+		return err
+	}
+	if !model.CrossedBackInStock(skuStock-delta, skuStock) {
+		return nil
+	}
+	var waiting int64
+	if err := tx.Model(&model.RestockSubscription{}).
+		Where("sku_id = ? AND notified_at IS NULL", skuID).
+		Count(&waiting).Error; err != nil {
+		return err
+	}
+	if waiting == 0 {
+		return nil
+	}
+	return tx.Create(&model.StockEvent{
+		Type:      consts.StockEventBackInStock,
+		ProductID: productID,
+		SkuID:     skuID,
+		Stock:     skuStock,
+	}).Error
+}
@@ -0,0 +1,84 @@
+package dao
+
+import (
+	"context"
+	"douyin/repository/db/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// StockReservationDao 定义购物车库存预占数据访问对象
+type StockReservationDao struct {
+	db *gorm.DB
+}
+
+// NewStockReservationDao 根据传入的数据库连接创建新的 StockReservationDao 实例
+func NewStockReservationDao(db *gorm.DB) *StockReservationDao {
+	return &StockReservationDao{
+		db: db,
+	}
+}
+
+// Reserve 写入或刷新用户对某 SKU 的预占，数量覆盖为 quantity，过期时间顺延到 expiresAt
+// 调用方需先锁定 SKU 行并校验可售库存
+func (dao *StockReservationDao) Reserve(ctx context.Context, userID uint, sku *model.ProductSku, quantity int, expiresAt time.Time) error {
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "sku_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"quantity", "expires_at", "updated_at"}),
+	}).Create(&model.StockReservation{
+		UserID:    userID,
+		SkuID:     sku.ID,
+		ProductID: sku.ProductID,
+		Quantity:  quantity,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+// SumReservedByOthers 统计其他用户对某 SKU 未过期的预占数量
+func (dao *StockReservationDao) SumReservedByOthers(ctx context.Context, skuID, userID uint) (int, error) {
+	var reserved int
+	err := dao.db.WithContext(ctx).Model(&model.StockReservation{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("sku_id = ? AND user_id <> ? AND expires_at > ?", skuID, userID, time.Now()).
+		Scan(&reserved).Error
+	return reserved, err
+}
+
+// SumReservedByOthersBatch 批量统计其他用户对多个 SKU 未过期的预占数量，key 为 SKU ID
+func (dao *StockReservationDao) SumReservedByOthersBatch(ctx context.Context, skuIDs []uint, userID uint) (map[uint]int, error) {
+	reserved := make(map[uint]int, len(skuIDs))
+	if len(skuIDs) == 0 {
+		return reserved, nil
+	}
+	var rows []struct {
+		SkuID    uint
+		Reserved int
+	}
+	if err := dao.db.WithContext(ctx).Model(&model.StockReservation{}).
+		Select("sku_id, COALESCE(SUM(quantity), 0) AS reserved").
+		Where("sku_id IN ? AND user_id <> ? AND expires_at > ?", skuIDs, userID, time.Now()).
+		Group("sku_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		reserved[row.SkuID] = row.Reserved
+	}
+	return reserved, nil
+}
+
+// Release 释放用户的预占，skuIDs 为空时释放该用户全部预占
+func (dao *StockReservationDao) Release(ctx context.Context, userID uint, skuIDs []uint) error {
+	query := dao.db.WithContext(ctx).Where("user_id = ?", userID)
+	if len(skuIDs) > 0 {
+		query = query.Where("sku_id IN ?", skuIDs)
+	}
+	return query.Delete(&model.StockReservation{}).Error
+}
+
+// DeleteExpired 清理已过期的预占记录，返回清理条数
+func (dao *StockReservationDao) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := dao.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&model.StockReservation{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,145 @@
+package dao
+
+import (
+	"context"
+	"douyin/repository/db/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SubOrderDao 定义子订单数据访问对象
+type SubOrderDao struct {
+	db *gorm.DB
+}
+
+// NewSubOrderDao 根据传入的数据库连接创建新的 SubOrderDao 实例
+func NewSubOrderDao(db *gorm.DB) *SubOrderDao {
+	return &SubOrderDao{
+		db: db,
+	}
+}
+
+// ListSubOrders 查询父订单的全部子订单（含订单项），按子订单ID排序
+func (dao *SubOrderDao) ListSubOrders(ctx context.Context, orderID string) ([]model.SubOrder, error) {
+	var subOrders []model.SubOrder
+	if err := dao.db.WithContext(ctx).Preload("OrderItems").
+		Where("order_id = ?", orderID).Order("sub_order_id ASC").
+		Find(&subOrders).Error; err != nil {
+		return nil, err
+	}
+	return subOrders, nil
+}
+
+// GetSubOrder 根据子订单ID查询子订单（含订单项）
+func (dao *SubOrderDao) GetSubOrder(ctx context.Context, subOrderID string) (*model.SubOrder, error) {
+	var subOrder model.SubOrder
+	if err := dao.db.WithContext(ctx).Preload("OrderItems").
+		Where("sub_order_id = ?", subOrderID).First(&subOrder).Error; err != nil {
+		return nil, err
+	}
+	return &subOrder, nil
+}
+
+// UpdateSubOrderStatus 将子订单从 from 状态流转到 to 状态并写入状态流转记录，随后按全部子订单的状态汇总更新父订单
+// 以 from 作为更新条件，若子订单状态已被并发修改则返回 ErrOrderStatusConflict；合法性校验由 service 层负责
+func (dao *SubOrderDao) UpdateSubOrderStatus(ctx context.Context, subOrder *model.SubOrder, from, to int, actor string, actorID uint, reason string) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 先锁父订单，同一订单下子订单的流转与父订单状态汇总串行执行
+		var order model.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_id = ?", subOrder.OrderID).First(&order).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&model.SubOrder{}).
+			Where("sub_order_id = ? AND status = ?", subOrder.SubOrderID, from).
+			Update("status", to)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrOrderStatusConflict
+		}
+		if err := tx.Create(&model.OrderStatusHistory{
+			OrderID:    subOrder.OrderID,
+			SubOrderID: subOrder.SubOrderID,
+			FromStatus: from,
+			ToStatus:   to,
+			Actor:      actor,
+			ActorID:    actorID,
+			Reason:     reason,
+		}).Error; err != nil {
+			return err
+		}
+		return syncParentOrderStatus(ctx, tx, &order, actor, actorID)
+	})
+}
+
+// syncParentOrderStatus 按子订单状态汇总父订单状态（model.ParentOrderStatus），状态有变化时更新并写入流转记录
+// 父订单需已在当前事务中加锁
+func syncParentOrderStatus(ctx context.Context, tx *gorm.DB, order *model.Order, actor string, actorID uint) error {
+	var subOrders []model.SubOrder
+	if err := tx.WithContext(ctx).Select("sub_order_id", "status").
+		Where("order_id = ?", order.OrderID).Find(&subOrders).Error; err != nil {
+		return err
+	}
+	status := model.ParentOrderStatus(subOrders)
+	if status == 0 || status == order.Status {
+		return nil
+	}
+	if err := tx.WithContext(ctx).Model(&model.Order{}).
+		Where("order_id = ?", order.OrderID).
+		Update("status", status).Error; err != nil {
+		return err
+	}
+	if err := tx.WithContext(ctx).Create(&model.OrderStatusHistory{
+		OrderID:    order.OrderID,
+		FromStatus: order.Status,
+		ToStatus:   status,
+		Actor:      actor,
+		ActorID:    actorID,
+		Reason:     "子订单状态汇总",
+	}).Error; err != nil {
+		return err
+	}
+	order.Status = status
+	return nil
+}
+
+// EnsureSubOrders 为引入子订单之前创建的订单按商家补建子订单，子订单状态取父订单当前状态，并回填订单项与售后单的子订单ID
+// 可重复执行，返回补建子订单的订单数量
+func (dao *SubOrderDao) EnsureSubOrders(ctx context.Context) (int, error) {
+	var orders []model.Order
+	if err := dao.db.WithContext(ctx).Preload("OrderItems").
+		Where("order_id NOT IN (?)", dao.db.Model(&model.SubOrder{}).Select("order_id")).
+		Find(&orders).Error; err != nil {
+		return 0, err
+	}
+	created := 0
+	for i := range orders {
+		order := &orders[i]
+		if len(order.OrderItems) == 0 {
+			continue
+		}
+		err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			subOrders := model.SplitSubOrders(order, order.OrderItems)
+			if err := tx.Omit("OrderItems", "Order").Create(&subOrders).Error; err != nil {
+				return err
+			}
+			for _, item := range order.OrderItems {
+				if err := tx.Model(&model.OrderItem{}).Where("id = ?", item.ID).
+					Update("sub_order_id", item.SubOrderID).Error; err != nil {
+					return err
+				}
+			}
+			// 已有的售后单归属到其退款项所在的子订单
+			return tx.Model(&model.Refund{}).Where("order_id = ? AND sub_order_id = ?", order.OrderID, "").
+				Update("sub_order_id", gorm.Expr("(SELECT oi.sub_order_id FROM refund_items ri JOIN order_items oi ON oi.id = ri.order_item_id WHERE ri.refund_id = refunds.id LIMIT 1)")).Error
+		})
+		if err != nil {
+			return 0, err
+		}
+		created++
+	}
+	return created, nil
+}
@@ -102,3 +102,13 @@ func (dao *UserDao) GetUserByEmail(email string) (*model.User, error) {
 	}
 	return &user, nil
 }
+
+// GetExchangeRate 查询币种汇率，用于校验用户设置的偏好币种
+func (dao *UserDao) GetExchangeRate(currency string) (*model.ExchangeRate, error) {
+	return NewExchangeRateDao(dao.db).GetRate(dao.ctx, currency)
+}
+
+// GetWalletBalance 查询用户钱包余额（分）
+func (dao *UserDao) GetWalletBalance(userID uint) (int64, error) {
+	return NewWalletDao(dao.db).GetBalance(dao.ctx, userID)
+}
@@ -0,0 +1,231 @@
+package dao
+
+import (
+	"context"
+	"douyin/config"
+	"douyin/consts"
+	"douyin/pkg/utils/encryption"
+	"douyin/pkg/utils/money"
+	"douyin/repository/db/model"
+	"douyin/types"
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrInsufficientBalance 账户余额不足
+	ErrInsufficientBalance = errors.New("账户余额不足")
+	// ErrWalletDuplicateRef 同一用户同一类型的流水已使用过该业务单号
+	ErrWalletDuplicateRef = errors.New("该业务单号已入账，请勿重复提交")
+)
+
+// WalletPosting 一笔钱包记账：用户钱包变动 Amount，对方系统账户变动 -Amount
+type WalletPosting struct {
+	UserID         uint   // 用户ID
+	Type           string // 流水类型，取值见 consts.WalletTxn*
+	Amount         int64  // 用户钱包变动金额（分），正数入账、负数出账
+	CounterAccount string // 对方账户，取值见 consts.WalletAccount*
+	RefID          string // 关联业务单号
+	Remark         string // 备注
+	UniqueRef      bool   // 为 true 时同一用户同一类型的 RefID 只能入账一次，重复时返回 ErrWalletDuplicateRef
+}
+
+// WalletDao 钱包及复式记账流水数据访问对象
+type WalletDao struct {
+	db *gorm.DB
+}
+
+// NewWalletDao 根据传入的数据库连接创建新的 WalletDao 实例
+func NewWalletDao(db *gorm.DB) *WalletDao {
+	return &WalletDao{
+		db: db,
+	}
+}
+
+// Post 加行锁修改用户钱包余额，并写入借贷两条流水；出账后余额不能小于 0
+// UniqueRef 的去重检查在钱包行锁内进行，同一用户的并发请求不会重复入账
+// 返回用户钱包一侧的流水记录
+func (dao *WalletDao) Post(ctx context.Context, posting *WalletPosting) (*model.WalletTransaction, error) {
+	if posting.Amount == 0 {
+		return nil, errors.New("记账金额不能为 0")
+	}
+	secret := config.GetMoneySecret()
+	var entry *model.WalletTransaction
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		wallet, balance, err := lockWallet(ctx, tx, posting.UserID)
+		if err != nil {
+			return err
+		}
+		if posting.UniqueRef {
+			var count int64
+			if err := tx.Model(&model.WalletTransaction{}).
+				Where("account = ? AND type = ? AND ref_id = ?", model.WalletUserAccount(posting.UserID), posting.Type, posting.RefID).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrWalletDuplicateRef
+			}
+		}
+		balance += posting.Amount
+		if balance < 0 {
+			return ErrInsufficientBalance
+		}
+		encrypted, err := encryption.EncryptMoney(secret, balance)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(wallet).Update("balance", encrypted).Error; err != nil {
+			return err
+		}
+
+		entry, err = writeWalletEntries(tx, posting, encrypted)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// GetBalance 查询用户钱包余额（分）
+func (dao *WalletDao) GetBalance(ctx context.Context, userID uint) (int64, error) {
+	var balance int64
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		_, balance, err = lockWallet(ctx, tx, userID)
+		return err
+	})
+	return balance, err
+}
+
+// ListTransactions 分页查询用户钱包流水，按时间倒序
+func (dao *WalletDao) ListTransactions(ctx context.Context, userID uint, req *types.WalletTransactionListReq) ([]model.WalletTransaction, int64, error) {
+	query := dao.db.WithContext(ctx).Model(&model.WalletTransaction{}).
+		Where("account = ?", model.WalletUserAccount(userID))
+	if req.Type != "" {
+		query = query.Where("type = ?", req.Type)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []model.WalletTransaction
+	if err := query.Order("id DESC").
+		Offset((req.PageNum - 1) * req.PageSize).Limit(req.PageSize).
+		Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// GetUserTransactionByRef 按流水类型与业务单号查询用户钱包一侧的流水，不存在时返回 gorm.ErrRecordNotFound
+func (dao *WalletDao) GetUserTransactionByRef(ctx context.Context, txnType, refID string) (*model.WalletTransaction, error) {
+	var entry model.WalletTransaction
+	if err := dao.db.WithContext(ctx).
+		Where("type = ? AND ref_id = ? AND account LIKE ?", txnType, refID, "user:%").
+		Order("id DESC").
+		First(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// DecryptWalletAmount 解密钱包余额或流水中的余额字段
+func DecryptWalletAmount(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return encryption.DecryptMoney(config.GetMoneySecret(), value)
+}
+
+// lockWallet 加行锁读取用户钱包并解密余额
+// 钱包不存在时按 User.Money 中的历史余额开户，并记一笔期初调整流水
+func lockWallet(ctx context.Context, tx *gorm.DB, userID uint) (*model.Wallet, int64, error) {
+	secret := config.GetMoneySecret()
+	var wallet model.Wallet
+	err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).First(&wallet).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := openWallet(ctx, tx, userID, secret); err != nil {
+			return nil, 0, err
+		}
+		err = tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", userID).First(&wallet).Error
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	balance, err := encryption.DecryptMoney(secret, wallet.Balance)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &wallet, balance, nil
+}
+
+// openWallet 为用户开户，期初余额取自 User.Money；并发开户时只有一个请求会写入
+func openWallet(ctx context.Context, tx *gorm.DB, userID uint, secret string) error {
+	var user model.User
+	if err := tx.WithContext(ctx).Where("id = ?", userID).First(&user).Error; err != nil {
+		return err
+	}
+	var opening int64
+	if user.Money != "" {
+		legacy, err := user.DecryptMoney(consts.MoneyDecryptKey)
+		if err != nil {
+			return err
+		}
+		opening = money.FromFloat(legacy).MinorUnits()
+	}
+	encrypted, err := encryption.EncryptMoney(secret, opening)
+	if err != nil {
+		return err
+	}
+	result := tx.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.Wallet{UserID: userID, Balance: encrypted})
+	if result.Error != nil || result.RowsAffected == 0 || opening == 0 {
+		return result.Error
+	}
+	_, err = writeWalletEntries(tx.WithContext(ctx), &WalletPosting{
+		UserID:         userID,
+		Type:           consts.WalletTxnAdjustment,
+		Amount:         opening,
+		CounterAccount: consts.WalletAccountOpening,
+		Remark:         "期初余额迁移",
+	}, encrypted)
+	return err
+}
+
+// writeWalletEntries 写入一笔记账的借贷两条流水
+func writeWalletEntries(tx *gorm.DB, posting *WalletPosting, balanceAfter string) (*model.WalletTransaction, error) {
+	txnNo := uuid.New().String()
+	entries := []model.WalletTransaction{
+		{
+			TxnNo:        txnNo,
+			Account:      model.WalletUserAccount(posting.UserID),
+			UserID:       posting.UserID,
+			Type:         posting.Type,
+			Amount:       posting.Amount,
+			BalanceAfter: balanceAfter,
+			RefID:        posting.RefID,
+			Remark:       posting.Remark,
+		},
+		{
+			TxnNo:   txnNo,
+			Account: posting.CounterAccount,
+			UserID:  posting.UserID,
+			Type:    posting.Type,
+			Amount:  -posting.Amount,
+			RefID:   posting.RefID,
+			Remark:  posting.Remark,
+		},
+	}
+	if err := tx.Create(&entries).Error; err != nil {
+		return nil, err
+	}
+	return &entries[0], nil
+}
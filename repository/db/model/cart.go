@@ -1,6 +1,7 @@
 package model
 
 import (
+	"douyin/pkg/utils/money"
 	"fmt"
 	"time"
 )
@@ -10,11 +11,14 @@ import (
 // 因为 GORM 默认会将结构体 CartItem 对应到表名 cart_items，
 // 但这里我们也可以通过实现 TableName() 来显式指定。
 type CartItem struct {
-	UserID    uint      `gorm:"primaryKey;autoIncrement:false"`     // 用户ID，复合主键的一部分
-	ProductID uint      `gorm:"primaryKey;autoIncrement:false"`     // 商品ID，复合主键的一部分
-	Quantity  int32     `gorm:"column:quantity;not null;default:1"` // 商品数量，默认为1
-	CreatedAt time.Time `gorm:"column:created_at"`                  // 添加时间
-	UpdatedAt time.Time `gorm:"column:updated_at"`                  // 更新时间
+	UserID        uint         `gorm:"primaryKey;autoIncrement:false"`                              // 用户ID，复合主键的一部分
+	SkuID         uint         `gorm:"primaryKey;autoIncrement:false"`                              // SKU ID，复合主键的一部分
+	ProductID     uint         `gorm:"column:product_id;not null;index"`                            // SKU 所属商品ID
+	Quantity      int32        `gorm:"column:quantity;not null;default:1"`                          // 商品数量，默认为1
+	Selected      bool         `gorm:"column:selected;not null;default:true"`                       // 是否勾选，结算时只处理勾选的商品
+	PriceSnapshot money.Amount `gorm:"column:price_snapshot;type:decimal(20,2);not null;default:0"` // 用户最近一次加购/修改时的 SKU 单价（基础币种），用于提示价格变动
+	CreatedAt     time.Time    `gorm:"column:created_at"`                                           // 添加时间
+	UpdatedAt     time.Time    `gorm:"column:updated_at"`                                           // 更新时间
 }
 
 // TableName 指定当前模型所映射的数据库表名为 cart_items
@@ -24,8 +28,8 @@ func (CartItem) TableName() string {
 
 // PrintInfo 输出购物车项的详细信息（用于调试）
 func (c *CartItem) PrintInfo() {
-	fmt.Printf("购物车项信息 - 用户ID: %d, 商品ID: %d, 数量: %d, 添加时间: %s, 更新时间: %s\n",
-		c.UserID, c.ProductID, c.Quantity,
+	fmt.Printf("购物车项信息 - 用户ID: %d, 商品ID: %d, SKU ID: %d, 数量: %d, 添加时间: %s, 更新时间: %s\n",
+		c.UserID, c.ProductID, c.SkuID, c.Quantity,
 		c.CreatedAt.Format("2006-01-02 15:04:05"),
 		c.UpdatedAt.Format("2006-01-02 15:04:05"))
 }
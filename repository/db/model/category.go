@@ -5,10 +5,12 @@ import (
 	"time"
 )
 
-// Category 商品分类模型
+// Category 商品分类模型，通过 ParentID 组成多级分类，顶级分类的 ParentID 为 0
 type Category struct {
-	ID        uint      `gorm:"primaryKey"`                  // 分类ID
-	CreatedAt time.Time `gorm:"column:created_at"`           // 分类创建时间
-	UpdatedAt time.Time `gorm:"column:updated_at"`           // 分类更新时间
-	Name      string    `gorm:"column:name;unique;not null"` // 分类名称，唯一
+	ID        uint      `gorm:"primaryKey"`                                // 分类ID
+	CreatedAt time.Time `gorm:"column:created_at"`                         // 分类创建时间
+	UpdatedAt time.Time `gorm:"column:updated_at"`                         // 分类更新时间
+	Name      string    `gorm:"column:name;unique;not null"`               // 分类名称，唯一
+	ParentID  uint      `gorm:"column:parent_id;not null;default:0;index"` // 父分类ID，0 表示顶级分类
+	Sort      int       `gorm:"column:sort;not null;default:0"`            // 同级分类中的排序，越小越靠前
 }
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"time"
+
+	"douyin/consts"
+	"douyin/pkg/utils/money"
+)
+
+// CouponTemplate 优惠券模板，由管理员创建，用户领取后生成 UserCoupon
+// 金额字段均为基础币种；CategoryID 不为 0 时只有该分类及其子孙分类下的商品参与门槛计算与优惠分摊
+type CouponTemplate struct {
+	ID              uint         `gorm:"primaryKey"`
+	Name            string       `gorm:"column:name;size:100;not null"`                             // 优惠券名称
+	Type            string       `gorm:"column:type;size:20;not null"`                              // 类型，取值见 consts.CouponType*
+	Value           money.Amount `gorm:"column:value;type:decimal(20,2);not null;default:0"`        // 立减/满减金额
+	Percent         int          `gorm:"column:percent;not null;default:0"`                         // 折扣券减免的百分比，如 20 表示减免 20%
+	Threshold       money.Amount `gorm:"column:threshold;type:decimal(20,2);not null;default:0"`    // 使用门槛（适用商品金额），0 表示无门槛
+	MaxDiscount     money.Amount `gorm:"column:max_discount;type:decimal(20,2);not null;default:0"` // 折扣券最高优惠金额，0 表示不限
+	CategoryID      uint         `gorm:"column:category_id;not null;default:0;index"`               // 适用分类，0 表示全场通用
+	Stackable       bool         `gorm:"column:stackable;not null;default:false"`                   // 是否可与其他可叠加券同时使用
+	TotalQuantity   int          `gorm:"column:total_quantity;not null;default:0"`                  // 发行总量，0 表示不限
+	ClaimedQuantity int          `gorm:"column:claimed_quantity;not null;default:0"`                // 已领取数量
+	PerUserLimit    int          `gorm:"column:per_user_limit;not null;default:1"`                  // 每个用户最多领取张数
+	ClaimStartAt    time.Time    `gorm:"column:claim_start_at"`                                     // 领取开始时间
+	ClaimEndAt      time.Time    `gorm:"column:claim_end_at"`                                       // 领取结束时间
+	ValidDays       int          `gorm:"column:valid_days;not null;default:0"`                      // 领取后的有效天数，0 表示以 ExpiresAt 为准
+	ExpiresAt       time.Time    `gorm:"column:expires_at"`                                         // 使用截止时间，领取后的有效期不会超过该时间
+	Enabled         bool         `gorm:"column:enabled;not null;default:true"`                      // 是否启用，停用后不可领取也不可使用
+	CreatedBy       uint         `gorm:"column:created_by"`                                         // 创建人ID
+	CreatedAt       time.Time    `gorm:"column:created_at"`
+	UpdatedAt       time.Time    `gorm:"column:updated_at"`
+}
+
+// TableName 指定优惠券模板表名
+func (CouponTemplate) TableName() string {
+	return "coupon_templates"
+}
+
+// Discount 计算适用商品金额 subtotal 可获得的优惠，subtotal 与返回值为订单币种，模板金额按 rate 从基础币种换算
+// 未达到使用门槛时返回 0，优惠金额不会超过 subtotal
+func (t *CouponTemplate) Discount(subtotal money.Amount, rate money.Rate) money.Amount {
+	if subtotal <= 0 || subtotal < t.Threshold.Convert(rate) {
+		return 0
+	}
+	var discount money.Amount
+	switch t.Type {
+	case consts.CouponTypeFixed, consts.CouponTypeThreshold:
+		discount = t.Value.Convert(rate)
+	case consts.CouponTypePercent:
+		// 四舍五入到分
+		discount = (subtotal*money.Amount(t.Percent) + 50) / 100
+		if t.MaxDiscount > 0 {
+			if limit := t.MaxDiscount.Convert(rate); discount > limit {
+				discount = limit
+			}
+		}
+	}
+	if discount > subtotal {
+		discount = subtotal
+	}
+	return discount
+}
+
+// ExpiryFor 计算在 claimedAt 领取的优惠券的到期时间
+func (t *CouponTemplate) ExpiryFor(claimedAt time.Time) time.Time {
+	if t.ValidDays <= 0 {
+		return t.ExpiresAt
+	}
+	expiresAt := claimedAt.AddDate(0, 0, t.ValidDays)
+	if !t.ExpiresAt.IsZero() && t.ExpiresAt.Before(expiresAt) {
+		return t.ExpiresAt
+	}
+	return expiresAt
+}
+
+// UserCoupon 用户领取的优惠券
+type UserCoupon struct {
+	ID             uint           `gorm:"primaryKey"`
+	UserID         uint           `gorm:"column:user_id;not null;index:idx_user_coupon_status"`
+	TemplateID     uint           `gorm:"column:template_id;not null;index"`
+	Status         string         `gorm:"column:status;size:20;not null;index:idx_user_coupon_status"`  // 状态，取值见 consts.UserCouponStatus*
+	ClaimedAt      time.Time      `gorm:"column:claimed_at"`                                            // 领取时间
+	ExpiresAt      time.Time      `gorm:"column:expires_at;index"`                                      // 到期时间
+	UsedAt         *time.Time     `gorm:"column:used_at"`                                               // 使用时间
+	OrderID        string         `gorm:"column:order_id;size:64;index"`                                // 使用该券的订单
+	DiscountAmount money.Amount   `gorm:"column:discount_amount;type:decimal(20,2);not null;default:0"` // 在订单中抵扣的金额（订单币种）
+	CreatedAt      time.Time      `gorm:"column:created_at"`
+	UpdatedAt      time.Time      `gorm:"column:updated_at"`
+	Template       CouponTemplate `gorm:"foreignKey:TemplateID"`
+}
+
+// TableName 指定用户优惠券表名
+func (UserCoupon) TableName() string {
+	return "user_coupons"
+}
+
+// Usable 判断优惠券在 now 时刻能否用于下单
+func (c *UserCoupon) Usable(now time.Time) bool {
+	return c.Status == consts.UserCouponStatusUnused && now.Before(c.ExpiresAt) && c.Template.Enabled
+}
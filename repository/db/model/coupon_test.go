@@ -0,0 +1,50 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"douyin/consts"
+	"douyin/pkg/utils/money"
+)
+
+// TestCouponTemplate_Discount 校验各类优惠券的优惠金额计算
+func TestCouponTemplate_Discount(t *testing.T) {
+	cases := []struct {
+		name     string
+		template CouponTemplate
+		subtotal money.Amount
+		want     money.Amount
+	}{
+		{"立减券", CouponTemplate{Type: consts.CouponTypeFixed, Value: 500}, 2000, 500},
+		{"立减券不超过商品金额", CouponTemplate{Type: consts.CouponTypeFixed, Value: 500}, 300, 300},
+		{"满减券达到门槛", CouponTemplate{Type: consts.CouponTypeThreshold, Value: 1000, Threshold: 10000}, 10000, 1000},
+		{"满减券未达到门槛", CouponTemplate{Type: consts.CouponTypeThreshold, Value: 1000, Threshold: 10000}, 9999, 0},
+		{"折扣券四舍五入", CouponTemplate{Type: consts.CouponTypePercent, Percent: 15}, 999, 150},
+		{"折扣券封顶", CouponTemplate{Type: consts.CouponTypePercent, Percent: 50, MaxDiscount: 2000}, 10000, 2000},
+		{"没有适用商品", CouponTemplate{Type: consts.CouponTypeFixed, Value: 500}, 0, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, c.template.Discount(c.subtotal, money.OneRate))
+		})
+	}
+
+	// 模板金额按汇率换算为订单币种：汇率 2 时满 200 减 20
+	rate, _ := money.ParseRate("2")
+	template := CouponTemplate{Type: consts.CouponTypeThreshold, Value: 1000, Threshold: 10000}
+	assert.Equal(t, money.Amount(0), template.Discount(19999, rate))
+	assert.Equal(t, money.Amount(2000), template.Discount(20000, rate))
+}
+
+// TestCouponTemplate_ExpiryFor 校验领取后有效期不超过模板的使用截止时间
+func TestCouponTemplate_ExpiryFor(t *testing.T) {
+	claimedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	deadline := time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, deadline, (&CouponTemplate{ExpiresAt: deadline}).ExpiryFor(claimedAt))
+	assert.Equal(t, claimedAt.AddDate(0, 0, 3), (&CouponTemplate{ValidDays: 3, ExpiresAt: deadline}).ExpiryFor(claimedAt))
+	assert.Equal(t, deadline, (&CouponTemplate{ValidDays: 7, ExpiresAt: deadline}).ExpiryFor(claimedAt))
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"douyin/pkg/utils/money"
+)
+
+// ExchangeRate 汇率，表示 1 单位基础币种可兑换的目标币种数量；基础币种自身不入表，汇率恒为 1
+type ExchangeRate struct {
+	Currency  string     `gorm:"primaryKey;column:currency;size:10" json:"currency"`  // 目标币种，ISO 4217 代码
+	Rate      money.Rate `gorm:"not null;column:rate;type:decimal(20,8)" json:"rate"` // 汇率
+	Source    string     `gorm:"column:source;size:32" json:"source"`                 // 数据来源：file / admin
+	UpdatedBy uint       `gorm:"column:updated_by" json:"updated_by"`                 // 最后修改人，文件导入时为 0
+	UpdatedAt time.Time  `gorm:"column:updated_at" json:"updated_at"`                 // 更新时间
+}
+
+// TableName 设置表名
+func (ExchangeRate) TableName() string {
+	return "exchange_rates"
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"time"
+
+	"douyin/pkg/utils/money"
+)
+
+// FlashSaleSession 秒杀场次，由管理员排期；开始前库存预热到 Redis，抢购时在 Redis 中原子扣减，下单由队列 worker 异步完成
+// Stock 为本场次投放的数量，从 SKU 库存中划出，下单时仍按普通订单扣减 ProductSku.Stock
+type FlashSaleSession struct {
+	ID        uint         `gorm:"primaryKey"`
+	ProductID uint         `gorm:"column:product_id;not null;index"`                   // 秒杀商品ID
+	SkuID     uint         `gorm:"column:sku_id;not null;default:0"`                   // 秒杀 SKU ID
+	Price     money.Amount `gorm:"column:price;type:decimal(20,2);not null;default:0"` // 秒杀价（基础币种）
+	Stock     int          `gorm:"column:stock;not null;default:0"`                    // 投放数量
+	Sold      int          `gorm:"column:sold;not null;default:0"`                     // 已成功下单的数量
+	StartAt   time.Time    `gorm:"column:start_at;index"`                              // 开始时间
+	EndAt     time.Time    `gorm:"column:end_at;index"`                                // 结束时间
+	Enabled   bool         `gorm:"column:enabled;not null;default:true"`               // 是否启用，停用后立即从 Redis 下线
+	CreatedBy uint         `gorm:"column:created_by"`                                  // 创建人ID
+	CreatedAt time.Time    `gorm:"column:created_at"`
+	UpdatedAt time.Time    `gorm:"column:updated_at"`
+	Product   Product      `gorm:"foreignKey:ProductID"`
+	Sku       ProductSku   `gorm:"foreignKey:SkuID"`
+}
+
+// TableName 指定秒杀场次表名
+func (FlashSaleSession) TableName() string {
+	return "flash_sale_sessions"
+}
+
+// Active 判断场次在 now 时刻是否处于抢购时间内
+func (s *FlashSaleSession) Active(now time.Time) bool {
+	return s.Enabled && !now.Before(s.StartAt) && now.Before(s.EndAt)
+}
+
+// FlashSaleOrder 秒杀成交记录，(SessionID, UserID) 唯一，在数据库层面保证每人每场只能成交一单
+type FlashSaleOrder struct {
+	ID        uint      `gorm:"primaryKey"`
+	SessionID uint      `gorm:"column:session_id;not null;uniqueIndex:idx_flash_sale_user"`
+	UserID    uint      `gorm:"column:user_id;not null;uniqueIndex:idx_flash_sale_user"`
+	OrderID   string    `gorm:"column:order_id;size:64;not null;index"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+// TableName 指定秒杀成交记录表名
+func (FlashSaleOrder) TableName() string {
+	return "flash_sale_orders"
+}
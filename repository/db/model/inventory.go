@@ -0,0 +1,100 @@
+package model
+
+import (
+	"time"
+)
+
+// Warehouse 仓库，SKU 的库存按仓库分别记录
+type Warehouse struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Code      string    `gorm:"column:code;size:32;not null;uniqueIndex" json:"code"` // 仓库编码
+	Name      string    `gorm:"column:name;size:100;not null" json:"name"`            // 仓库名称
+	Address   string    `gorm:"column:address;size:255" json:"address"`               // 仓库地址
+	Priority  int       `gorm:"column:priority;not null;default:0" json:"priority"`   // 分配优先级，下单时优先占用数值小的仓库
+	Enabled   bool      `gorm:"column:enabled;not null;default:true" json:"enabled"`  // 停用的仓库不参与下单分配，已有占用仍可正常出库或释放
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// TableName 设置表名
+func (Warehouse) TableName() string {
+	return "warehouses"
+}
+
+// InventoryLevel 仓库中某个 SKU 的库存水位，所有变动都必须加行锁并同时写入 InventoryMovement
+// 可售数量为 OnHand - Reserved；ProductSku.Stock 缓存该 SKU 在各仓库的可售数量之和
+type InventoryLevel struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	WarehouseID uint      `gorm:"column:warehouse_id;not null;uniqueIndex:idx_level_warehouse_sku" json:"warehouse_id"` // 仓库ID
+	SkuID       uint      `gorm:"column:sku_id;not null;uniqueIndex:idx_level_warehouse_sku;index" json:"sku_id"`       // SKU ID
+	ProductID   uint      `gorm:"column:product_id;not null;index" json:"product_id"`                                   // SKU 所属商品ID
+	OnHand      int       `gorm:"column:on_hand;not null;default:0" json:"on_hand"`                                     // 在库数量
+	Reserved    int       `gorm:"column:reserved;not null;default:0" json:"reserved"`                                   // 被未支付订单占用的数量
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// TableName 设置表名
+func (InventoryLevel) TableName() string {
+	return "inventory_levels"
+}
+
+// Available 返回可售数量
+func (l *InventoryLevel) Available() int {
+	return l.OnHand - l.Reserved
+}
+
+// InventoryMovement 库存流水，只追加不修改；同一库存水位的流水变动量之和等于其当前的在库与占用数量
+type InventoryMovement struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	WarehouseID    uint      `gorm:"column:warehouse_id;not null;index" json:"warehouse_id"`         // 仓库ID
+	SkuID          uint      `gorm:"column:sku_id;not null;index" json:"sku_id"`                     // SKU ID
+	ProductID      uint      `gorm:"column:product_id;not null;index" json:"product_id"`             // SKU 所属商品ID
+	Type           string    `gorm:"column:type;size:20;not null;index" json:"type"`                 // 流水类型，取值见 consts.InventoryMove*
+	OnHandChange   int       `gorm:"column:on_hand_change;not null" json:"on_hand_change"`           // 在库数量变动，正数增加、负数减少
+	ReservedChange int       `gorm:"column:reserved_change;not null" json:"reserved_change"`         // 占用数量变动
+	OnHandAfter    int       `gorm:"column:on_hand_after;not null" json:"on_hand_after"`             // 变动后在库数量
+	ReservedAfter  int       `gorm:"column:reserved_after;not null" json:"reserved_after"`           // 变动后占用数量
+	RefType        string    `gorm:"column:ref_type;size:20;index:idx_movement_ref" json:"ref_type"` // 关联单据类型，取值见 consts.InventoryRef*
+	RefID          string    `gorm:"column:ref_id;size:64;index:idx_movement_ref" json:"ref_id"`     // 关联单据号
+	Actor          string    `gorm:"column:actor;size:20" json:"actor"`                              // 操作方（user/admin/system）
+	ActorID        uint      `gorm:"column:actor_id" json:"actor_id"`                                // 操作人ID，系统操作为 0
+	Remark         string    `gorm:"column:remark;size:255" json:"remark"`                           // 备注
+	CreatedAt      time.Time `gorm:"column:created_at;index" json:"created_at"`                      // 记账时间
+}
+
+// TableName 设置表名
+func (InventoryMovement) TableName() string {
+	return "inventory_movements"
+}
+
+// InventoryAllocation 从某个仓库分配的数量
+type InventoryAllocation struct {
+	WarehouseID uint
+	Quantity    int
+}
+
+// AllocateInventory 按 levels 的顺序（即仓库优先级）依次占用可售数量，直到满足 quantity
+// 可售数量之和不足时返回 nil；单个仓库不足时拆分到多个仓库
+func AllocateInventory(levels []InventoryLevel, quantity int) []InventoryAllocation {
+	var allocations []InventoryAllocation
+	remaining := quantity
+	for _, level := range levels {
+		if remaining <= 0 {
+			break
+		}
+		take := level.Available()
+		if take <= 0 {
+			continue
+		}
+		if take > remaining {
+			take = remaining
+		}
+		allocations = append(allocations, InventoryAllocation{WarehouseID: level.WarehouseID, Quantity: take})
+		remaining -= take
+	}
+	if remaining > 0 {
+		return nil
+	}
+	return allocations
+}
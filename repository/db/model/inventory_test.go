@@ -0,0 +1,25 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAllocateInventory 校验按仓库优先级依次占用可售数量，不足时拆分到多个仓库
+func TestAllocateInventory(t *testing.T) {
+	levels := []InventoryLevel{
+		{WarehouseID: 1, OnHand: 5, Reserved: 3},
+		{WarehouseID: 2, OnHand: 2, Reserved: 2},
+		{WarehouseID: 3, OnHand: 10},
+	}
+
+	assert.Equal(t, []InventoryAllocation{{WarehouseID: 1, Quantity: 2}}, AllocateInventory(levels, 2))
+	assert.Equal(t, []InventoryAllocation{
+		{WarehouseID: 1, Quantity: 2},
+		{WarehouseID: 3, Quantity: 4},
+	}, AllocateInventory(levels, 6), "跳过没有可售数量的仓库")
+	assert.Len(t, AllocateInventory(levels, 12), 2)
+	assert.Nil(t, AllocateInventory(levels, 13), "可售数量之和不足")
+	assert.Nil(t, AllocateInventory(nil, 1))
+}
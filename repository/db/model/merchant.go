@@ -0,0 +1,58 @@
+package model
+
+import (
+	"time"
+
+	"douyin/pkg/utils/money"
+)
+
+// Merchant 商家资料，每个用户最多开设一个店铺；商品归属于商家，订单支付后的货款按商品归属结算给商家
+type Merchant struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	UserID       uint      `gorm:"not null;column:user_id;uniqueIndex" json:"user_id"`                   // 店主用户ID，货款结算到该用户的钱包
+	Name         string    `gorm:"type:varchar(100);not null;column:name;uniqueIndex" json:"name"`       // 店铺名称
+	Description  string    `gorm:"type:varchar(500);column:description" json:"description"`              // 店铺简介
+	ContactEmail string    `gorm:"type:varchar(255);not null;column:contact_email" json:"contact_email"` // 联系邮箱，接收低库存预警等通知
+	Status       string    `gorm:"type:varchar(20);not null;column:status;index" json:"status"`          // 状态，取值见 consts.MerchantStatus*
+	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// TableName 设置表名
+func (Merchant) TableName() string {
+	return "merchants"
+}
+
+// MerchantSettlement 商家结算明细：订单支付成功时按商家写入应收货款，售后退款时写入负数扣回，
+// 结算时将同一商家待结算明细的基础币种金额合计记入商家钱包
+type MerchantSettlement struct {
+	ID         uint         `gorm:"primaryKey" json:"id"`
+	MerchantID uint         `gorm:"not null;column:merchant_id;index:idx_settlement_merchant_status" json:"merchant_id"`        // 商家ID
+	Status     string       `gorm:"type:varchar(20);not null;column:status;index:idx_settlement_merchant_status" json:"status"` // 状态，取值见 consts.SettlementStatus*
+	Type       string       `gorm:"type:varchar(20);not null;column:type" json:"type"`                                          // 类型，取值见 consts.SettlementType*
+	OrderID    string       `gorm:"not null;column:order_id;size:64;index" json:"order_id"`                                     // 订单ID
+	RefundNo   string       `gorm:"column:refund_no;size:64" json:"refund_no"`                                                  // 售后单号，退款扣回时记录
+	Amount     money.Amount `gorm:"not null;column:amount;type:decimal(20,2)" json:"amount"`                                    // 金额（订单币种），扣回为负数
+	Currency   string       `gorm:"not null;column:currency;size:10" json:"currency"`                                           // 订单币种
+	BaseAmount money.Amount `gorm:"not null;column:base_amount;type:decimal(20,2)" json:"base_amount"`                          // 按订单汇率快照换算的基础币种金额，实际结算金额
+	BatchNo    string       `gorm:"column:batch_no;size:64;index" json:"batch_no"`                                              // 结算批次号，与钱包流水的关联单号一致
+	SettledAt  *time.Time   `gorm:"column:settled_at" json:"settled_at"`                                                        // 结算时间
+	CreatedAt  time.Time    `gorm:"column:created_at" json:"created_at"`
+}
+
+// TableName 设置表名
+func (MerchantSettlement) TableName() string {
+	return "merchant_settlements"
+}
+
+// MerchantOrderAmounts 按商家汇总订单项的实付金额（订单项金额减去分摊的优惠），不属于任何商家（平台自营）的订单项不计入
+func MerchantOrderAmounts(items []OrderItem) map[uint]money.Amount {
+	amounts := make(map[uint]money.Amount)
+	for _, item := range items {
+		if item.MerchantID == 0 {
+			continue
+		}
+		amounts[item.MerchantID] += item.Cost.Mul(int64(item.Quantity)) - item.Discount
+	}
+	return amounts
+}
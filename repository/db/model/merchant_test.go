@@ -0,0 +1,22 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"douyin/pkg/utils/money"
+)
+
+// TestMerchantOrderAmounts 校验按商家汇总订单项实付金额，平台自营订单项不参与结算
+func TestMerchantOrderAmounts(t *testing.T) {
+	items := []OrderItem{
+		{MerchantID: 1, Cost: 1000, Quantity: 2, Discount: 150},
+		{MerchantID: 2, Cost: 500, Quantity: 1},
+		{MerchantID: 1, Cost: 300, Quantity: 3, Discount: 50},
+		{MerchantID: 0, Cost: 9900, Quantity: 1},
+	}
+
+	assert.Equal(t, map[uint]money.Amount{1: 2700, 2: 500}, MerchantOrderAmounts(items))
+	assert.Empty(t, MerchantOrderAmounts(items[3:]))
+}
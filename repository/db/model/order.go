@@ -2,27 +2,49 @@ package model
 
 import (
 	"time"
+
+	"douyin/consts"
+	"douyin/pkg/utils/money"
 )
 
 // Order 订单模型
 type Order struct {
-	OrderID       string      `gorm:"primaryKey;column:order_id;size:64" json:"order_id"`                // 订单ID
-	UserID        uint        `gorm:"not null;column:user_id" json:"user_id"`                            // 用户ID
-	UserCurrency  string      `gorm:"not null;column:user_currency;size:10" json:"user_currency"`        // 用户货币
-	Email         string      `gorm:"not null;column:email;size:255" json:"email"`                       // 用户邮箱
-	FirstName     string      `gorm:"column:firstname;size:50" json:"first_name"`                        // 名
-	LastName      string      `gorm:"column:lastname;size:50" json:"last_name"`                          // 姓
-	StreetAddress string      `gorm:"column:street_address;not null;size:255" json:"street_address"`     // 街道地址
-	City          string      `gorm:"column:city;not null;size:100" json:"city"`                         // 城市
-	State         string      `gorm:"column:state;not null;size:100" json:"state"`                       // 省/州
-	Country       string      `gorm:"column:country;not null;size:100" json:"country"`                   // 国家
-	ZipCode       string      `gorm:"column:zip_code;not null;size:20" json:"zip_code"`                  // 邮政编码
-	CreatedAt     time.Time   `gorm:"column:created_at" json:"created_at"`                               // 订单创建时间
-	Status        string      `gorm:"column:status;default:'pending';size:50" json:"status"`             // 订单状态
-	OrderItems    []OrderItem `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"order_items"` // 订单项
+	OrderID        string       `gorm:"primaryKey;column:order_id;size:64" json:"order_id"`                                  // 订单ID
+	UserID         uint         `gorm:"not null;column:user_id" json:"user_id"`                                              // 用户ID
+	UserCurrency   string       `gorm:"not null;column:user_currency;size:10" json:"user_currency"`                          // 用户货币，订单项单价与支付金额均以该币种计价
+	BaseCurrency   string       `gorm:"column:base_currency;size:10" json:"base_currency"`                                   // 下单时的基础币种（商品定价币种）
+	ExchangeRate   money.Rate   `gorm:"column:exchange_rate;type:decimal(20,8)" json:"exchange_rate"`                        // 下单时基础币种到用户货币的汇率快照
+	DiscountAmount money.Amount `gorm:"column:discount_amount;type:decimal(20,2);not null;default:0" json:"discount_amount"` // 优惠券抵扣总额（订单币种），已分摊到各订单项
+	Email          string       `gorm:"not null;column:email;size:255" json:"email"`                                         // 用户邮箱
+	FirstName      string       `gorm:"column:firstname;size:50" json:"first_name"`                                          // 名
+	LastName       string       `gorm:"column:lastname;size:50" json:"last_name"`                                            // 姓
+	StreetAddress  string       `gorm:"column:street_address;not null;size:255" json:"street_address"`                       // 街道地址
+	City           string       `gorm:"column:city;not null;size:100" json:"city"`                                           // 城市
+	State          string       `gorm:"column:state;not null;size:100" json:"state"`                                         // 省/州
+	Country        string       `gorm:"column:country;not null;size:100" json:"country"`                                     // 国家
+	ZipCode        string       `gorm:"column:zip_code;not null;size:20" json:"zip_code"`                                    // 邮政编码
+	CreatedAt      time.Time    `gorm:"column:created_at" json:"created_at"`                                                 // 订单创建时间
+	Status         int          `gorm:"column:status;not null;default:1;index" json:"status"`                                // 订单状态，取值见 consts.OrderType*
+	OrderItems     []OrderItem  `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"order_items"`                   // 订单项
+	SubOrders      []SubOrder   `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"sub_orders"`                    // 按商家拆分的子订单
 }
 
 // 外键约束
 func (Order) TableName() string {
 	return "orders"
 }
+
+// CanTransitTo 判断订单能否从当前状态流转到目标状态
+func (o *Order) CanTransitTo(status int) bool {
+	for _, next := range consts.OrderStatusTransitions[o.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusText 返回订单状态的中文描述
+func (o *Order) StatusText() string {
+	return consts.OrderTypeMap[o.Status]
+}
@@ -2,18 +2,26 @@
 package model
 
 import (
+	"douyin/pkg/utils/money"
 	"time"
 )
 
 // OrderItem 订单项模型
 // OrderItem 订单项模型
 type OrderItem struct {
-	ID        uint      `gorm:"primaryKey"`                 // 订单项ID
-	OrderID   string    `gorm:"column:order_id;not null"`   // 订单ID
-	ProductID uint      `gorm:"column:product_id;not null"` // 商品ID
-	Quantity  int32     `gorm:"column:quantity;not null"`   // 商品数量
-	Cost      float64   `gorm:"column:cost;not null"`       // 商品成本（下单时价格）
-	CreatedAt time.Time `gorm:"-"`                          // 忽略创建时间字段
+	ID             uint         `gorm:"primaryKey"`                                            // 订单项ID
+	OrderID        string       `gorm:"column:order_id;not null"`                              // 订单ID
+	ProductID      uint         `gorm:"column:product_id;not null"`                            // 商品ID
+	SubOrderID     string       `gorm:"column:sub_order_id;size:64;index"`                     // 所属子订单ID，下单时按商家拆分
+	MerchantID     uint         `gorm:"column:merchant_id;not null;default:0;index"`           // 商品所属商家ID快照，0 表示平台自营
+	SkuID          uint         `gorm:"column:sku_id;not null;default:0"`                      // SKU ID，库存按 SKU 扣减与归还
+	Quantity       int32        `gorm:"column:quantity;not null"`                              // 商品数量
+	Cost           money.Amount `gorm:"column:cost;type:decimal(20,2);not null"`               // 商品成本（下单时价格，按汇率快照换算为订单币种）
+	Discount       money.Amount `gorm:"column:discount;type:decimal(20,2);not null;default:0"` // 该订单项整行分摊到的优惠金额，退款时按数量比例扣除
+	ProductName    string       `gorm:"column:product_name;size:255"`                          // 商品名称快照
+	ProductPicture string       `gorm:"column:product_picture;size:1000"`                      // 商品图片快照（SKU 有图片时为 SKU 图片）
+	SkuSpecs       string       `gorm:"column:sku_specs;size:255"`                             // SKU 规格描述快照，如「颜色:红;尺码:L」
+	CreatedAt      time.Time    `gorm:"-"`                                                     // 忽略创建时间字段
 }
 
 // 外键约束
@@ -0,0 +1,24 @@
+// model/order_status_history.go
+package model
+
+import (
+	"time"
+)
+
+// OrderStatusHistory 订单状态流转记录，每次状态变更追加一条，不做修改；子订单单独流转时同时记录子订单ID
+type OrderStatusHistory struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`                                   // 记录ID
+	OrderID    string    `gorm:"column:order_id;not null;size:64;index" json:"order_id"` // 订单ID
+	SubOrderID string    `gorm:"column:sub_order_id;size:64" json:"sub_order_id"`        // 子订单ID，父订单整体流转时为空
+	FromStatus int       `gorm:"column:from_status;not null" json:"from_status"`         // 变更前状态，新建订单时为 0
+	ToStatus   int       `gorm:"column:to_status;not null" json:"to_status"`             // 变更后状态
+	Actor      string    `gorm:"column:actor;not null;size:20" json:"actor"`             // 操作者类型：user/admin/merchant/system
+	ActorID    uint      `gorm:"column:actor_id" json:"actor_id"`                        // 操作者ID，系统操作时为 0
+	Reason     string    `gorm:"column:reason;size:255" json:"reason"`                   // 变更原因
+	CreatedAt  time.Time `gorm:"column:created_at" json:"created_at"`                    // 变更时间
+}
+
+// TableName 设置表名
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"douyin/consts"
+)
+
+// TestOrder_CanTransitTo 校验订单状态机的合法与非法流转
+func TestOrder_CanTransitTo(t *testing.T) {
+	cases := []struct {
+		name string
+		from int
+		to   int
+		want bool
+	}{
+		{"未支付->已支付", consts.OrderTypeUnPaid, consts.OrderTypePendingShipping, true},
+		{"未支付->已取消", consts.OrderTypeUnPaid, consts.OrderTypeCancelled, true},
+		{"未支付->超时关闭", consts.OrderTypeUnPaid, consts.OrderTypeClosed, true},
+		{"未支付->已发货", consts.OrderTypeUnPaid, consts.OrderTypeShipping, false},
+		{"已支付->已发货", consts.OrderTypePendingShipping, consts.OrderTypeShipping, true},
+		{"已支付->已取消", consts.OrderTypePendingShipping, consts.OrderTypeCancelled, false},
+		{"已发货->已收货", consts.OrderTypeShipping, consts.OrderTypeReceipt, true},
+		{"已收货->已退款", consts.OrderTypeReceipt, consts.OrderTypeRefunded, true},
+		{"已取消为终态", consts.OrderTypeCancelled, consts.OrderTypeUnPaid, false},
+		{"已关闭为终态", consts.OrderTypeClosed, consts.OrderTypePendingShipping, false},
+		{"已退款为终态", consts.OrderTypeRefunded, consts.OrderTypeReceipt, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			o := &Order{Status: c.from}
+			assert.Equal(t, c.want, o.CanTransitTo(c.to))
+		})
+	}
+}
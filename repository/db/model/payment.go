@@ -1,14 +1,74 @@
 package model
 
-import "time"
+import (
+	"time"
 
-// Payment represents a payment record for an order.
+	"douyin/consts"
+	"douyin/pkg/utils/money"
+)
+
+// Payment 支付单模型，每个订单下单时生成一条待支付记录，用户选择支付渠道后由对应的 PaymentProvider 处理
 type Payment struct {
-	ID        uint      `gorm:"primaryKey"`
-	OrderID   string    // Associated Order ID (assuming OrderID in Order model is string, adjust if necessary)
-	Amount    float64   // Payment amount
-	Status    string    // Payment status, e.g., "UNPAID", "PAID", "FAILED"
-	CreatedAt time.Time // Timestamp of payment creation
-	// UpdatedAt time.Time // Optionally, if payment status can change
-	// TransactionID string // Optional: payment gateway transaction ID
+	TransactionID  string       `gorm:"primaryKey;column:transaction_id;size:64" json:"transaction_id"`                      // 交易ID（本系统生成）
+	OrderID        string       `gorm:"not null;column:order_id;size:64;index" json:"order_id"`                              // 订单ID
+	UserID         uint         `gorm:"not null;column:user_id;index" json:"user_id"`                                        // 用户ID
+	Amount         money.Amount `gorm:"not null;column:amount;type:decimal(20,2)" json:"amount"`                             // 支付金额
+	Currency       string       `gorm:"column:currency;size:10" json:"currency"`                                             // 支付币种
+	Provider       string       `gorm:"column:provider;size:32;index" json:"provider"`                                       // 支付渠道，取值见 consts.PaymentProvider*
+	ProviderRef    string       `gorm:"column:provider_ref;size:128;index" json:"provider_ref"`                              // 支付渠道侧的交易/扣款单号
+	Status         string       `gorm:"column:status;not null;default:'UNPAID';size:20;index" json:"status"`                 // 支付状态，取值见 consts.PaymentStatus*
+	FailureReason  string       `gorm:"column:failure_reason;size:255" json:"failure_reason"`                                // 最近一次支付失败原因
+	RefundedAmount money.Amount `gorm:"column:refunded_amount;type:decimal(20,2);not null;default:0" json:"refunded_amount"` // 累计已退款金额
+	NeedsReconcile bool         `gorm:"column:needs_reconcile;not null;default:false;index" json:"needs_reconcile"`          // 渠道扣款与本地状态不一致需要对账，如订单关闭后才扣款成功且自动退款未完成
+	PaidAt         *time.Time   `gorm:"column:paid_at" json:"paid_at"`                                                       // 支付成功时间
+	CreatedAt      time.Time    `gorm:"column:created_at" json:"created_at"`                                                 // 创建时间
+	UpdatedAt      time.Time    `gorm:"column:updated_at" json:"updated_at"`                                                 // 更新时间
+}
+
+// TableName 设置表名
+func (Payment) TableName() string {
+	return "payments"
+}
+
+// CanTransitTo 判断支付单能否从当前状态流转到目标状态
+func (p *Payment) CanTransitTo(status string) bool {
+	for _, next := range consts.PaymentStatusTransitions[p.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// PaymentStatusHistory 支付单状态流转记录
+type PaymentStatusHistory struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	TransactionID string    `gorm:"not null;column:transaction_id;size:64;index" json:"transaction_id"` // 交易ID
+	FromStatus    string    `gorm:"column:from_status;size:20" json:"from_status"`                      // 变更前状态，创建时为空
+	ToStatus      string    `gorm:"not null;column:to_status;size:20" json:"to_status"`                 // 变更后状态
+	Provider      string    `gorm:"column:provider;size:32" json:"provider"`                            // 变更时的支付渠道
+	ProviderRef   string    `gorm:"column:provider_ref;size:128" json:"provider_ref"`                   // 变更时的渠道单号
+	Reason        string    `gorm:"column:reason;size:255" json:"reason"`                               // 变更原因
+	CreatedAt     time.Time `gorm:"column:created_at" json:"created_at"`                                // 变更时间
+}
+
+// TableName 设置表名
+func (PaymentStatusHistory) TableName() string {
+	return "payment_status_history"
+}
+
+// PaymentNotification 已处理的支付渠道回调通知，(provider, transaction_id, status) 唯一，用于回调去重和审计
+type PaymentNotification struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	Provider      string    `gorm:"not null;column:provider;size:32;uniqueIndex:idx_payment_notification" json:"provider"`             // 支付渠道
+	TransactionID string    `gorm:"not null;column:transaction_id;size:64;uniqueIndex:idx_payment_notification" json:"transaction_id"` // 交易ID
+	Status        string    `gorm:"not null;column:status;size:20;uniqueIndex:idx_payment_notification" json:"status"`                 // 通知的支付状态
+	ProviderRef   string    `gorm:"column:provider_ref;size:128" json:"provider_ref"`                                                  // 渠道单号
+	Payload       string    `gorm:"column:payload;type:text" json:"payload"`                                                           // 原始通知报文
+	CreatedAt     time.Time `gorm:"column:created_at" json:"created_at"`                                                               // 接收时间
+}
+
+// TableName 设置表名
+func (PaymentNotification) TableName() string {
+	return "payment_notifications"
 }
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"douyin/consts"
+)
+
+// TestPayment_CanTransitTo 校验支付单状态机的合法与非法流转
+func TestPayment_CanTransitTo(t *testing.T) {
+	cases := []struct {
+		name string
+		from string
+		to   string
+		want bool
+	}{
+		{"待支付->处理中", consts.PaymentStatusUnpaid, consts.PaymentStatusPending, true},
+		{"待支付->已支付", consts.PaymentStatusUnpaid, consts.PaymentStatusPaid, true},
+		{"处理中->失败", consts.PaymentStatusPending, consts.PaymentStatusFailed, true},
+		{"失败后可重新支付", consts.PaymentStatusFailed, consts.PaymentStatusPaid, true},
+		{"已支付->已退款", consts.PaymentStatusPaid, consts.PaymentStatusRefunded, true},
+		{"已支付不能失效", consts.PaymentStatusPaid, consts.PaymentStatusExpired, false},
+		{"处理中等待超时后失效待对账", consts.PaymentStatusPending, consts.PaymentStatusExpired, true},
+		{"处理中不能直接退款", consts.PaymentStatusPending, consts.PaymentStatusRefunded, false},
+		{"已失效不能再支付", consts.PaymentStatusExpired, consts.PaymentStatusPaid, false},
+		{"已失效后迟到的扣款退回", consts.PaymentStatusExpired, consts.PaymentStatusRefunded, true},
+		{"已退款为终态", consts.PaymentStatusRefunded, consts.PaymentStatusPaid, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := &Payment{Status: c.from}
+			assert.Equal(t, c.want, p.CanTransitTo(c.to))
+		})
+	}
+}
@@ -2,18 +2,23 @@
 package model
 
 import (
+	"douyin/pkg/utils/money"
 	"time"
 )
 
 // Product 商品模型
+// 价格与库存以 ProductSku 为准，Price 与 Stock 是各 SKU 的最低价与总库存，随 SKU 变更与库存扣减同步更新，供列表展示与搜索筛选使用
 type Product struct {
-	ID          uint      `gorm:"primaryKey"`            // 商品ID
-	CreatedAt   time.Time `gorm:"column:created_at"`     // 商品创建时间
-	UpdatedAt   time.Time `gorm:"column:updated_at"`     // 商品更新时间
-	Name        string    `gorm:"column:name;not null"`  // 商品名称
-	Description string    `gorm:"column:description"`    // 商品描述
-	Picture     string    `gorm:"column:picture"`        // 商品图片地址
-	Price       float64   `gorm:"column:price;not null"` // 商品价格
-	Stock       int       `gorm:"column:stock"`          // 商品库存
-	Version     int       `gorm:"column:version;default:1"` // 版本号，用于乐观锁
+	ID                uint         `gorm:"primaryKey"`                                                                              // 商品ID
+	CreatedAt         time.Time    `gorm:"column:created_at"`                                                                       // 商品创建时间
+	UpdatedAt         time.Time    `gorm:"column:updated_at"`                                                                       // 商品更新时间
+	Name              string       `gorm:"column:name;not null;index:idx_product_fulltext,class:FULLTEXT,option:WITH PARSER ngram"` // 商品名称
+	Description       string       `gorm:"column:description;index:idx_product_fulltext"`                                           // 商品描述，与名称共同建立全文索引（ngram 分词，支持中文）
+	Picture           string       `gorm:"column:picture"`                                                                          // 商品图片地址
+	MerchantID        uint         `gorm:"column:merchant_id;not null;default:0;index"`                                             // 所属商家ID，0 表示平台自营
+	Price             money.Amount `gorm:"column:price;type:decimal(20,2);not null"`                                                // 商品价格（基础币种），即 SKU 最低价
+	Stock             int          `gorm:"column:stock"`                                                                            // 商品库存，即 SKU 库存之和
+	Sales             int          `gorm:"column:sales;not null;default:0"`                                                         // 销量，支付成功时按订单项数量累加
+	LowStockThreshold int          `gorm:"column:low_stock_threshold;not null;default:0"`                                           // 低库存预警阈值，总库存降至该值及以下时提醒商家，0 表示不预警
+	Version           int          `gorm:"column:version;default:1"`                                                                // 版本号，用于乐观锁
 }
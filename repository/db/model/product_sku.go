@@ -0,0 +1,87 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"douyin/pkg/utils/money"
+)
+
+// ProductSpec 商品规格属性（如颜色、尺码）及其可选值，商品的每个 SKU 在每个规格上取一个值
+type ProductSpec struct {
+	ID        uint     `gorm:"primaryKey"`
+	ProductID uint     `gorm:"column:product_id;not null;index"`             // 商品ID
+	Name      string   `gorm:"column:name;size:50;not null"`                 // 规格名称
+	Values    []string `gorm:"column:spec_values;type:text;serializer:json"` // 可选值
+	Sort      int      `gorm:"column:sort;not null;default:0"`               // 排序，决定 SKU 规格描述中的先后顺序
+}
+
+// TableName 指定商品规格表名
+func (ProductSpec) TableName() string {
+	return "product_specs"
+}
+
+// ProductSku 商品的最小售卖单元，拥有独立的价格、库存、版本号与图片；购物车、预占与订单项均按 SKU 记录
+// 没有规格的商品只有一个默认 SKU（SpecKey 为空）；Product.Price 与 Product.Stock 分别为其 SKU 的最低价与总库存
+type ProductSku struct {
+	ID        uint              `gorm:"primaryKey"`
+	ProductID uint              `gorm:"column:product_id;not null;uniqueIndex:idx_sku_product_spec"`        // 商品ID
+	SpecKey   string            `gorm:"column:spec_key;size:255;not null;uniqueIndex:idx_sku_product_spec"` // 按规格顺序拼接的规格描述，如「颜色:红;尺码:L」，同一商品内唯一
+	Specs     map[string]string `gorm:"column:specs;type:text;serializer:json"`                             // 规格名称到取值的映射
+	Price     money.Amount      `gorm:"column:price;type:decimal(20,2);not null"`                           // 价格（基础币种）
+	Stock     int               `gorm:"column:stock;not null;default:0"`                                    // 可售库存，即各仓库在库减占用之和，由库存台账维护
+	Version   int               `gorm:"column:version;not null;default:1"`                                  // 版本号，用于乐观锁
+	Picture   string            `gorm:"column:picture;size:1000"`                                           // SKU 图片，为空时使用商品图片
+	CreatedAt time.Time         `gorm:"column:created_at"`
+	UpdatedAt time.Time         `gorm:"column:updated_at"`
+	Product   Product           `gorm:"foreignKey:ProductID"`
+}
+
+// TableName 指定商品 SKU 表名
+func (ProductSku) TableName() string {
+	return "product_skus"
+}
+
+// DisplayPicture 返回 SKU 图片，未设置时使用商品图片
+func (s *ProductSku) DisplayPicture() string {
+	if s.Picture != "" {
+		return s.Picture
+	}
+	return s.Product.Picture
+}
+
+// SkuSpecKey 按规格的 Sort 顺序拼接 SKU 的规格描述，如「颜色:红;尺码:L」；商品没有规格时返回空串（默认 SKU）
+// values 必须为每个规格给出一个属于其可选值的取值，且不能包含未定义的规格
+func SkuSpecKey(specs []ProductSpec, values map[string]string) (string, error) {
+	if len(values) != len(specs) {
+		return "", fmt.Errorf("SKU 需要为 %d 个规格各选择一个取值", len(specs))
+	}
+	ordered := make([]ProductSpec, len(specs))
+	copy(ordered, specs)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Sort < ordered[j].Sort })
+
+	parts := make([]string, 0, len(ordered))
+	for _, spec := range ordered {
+		value, ok := values[spec.Name]
+		if !ok {
+			return "", fmt.Errorf("SKU 缺少规格「%s」的取值", spec.Name)
+		}
+		if !spec.HasValue(value) {
+			return "", fmt.Errorf("规格「%s」没有可选值「%s」", spec.Name, value)
+		}
+		parts = append(parts, spec.Name+":"+value)
+	}
+	return strings.Join(parts, ";"), nil
+}
+
+// HasValue 判断 value 是否为该规格的可选值
+func (s *ProductSpec) HasValue(value string) bool {
+	for _, v := range s.Values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
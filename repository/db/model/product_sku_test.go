@@ -0,0 +1,32 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSkuSpecKey 校验 SKU 规格描述按规格顺序拼接，并拒绝缺失、多余或未定义的取值
+func TestSkuSpecKey(t *testing.T) {
+	specs := []ProductSpec{
+		{Name: "尺码", Values: []string{"M", "L"}, Sort: 2},
+		{Name: "颜色", Values: []string{"红", "蓝"}, Sort: 1},
+	}
+
+	key, err := SkuSpecKey(specs, map[string]string{"尺码": "L", "颜色": "红"})
+	assert.NoError(t, err)
+	assert.Equal(t, "颜色:红;尺码:L", key)
+
+	key, err = SkuSpecKey(nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "", key, "没有规格的商品为默认 SKU")
+
+	_, err = SkuSpecKey(specs, map[string]string{"颜色": "红"})
+	assert.Error(t, err, "缺少规格取值")
+
+	_, err = SkuSpecKey(specs, map[string]string{"颜色": "红", "版本": "标准"})
+	assert.Error(t, err, "包含未定义的规格")
+
+	_, err = SkuSpecKey(specs, map[string]string{"颜色": "绿", "尺码": "L"})
+	assert.Error(t, err, "取值不在可选值中")
+}
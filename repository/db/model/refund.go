@@ -0,0 +1,50 @@
+package model
+
+import (
+	"douyin/pkg/utils/money"
+	"time"
+)
+
+// Refund 售后（退款/退货）单，一个子订单可以按订单项多次申请，累计退款不超过实付金额；退款从父订单的支付单原路退回
+type Refund struct {
+	ID            uint         `gorm:"primaryKey" json:"id"`
+	RefundNo      string       `gorm:"not null;column:refund_no;size:64;uniqueIndex" json:"refund_no"` // 售后单号
+	OrderID       string       `gorm:"not null;column:order_id;size:64;index" json:"order_id"`         // 订单ID
+	SubOrderID    string       `gorm:"column:sub_order_id;size:64;index" json:"sub_order_id"`          // 子订单ID，一张售后单只包含同一子订单的商品
+	UserID        uint         `gorm:"not null;column:user_id;index" json:"user_id"`                   // 申请用户
+	TransactionID string       `gorm:"not null;column:transaction_id;size:64" json:"transaction_id"`   // 原支付交易ID
+	Type          string       `gorm:"not null;column:type;size:20" json:"type"`                       // 售后类型，取值见 consts.RefundType*
+	Reason        string       `gorm:"column:reason;size:500" json:"reason"`                           // 申请原因
+	Evidence      []string     `gorm:"column:evidence;type:text;serializer:json" json:"evidence"`      // 凭证图片地址
+	Amount        money.Amount `gorm:"not null;column:amount;type:decimal(20,2)" json:"amount"`        // 退款金额
+	Status        string       `gorm:"not null;column:status;size:20;index" json:"status"`             // 状态，取值见 consts.RefundStatus*
+	ReviewerID    uint         `gorm:"column:reviewer_id" json:"reviewer_id"`                          // 审核人
+	ReviewNote    string       `gorm:"column:review_note;size:500" json:"review_note"`                 // 审核备注
+	RefundMethod  string       `gorm:"column:refund_method;size:32" json:"refund_method"`              // 实际退款渠道（原支付渠道或 wallet）
+	RefundRef     string       `gorm:"column:refund_ref;size:128" json:"refund_ref"`                   // 渠道退款单号
+	ReviewedAt    *time.Time   `gorm:"column:reviewed_at" json:"reviewed_at"`                          // 审核时间
+	CreatedAt     time.Time    `gorm:"column:created_at" json:"created_at"`                            // 申请时间
+	UpdatedAt     time.Time    `gorm:"column:updated_at" json:"updated_at"`                            // 更新时间
+	Items         []RefundItem `gorm:"foreignKey:RefundID;constraint:OnDelete:CASCADE" json:"items"`   // 退款的订单项
+}
+
+// TableName 设置表名
+func (Refund) TableName() string {
+	return "refunds"
+}
+
+// RefundItem 售后单中的订单项及退款数量
+type RefundItem struct {
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	RefundID    uint         `gorm:"not null;column:refund_id;index" json:"refund_id"`         // 售后单ID
+	OrderItemID uint         `gorm:"not null;column:order_item_id;index" json:"order_item_id"` // 订单项ID
+	ProductID   uint         `gorm:"not null;column:product_id" json:"product_id"`             // 商品ID
+	SkuID       uint         `gorm:"not null;column:sku_id;default:0" json:"sku_id"`           // SKU ID，退货入库时归还到该 SKU
+	Quantity    int32        `gorm:"not null;column:quantity" json:"quantity"`                 // 退款数量
+	Amount      money.Amount `gorm:"not null;column:amount;type:decimal(20,2)" json:"amount"`  // 该项退款金额
+}
+
+// TableName 设置表名
+func (RefundItem) TableName() string {
+	return "refund_items"
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"time"
+
+	"douyin/consts"
+)
+
+// Shipment 发货单：子订单发货时创建，一个子订单只发货一次（包含子订单的全部订单项）
+// 物流状态由物流轨迹查询定期刷新；买家确认收货或发货超过自动确认期限后记录确认收货时间
+type Shipment struct {
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	OrderID       string          `gorm:"column:order_id;not null;size:64;index" json:"order_id"`               // 父订单ID
+	SubOrderID    string          `gorm:"column:sub_order_id;not null;size:64;uniqueIndex" json:"sub_order_id"` // 子订单ID
+	MerchantID    uint            `gorm:"column:merchant_id;not null;default:0;index" json:"merchant_id"`       // 发货商家ID，0 表示平台自营
+	Carrier       string          `gorm:"column:carrier;not null;size:32" json:"carrier"`                       // 快递公司编码，如 SF、YTO
+	TrackingNo    string          `gorm:"column:tracking_no;not null;size:64;index" json:"tracking_no"`         // 快递单号
+	Status        string          `gorm:"column:status;not null;size:20;index" json:"status"`                   // 物流状态，取值见 consts.ShipmentStatus*
+	Events        []TrackingEvent `gorm:"column:events;type:text;serializer:json" json:"events"`                // 最近一次查询到的物流轨迹
+	ShippedAt     time.Time       `gorm:"column:shipped_at" json:"shipped_at"`                                  // 发货时间
+	DeliveredAt   *time.Time      `gorm:"column:delivered_at" json:"delivered_at"`                              // 快递签收时间
+	ReceivedAt    *time.Time      `gorm:"column:received_at" json:"received_at"`                                // 确认收货时间（买家确认或自动确认）
+	AutoConfirmAt time.Time       `gorm:"column:auto_confirm_at;index" json:"auto_confirm_at"`                  // 超过该时间仍未确认收货时自动确认
+	LastTrackedAt *time.Time      `gorm:"column:last_tracked_at" json:"last_tracked_at"`                        // 最近一次查询物流轨迹的时间
+	CreatedAt     time.Time       `gorm:"column:created_at" json:"created_at"`                                  // 创建时间
+	UpdatedAt     time.Time       `gorm:"column:updated_at" json:"updated_at"`                                  // 更新时间
+	Items         []ShipmentItem  `gorm:"foreignKey:ShipmentID;constraint:OnDelete:CASCADE" json:"items"`       // 发货的订单项
+}
+
+// TableName 设置表名
+func (Shipment) TableName() string {
+	return "shipments"
+}
+
+// ShipmentItem 发货单中的订单项及发货数量
+type ShipmentItem struct {
+	ID          uint  `gorm:"primaryKey" json:"id"`
+	ShipmentID  uint  `gorm:"not null;column:shipment_id;index" json:"shipment_id"`     // 发货单ID
+	OrderItemID uint  `gorm:"not null;column:order_item_id;index" json:"order_item_id"` // 订单项ID
+	ProductID   uint  `gorm:"not null;column:product_id" json:"product_id"`             // 商品ID
+	SkuID       uint  `gorm:"not null;column:sku_id;default:0" json:"sku_id"`           // SKU ID
+	Quantity    int32 `gorm:"not null;column:quantity" json:"quantity"`                 // 发货数量
+}
+
+// TableName 设置表名
+func (ShipmentItem) TableName() string {
+	return "shipment_items"
+}
+
+// TrackingEvent 一条物流轨迹
+type TrackingEvent struct {
+	Time        time.Time `json:"time"`        // 发生时间
+	Location    string    `json:"location"`    // 所在地
+	Description string    `json:"description"` // 轨迹描述
+}
+
+// NewShipment 为子订单创建发货单，发货单包含子订单的全部订单项，自动确认收货时间为发货时间加 autoConfirmDays 天
+func NewShipment(subOrder *SubOrder, carrier, trackingNo string, shippedAt time.Time, autoConfirmDays int) *Shipment {
+	items := make([]ShipmentItem, 0, len(subOrder.OrderItems))
+	for _, item := range subOrder.OrderItems {
+		items = append(items, ShipmentItem{
+			OrderItemID: item.ID,
+			ProductID:   item.ProductID,
+			SkuID:       item.SkuID,
+			Quantity:    item.Quantity,
+		})
+	}
+	return &Shipment{
+		OrderID:       subOrder.OrderID,
+		SubOrderID:    subOrder.SubOrderID,
+		MerchantID:    subOrder.MerchantID,
+		Carrier:       carrier,
+		TrackingNo:    trackingNo,
+		Status:        consts.ShipmentStatusShipped,
+		ShippedAt:     shippedAt,
+		AutoConfirmAt: shippedAt.AddDate(0, 0, autoConfirmDays),
+		Items:         items,
+	}
+}
+
+// ApplyTracking 用物流轨迹查询结果更新发货单的物流状态与轨迹，返回物流状态是否发生变化
+// 已签收的发货单不会回退状态；首次签收时以最后一条轨迹的时间（无轨迹时为 now）作为签收时间
+func (s *Shipment) ApplyTracking(status string, events []TrackingEvent, now time.Time) bool {
+	s.LastTrackedAt = &now
+	if len(events) > 0 {
+		s.Events = events
+	}
+	if status == "" || status == s.Status || s.Status == consts.ShipmentStatusDelivered {
+		return false
+	}
+	s.Status = status
+	if status == consts.ShipmentStatusDelivered {
+		deliveredAt := now
+		if len(events) > 0 {
+			deliveredAt = events[len(events)-1].Time
+		}
+		s.DeliveredAt = &deliveredAt
+	}
+	return true
+}
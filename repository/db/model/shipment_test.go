@@ -0,0 +1,60 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"douyin/consts"
+)
+
+// TestNewShipment 校验发货单包含子订单全部订单项，并按天数计算自动确认收货时间
+func TestNewShipment(t *testing.T) {
+	shippedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	subOrder := &SubOrder{
+		SubOrderID: "o-1-1",
+		OrderID:    "o-1",
+		MerchantID: 3,
+		OrderItems: []OrderItem{
+			{ID: 11, ProductID: 5, SkuID: 7, Quantity: 2},
+			{ID: 12, ProductID: 6, Quantity: 1},
+		},
+	}
+
+	shipment := NewShipment(subOrder, "SF", "SF100", shippedAt, 10)
+	assert.Equal(t, "o-1", shipment.OrderID)
+	assert.Equal(t, "o-1-1", shipment.SubOrderID)
+	assert.Equal(t, uint(3), shipment.MerchantID)
+	assert.Equal(t, consts.ShipmentStatusShipped, shipment.Status)
+	assert.Equal(t, shippedAt.AddDate(0, 0, 10), shipment.AutoConfirmAt)
+	require.Len(t, shipment.Items, 2)
+	assert.Equal(t, ShipmentItem{OrderItemID: 11, ProductID: 5, SkuID: 7, Quantity: 2}, shipment.Items[0])
+	assert.Equal(t, uint(12), shipment.Items[1].OrderItemID)
+}
+
+func TestShipmentApplyTracking(t *testing.T) {
+	now := time.Date(2024, 5, 3, 12, 0, 0, 0, time.UTC)
+	signedAt := now.Add(-time.Hour)
+	shipment := &Shipment{Status: consts.ShipmentStatusShipped}
+
+	// 状态未变化时只更新轨迹与查询时间
+	assert.False(t, shipment.ApplyTracking(consts.ShipmentStatusShipped, nil, now))
+	assert.Equal(t, now, *shipment.LastTrackedAt)
+
+	events := []TrackingEvent{{Time: now.Add(-24 * time.Hour), Description: "已揽收"}}
+	assert.True(t, shipment.ApplyTracking(consts.ShipmentStatusInTransit, events, now))
+	assert.Equal(t, consts.ShipmentStatusInTransit, shipment.Status)
+	assert.Nil(t, shipment.DeliveredAt)
+
+	events = append(events, TrackingEvent{Time: signedAt, Description: "已签收"})
+	assert.True(t, shipment.ApplyTracking(consts.ShipmentStatusDelivered, events, now))
+	require.NotNil(t, shipment.DeliveredAt)
+	assert.Equal(t, signedAt, *shipment.DeliveredAt)
+	assert.Len(t, shipment.Events, 2)
+
+	// 已签收后不再回退
+	assert.False(t, shipment.ApplyTracking(consts.ShipmentStatusInTransit, nil, now))
+	assert.Equal(t, consts.ShipmentStatusDelivered, shipment.Status)
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+)
+
+// StockEvent 库存事件：库存变动跨越低库存阈值或从缺货恢复时，与库存变动在同一事务中写入
+// 库存通知 worker 轮询未处理的事件并发送邮件，邮件发送不影响库存事务本身
+type StockEvent struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Type        string     `gorm:"type:varchar(20);not null;column:type" json:"type"`    // 事件类型，取值见 consts.StockEvent*
+	ProductID   uint       `gorm:"not null;column:product_id;index" json:"product_id"`   // 商品ID
+	SkuID       uint       `gorm:"not null;column:sku_id" json:"sku_id"`                 // 触发事件的 SKU ID
+	Stock       int        `gorm:"not null;column:stock" json:"stock"`                   // 事件发生后的库存：低库存事件为商品总库存，到货事件为 SKU 库存
+	Threshold   int        `gorm:"not null;default:0;column:threshold" json:"threshold"` // 低库存事件触发时的预警阈值
+	ProcessedAt *time.Time `gorm:"column:processed_at;index" json:"processed_at"`        // 处理时间，为空表示待处理
+	CreatedAt   time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+// TableName 设置表名
+func (StockEvent) TableName() string {
+	return "stock_events"
+}
+
+// RestockSubscription 到货提醒订阅：SKU 缺货时用户订阅，库存恢复后发送一次邮件
+// 邮件发出后记录 NotifiedAt，用户重新订阅时清空，以便下一次到货时再次提醒
+type RestockSubscription struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;column:user_id;uniqueIndex:idx_restock_user_sku" json:"user_id"`     // 用户ID
+	SkuID      uint       `gorm:"not null;column:sku_id;uniqueIndex:idx_restock_user_sku;index" json:"sku_id"` // SKU ID
+	ProductID  uint       `gorm:"not null;column:product_id;index" json:"product_id"`                          // SKU 所属商品ID
+	Email      string     `gorm:"type:varchar(255);not null;column:email" json:"email"`                        // 订阅时用户的邮箱
+	NotifiedAt *time.Time `gorm:"column:notified_at" json:"notified_at"`                                       // 到货提醒发送时间，为空表示等待提醒
+	CreatedAt  time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// TableName 设置表名
+func (RestockSubscription) TableName() string {
+	return "restock_subscriptions"
+}
+
+// CrossedLowStock 判断库存从 before 变为 after 时是否降至低库存阈值及以下
+// 阈值为 0 表示不预警；已经处于低库存时继续减少不再重复触发
+func CrossedLowStock(before, after, threshold int) bool {
+	if threshold <= 0 {
+		return false
+	}
+	return before > threshold && after <= threshold
+}
+
+// CrossedBackInStock 判断库存从 before 变为 after 时是否从缺货恢复为有货
+func CrossedBackInStock(before, after int) bool {
+	return before <= 0 && after > 0
+}
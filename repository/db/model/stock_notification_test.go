@@ -0,0 +1,25 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCrossedLowStock 校验只有库存从阈值之上降至阈值及以下时才触发低库存预警
+func TestCrossedLowStock(t *testing.T) {
+	assert.True(t, CrossedLowStock(12, 10, 10))
+	assert.True(t, CrossedLowStock(12, 0, 10))
+	assert.False(t, CrossedLowStock(10, 8, 10), "已处于低库存时不重复预警")
+	assert.False(t, CrossedLowStock(8, 12, 10), "库存增加不预警")
+	assert.False(t, CrossedLowStock(5, 0, 0), "阈值为 0 表示不预警")
+}
+
+// TestCrossedBackInStock 校验只有库存从 0 及以下恢复为正数时才触发到货提醒
+func TestCrossedBackInStock(t *testing.T) {
+	assert.True(t, CrossedBackInStock(0, 3))
+	assert.True(t, CrossedBackInStock(-1, 1))
+	assert.False(t, CrossedBackInStock(2, 5), "原本有货不提醒")
+	assert.False(t, CrossedBackInStock(0, 0))
+	assert.False(t, CrossedBackInStock(3, 0))
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+)
+
+// StockReservation 购物车库存预占：加入购物车时为该用户的购物车行按 SKU 占用库存，到期自动失效
+// 预占不扣减 ProductSku.Stock，只在计算可售库存时从中减去其他用户未过期的预占；结算下单时才占用仓库库存并释放预占
+type StockReservation struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;column:user_id;uniqueIndex:idx_reservation_user_sku" json:"user_id"`     // 用户ID
+	SkuID     uint      `gorm:"not null;column:sku_id;uniqueIndex:idx_reservation_user_sku;index" json:"sku_id"` // SKU ID
+	ProductID uint      `gorm:"not null;column:product_id;index" json:"product_id"`                              // SKU 所属商品ID
+	Quantity  int       `gorm:"not null;column:quantity" json:"quantity"`                                        // 预占数量，与购物车行数量一致
+	ExpiresAt time.Time `gorm:"not null;column:expires_at;index" json:"expires_at"`                              // 过期时间，过期后不再占用库存
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// TableName 设置表名
+func (StockReservation) TableName() string {
+	return "stock_reservations"
+}
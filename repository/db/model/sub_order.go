@@ -0,0 +1,90 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"douyin/consts"
+	"douyin/pkg/utils/money"
+)
+
+// SubOrder 子订单：下单时按商品所属商家拆分，每个子订单独立发货、收货与售后
+// 支付只针对父订单（Order）进行一次，支付成功、取消或超时关闭时子订单随父订单一起流转；
+// 之后子订单各自流转，父订单状态由子订单状态汇总得到（见 ParentOrderStatus）
+type SubOrder struct {
+	SubOrderID     string       `gorm:"primaryKey;column:sub_order_id;size:64" json:"sub_order_id"`                          // 子订单ID，为父订单ID加序号
+	OrderID        string       `gorm:"column:order_id;not null;size:64;index" json:"order_id"`                              // 父订单ID
+	UserID         uint         `gorm:"column:user_id;not null;index" json:"user_id"`                                        // 买家用户ID
+	MerchantID     uint         `gorm:"column:merchant_id;not null;default:0;index" json:"merchant_id"`                      // 商家ID，0 表示平台自营
+	Status         int          `gorm:"column:status;not null;default:1;index" json:"status"`                                // 子订单状态，取值见 consts.OrderType*
+	Amount         money.Amount `gorm:"column:amount;type:decimal(20,2);not null" json:"amount"`                             // 子订单实付金额（订单币种），即订单项金额减去分摊的优惠
+	DiscountAmount money.Amount `gorm:"column:discount_amount;type:decimal(20,2);not null;default:0" json:"discount_amount"` // 子订单订单项分摊到的优惠合计
+	CreatedAt      time.Time    `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt      time.Time    `gorm:"column:updated_at" json:"updated_at"`
+	OrderItems     []OrderItem  `gorm:"foreignKey:SubOrderID;references:SubOrderID;constraint:-" json:"order_items"` // 子订单的订单项
+	Order          *Order       `gorm:"foreignKey:OrderID;references:OrderID" json:"-"`                              // 父订单，只在商家查询时预加载
+}
+
+// TableName 设置表名
+func (SubOrder) TableName() string {
+	return "sub_orders"
+}
+
+// CanTransitTo 判断子订单能否从当前状态流转到目标状态，状态机与订单相同
+func (s *SubOrder) CanTransitTo(status int) bool {
+	for _, next := range consts.OrderStatusTransitions[s.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusText 返回子订单状态的中文描述
+func (s *SubOrder) StatusText() string {
+	return consts.OrderTypeMap[s.Status]
+}
+
+// SplitSubOrders 按商家拆分订单项，每个商家（含平台自营）一个子订单，顺序与商家在订单项中首次出现的顺序一致
+// 拆分结果的状态与父订单相同，并为 items 回填所属的子订单ID；订单项的优惠需已分摊完毕
+func SplitSubOrders(order *Order, items []OrderItem) []SubOrder {
+	var subOrders []SubOrder
+	indexByMerchant := make(map[uint]int)
+	for i := range items {
+		idx, ok := indexByMerchant[items[i].MerchantID]
+		if !ok {
+			idx = len(subOrders)
+			indexByMerchant[items[i].MerchantID] = idx
+			subOrders = append(subOrders, SubOrder{
+				SubOrderID: fmt.Sprintf("%s-%d", order.OrderID, idx+1),
+				OrderID:    order.OrderID,
+				UserID:     order.UserID,
+				MerchantID: items[i].MerchantID,
+				Status:     order.Status,
+				CreatedAt:  order.CreatedAt,
+			})
+		}
+		items[i].SubOrderID = subOrders[idx].SubOrderID
+		subOrders[idx].Amount += items[i].Cost.Mul(int64(items[i].Quantity)) - items[i].Discount
+		subOrders[idx].DiscountAmount += items[i].Discount
+	}
+	return subOrders
+}
+
+// ParentOrderStatus 由子订单状态汇总父订单状态：已退款的子订单不参与汇总，其余子订单取履约进度最慢的状态
+// （待支付、待发货、待收货、已收货按进度递增），全部子订单都已退款时父订单为已退款；没有子订单时返回 0
+func ParentOrderStatus(subOrders []SubOrder) int {
+	status := 0
+	for _, sub := range subOrders {
+		if sub.Status == consts.OrderTypeRefunded {
+			continue
+		}
+		if status == 0 || sub.Status < status {
+			status = sub.Status
+		}
+	}
+	if status == 0 && len(subOrders) > 0 {
+		return consts.OrderTypeRefunded
+	}
+	return status
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"douyin/consts"
+)
+
+// TestSplitSubOrders 校验按商家拆分子订单并回填订单项的子订单ID
+func TestSplitSubOrders(t *testing.T) {
+	order := &Order{OrderID: "o-1", UserID: 9, Status: consts.OrderTypeUnPaid}
+	items := []OrderItem{
+		{MerchantID: 2, Cost: 1000, Quantity: 2, Discount: 100},
+		{MerchantID: 0, Cost: 500, Quantity: 1},
+		{MerchantID: 2, Cost: 300, Quantity: 1, Discount: 20},
+	}
+
+	subOrders := SplitSubOrders(order, items)
+	require.Len(t, subOrders, 2)
+	assert.Equal(t, "o-1-1", subOrders[0].SubOrderID)
+	assert.Equal(t, uint(2), subOrders[0].MerchantID)
+	assert.EqualValues(t, 2180, subOrders[0].Amount)
+	assert.EqualValues(t, 120, subOrders[0].DiscountAmount)
+	assert.Equal(t, "o-1-2", subOrders[1].SubOrderID)
+	assert.EqualValues(t, 500, subOrders[1].Amount)
+	assert.Equal(t, consts.OrderTypeUnPaid, subOrders[1].Status)
+	assert.Equal(t, uint(9), subOrders[1].UserID)
+
+	assert.Equal(t, []string{"o-1-1", "o-1-2", "o-1-1"}, []string{items[0].SubOrderID, items[1].SubOrderID, items[2].SubOrderID})
+}
+
+func TestParentOrderStatus(t *testing.T) {
+	subs := func(statuses ...int) []SubOrder {
+		result := make([]SubOrder, 0, len(statuses))
+		for _, status := range statuses {
+			result = append(result, SubOrder{Status: status})
+		}
+		return result
+	}
+
+	assert.Equal(t, 0, ParentOrderStatus(nil))
+	assert.Equal(t, consts.OrderTypePendingShipping, ParentOrderStatus(subs(consts.OrderTypeShipping, consts.OrderTypePendingShipping)))
+	assert.Equal(t, consts.OrderTypeShipping, ParentOrderStatus(subs(consts.OrderTypeShipping, consts.OrderTypeReceipt)))
+	assert.Equal(t, consts.OrderTypeReceipt, ParentOrderStatus(subs(consts.OrderTypeRefunded, consts.OrderTypeReceipt)), "已退款的子订单不参与汇总")
+	assert.Equal(t, consts.OrderTypeRefunded, ParentOrderStatus(subs(consts.OrderTypeRefunded, consts.OrderTypeRefunded)))
+	assert.Equal(t, consts.OrderTypeClosed, ParentOrderStatus(subs(consts.OrderTypeClosed, consts.OrderTypeClosed)))
+}
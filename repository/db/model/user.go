@@ -20,7 +20,8 @@ type User struct {
 	NickName       string    `gorm:"column:nick_name;type:varchar(255)"`         // 昵称
 	Status         string    `gorm:"type:varchar(50);default:'active'"`          // 用户状态，默认激活
 	Avatar         string    `gorm:"type:varchar(1000)"`                         // 头像
-	Money          string    `gorm:"type:varchar(255)"`                          // 用户余额（直接存储字符串，不做加解密）
+	Money          string    `gorm:"type:varchar(255)"`                          // 历史余额，仅用于开通钱包时迁移期初余额，之后以 wallets 表为准
+	Currency       string    `gorm:"type:varchar(10)"`                           // 偏好币种，用于商品价格展示和下单默认币种，为空时使用基础币种
 	Relations      []User    `gorm:"many2many:relation;"`                        // 用户之间的关系
 }
 
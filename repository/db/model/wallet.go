@@ -0,0 +1,43 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Wallet 用户钱包，缓存当前余额，所有余额变动都必须加行锁并同时写入 WalletTransaction
+type Wallet struct {
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false;column:user_id" json:"user_id"` // 用户ID
+	Balance   string    `gorm:"not null;column:balance;size:255" json:"-"`                    // 余额（分），配置 MoneySecret 时加密存储
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// TableName 设置表名
+func (Wallet) TableName() string {
+	return "wallets"
+}
+
+// WalletTransaction 钱包复式记账流水，同一 TxnNo 下各账户金额之和为 0
+type WalletTransaction struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	TxnNo        string    `gorm:"not null;column:txn_no;size:64;index" json:"txn_no"`   // 记账凭证号，借贷两条记录相同
+	Account      string    `gorm:"not null;column:account;size:64;index" json:"account"` // 账户，用户钱包为 user:{id}，系统账户见 consts.WalletAccount*
+	UserID       uint      `gorm:"column:user_id;index" json:"user_id"`                  // 关联用户
+	Type         string    `gorm:"not null;column:type;size:20" json:"type"`             // 流水类型，取值见 consts.WalletTxn*
+	Amount       int64     `gorm:"not null;column:amount" json:"amount"`                 // 金额（分），正数入账、负数出账
+	BalanceAfter string    `gorm:"column:balance_after;size:255" json:"-"`               // 变动后余额（分），仅用户钱包记录，加密规则同 Wallet.Balance
+	RefID        string    `gorm:"column:ref_id;size:64;index" json:"ref_id"`            // 关联业务单号（交易ID、售后单号等）
+	Remark       string    `gorm:"column:remark;size:255" json:"remark"`                 // 备注
+	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"`                  // 记账时间
+}
+
+// TableName 设置表名
+func (WalletTransaction) TableName() string {
+	return "wallet_transactions"
+}
+
+// WalletUserAccount 返回用户钱包的记账账户名
+func WalletUserAccount(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
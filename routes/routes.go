@@ -30,13 +30,32 @@ func NewRouter(engine *gin.Engine, db *gorm.DB) {
 			v1.UserLoginHandler(), // 用户登录接口
 		)
 
-		apiV1.POST("/product/get", v1.GetProduct)   // 获取单个商品接口
-		apiV1.GET("/product/list", v1.ListProducts) // 获取商品列表接口
+		// 支付渠道异步通知，通过 HMAC 签名鉴权
+		apiV1.POST("/payment/callback/:provider", v1.PaymentCallbackHandler())
+
+		// 商品价格按登录用户的偏好币种展示，未登录时使用基础币种
+		optionalAuth := middleware.OptionalAuthMiddleware()
+		apiV1.POST("/product/get", optionalAuth, v1.GetProduct)       // 获取单个商品接口
+		apiV1.GET("/product/list", optionalAuth, v1.ListProducts)     // 获取商品列表接口
+		apiV1.GET("/product/search", optionalAuth, v1.SearchProducts) // 商品搜索接口
+
+		apiV1.GET("/exchange-rate/list", v1.ExchangeRateListHandler()) // 汇率列表接口
+		apiV1.GET("/flash-sale/list", v1.FlashSaleListHandler())       // 进行中及即将开始的秒杀场次接口
+		apiV1.GET("/category/tree", v1.CategoryTreeHandler())          // 商品分类树接口
+
+		// 游客购物车，令牌保存在签名 Cookie 中，登录时合并到用户购物车
+		apiV1.POST("/guest-cart/get", v1.GuestCartGetHandler())                     // 获取游客购物车接口
+		apiV1.POST("/guest-cart/add", v1.GuestCartAddHandler())                     // 添加游客购物车商品接口
+		apiV1.POST("/guest-cart/update_quantity", v1.GuestCartSetQuantityHandler()) // 设置游客购物车商品数量接口
+		apiV1.POST("/guest-cart/remove", v1.GuestCartRemoveHandler())               // 移除游客购物车商品接口
+		apiV1.POST("/guest-cart/empty", v1.GuestCartEmptyHandler())                 // 清空游客购物车接口
 
 		// 定义需要登录验证的接口分组
 		authGroup := apiV1.Group("")
 		// 应用身份验证中间件
 		authGroup.Use(middleware.AuthMiddleware())
+		// 下单类接口支持 Idempotency-Key，重复提交在 24 小时内返回首次结果
+		idempotent := middleware.Idempotency(24 * time.Hour)
 		{
 			// 用户相关接口
 			authGroup.POST("user/change_password", v1.UserChangePasswordHandler()) // 修改密码接口
@@ -46,22 +65,115 @@ func NewRouter(engine *gin.Engine, db *gorm.DB) {
 			authGroup.POST("user/logout", v1.UserLogoutHandler())                  // 用户登出接口
 
 			// 订单相关接口
-			authGroup.POST("order/create", v1.OrderCreateHandler()) // 创建订单接口
-			authGroup.POST("order/update", v1.OrderUpdateHandler()) // 更新订单接口
+			authGroup.POST("order/create", idempotent, v1.OrderCreateHandler())      // 创建订单接口
+			authGroup.POST("order/update", v1.OrderUpdateHandler())                  // 更新订单接口
+			authGroup.GET("order/list", v1.OrderListHandler())                       // 订单列表接口
+			authGroup.GET("order/:id", v1.OrderDetailHandler())                      // 订单详情接口
+			authGroup.GET("order/:id/history", v1.OrderHistoryHandler())             // 订单状态流转记录接口
+			authGroup.GET("order/:id/shipments", v1.OrderShipmentListHandler())      // 订单物流查询接口
+			authGroup.POST("order/confirm_receipt", v1.OrderConfirmReceiptHandler()) // 确认收货接口
+
+			// 支付相关接口
+			authGroup.POST("payment/pay", idempotent, v1.PaymentPayHandler()) // 发起支付接口
+			authGroup.GET("payment/:transaction_id", v1.PaymentGetHandler())  // 查询支付单接口
+
+			// 钱包相关接口
+			authGroup.GET("user/wallet/transactions", v1.WalletTransactionsHandler()) // 钱包流水接口
+
+			// 钱包管理接口（需要 wallet:manage 权限）
+			authGroup.POST("admin/wallet/top_up", middleware.RBAC("wallet:manage"), idempotent, v1.AdminWalletTopUpHandler())  // 登记充值到账接口
+			authGroup.POST("admin/wallet/adjust", middleware.RBAC("wallet:manage"), idempotent, v1.AdminWalletAdjustHandler()) // 人工调整余额接口
+
+			// 售后相关接口
+			authGroup.POST("refund/apply", v1.RefundApplyHandler())      // 申请退款/退货接口
+			authGroup.GET("refund/list", v1.RefundListHandler())         // 售后单列表接口
+			authGroup.GET("refund/:refund_no", v1.RefundDetailHandler()) // 售后单详情接口
+
+			// 售后审核接口（需要 refund:review 权限）
+			authGroup.GET("admin/refund/list", middleware.RBAC("refund:review"), v1.AdminRefundListHandler())      // 管理员售后单列表接口
+			authGroup.POST("admin/refund/review", middleware.RBAC("refund:review"), v1.AdminRefundReviewHandler()) // 管理员审核售后接口
+
+			// 优惠券相关接口
+			authGroup.GET("coupon/list", v1.CouponListHandler())    // 可领取优惠券列表接口
+			authGroup.POST("coupon/claim", v1.CouponClaimHandler()) // 领取优惠券接口
+			authGroup.GET("coupon/mine", v1.CouponMineHandler())    // 我的优惠券接口
+
+			// 优惠券管理接口（需要 coupon:manage 权限）
+			authGroup.POST("admin/coupon/create", middleware.RBAC("coupon:manage"), v1.AdminCouponCreateHandler()) // 创建优惠券模板接口
+			authGroup.GET("admin/coupon/list", middleware.RBAC("coupon:manage"), v1.AdminCouponListHandler())      // 优惠券模板列表接口
+			authGroup.POST("admin/coupon/status", middleware.RBAC("coupon:manage"), v1.AdminCouponStatusHandler()) // 启用/停用优惠券模板接口
+
+			// 商品分类管理接口（需要 category:manage 权限）
+			authGroup.POST("admin/category/create", middleware.RBAC("category:manage"), v1.AdminCategoryCreateHandler())                // 创建分类接口
+			authGroup.POST("admin/category/update", middleware.RBAC("category:manage"), v1.AdminCategoryUpdateHandler())                // 修改分类接口
+			authGroup.POST("admin/category/delete", middleware.RBAC("category:manage"), v1.AdminCategoryDeleteHandler())                // 删除分类接口
+			authGroup.POST("admin/category/assign_product", middleware.RBAC("category:manage"), v1.AdminProductCategoryAssignHandler()) // 设置商品分类接口
+
+			// 秒杀相关接口，抢购按用户限流，抢到名额后轮询结果
+			authGroup.POST("flash-sale/buy",
+				middleware.RateLimitMiddleware(cache.Rdb, "flash_sale", 5, 1*time.Second),
+				v1.FlashSaleBuyHandler(), // 秒杀抢购接口
+			)
+			authGroup.GET("flash-sale/result", v1.FlashSaleResultHandler()) // 秒杀结果查询接口
+
+			// 秒杀管理接口（需要 flash_sale:manage 权限）
+			authGroup.POST("admin/flash-sale/create", middleware.RBAC("flash_sale:manage"), v1.AdminFlashSaleCreateHandler()) // 创建秒杀场次接口
+			authGroup.GET("admin/flash-sale/list", middleware.RBAC("flash_sale:manage"), v1.AdminFlashSaleListHandler())      // 秒杀场次列表接口
+			authGroup.POST("admin/flash-sale/status", middleware.RBAC("flash_sale:manage"), v1.AdminFlashSaleStatusHandler()) // 启用/停用秒杀场次接口
+
+			// 仓库与库存管理接口（需要 inventory:manage 权限）
+			authGroup.POST("admin/warehouse/create", middleware.RBAC("inventory:manage"), v1.AdminWarehouseCreateHandler())         // 创建仓库接口
+			authGroup.POST("admin/warehouse/update", middleware.RBAC("inventory:manage"), v1.AdminWarehouseUpdateHandler())         // 修改仓库接口
+			authGroup.GET("admin/warehouse/list", middleware.RBAC("inventory:manage"), v1.AdminWarehouseListHandler())              // 仓库列表接口
+			authGroup.POST("admin/inventory/receive", middleware.RBAC("inventory:manage"), v1.AdminInventoryReceiveHandler())       // 入库接口
+			authGroup.POST("admin/inventory/adjust", middleware.RBAC("inventory:manage"), v1.AdminInventoryAdjustHandler())         // 盘点调整接口
+			authGroup.GET("admin/inventory/levels", middleware.RBAC("inventory:manage"), v1.AdminInventoryLevelListHandler())       // 库存水位查询接口
+			authGroup.GET("admin/inventory/movements", middleware.RBAC("inventory:manage"), v1.AdminInventoryMovementListHandler()) // 库存流水查询接口
+			authGroup.POST("admin/inventory/reconcile", middleware.RBAC("inventory:manage"), v1.AdminInventoryReconcileHandler())   // 库存对账接口
+
+			// 汇率管理接口（需要 exchange_rate:manage 权限）
+			authGroup.POST("admin/exchange-rate/update", middleware.RBAC("exchange_rate:manage"), v1.AdminExchangeRateUpdateHandler()) // 批量更新汇率接口
+			authGroup.POST("admin/exchange-rate/reload", middleware.RBAC("exchange_rate:manage"), v1.AdminExchangeRateReloadHandler()) // 从汇率文件重新导入接口
 
 			// 商品相关接口
-			authGroup.POST("product/create", v1.CreateProduct)          // 创建商品接口
-			authGroup.POST("product/update", v1.UpdateProduct)          // 更新商品接口
-			authGroup.POST("product/delete", v1.DeleteProduct)          // 删除商品接口
-			authGroup.POST("checkout/order", v1.CheckoutOrderHandler()) // 结算订单接口
+			authGroup.POST("product/create", v1.CreateProduct)                      // 创建商品接口
+			authGroup.POST("product/update", v1.UpdateProduct)                      // 更新商品接口
+			authGroup.POST("product/delete", v1.DeleteProduct)                      // 删除商品接口
+			authGroup.POST("checkout/order", idempotent, v1.CheckoutOrderHandler()) // 结算订单接口
+
+			// 商家相关接口，店铺管理与查询只对当前用户自己的店铺生效
+			authGroup.POST("merchant/register", v1.MerchantRegisterHandler())             // 开设店铺接口
+			authGroup.GET("merchant/profile", v1.MerchantProfileHandler())                // 店铺资料接口
+			authGroup.POST("merchant/update", v1.MerchantUpdateHandler())                 // 修改店铺资料接口
+			authGroup.GET("merchant/product/list", v1.MerchantProductListHandler())       // 店铺商品列表接口
+			authGroup.GET("merchant/order/list", v1.MerchantOrderListHandler())           // 店铺订单列表接口
+			authGroup.GET("merchant/order/:id", v1.MerchantOrderDetailHandler())          // 店铺订单详情接口
+			authGroup.POST("merchant/order/ship", v1.MerchantOrderShipHandler())          // 商家发货接口
+			authGroup.GET("merchant/settlement/list", v1.MerchantSettlementListHandler()) // 店铺结算明细接口
+
+			// 商家管理接口（需要 merchant:manage 权限）
+			authGroup.GET("admin/merchant/list", middleware.RBAC("merchant:manage"), v1.AdminMerchantListHandler())      // 商家列表接口
+			authGroup.POST("admin/merchant/status", middleware.RBAC("merchant:manage"), v1.AdminMerchantStatusHandler()) // 启用/停用商家接口
+			authGroup.POST("admin/merchant/settle", middleware.RBAC("merchant:manage"), v1.AdminMerchantSettleHandler()) // 货款结算接口
+
+			// 发货管理接口（需要 order:ship 权限），用于平台自营商品发货或代商家发货
+			authGroup.POST("admin/order/ship", middleware.RBAC("order:ship"), v1.AdminOrderShipHandler()) // 管理员发货接口
+
+			// 到货提醒相关接口
+			authGroup.POST("product/restock/subscribe", v1.RestockSubscribeHandler())     // 订阅到货提醒接口
+			authGroup.POST("product/restock/unsubscribe", v1.RestockUnsubscribeHandler()) // 取消到货提醒接口
+			authGroup.GET("product/restock/list", v1.RestockListHandler())                // 我的到货提醒接口
 
 			// 购物车相关接口
 			// 创建 CartController 的实例，传入数据库实例 db
 			cartController := v1.NewCartController(db)
-			authGroup.POST("cart/create", cartController.CreateCart) // 创建购物车接口
-			authGroup.POST("cart/get", cartController.GetCart)       // 获取购物车信息接口
-			authGroup.POST("cart/empty", cartController.EmptyCart)   // 清空购物车接口
-			authGroup.POST("cart/add", cartController.AddItem)       // 添加或更新购物车商品接口
+			authGroup.POST("cart/create", cartController.CreateCart)           // 创建购物车接口
+			authGroup.POST("cart/get", cartController.GetCart)                 // 获取购物车信息接口
+			authGroup.POST("cart/empty", cartController.EmptyCart)             // 清空购物车接口
+			authGroup.POST("cart/add", cartController.AddItem)                 // 添加或更新购物车商品接口
+			authGroup.POST("cart/remove", cartController.RemoveItem)           // 移除购物车商品接口
+			authGroup.POST("cart/update_quantity", cartController.SetQuantity) // 设置购物车商品数量接口
+			authGroup.POST("cart/select", cartController.SelectItems)          // 勾选/取消勾选购物车商品接口
 		}
 	}
 }
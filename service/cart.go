@@ -2,20 +2,31 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"time"
+
+	"douyin/config"
+	"douyin/consts"
+	"douyin/pkg/utils/log"
 	"douyin/repository/db/dao"
 	"douyin/repository/db/model"
+	"douyin/types"
 	"gorm.io/gorm"
 )
 
 // CartService 购物车服务，封装购物车业务逻辑
 type CartService struct {
-	dao *dao.CartDao
+	dao            *dao.CartDao
+	reservationDao *dao.StockReservationDao
+	rates          *ExchangeRateService
 }
 
 // NewCartService 创建 CartService 实例
 func NewCartService(db *gorm.DB) *CartService {
 	return &CartService{
-		dao: dao.NewCartDao(db),
+		dao:            dao.NewCartDao(db),
+		reservationDao: dao.NewStockReservationDao(db),
+		rates:          NewExchangeRateService(db),
 	}
 }
 
@@ -25,23 +36,202 @@ func (s *CartService) CreateCart(ctx context.Context, userID uint) error {
 	return s.dao.CreateCart(ctx, userID)
 }
 
-// GetCart 获取用户购物车信息
-func (s *CartService) GetCart(ctx context.Context, userID uint) ([]model.CartItem, error) {
-	// UserID is now passed directly
-	return s.dao.GetCart(ctx, userID)
+// GetCart 获取用户购物车信息，补充 SKU 当前的名称、图片、价格与可售数量，价格按用户偏好币种展示
+func (s *CartService) GetCart(ctx context.Context, userID uint) (*types.CartResp, error) {
+	items, err := s.dao.GetCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	skuIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		skuIDs = append(skuIDs, item.SkuID)
+	}
+	skus, err := s.dao.ListCartSkus(ctx, skuIDs)
+	if err != nil {
+		return nil, err
+	}
+	reserved, err := s.reservationDao.SumReservedByOthersBatch(ctx, skuIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := s.rates.DisplayQuote(ctx, userID, "")
+	if err != nil {
+		return nil, err
+	}
+	return buildCartResp(items, skus, reserved, quote), nil
 }
 
-// EmptyCart 清空购物车
+// buildCartResp 组装购物车响应，已下架的商品或 SKU 保留在列表中但标记为无效，不计入金额合计；reserved 的 key 为 SKU ID
+func buildCartResp(items []model.CartItem, skus []model.ProductSku, reserved map[uint]int, quote *ExchangeQuote) *types.CartResp {
+	skuMap := make(map[uint]model.ProductSku, len(skus))
+	for _, sku := range skus {
+		skuMap[sku.ID] = sku
+	}
+
+	resp := &types.CartResp{
+		Items:    make([]types.CartItemResp, 0, len(items)),
+		Currency: quote.Currency,
+	}
+	for _, item := range items {
+		line := types.CartItemResp{
+			ProductID: item.ProductID,
+			SkuID:     item.SkuID,
+			Quantity:  item.Quantity,
+			Selected:  item.Selected,
+		}
+		resp.TotalQuantity += int64(item.Quantity)
+		if item.Selected {
+			resp.SelectedQuantity += int64(item.Quantity)
+		}
+
+		sku, ok := skuMap[item.SkuID]
+		if !ok {
+			line.Invalid = true
+			line.Warnings = cartItemWarnings(item, nil, line)
+			resp.HasWarnings = true
+			resp.Items = append(resp.Items, line)
+			continue
+		}
+		line.ProductID = sku.ProductID
+		line.SkuSpecs = sku.SpecKey
+		line.ProductName = sku.Product.Name
+		line.Picture = sku.DisplayPicture()
+		line.Price = quote.Convert(sku.Price)
+		line.Subtotal = line.Price.Mul(int64(item.Quantity))
+		line.Available = sku.Stock - reserved[item.SkuID]
+		if line.Available < 0 {
+			line.Available = 0
+		}
+		line.InStock = line.Available >= int(item.Quantity)
+		if item.PriceSnapshot > 0 {
+			line.SnapshotPrice = quote.Convert(item.PriceSnapshot)
+		}
+		line.Warnings = cartItemWarnings(item, &sku, line)
+		resp.HasWarnings = resp.HasWarnings || len(line.Warnings) > 0
+
+		resp.TotalAmount += line.Subtotal
+		if item.Selected {
+			resp.SelectedAmount += line.Subtotal
+		}
+		resp.Items = append(resp.Items, line)
+	}
+	return resp
+}
+
+// cartItemWarnings 对比购物车行与 SKU 当前信息，生成价格变动、库存不足、商品下架提示
+// sku 为 nil 表示商品或 SKU 已下架；价格以基础币种比较，提示中的金额为 line 中已换算的展示币种金额；没有价格快照的行不比较价格
+func cartItemWarnings(item model.CartItem, sku *model.ProductSku, line types.CartItemResp) []types.CartWarning {
+	if sku == nil {
+		return []types.CartWarning{{
+			ProductID: item.ProductID,
+			SkuID:     item.SkuID,
+			Type:      consts.CartWarningProductDeleted,
+			Message:   "商品已下架",
+		}}
+	}
+
+	name := sku.Product.Name
+	if sku.SpecKey != "" {
+		name += "（" + sku.SpecKey + "）"
+	}
+	var warnings []types.CartWarning
+	if item.PriceSnapshot > 0 && sku.Price != item.PriceSnapshot {
+		warning := types.CartWarning{
+			ProductID:     item.ProductID,
+			SkuID:         item.SkuID,
+			Type:          consts.CartWarningPriceIncreased,
+			Message:       fmt.Sprintf("%s 已涨价，单价由 %s 变为 %s", name, line.SnapshotPrice, line.Price),
+			SnapshotPrice: line.SnapshotPrice,
+			CurrentPrice:  line.Price,
+		}
+		if sku.Price < item.PriceSnapshot {
+			warning.Type = consts.CartWarningPriceDecreased
+			warning.Message = fmt.Sprintf("%s 已降价，单价由 %s 变为 %s", name, line.SnapshotPrice, line.Price)
+		}
+		warnings = append(warnings, warning)
+	}
+	if !line.InStock {
+		message := fmt.Sprintf("%s 库存不足，当前仅剩 %d 件", name, line.Available)
+		if line.Available == 0 {
+			message = name + " 已售罄"
+		}
+		warnings = append(warnings, types.CartWarning{
+			ProductID: item.ProductID,
+			SkuID:     item.SkuID,
+			Type:      consts.CartWarningInsufficientStock,
+			Message:   message,
+			Available: line.Available,
+		})
+	}
+	return warnings
+}
+
+// EmptyCart 清空购物车，并立即释放全部库存预占
 func (s *CartService) EmptyCart(ctx context.Context, userID uint) error {
 	// UserID is now passed directly
 	return s.dao.EmptyCart(ctx, userID)
 }
 
-// AddItem 往购物车中添加(或更新)商品
-// 由于本例中只做简单转发给 dao，因此可以在此处加一些
-// 例如商品合法性校验、库存校验等高级逻辑
-func (s *CartService) AddItem(ctx context.Context, userID, productID uint, quantity int32) error {
-	// 此处可添加业务逻辑，比如先查库存是否足够等...
-	// The DAO layer now handles stock checking and deduction within a transaction.
-	return s.dao.AddItem(ctx, userID, productID, quantity)
+// AddItem 往购物车中添加(或更新)商品，skuID 为 0 时使用商品唯一的 SKU
+// 不扣减库存，而是为购物车行预占 SKU 库存，预占在配置的有效期后自动失效，每次加购都会顺延有效期
+func (s *CartService) AddItem(ctx context.Context, userID, productID, skuID uint, quantity int32) error {
+	return s.dao.AddItem(ctx, userID, productID, skuID, quantity, time.Now().Add(config.GetCartReservationTTL()))
+}
+
+// RemoveItems 从购物车中移除指定 SKU，并释放对应的库存预占
+func (s *CartService) RemoveItems(ctx context.Context, userID uint, skuIDs []uint) error {
+	return s.dao.RemoveItems(ctx, userID, skuIDs)
+}
+
+// SetQuantity 将购物车商品设置为指定数量，为 0 时移除该商品；预占有效期同样顺延
+func (s *CartService) SetQuantity(ctx context.Context, userID, productID, skuID uint, quantity int32) error {
+	return s.dao.SetQuantity(ctx, userID, productID, skuID, quantity, time.Now().Add(config.GetCartReservationTTL()))
+}
+
+// SelectItems 勾选或取消勾选购物车商品，skuIDs 为空时作用于整个购物车
+func (s *CartService) SelectItems(ctx context.Context, userID uint, skuIDs []uint, selected bool) error {
+	affected, err := s.dao.SetSelected(ctx, userID, skuIDs, selected)
+	if err != nil {
+		return err
+	}
+	if affected == 0 && len(skuIDs) > 0 {
+		// 状态未变化时也会返回 0 行，因此只有商品确实不在购物车中才报错
+		items, err := s.dao.GetCart(ctx, userID)
+		if err != nil {
+			return err
+		}
+		inCart := make(map[uint]bool, len(items))
+		for _, item := range items {
+			inCart[item.SkuID] = true
+		}
+		for _, id := range skuIDs {
+			if !inCart[id] {
+				return dao.ErrCartItemNotFound
+			}
+		}
+	}
+	return nil
+}
+
+// ListenAndReleaseExpired 定期清理过期的库存预占，与 OrderService.ListenAndCloseUnpaid 一样作为后台协程运行
+// 过期预占在查询可售库存时已被忽略，清理只是回收存储，因此执行失败时等待下一轮即可
+func (s *CartService) ListenAndReleaseExpired(ctx context.Context) {
+	ticker := time.NewTicker(config.GetCartCleanupInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Infof("库存预占清理 worker 退出")
+			return
+		case now := <-ticker.C:
+			released, err := s.reservationDao.DeleteExpired(ctx, now)
+			if err != nil {
+				log.Errorf("清理过期库存预占失败: %v", err)
+				continue
+			}
+			if released > 0 {
+				log.Infof("已释放 %d 条过期库存预占", released)
+			}
+		}
+	}
 }
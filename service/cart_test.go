@@ -0,0 +1,86 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"douyin/consts"
+	"douyin/pkg/utils/money"
+	"douyin/repository/db/model"
+	"douyin/types"
+)
+
+func TestBuildCartResp(t *testing.T) {
+	rate, err := money.ParseRate("2")
+	require.NoError(t, err)
+	quote := &ExchangeQuote{Base: "USD", Currency: "EUR", Rate: rate}
+
+	items := []model.CartItem{
+		{ProductID: 1, SkuID: 11, Quantity: 2, Selected: true, PriceSnapshot: 1000},
+		{ProductID: 2, SkuID: 21, Quantity: 3, Selected: false, PriceSnapshot: 100},
+		{ProductID: 3, SkuID: 31, Quantity: 1, Selected: true}, // 商品已下架
+	}
+	skus := []model.ProductSku{
+		{ID: 11, ProductID: 1, SpecKey: "颜色:白", Picture: "cup-white.png", Price: 1050, Stock: 5,
+			Product: model.Product{ID: 1, Name: "杯子", Picture: "cup.png"}},
+		{ID: 21, ProductID: 2, Price: 100, Stock: 4, Product: model.Product{ID: 2, Name: "勺子", Picture: "spoon.png"}},
+	}
+	reserved := map[uint]int{21: 2}
+
+	resp := buildCartResp(items, skus, reserved, quote)
+	require.Len(t, resp.Items, 3)
+	assert.Equal(t, "EUR", resp.Currency)
+
+	cup := resp.Items[0]
+	assert.Equal(t, "杯子", cup.ProductName)
+	assert.Equal(t, uint(11), cup.SkuID)
+	assert.Equal(t, "颜色:白", cup.SkuSpecs)
+	assert.Equal(t, "cup-white.png", cup.Picture)
+	assert.Equal(t, money.Amount(2100), cup.Price)
+	assert.Equal(t, money.Amount(4200), cup.Subtotal)
+	assert.Equal(t, 5, cup.Available)
+	assert.True(t, cup.InStock)
+	assert.Equal(t, money.Amount(2000), cup.SnapshotPrice)
+	require.Len(t, cup.Warnings, 1)
+	assert.Equal(t, consts.CartWarningPriceIncreased, cup.Warnings[0].Type)
+	assert.Equal(t, money.Amount(2000), cup.Warnings[0].SnapshotPrice)
+	assert.Equal(t, money.Amount(2100), cup.Warnings[0].CurrentPrice)
+
+	spoon := resp.Items[1]
+	assert.Equal(t, "spoon.png", spoon.Picture, "SKU 没有图片时使用商品图片")
+	assert.Equal(t, 2, spoon.Available)
+	assert.False(t, spoon.InStock)
+	assert.Equal(t, money.Amount(600), spoon.Subtotal)
+	require.Len(t, spoon.Warnings, 1)
+	assert.Equal(t, consts.CartWarningInsufficientStock, spoon.Warnings[0].Type)
+	assert.Equal(t, 2, spoon.Warnings[0].Available)
+
+	assert.True(t, resp.Items[2].Invalid)
+	require.Len(t, resp.Items[2].Warnings, 1)
+	assert.Equal(t, consts.CartWarningProductDeleted, resp.Items[2].Warnings[0].Type)
+	assert.True(t, resp.HasWarnings)
+	assert.Equal(t, money.Amount(0), resp.Items[2].Subtotal)
+
+	assert.Equal(t, int64(6), resp.TotalQuantity)
+	assert.Equal(t, int64(3), resp.SelectedQuantity)
+	assert.Equal(t, money.Amount(4800), resp.TotalAmount)
+	assert.Equal(t, money.Amount(4200), resp.SelectedAmount)
+}
+
+func TestCartItemWarningsPriceDecreased(t *testing.T) {
+	item := model.CartItem{ProductID: 1, SkuID: 11, Quantity: 1, PriceSnapshot: 1200}
+	sku := &model.ProductSku{ID: 11, ProductID: 1, SpecKey: "颜色:白", Price: 1000, Stock: 10, Product: model.Product{ID: 1, Name: "杯子"}}
+	line := types.CartItemResp{Price: 1000, SnapshotPrice: 1200, Available: 10, InStock: true}
+
+	warnings := cartItemWarnings(item, sku, line)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, consts.CartWarningPriceDecreased, warnings[0].Type)
+	assert.Equal(t, uint(11), warnings[0].SkuID)
+	assert.Contains(t, warnings[0].Message, "颜色:白")
+
+	// 没有价格快照（游客购物车或历史数据）时不比较价格
+	item.PriceSnapshot = 0
+	assert.Empty(t, cartItemWarnings(item, sku, line))
+}
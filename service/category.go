@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"douyin/pkg/utils/log"
+	"douyin/repository/db/dao"
+	"douyin/repository/db/model"
+	"douyin/types"
+	"gorm.io/gorm"
+)
+
+// ErrCategoryNotFound 分类不存在
+var ErrCategoryNotFound = errors.New("分类不存在")
+
+// CategoryService 商品分类服务：维护多级分类、商品与分类的多对多关联，并为商品列表提供按分类（含子孙分类）筛选
+type CategoryService struct {
+	categoryDao        *dao.CategoryDao
+	productCategoryDao *dao.ProductCategoryDao
+}
+
+// NewCategoryService 创建新的 CategoryService 实例
+func NewCategoryService(db *gorm.DB) *CategoryService {
+	return &CategoryService{
+		categoryDao:        dao.NewCategoryDao(db),
+		productCategoryDao: dao.NewProductCategoryDao(db),
+	}
+}
+
+// CreateCategory 创建分类，父分类必须存在
+func (s *CategoryService) CreateCategory(ctx context.Context, req *types.CategoryCreateReq) (*types.Category, error) {
+	if req.ParentID != 0 {
+		if _, err := s.getCategory(ctx, req.ParentID); err != nil {
+			return nil, err
+		}
+	}
+	category := &model.Category{
+		Name:     req.Name,
+		ParentID: req.ParentID,
+		Sort:     req.Sort,
+	}
+	if err := s.categoryDao.CreateCategory(ctx, category); err != nil {
+		log.Errorf("创建分类失败 (name: %s): %v", req.Name, err)
+		return nil, err
+	}
+	log.Infof("分类已创建 (categoryID: %d, parentID: %d)", category.ID, category.ParentID)
+	return buildCategoryResp(category), nil
+}
+
+// UpdateCategory 修改分类名称、排序或移动到新的父分类下，不允许移动到自身或自身的子孙分类下
+func (s *CategoryService) UpdateCategory(ctx context.Context, req *types.CategoryUpdateReq) (*types.Category, error) {
+	category, err := s.getCategory(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		category.Name = *req.Name
+	}
+	if req.Sort != nil {
+		category.Sort = *req.Sort
+	}
+	if req.ParentID != nil && *req.ParentID != category.ParentID {
+		all, err := s.categoryDao.ListAllCategories(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateCategoryParent(all, category.ID, *req.ParentID); err != nil {
+			return nil, err
+		}
+		category.ParentID = *req.ParentID
+	}
+	if err := s.categoryDao.UpdateCategory(ctx, category); err != nil {
+		log.Errorf("修改分类失败 (categoryID: %d): %v", category.ID, err)
+		return nil, err
+	}
+	log.Infof("分类已修改 (categoryID: %d, parentID: %d)", category.ID, category.ParentID)
+	return buildCategoryResp(category), nil
+}
+
+// DeleteCategory 删除没有子分类的分类，同时解除其与商品的关联
+func (s *CategoryService) DeleteCategory(ctx context.Context, categoryID uint) error {
+	if err := s.categoryDao.DeleteCategory(ctx, categoryID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCategoryNotFound
+		}
+		return err
+	}
+	log.Infof("分类已删除 (categoryID: %d)", categoryID)
+	return nil
+}
+
+// Tree 返回完整的分类树
+func (s *CategoryService) Tree(ctx context.Context) ([]*types.Category, error) {
+	all, err := s.categoryDao.ListAllCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return buildCategoryTree(all), nil
+}
+
+// Descendants 返回分类及其全部子孙分类的ID，分类不存在时返回 ErrCategoryNotFound
+func (s *CategoryService) Descendants(ctx context.Context, categoryID uint) ([]uint, error) {
+	all, err := s.categoryDao.ListAllCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := categoryDescendants(all, categoryID)
+	if len(ids) == 0 {
+		return nil, ErrCategoryNotFound
+	}
+	return ids, nil
+}
+
+// AssignProduct 设置商品所属的分类，整体替换商品原有的分类
+func (s *CategoryService) AssignProduct(ctx context.Context, req *types.ProductCategoryAssignReq) error {
+	exists, err := s.productCategoryDao.ProductExists(ctx, req.ProductID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("商品不存在")
+	}
+	categoryIDs := uniqueUints(req.CategoryIDs)
+	if len(categoryIDs) > 0 {
+		all, err := s.categoryDao.ListAllCategories(ctx)
+		if err != nil {
+			return err
+		}
+		known := make(map[uint]bool, len(all))
+		for _, c := range all {
+			known[c.ID] = true
+		}
+		for _, id := range categoryIDs {
+			if !known[id] {
+				return fmt.Errorf("%w: %d", ErrCategoryNotFound, id)
+			}
+		}
+	}
+	if err := s.productCategoryDao.SetProductCategories(ctx, req.ProductID, categoryIDs); err != nil {
+		log.Errorf("设置商品分类失败 (productID: %d): %v", req.ProductID, err)
+		return err
+	}
+	log.Infof("商品分类已更新 (productID: %d, categories: %v)", req.ProductID, categoryIDs)
+	syncProductIndex(ctx, req.ProductID)
+	return nil
+}
+
+// FillProductCategories 为商品填充所属分类的名称，查询失败时只记录日志，不影响商品展示
+func (s *CategoryService) FillProductCategories(ctx context.Context, products []types.Product) {
+	if len(products) == 0 {
+		return
+	}
+	productIDs := make([]uint, 0, len(products))
+	for _, p := range products {
+		productIDs = append(productIDs, uint(p.ID))
+	}
+	relations, err := s.productCategoryDao.ListByProducts(ctx, productIDs)
+	if err != nil {
+		log.Warnf("查询商品分类失败: %v", err)
+		return
+	}
+	if len(relations) == 0 {
+		return
+	}
+	all, err := s.categoryDao.ListAllCategories(ctx)
+	if err != nil {
+		log.Warnf("查询分类失败: %v", err)
+		return
+	}
+	names := make(map[uint]string, len(all))
+	for _, c := range all {
+		names[c.ID] = c.Name
+	}
+	byProduct := make(map[uint][]string, len(products))
+	for _, r := range relations {
+		if name, ok := names[r.CategoryID]; ok {
+			byProduct[r.ProductID] = append(byProduct[r.ProductID], name)
+		}
+	}
+	for i := range products {
+		products[i].Categories = byProduct[uint(products[i].ID)]
+	}
+}
+
+// getCategory 查询分类，不存在时返回 ErrCategoryNotFound
+func (s *CategoryService) getCategory(ctx context.Context, categoryID uint) (*model.Category, error) {
+	category, err := s.categoryDao.GetCategory(ctx, categoryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %d", ErrCategoryNotFound, categoryID)
+		}
+		return nil, err
+	}
+	return category, nil
+}
+
+// validateCategoryParent 校验将 categoryID 移动到 parentID 下是否合法：父分类必须存在，且不能是自身或自身的子孙分类
+func validateCategoryParent(all []model.Category, categoryID, parentID uint) error {
+	if parentID == 0 {
+		return nil
+	}
+	found := false
+	for _, c := range all {
+		if c.ID == parentID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: %d", ErrCategoryNotFound, parentID)
+	}
+	for _, id := range categoryDescendants(all, categoryID) {
+		if id == parentID {
+			return errors.New("不能将分类移动到自身或其子分类下")
+		}
+	}
+	return nil
+}
+
+// categoryDescendants 返回 rootID 及其全部子孙分类的ID（rootID 在首位），rootID 不存在时返回空
+func categoryDescendants(all []model.Category, rootID uint) []uint {
+	children := make(map[uint][]uint, len(all))
+	exists := false
+	for _, c := range all {
+		children[c.ParentID] = append(children[c.ParentID], c.ID)
+		if c.ID == rootID {
+			exists = true
+		}
+	}
+	if !exists {
+		return nil
+	}
+	ids := []uint{rootID}
+	visited := map[uint]bool{rootID: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			// 数据异常形成环时避免死循环
+			if !visited[child] {
+				visited[child] = true
+				ids = append(ids, child)
+			}
+		}
+	}
+	return ids
+}
+
+// buildCategoryTree 将分类组装为树，all 已按排序规则排好序；父分类不存在的分类作为顶级分类返回
+func buildCategoryTree(all []model.Category) []*types.Category {
+	nodes := make(map[uint]*types.Category, len(all))
+	for i := range all {
+		nodes[all[i].ID] = buildCategoryResp(&all[i])
+	}
+	roots := make([]*types.Category, 0)
+	for _, c := range all {
+		node := nodes[c.ID]
+		if parent, ok := nodes[c.ParentID]; ok && c.ParentID != c.ID {
+			parent.Children = append(parent.Children, node)
+			continue
+		}
+		roots = append(roots, node)
+	}
+	return roots
+}
+
+// buildCategoryResp 将分类模型转换为响应结构
+func buildCategoryResp(c *model.Category) *types.Category {
+	return &types.Category{
+		ID:       uint32(c.ID),
+		Name:     c.Name,
+		ParentID: uint32(c.ParentID),
+		Sort:     c.Sort,
+	}
+}
+
+// uniqueUints 去除重复ID并保持原有顺序
+func uniqueUints(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
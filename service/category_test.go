@@ -0,0 +1,52 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"douyin/repository/db/model"
+)
+
+// 1 数码
+// ├── 2 手机
+// │   └── 4 手机配件
+// └── 3 电脑
+// 5 服装
+// 顺序与 CategoryDao.ListAllCategories 一致（按 sort、id 升序）
+var testCategories = []model.Category{
+	{ID: 1, Name: "数码"},
+	{ID: 4, Name: "手机配件", ParentID: 2},
+	{ID: 5, Name: "服装"},
+	{ID: 2, Name: "手机", ParentID: 1, Sort: 1},
+	{ID: 3, Name: "电脑", ParentID: 1, Sort: 2},
+}
+
+func TestCategoryDescendants(t *testing.T) {
+	assert.ElementsMatch(t, []uint{1, 2, 3, 4}, categoryDescendants(testCategories, 1))
+	assert.Equal(t, uint(1), categoryDescendants(testCategories, 1)[0])
+	assert.Equal(t, []uint{2, 4}, categoryDescendants(testCategories, 2))
+	assert.Equal(t, []uint{5}, categoryDescendants(testCategories, 5))
+	assert.Empty(t, categoryDescendants(testCategories, 99))
+}
+
+func TestValidateCategoryParent(t *testing.T) {
+	assert.NoError(t, validateCategoryParent(testCategories, 4, 3))
+	assert.NoError(t, validateCategoryParent(testCategories, 2, 0))
+	assert.Error(t, validateCategoryParent(testCategories, 1, 1), "不能移动到自身下")
+	assert.Error(t, validateCategoryParent(testCategories, 1, 4), "不能移动到子孙分类下")
+	assert.ErrorIs(t, validateCategoryParent(testCategories, 2, 99), ErrCategoryNotFound)
+}
+
+func TestBuildCategoryTree(t *testing.T) {
+	tree := buildCategoryTree(testCategories)
+	require.Len(t, tree, 2)
+	assert.Equal(t, "数码", tree[0].Name)
+	assert.Equal(t, "服装", tree[1].Name)
+	require.Len(t, tree[0].Children, 2)
+	assert.Equal(t, "手机", tree[0].Children[0].Name)
+	assert.Equal(t, "电脑", tree[0].Children[1].Name)
+	require.Len(t, tree[0].Children[0].Children, 1)
+	assert.Equal(t, "手机配件", tree[0].Children[0].Children[0].Name)
+}
@@ -1,25 +1,125 @@
 package service
 
 import (
+	"context"
+	"douyin/pkg/utils/log"
+	"douyin/repository/cache"
 	"douyin/repository/db/dao"
+	"douyin/repository/db/model"
 	"douyin/types"
+	"errors"
 	"gorm.io/gorm"
 )
 
+// ErrCartChanged 购物车商品在加入后发生了价格、库存或上下架变化，需用户确认后重新结算
+var ErrCartChanged = errors.New("购物车商品信息已变更，请确认后重新结算")
+
+// CartChangedError 携带变动明细的 ErrCartChanged
+type CartChangedError struct {
+	Warnings []types.CartWarning
+}
+
+func (e *CartChangedError) Error() string {
+	return ErrCartChanged.Error()
+}
+
+func (e *CartChangedError) Unwrap() error {
+	return ErrCartChanged
+}
+
 // CheckoutService 结算服务
 type CheckoutService struct {
-	dao *dao.CheckoutDao
+	dao            *dao.CheckoutDao
+	orderDao       *dao.OrderDao
+	cartDao        *dao.CartDao
+	reservationDao *dao.StockReservationDao
+	addressDao     *dao.AddressDao
+	rates          *ExchangeRateService
+	unpaidQueue    *cache.DelayQueue // 与 OrderService 共用的未支付订单超时关闭队列
 }
 
 // NewCheckoutService 创建新的 CheckoutService 实例
 func NewCheckoutService(db *gorm.DB) *CheckoutService {
 	return &CheckoutService{
-		dao: dao.NewCheckoutDao(db),
+		dao:            dao.NewCheckoutDao(db),
+		orderDao:       dao.NewOrderDao(db),
+		cartDao:        dao.NewCartDao(db),
+		reservationDao: dao.NewStockReservationDao(db),
+		addressDao:     dao.NewAddressDao(db),
+		rates:          NewExchangeRateService(db),
+		unpaidQueue:    newUnpaidQueue(),
 	}
 }
 
-// CheckoutOrder 订单结算接口
-// 修改参数类型为 *types.CreateOrderReq 以匹配 DAO 层要求
-func (s *CheckoutService) CheckoutOrder(userID uint, req *types.CreateOrderReq) (string, error) {
-	return s.dao.CheckoutOrder(userID, req)
+// CheckoutOrder 结算购物车：购物车中勾选的商品生成一个订单，与直接下单共用 OrderDao.CreateOrder 的库存校验与扣减逻辑
+// 商品在加入购物车后发生变化且用户未确认时返回 CartChangedError；库存不足与商品下架即使确认也无法结算
+func (s *CheckoutService) CheckoutOrder(ctx context.Context, userID uint, req *types.CheckoutReq) (*types.CheckoutResp, error) {
+	address, err := loadShippingAddress(ctx, s.addressDao, userID, req.AddressID)
+	if err != nil {
+		return nil, err
+	}
+	order, err := newOrderFromAddress(ctx, s.rates, userID, address, req.UserCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	warnings, err := s.cartWarnings(ctx, userID, order)
+	if err != nil {
+		return nil, err
+	}
+	if len(warnings) > 0 && !req.ConfirmChanges {
+		log.Infof("购物车商品已变更，等待用户确认 (userID: %d, warnings: %d)", userID, len(warnings))
+		return nil, &CartChangedError{Warnings: warnings}
+	}
+
+	payment, err := s.dao.CheckoutOrder(ctx, order, newCouponPricer(req.CouponIDs))
+	if err != nil {
+		log.Errorf("购物车结算失败 (userID: %d): %v", userID, err)
+		return nil, err
+	}
+	log.Infof("购物车结算成功 (userID: %d, orderID: %s, transactionID: %s)", userID, order.OrderID, payment.TransactionID)
+	scheduleUnpaidTimeout(ctx, s.unpaidQueue, order.OrderID, order.CreatedAt)
+	syncOrderProductIndex(ctx, s.orderDao, order.OrderID)
+	return &types.CheckoutResp{
+		OrderID:        order.OrderID,
+		TransactionID:  payment.TransactionID,
+		TotalAmount:    payment.Amount,
+		DiscountAmount: order.DiscountAmount,
+		Warnings:       warnings,
+	}, nil
+}
+
+// cartWarnings 汇总勾选的购物车行自加入后发生的变化，金额按订单的汇率快照换算
+// 只是结算前的预检查，事务内仍由 OrderDao.CreateOrder 做最终的库存与商品校验
+func (s *CheckoutService) cartWarnings(ctx context.Context, userID uint, order *model.Order) ([]types.CartWarning, error) {
+	cartItems, err := s.cartDao.GetCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]model.CartItem, 0, len(cartItems))
+	skuIDs := make([]uint, 0, len(cartItems))
+	for _, item := range cartItems {
+		if item.Selected {
+			items = append(items, item)
+			skuIDs = append(skuIDs, item.SkuID)
+		}
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	skus, err := s.cartDao.ListCartSkus(ctx, skuIDs)
+	if err != nil {
+		return nil, err
+	}
+	reserved, err := s.reservationDao.SumReservedByOthersBatch(ctx, skuIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+	quote := &ExchangeQuote{Base: order.BaseCurrency, Currency: order.UserCurrency, Rate: order.ExchangeRate}
+
+	var warnings []types.CartWarning
+	for _, line := range buildCartResp(items, skus, reserved, quote).Items {
+		warnings = append(warnings, line.Warnings...)
+	}
+	return warnings, nil
 }
@@ -0,0 +1,369 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"douyin/consts"
+	"douyin/pkg/utils/log"
+	"douyin/pkg/utils/money"
+	"douyin/repository/db/dao"
+	"douyin/repository/db/model"
+	"douyin/types"
+	"gorm.io/gorm"
+)
+
+// ErrCouponUnavailable 下单使用的优惠券不存在、不属于当前用户、已使用或已过期
+var ErrCouponUnavailable = errors.New("优惠券不可用")
+
+// CouponService 优惠券服务：管理员维护优惠券模板，用户领取后在下单/结算时使用
+type CouponService struct {
+	couponDao *dao.CouponDao
+}
+
+// NewCouponService 创建新的 CouponService 实例
+func NewCouponService(db *gorm.DB) *CouponService {
+	return &CouponService{
+		couponDao: dao.NewCouponDao(db),
+	}
+}
+
+// CreateTemplate 管理员创建优惠券模板
+func (s *CouponService) CreateTemplate(ctx context.Context, adminID uint, req *types.CouponTemplateCreateReq) (*types.CouponTemplateResp, error) {
+	template := &model.CouponTemplate{
+		Name:          req.Name,
+		Type:          req.Type,
+		Value:         req.Value,
+		Percent:       req.Percent,
+		Threshold:     req.Threshold,
+		MaxDiscount:   req.MaxDiscount,
+		CategoryID:    req.CategoryID,
+		Stackable:     req.Stackable,
+		TotalQuantity: req.TotalQuantity,
+		PerUserLimit:  req.PerUserLimit,
+		ClaimStartAt:  time.Unix(req.ClaimStartAt, 0),
+		ClaimEndAt:    time.Unix(req.ClaimEndAt, 0),
+		ValidDays:     req.ValidDays,
+		ExpiresAt:     time.Unix(req.ExpiresAt, 0),
+		Enabled:       true,
+		CreatedBy:     adminID,
+	}
+	if template.PerUserLimit == 0 {
+		template.PerUserLimit = 1
+	}
+	if err := validateCouponTemplate(template); err != nil {
+		return nil, err
+	}
+	if err := s.couponDao.CreateTemplate(ctx, template); err != nil {
+		log.Errorf("创建优惠券模板失败 (adminID: %d): %v", adminID, err)
+		return nil, err
+	}
+	log.Infof("优惠券模板已创建 (templateID: %d, adminID: %d, type: %s)", template.ID, adminID, template.Type)
+	return buildCouponTemplateResp(template), nil
+}
+
+// SetTemplateEnabled 管理员启用或停用优惠券模板，停用后已领取未使用的券也无法下单
+func (s *CouponService) SetTemplateEnabled(ctx context.Context, adminID uint, req *types.CouponTemplateStatusReq) error {
+	if err := s.couponDao.SetTemplateEnabled(ctx, req.TemplateID, req.Enabled); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("优惠券模板不存在")
+		}
+		return err
+	}
+	log.Infof("优惠券模板状态已更新 (templateID: %d, enabled: %t, adminID: %d)", req.TemplateID, req.Enabled, adminID)
+	return nil
+}
+
+// ListTemplates 分页查询优惠券模板，claimableOnly 为 true 时只返回当前可领取的模板
+func (s *CouponService) ListTemplates(ctx context.Context, req *types.CouponTemplateListReq, claimableOnly bool) (*types.DataListResp, error) {
+	var claimableAt time.Time
+	if claimableOnly {
+		claimableAt = time.Now()
+	}
+	templates, total, err := s.couponDao.ListTemplates(ctx, claimableAt, req.PageNum, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*types.CouponTemplateResp, 0, len(templates))
+	for i := range templates {
+		items = append(items, buildCouponTemplateResp(&templates[i]))
+	}
+	return &types.DataListResp{
+		Item:  items,
+		Total: total,
+	}, nil
+}
+
+// Claim 用户领取优惠券
+func (s *CouponService) Claim(ctx context.Context, userID, templateID uint) (*types.UserCouponResp, error) {
+	coupon, err := s.couponDao.ClaimCoupon(ctx, userID, templateID, time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("优惠券不存在")
+		}
+		log.Warnf("领取优惠券失败 (userID: %d, templateID: %d): %v", userID, templateID, err)
+		return nil, err
+	}
+	log.Infof("用户 %d 领取了优惠券 (templateID: %d, couponID: %d)", userID, templateID, coupon.ID)
+	return buildUserCouponResp(coupon), nil
+}
+
+// ListUserCoupons 分页查询用户的优惠券，查询前先将已过期的券标记为已过期
+func (s *CouponService) ListUserCoupons(ctx context.Context, userID uint, req *types.UserCouponListReq) (*types.DataListResp, error) {
+	if err := s.couponDao.ExpireUserCoupons(ctx, userID, time.Now()); err != nil {
+		log.Warnf("标记过期优惠券失败 (userID: %d): %v", userID, err)
+	}
+	coupons, total, err := s.couponDao.ListUserCoupons(ctx, userID, req.Status, req.PageNum, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*types.UserCouponResp, 0, len(coupons))
+	for i := range coupons {
+		items = append(items, buildUserCouponResp(&coupons[i]))
+	}
+	return &types.DataListResp{
+		Item:  items,
+		Total: total,
+	}, nil
+}
+
+// couponPricer 下单时使用优惠券的 dao.OrderPricer 实现
+type couponPricer struct {
+	couponIDs []uint
+}
+
+// newCouponPricer 根据下单请求中的优惠券ID构造 OrderPricer，未使用优惠券时返回 nil
+func newCouponPricer(couponIDs []uint) dao.OrderPricer {
+	if len(couponIDs) == 0 {
+		return nil
+	}
+	return &couponPricer{couponIDs: couponIDs}
+}
+
+// Price 锁定并校验优惠券，按叠加规则计算优惠、分摊到订单项，并将优惠券标记为已使用
+func (p *couponPricer) Price(ctx context.Context, tx *gorm.DB, order *model.Order, items []model.OrderItem) (money.Amount, error) {
+	now := time.Now()
+	couponDao := dao.NewCouponDao(tx)
+	coupons, err := couponDao.LockUserCoupons(ctx, order.UserID, p.couponIDs)
+	if err != nil {
+		return 0, err
+	}
+	if err := validateOrderCoupons(coupons, p.couponIDs, now); err != nil {
+		return 0, err
+	}
+
+	productIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	// 分类券适用于券所属分类及其全部子孙分类下的商品
+	scopes := make(map[uint][]uint)
+	for _, coupon := range coupons {
+		if coupon.Template.CategoryID != 0 {
+			scopes[coupon.Template.CategoryID] = nil
+		}
+	}
+	var categoryIDs []uint
+	if len(scopes) > 0 {
+		all, err := dao.NewCategoryDao(tx).ListAllCategories(ctx)
+		if err != nil {
+			return 0, err
+		}
+		for rootID := range scopes {
+			scopes[rootID] = categoryDescendants(all, rootID)
+			categoryIDs = append(categoryIDs, scopes[rootID]...)
+		}
+	}
+	relations, err := couponDao.ListProductCategories(ctx, productIDs, categoryIDs)
+	if err != nil {
+		return 0, err
+	}
+	productCategories := couponProductCategories(relations, scopes)
+
+	amounts, err := allocateCouponDiscounts(items, coupons, productCategories, order.ExchangeRate)
+	if err != nil {
+		return 0, err
+	}
+	var total money.Amount
+	for i, coupon := range coupons {
+		if err := couponDao.MarkUsed(ctx, coupon.ID, order.OrderID, amounts[i], now); err != nil {
+			return 0, err
+		}
+		total += amounts[i]
+	}
+	log.Infof("订单 %s 使用了 %d 张优惠券，共抵扣 %s %s", order.OrderID, len(coupons), total, order.UserCurrency)
+	return total, nil
+}
+
+// validateCouponTemplate 校验优惠券模板的金额与时间配置
+func validateCouponTemplate(t *model.CouponTemplate) error {
+	switch t.Type {
+	case consts.CouponTypeFixed:
+		if t.Value <= 0 {
+			return errors.New("立减券的减免金额必须大于 0")
+		}
+	case consts.CouponTypeThreshold:
+		if t.Value <= 0 || t.Threshold <= 0 {
+			return errors.New("满减券的门槛与减免金额必须大于 0")
+		}
+		if t.Value > t.Threshold {
+			return errors.New("满减券的减免金额不能超过门槛")
+		}
+	case consts.CouponTypePercent:
+		if t.Percent <= 0 || t.Percent >= 100 {
+			return errors.New("折扣券的减免百分比必须在 1 到 99 之间")
+		}
+	default:
+		return fmt.Errorf("不支持的优惠券类型: %s", t.Type)
+	}
+	if t.Threshold < 0 || t.MaxDiscount < 0 {
+		return errors.New("优惠券金额不能为负数")
+	}
+	if !t.ClaimStartAt.Before(t.ClaimEndAt) {
+		return errors.New("领取结束时间必须晚于开始时间")
+	}
+	if t.ExpiresAt.Before(t.ClaimEndAt) {
+		return errors.New("使用截止时间不能早于领取结束时间")
+	}
+	return nil
+}
+
+// validateOrderCoupons 校验下单使用的优惠券：均属于当前用户且可用、同一模板只能用一张，
+// 不可叠加的券只能单独使用，可叠加的券之间可以同时使用
+func validateOrderCoupons(coupons []model.UserCoupon, requested []uint, now time.Time) error {
+	if len(requested) > consts.CouponMaxPerOrder {
+		return fmt.Errorf("单个订单最多使用 %d 张优惠券", consts.CouponMaxPerOrder)
+	}
+	found := make(map[uint]bool, len(coupons))
+	templates := make(map[uint]bool, len(coupons))
+	for _, coupon := range coupons {
+		found[coupon.ID] = true
+		if !coupon.Usable(now) {
+			return fmt.Errorf("%w: %s", ErrCouponUnavailable, coupon.Template.Name)
+		}
+		if templates[coupon.TemplateID] {
+			return fmt.Errorf("同一种优惠券每单只能使用一张: %s", coupon.Template.Name)
+		}
+		templates[coupon.TemplateID] = true
+		if !coupon.Template.Stackable && len(requested) > 1 {
+			return fmt.Errorf("「%s」不能与其他优惠券同时使用", coupon.Template.Name)
+		}
+	}
+	for _, id := range requested {
+		if !found[id] {
+			return fmt.Errorf("%w: 优惠券 %d 不存在", ErrCouponUnavailable, id)
+		}
+	}
+	return nil
+}
+
+// couponProductCategories 根据商品与分类的关联关系计算商品适用的分类券范围，scopes 为券所属分类到其子孙分类（含自身）的映射
+// 返回 商品ID -> 券所属分类ID 的集合，商品属于某个子孙分类即视为属于券所属分类
+func couponProductCategories(relations []model.ProductCategory, scopes map[uint][]uint) map[uint]map[uint]bool {
+	roots := make(map[uint][]uint)
+	for rootID, ids := range scopes {
+		for _, id := range ids {
+			roots[id] = append(roots[id], rootID)
+		}
+	}
+	productCategories := make(map[uint]map[uint]bool, len(relations))
+	for _, relation := range relations {
+		for _, rootID := range roots[relation.CategoryID] {
+			if productCategories[relation.ProductID] == nil {
+				productCategories[relation.ProductID] = make(map[uint]bool)
+			}
+			productCategories[relation.ProductID][rootID] = true
+		}
+	}
+	return productCategories
+}
+
+// allocateCouponDiscounts 依次计算每张优惠券的优惠并按剩余金额比例分摊到适用的订单项，写入 items[i].Discount
+// 先使用立减/满减券再使用折扣券，后使用的券以扣除前序优惠后的金额计算门槛与折扣；
+// 返回与 coupons 一一对应的抵扣金额，任一张券未满足使用条件时返回错误
+func allocateCouponDiscounts(items []model.OrderItem, coupons []model.UserCoupon, productCategories map[uint]map[uint]bool, rate money.Rate) ([]money.Amount, error) {
+	remaining := make([]money.Amount, len(items))
+	for i, item := range items {
+		remaining[i] = item.Cost.Mul(int64(item.Quantity)) - item.Discount
+	}
+
+	order := make([]int, len(coupons))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return coupons[order[i]].Template.Type != consts.CouponTypePercent && coupons[order[j]].Template.Type == consts.CouponTypePercent
+	})
+
+	amounts := make([]money.Amount, len(coupons))
+	for _, c := range order {
+		template := &coupons[c].Template
+		var (
+			eligible []int
+			weights  []money.Amount
+			subtotal money.Amount
+		)
+		for i, item := range items {
+			if template.CategoryID != 0 && !productCategories[item.ProductID][template.CategoryID] {
+				continue
+			}
+			eligible = append(eligible, i)
+			weights = append(weights, remaining[i])
+			subtotal += remaining[i]
+		}
+		discount := template.Discount(subtotal, rate)
+		if discount <= 0 {
+			return nil, fmt.Errorf("「%s」未满足使用条件", template.Name)
+		}
+		for k, part := range money.Allocate(discount, weights) {
+			i := eligible[k]
+			remaining[i] -= part
+			items[i].Discount += part
+		}
+		amounts[c] = discount
+	}
+	return amounts, nil
+}
+
+// buildCouponTemplateResp 将优惠券模板转换为响应结构
+func buildCouponTemplateResp(t *model.CouponTemplate) *types.CouponTemplateResp {
+	return &types.CouponTemplateResp{
+		ID:              t.ID,
+		Name:            t.Name,
+		Type:            t.Type,
+		Value:           t.Value,
+		Percent:         t.Percent,
+		Threshold:       t.Threshold,
+		MaxDiscount:     t.MaxDiscount,
+		CategoryID:      t.CategoryID,
+		Stackable:       t.Stackable,
+		TotalQuantity:   t.TotalQuantity,
+		ClaimedQuantity: t.ClaimedQuantity,
+		PerUserLimit:    t.PerUserLimit,
+		ClaimStartAt:    t.ClaimStartAt.Unix(),
+		ClaimEndAt:      t.ClaimEndAt.Unix(),
+		ValidDays:       t.ValidDays,
+		ExpiresAt:       t.ExpiresAt.Unix(),
+		Enabled:         t.Enabled,
+	}
+}
+
+// buildUserCouponResp 将用户优惠券转换为响应结构
+func buildUserCouponResp(c *model.UserCoupon) *types.UserCouponResp {
+	resp := &types.UserCouponResp{
+		ID:             c.ID,
+		Status:         c.Status,
+		ClaimedAt:      c.ClaimedAt.Unix(),
+		ExpiresAt:      c.ExpiresAt.Unix(),
+		OrderID:        c.OrderID,
+		DiscountAmount: c.DiscountAmount,
+		Template:       *buildCouponTemplateResp(&c.Template),
+	}
+	if c.UsedAt != nil {
+		resp.UsedAt = c.UsedAt.Unix()
+	}
+	return resp
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"douyin/consts"
+	"douyin/pkg/utils/money"
+	"douyin/repository/db/model"
+)
+
+func TestValidateCouponTemplate(t *testing.T) {
+	now := time.Now()
+	base := model.CouponTemplate{
+		Type:         consts.CouponTypeThreshold,
+		Value:        1000,
+		Threshold:    10000,
+		ClaimStartAt: now,
+		ClaimEndAt:   now.Add(24 * time.Hour),
+		ExpiresAt:    now.Add(48 * time.Hour),
+	}
+	valid := base
+	assert.NoError(t, validateCouponTemplate(&valid))
+
+	overThreshold := base
+	overThreshold.Value = 20000
+	assert.Error(t, validateCouponTemplate(&overThreshold))
+
+	badPercent := base
+	badPercent.Type = consts.CouponTypePercent
+	badPercent.Percent = 100
+	assert.Error(t, validateCouponTemplate(&badPercent))
+
+	badWindow := base
+	badWindow.ExpiresAt = now
+	assert.Error(t, validateCouponTemplate(&badWindow))
+
+	unknown := base
+	unknown.Type = "GIFT"
+	assert.Error(t, validateCouponTemplate(&unknown))
+}
+
+func TestValidateOrderCoupons(t *testing.T) {
+	now := time.Now()
+	coupon := func(id, templateID uint, stackable bool) model.UserCoupon {
+		return model.UserCoupon{
+			ID:         id,
+			TemplateID: templateID,
+			Status:     consts.UserCouponStatusUnused,
+			ExpiresAt:  now.Add(time.Hour),
+			Template:   model.CouponTemplate{ID: templateID, Enabled: true, Stackable: stackable},
+		}
+	}
+
+	assert.NoError(t, validateOrderCoupons([]model.UserCoupon{coupon(1, 1, false)}, []uint{1}, now))
+	assert.NoError(t, validateOrderCoupons([]model.UserCoupon{coupon(1, 1, true), coupon(2, 2, true)}, []uint{1, 2}, now))
+
+	t.Run("不可叠加的券不能与其他券同时使用", func(t *testing.T) {
+		err := validateOrderCoupons([]model.UserCoupon{coupon(1, 1, false), coupon(2, 2, true)}, []uint{1, 2}, now)
+		assert.Error(t, err)
+	})
+
+	t.Run("同一模板只能使用一张", func(t *testing.T) {
+		err := validateOrderCoupons([]model.UserCoupon{coupon(1, 1, true), coupon(2, 1, true)}, []uint{1, 2}, now)
+		assert.Error(t, err)
+	})
+
+	t.Run("优惠券不属于当前用户", func(t *testing.T) {
+		err := validateOrderCoupons([]model.UserCoupon{coupon(1, 1, true)}, []uint{1, 2}, now)
+		assert.ErrorIs(t, err, ErrCouponUnavailable)
+	})
+
+	t.Run("已过期或已使用", func(t *testing.T) {
+		expired := coupon(1, 1, false)
+		expired.ExpiresAt = now.Add(-time.Minute)
+		assert.ErrorIs(t, validateOrderCoupons([]model.UserCoupon{expired}, []uint{1}, now), ErrCouponUnavailable)
+
+		used := coupon(1, 1, false)
+		used.Status = consts.UserCouponStatusUsed
+		assert.ErrorIs(t, validateOrderCoupons([]model.UserCoupon{used}, []uint{1}, now), ErrCouponUnavailable)
+	})
+}
+
+func TestAllocateCouponDiscounts(t *testing.T) {
+	newItems := func() []model.OrderItem {
+		return []model.OrderItem{
+			{ProductID: 1, Quantity: 2, Cost: 3000},
+			{ProductID: 2, Quantity: 1, Cost: 4000},
+		}
+	}
+
+	t.Run("满减券按金额比例分摊到订单项", func(t *testing.T) {
+		items := newItems()
+		coupons := []model.UserCoupon{{Template: model.CouponTemplate{Type: consts.CouponTypeThreshold, Value: 1000, Threshold: 10000}}}
+		amounts, err := allocateCouponDiscounts(items, coupons, nil, money.OneRate)
+		require.NoError(t, err)
+		assert.Equal(t, []money.Amount{1000}, amounts)
+		assert.Equal(t, money.Amount(600), items[0].Discount)
+		assert.Equal(t, money.Amount(400), items[1].Discount)
+	})
+
+	t.Run("未达到门槛", func(t *testing.T) {
+		coupons := []model.UserCoupon{{Template: model.CouponTemplate{Type: consts.CouponTypeThreshold, Value: 1000, Threshold: 20000}}}
+		_, err := allocateCouponDiscounts(newItems(), coupons, nil, money.OneRate)
+		assert.Error(t, err)
+	})
+
+	t.Run("分类券只作用于该分类下的商品", func(t *testing.T) {
+		items := newItems()
+		coupons := []model.UserCoupon{{Template: model.CouponTemplate{Type: consts.CouponTypeFixed, Value: 500, CategoryID: 9}}}
+		amounts, err := allocateCouponDiscounts(items, coupons, map[uint]map[uint]bool{2: {9: true}}, money.OneRate)
+		require.NoError(t, err)
+		assert.Equal(t, []money.Amount{500}, amounts)
+		assert.Equal(t, money.Amount(0), items[0].Discount)
+		assert.Equal(t, money.Amount(500), items[1].Discount)
+	})
+
+	t.Run("折扣券在立减券之后计算", func(t *testing.T) {
+		items := newItems()
+		coupons := []model.UserCoupon{
+			{Template: model.CouponTemplate{Type: consts.CouponTypePercent, Percent: 10, Stackable: true}},
+			{Template: model.CouponTemplate{Type: consts.CouponTypeFixed, Value: 1000, Stackable: true}},
+		}
+		amounts, err := allocateCouponDiscounts(items, coupons, nil, money.OneRate)
+		require.NoError(t, err)
+		assert.Equal(t, []money.Amount{900, 1000}, amounts)
+		assert.Equal(t, money.Amount(1900), items[0].Discount+items[1].Discount)
+	})
+}
+
+func TestCouponProductCategories(t *testing.T) {
+	// 分类 1 下有子分类 2，2 下有子分类 4；分类 5 与 1 无关
+	scopes := map[uint][]uint{
+		1: categoryDescendants(testCategories, 1),
+		2: categoryDescendants(testCategories, 2),
+	}
+	relations := []model.ProductCategory{
+		{ProductID: 10, CategoryID: 4},
+		{ProductID: 11, CategoryID: 3},
+		{ProductID: 12, CategoryID: 5},
+	}
+	productCategories := couponProductCategories(relations, scopes)
+	assert.Equal(t, map[uint]bool{1: true, 2: true}, productCategories[10])
+	assert.Equal(t, map[uint]bool{1: true}, productCategories[11])
+	assert.Nil(t, productCategories[12])
+
+	items := []model.OrderItem{
+		{ProductID: 10, Cost: 6000, Quantity: 1},
+		{ProductID: 12, Cost: 4000, Quantity: 1},
+	}
+	coupons := []model.UserCoupon{{Template: model.CouponTemplate{Type: consts.CouponTypeFixed, Value: 500, CategoryID: 1}}}
+	amounts, err := allocateCouponDiscounts(items, coupons, productCategories, money.OneRate)
+	require.NoError(t, err)
+	assert.Equal(t, []money.Amount{500}, amounts)
+	assert.Equal(t, money.Amount(500), items[0].Discount)
+	assert.Equal(t, money.Amount(0), items[1].Discount)
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"douyin/config"
+	"douyin/consts"
+	"douyin/pkg/utils/log"
+	"douyin/pkg/utils/money"
+	"douyin/repository/db/dao"
+	"douyin/repository/db/model"
+	"douyin/types"
+	"gorm.io/gorm"
+)
+
+// ErrUnsupportedCurrency 币种格式非法或未配置汇率
+var ErrUnsupportedCurrency = errors.New("不支持的币种")
+
+// ExchangeQuote 一次换算使用的汇率快照
+type ExchangeQuote struct {
+	Base     string     // 基础币种
+	Currency string     // 目标币种
+	Rate     money.Rate // 1 单位基础币种可兑换的目标币种数量
+}
+
+// Convert 将基础币种金额换算为目标币种金额
+func (q *ExchangeQuote) Convert(amount money.Amount) money.Amount {
+	return amount.Convert(q.Rate)
+}
+
+// ExchangeRateService 汇率服务：商品以基础币种定价，下单和展示时按汇率换算为用户币种
+type ExchangeRateService struct {
+	rateDao *dao.ExchangeRateDao
+}
+
+// NewExchangeRateService 创建新的 ExchangeRateService 实例
+func NewExchangeRateService(db *gorm.DB) *ExchangeRateService {
+	return &ExchangeRateService{
+		rateDao: dao.NewExchangeRateDao(db),
+	}
+}
+
+// Quote 查询基础币种到目标币种的汇率，currency 为空时使用基础币种
+func (s *ExchangeRateService) Quote(ctx context.Context, currency string) (*ExchangeQuote, error) {
+	base := config.GetBaseCurrency()
+	if currency == "" {
+		currency = base
+	}
+	currency, err := normalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	if currency == base {
+		return &ExchangeQuote{Base: base, Currency: base, Rate: money.OneRate}, nil
+	}
+	rate, err := s.rateDao.GetRate(ctx, currency)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+		}
+		log.Errorf("查询汇率失败 (currency: %s): %v", currency, err)
+		return nil, err
+	}
+	return &ExchangeQuote{Base: base, Currency: currency, Rate: rate.Rate}, nil
+}
+
+// DisplayQuote 确定展示价格使用的汇率：优先使用请求指定的币种，其次是用户资料中的偏好币种，最后是基础币种
+// 用户偏好的币种已被下线时回退到基础币种
+func (s *ExchangeRateService) DisplayQuote(ctx context.Context, userID uint, requested string) (*ExchangeQuote, error) {
+	if requested != "" {
+		return s.Quote(ctx, requested)
+	}
+	if userID != 0 {
+		user, err := dao.NewUserDao(ctx).GetUserById(userID)
+		if err != nil {
+			log.Warnf("查询用户偏好币种失败 (userID: %d): %v", userID, err)
+		} else if user.Currency != "" {
+			quote, err := s.Quote(ctx, user.Currency)
+			if err == nil {
+				return quote, nil
+			}
+			log.Warnf("用户偏好币种不可用，使用基础币种展示 (userID: %d, currency: %s): %v", userID, user.Currency, err)
+		}
+	}
+	return s.Quote(ctx, "")
+}
+
+// ListRates 查询全部汇率
+func (s *ExchangeRateService) ListRates(ctx context.Context) (*types.ExchangeRateListResp, error) {
+	rates, err := s.rateDao.ListRates(ctx)
+	if err != nil {
+		log.Errorf("查询汇率列表失败: %v", err)
+		return nil, err
+	}
+	resp := &types.ExchangeRateListResp{
+		Base:  config.GetBaseCurrency(),
+		Rates: make([]types.ExchangeRateResp, 0, len(rates)),
+	}
+	for _, rate := range rates {
+		resp.Rates = append(resp.Rates, types.ExchangeRateResp{
+			Currency:  rate.Currency,
+			Rate:      rate.Rate,
+			Source:    rate.Source,
+			UpdatedAt: rate.UpdatedAt.Unix(),
+		})
+	}
+	return resp, nil
+}
+
+// UpdateRates 管理员批量更新汇率
+func (s *ExchangeRateService) UpdateRates(ctx context.Context, adminID uint, req *types.ExchangeRateUpdateReq) error {
+	rates := make(map[string]money.Rate, len(req.Rates))
+	for _, item := range req.Rates {
+		rates[item.Currency] = item.Rate
+	}
+	entries, err := buildExchangeRates(rates, config.GetBaseCurrency(), consts.ExchangeRateSourceAdmin, adminID)
+	if err != nil {
+		return err
+	}
+	if err := s.rateDao.UpsertRates(ctx, entries); err != nil {
+		log.Errorf("更新汇率失败 (adminID: %d): %v", adminID, err)
+		return err
+	}
+	log.Infof("汇率已更新 (adminID: %d, count: %d)", adminID, len(entries))
+	return nil
+}
+
+// ImportFile 从本地 CSV 或 JSON 文件导入汇率，返回导入的币种数量
+func (s *ExchangeRateService) ImportFile(ctx context.Context, path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("读取汇率文件失败: %w", err)
+	}
+	base := config.GetBaseCurrency()
+	rates, err := parseExchangeRates(filepath.Ext(path), data, base)
+	if err != nil {
+		return 0, fmt.Errorf("解析汇率文件 %s 失败: %w", path, err)
+	}
+	entries, err := buildExchangeRates(rates, base, consts.ExchangeRateSourceFile, 0)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.rateDao.UpsertRates(ctx, entries); err != nil {
+		return 0, err
+	}
+	log.Infof("已从 %s 导入 %d 个币种的汇率", path, len(entries))
+	return len(entries), nil
+}
+
+// exchangeRateFile JSON 汇率文件格式：{"base": "USD", "rates": {"EUR": "0.92"}}
+type exchangeRateFile struct {
+	Base  string                `json:"base"`
+	Rates map[string]money.Rate `json:"rates"`
+}
+
+// parseExchangeRates 解析汇率文件内容，ext 为 ".json" 或 ".csv"
+// CSV 每行为「币种,汇率」，首行可以是 currency,rate 表头
+func parseExchangeRates(ext string, data []byte, base string) (map[string]money.Rate, error) {
+	switch strings.ToLower(ext) {
+	case ".json":
+		var file exchangeRateFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, err
+		}
+		if file.Base != "" && !strings.EqualFold(file.Base, base) {
+			return nil, fmt.Errorf("文件基础币种 %s 与配置的基础币种 %s 不一致", file.Base, base)
+		}
+		return file.Rates, nil
+	case ".csv":
+		reader := csv.NewReader(bytes.NewReader(data))
+		reader.FieldsPerRecord = 2
+		reader.TrimLeadingSpace = true
+		rates := make(map[string]money.Rate)
+		for line := 1; ; line++ {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "currency") {
+				continue
+			}
+			rate, err := money.ParseRate(record[1])
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 行: %w", line, err)
+			}
+			rates[strings.TrimSpace(record[0])] = rate
+		}
+		return rates, nil
+	default:
+		return nil, fmt.Errorf("不支持的汇率文件格式: %s", ext)
+	}
+}
+
+// buildExchangeRates 校验币种并构造待写入的汇率记录，基础币种的汇率恒为 1，不入表
+func buildExchangeRates(rates map[string]money.Rate, base, source string, updatedBy uint) ([]model.ExchangeRate, error) {
+	entries := make([]model.ExchangeRate, 0, len(rates))
+	for currency, rate := range rates {
+		currency, err := normalizeCurrency(currency)
+		if err != nil {
+			return nil, err
+		}
+		if rate <= 0 {
+			return nil, fmt.Errorf("%w: %s", money.ErrInvalidRate, currency)
+		}
+		if currency == base {
+			continue
+		}
+		entries = append(entries, model.ExchangeRate{
+			Currency:  currency,
+			Rate:      rate,
+			Source:    source,
+			UpdatedBy: updatedBy,
+		})
+	}
+	return entries, nil
+}
+
+// normalizeCurrency 校验并规范化币种代码（三位大写字母）
+func normalizeCurrency(currency string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if len(currency) != 3 {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+	}
+	for _, c := range currency {
+		if c < 'A' || c > 'Z' {
+			return "", fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+		}
+	}
+	return currency, nil
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"douyin/pkg/utils/money"
+)
+
+func TestParseExchangeRates(t *testing.T) {
+	eur, _ := money.ParseRate("0.92")
+	jpy, _ := money.ParseRate("151.37")
+
+	t.Run("JSON", func(t *testing.T) {
+		rates, err := parseExchangeRates(".json", []byte(`{"base":"usd","rates":{"EUR":"0.92","JPY":151.37}}`), "USD")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]money.Rate{"EUR": eur, "JPY": jpy}, rates)
+	})
+
+	t.Run("JSON 基础币种不一致", func(t *testing.T) {
+		_, err := parseExchangeRates(".json", []byte(`{"base":"EUR","rates":{"USD":"1.08"}}`), "USD")
+		assert.Error(t, err)
+	})
+
+	t.Run("CSV 带表头", func(t *testing.T) {
+		rates, err := parseExchangeRates(".CSV", []byte("currency,rate\nEUR, 0.92\nJPY,151.37\n"), "USD")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]money.Rate{"EUR": eur, "JPY": jpy}, rates)
+	})
+
+	t.Run("CSV 汇率非法", func(t *testing.T) {
+		_, err := parseExchangeRates(".csv", []byte("EUR,abc\n"), "USD")
+		assert.ErrorIs(t, err, money.ErrInvalidRate)
+	})
+
+	t.Run("不支持的格式", func(t *testing.T) {
+		_, err := parseExchangeRates(".xml", nil, "USD")
+		assert.Error(t, err)
+	})
+}
+
+func TestBuildExchangeRates(t *testing.T) {
+	eur, _ := money.ParseRate("0.92")
+
+	entries, err := buildExchangeRates(map[string]money.Rate{"eur": eur, "USD": money.OneRate}, "USD", "file", 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "基础币种不应入表")
+	assert.Equal(t, "EUR", entries[0].Currency)
+
+	_, err = buildExchangeRates(map[string]money.Rate{"EURO": eur}, "USD", "file", 0)
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+}
@@ -0,0 +1,500 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"douyin/config"
+	"douyin/consts"
+	"douyin/pkg/utils/log"
+	"douyin/pkg/utils/money"
+	"douyin/repository/cache"
+	"douyin/repository/db/dao"
+	"douyin/repository/db/model"
+	"douyin/types"
+	"gorm.io/gorm"
+)
+
+const (
+	flashSalePopTimeout      = 5 * time.Second        // 下单 worker 阻塞等待任务的超时时间，超时后检查是否需要退出
+	flashSalePreloadInterval = time.Minute            // 定期预热新建或其他实例创建的场次
+	flashSaleRetryDelay      = 200 * time.Millisecond // 任务处理失败后首次重试的等待时间，之后每次翻倍
+	flashSaleMaxAttempts     = 5                      // 任务连续处理失败的次数上限，达到后移回队列末尾稍后再处理
+)
+
+// ErrFlashSaleUnavailable Redis 未初始化，无法进行秒杀
+var ErrFlashSaleUnavailable = errors.New("秒杀服务暂不可用")
+
+// flashSaleJob 秒杀下单任务，抢到名额后写入 Redis 队列，由 worker 异步创建订单
+type flashSaleJob struct {
+	SessionID uint   `json:"session_id"`
+	UserID    uint   `json:"user_id"`
+	AddressID uint   `json:"address_id"`
+	Currency  string `json:"currency"`
+}
+
+// FlashSaleService 秒杀服务：管理员排期场次，场次库存预热到 Redis，
+// 抢购请求只在 Redis 中通过 Lua 脚本原子扣减库存并投递下单任务，订单由 ListenAndCreateOrders 异步创建，客户端轮询结果
+type FlashSaleService struct {
+	flashSaleDao *dao.FlashSaleDao
+	orderDao     *dao.OrderDao
+	addressDao   *dao.AddressDao
+	rates        *ExchangeRateService
+	store        *cache.FlashSaleStore // Redis 未初始化时为 nil
+	unpaidQueue  *cache.DelayQueue
+}
+
+// newFlashSaleStore 创建秒杀的 Redis 存储，Redis 未初始化时返回 nil
+func newFlashSaleStore() *cache.FlashSaleStore {
+	if cache.RedisClient == nil {
+		return nil
+	}
+	return cache.NewFlashSaleStore(cache.RedisClient, consts.SkillProductQueues)
+}
+
+// NewFlashSaleService 创建新的 FlashSaleService 实例
+func NewFlashSaleService(db *gorm.DB) *FlashSaleService {
+	store := newFlashSaleStore()
+	if store == nil {
+		log.Warnf("Redis 未初始化，秒杀抢购不可用")
+	}
+	return &FlashSaleService{
+		flashSaleDao: dao.NewFlashSaleDao(db),
+		orderDao:     dao.NewOrderDao(db),
+		addressDao:   dao.NewAddressDao(db),
+		rates:        NewExchangeRateService(db),
+		store:        store,
+		unpaidQueue:  newUnpaidQueue(),
+	}
+}
+
+// CreateSession 管理员创建秒杀场次，创建后立即预热库存
+func (s *FlashSaleService) CreateSession(ctx context.Context, adminID uint, req *types.FlashSaleCreateReq) (*types.FlashSaleSessionResp, error) {
+	sku, err := s.flashSaleDao.GetSku(ctx, req.ProductID, req.SkuID)
+	if err != nil {
+		if errors.Is(err, dao.ErrSkuNotFound) {
+			return nil, errors.New("商品不存在")
+		}
+		return nil, err
+	}
+	session := &model.FlashSaleSession{
+		ProductID: sku.ProductID,
+		SkuID:     sku.ID,
+		Price:     req.Price,
+		Stock:     req.Stock,
+		StartAt:   time.Unix(req.StartAt, 0),
+		EndAt:     time.Unix(req.EndAt, 0),
+		Enabled:   true,
+		CreatedBy: adminID,
+	}
+	if err := validateFlashSaleSession(session, sku, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.flashSaleDao.CreateSession(ctx, session); err != nil {
+		log.Errorf("创建秒杀场次失败 (adminID: %d): %v", adminID, err)
+		return nil, err
+	}
+	log.Infof("秒杀场次已创建 (sessionID: %d, productID: %d, skuID: %d, stock: %d, adminID: %d)", session.ID, session.ProductID, session.SkuID, session.Stock, adminID)
+	s.preload(ctx, session)
+
+	session.Product = sku.Product
+	session.Sku = *sku
+	return buildFlashSaleSessionResp(session, session.Stock, money.OneRate, config.GetBaseCurrency()), nil
+}
+
+// SetSessionEnabled 管理员启用或停用秒杀场次，停用后立即从 Redis 下线，已抢到名额的下单任务会失败并提示
+func (s *FlashSaleService) SetSessionEnabled(ctx context.Context, adminID uint, req *types.FlashSaleStatusReq) error {
+	session, err := s.flashSaleDao.SetSessionEnabled(ctx, req.SessionID, req.Enabled)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("秒杀场次不存在")
+		}
+		return err
+	}
+	log.Infof("秒杀场次状态已更新 (sessionID: %d, enabled: %t, adminID: %d)", req.SessionID, req.Enabled, adminID)
+	if s.store == nil {
+		return nil
+	}
+	if req.Enabled {
+		s.preload(ctx, session)
+		return nil
+	}
+	if err := s.store.Offline(ctx, session.ID); err != nil {
+		log.Errorf("秒杀场次下线失败 (sessionID: %d): %v", session.ID, err)
+		return err
+	}
+	return nil
+}
+
+// ListSessions 分页查询秒杀场次，activeOnly 为 true 时只返回进行中或未开始的场次；剩余数量取自 Redis
+func (s *FlashSaleService) ListSessions(ctx context.Context, req *types.FlashSaleListReq, activeOnly bool) (*types.DataListResp, error) {
+	quote, err := s.rates.Quote(ctx, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+	var activeAt time.Time
+	if activeOnly {
+		activeAt = time.Now()
+	}
+	sessions, total, err := s.flashSaleDao.ListSessions(ctx, activeAt, req.PageNum, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := make(map[uint]int)
+	if s.store != nil {
+		ids := make([]uint, 0, len(sessions))
+		for _, session := range sessions {
+			ids = append(ids, session.ID)
+		}
+		if remaining, err = s.store.Remaining(ctx, ids); err != nil {
+			log.Warnf("查询秒杀剩余库存失败: %v", err)
+			remaining = make(map[uint]int)
+		}
+	}
+	items := make([]*types.FlashSaleSessionResp, 0, len(sessions))
+	for i := range sessions {
+		left, ok := remaining[sessions[i].ID]
+		if !ok {
+			// 未预热（如 Redis 数据丢失）时按数据库中的成交数量估算
+			left = sessions[i].Stock - sessions[i].Sold
+		}
+		items = append(items, buildFlashSaleSessionResp(&sessions[i], left, quote.Rate, quote.Currency))
+	}
+	return &types.DataListResp{
+		Item:  items,
+		Total: total,
+	}, nil
+}
+
+// Buy 抢购：校验收货地址后在 Redis 中原子扣减库存并投递下单任务，抢到名额时返回 PENDING，之后通过 Result 轮询下单结果
+func (s *FlashSaleService) Buy(ctx context.Context, userID uint, req *types.FlashSaleBuyReq) (*types.FlashSaleResultResp, error) {
+	if s.store == nil {
+		return nil, ErrFlashSaleUnavailable
+	}
+	if _, err := loadShippingAddress(ctx, s.addressDao, userID, req.AddressID); err != nil {
+		return nil, err
+	}
+	job, err := json.Marshal(flashSaleJob{
+		SessionID: req.SessionID,
+		UserID:    userID,
+		AddressID: req.AddressID,
+		Currency:  req.UserCurrency,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Acquire(ctx, req.SessionID, userID, time.Now(), job); err != nil {
+		return nil, err
+	}
+	log.Infof("用户 %d 抢到秒杀名额 (sessionID: %d)，等待创建订单", userID, req.SessionID)
+	return &types.FlashSaleResultResp{
+		SessionID: req.SessionID,
+		Status:    consts.FlashSaleResultPending,
+	}, nil
+}
+
+// Result 查询用户在场次中的抢购结果；Redis 中没有记录时以数据库中的成交记录为准
+func (s *FlashSaleService) Result(ctx context.Context, userID, sessionID uint) (*types.FlashSaleResultResp, error) {
+	resp := &types.FlashSaleResultResp{SessionID: sessionID}
+	if s.store != nil {
+		status, detail, err := s.store.Result(ctx, sessionID, userID)
+		if err != nil {
+			return nil, err
+		}
+		switch status {
+		case consts.FlashSaleResultSuccess:
+			resp.Status, resp.OrderID = status, detail
+			return resp, nil
+		case consts.FlashSaleResultFailed:
+			resp.Status, resp.Message = status, detail
+			return resp, nil
+		case consts.FlashSaleResultPending:
+			resp.Status = status
+			return resp, nil
+		}
+	}
+	record, err := s.flashSaleDao.GetUserOrder(ctx, sessionID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("未参与该场秒杀")
+		}
+		return nil, err
+	}
+	resp.Status, resp.OrderID = consts.FlashSaleResultSuccess, record.OrderID
+	return resp, nil
+}
+
+// ListenAndCreateOrders 消费秒杀下单队列，与 OrderService.ListenAndCloseUnpaid 一样作为后台协程运行
+// 启动时及之后每隔 flashSalePreloadInterval 预热未结束的场次，consts.FlashSaleWorkers 个协程并发创建订单
+// 每个协程取出的任务暂存在自己的处理中列表（按主机名和序号区分），处理完成后才删除，处理失败的任务退避重试后移回队列；
+// 启动时先将上次退出时未处理完的任务放回队列
+func (s *FlashSaleService) ListenAndCreateOrders(ctx context.Context) {
+	if s.store == nil {
+		log.Errorf("秒杀下单队列未初始化（Redis 不可用），worker 退出")
+		return
+	}
+	s.preloadSessions(ctx)
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Warnf("获取主机名失败，秒杀下单 worker 使用默认名称: %v", err)
+		hostname = "localhost"
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < consts.FlashSaleWorkers; i++ {
+		worker := fmt.Sprintf("%s:%d", hostname, i)
+		requeued, err := s.store.Requeue(ctx, worker)
+		if err != nil {
+			log.Errorf("放回秒杀下单 worker %s 未完成的任务失败: %v", worker, err)
+		} else if requeued > 0 {
+			log.Warnf("秒杀下单 worker %s 有 %d 个未完成的任务，已放回队列", worker, requeued)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.consume(ctx, worker)
+		}()
+	}
+
+	ticker := time.NewTicker(flashSalePreloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			log.Infof("秒杀下单 worker 退出")
+			return
+		case <-ticker.C:
+			s.preloadSessions(ctx)
+		}
+	}
+}
+
+// consume 循环取出下单任务并处理，直到 ctx 被取消；任务记录结果后才从 worker 的处理中列表删除
+func (s *FlashSaleService) consume(ctx context.Context, worker string) {
+	for ctx.Err() == nil {
+		payload, err := s.store.Pop(ctx, worker, flashSalePopTimeout)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Errorf("读取秒杀下单队列失败: %v", err)
+				time.Sleep(flashSalePopTimeout)
+			}
+			continue
+		}
+		if payload == nil {
+			continue
+		}
+		var job flashSaleJob
+		if err := json.Unmarshal(payload, &job); err != nil {
+			log.Errorf("解析秒杀下单任务失败，任务已丢弃 (%s): %v", payload, err)
+			s.ack(worker, payload)
+			continue
+		}
+		if s.processJob(ctx, &job) {
+			s.ack(worker, payload)
+			continue
+		}
+		if err := s.store.Retry(context.Background(), worker, payload); err != nil {
+			log.Errorf("秒杀下单任务移回队列失败，将在下次启动时重新处理 (worker: %s): %v", worker, err)
+		}
+	}
+}
+
+// processJob 处理下单任务，handleJob 因 Redis 或数据库暂时不可用失败时按指数退避重试，
+// 连续失败 flashSaleMaxAttempts 次或 ctx 被取消时返回 false，由调用方将任务移回队列
+func (s *FlashSaleService) processJob(ctx context.Context, job *flashSaleJob) bool {
+	delay := flashSaleRetryDelay
+	for attempt := 1; ; attempt++ {
+		if s.handleJob(ctx, job) {
+			return true
+		}
+		if attempt >= flashSaleMaxAttempts {
+			log.Warnf("秒杀下单任务连续 %d 次处理失败，移回队列稍后重试 (sessionID: %d, userID: %d)", attempt, job.SessionID, job.UserID)
+			return false
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// ack 从 worker 的处理中列表删除已处理的任务，失败时任务会在下次启动时重新处理
+func (s *FlashSaleService) ack(worker string, payload []byte) {
+	if err := s.store.Ack(context.Background(), worker, payload); err != nil {
+		log.Errorf("确认秒杀下单任务失败 (worker: %s): %v", worker, err)
+	}
+}
+
+// handleJob 为抢到名额的用户创建订单并记录结果，失败时归还 Redis 中的名额
+// 结果写入 Redis 后返回 true；返回 false 时由 processJob 退避重试，仍失败则移回队列。
+// 重新处理时结果已不是 PENDING（上次已记录结果但未确认任务）则跳过；
+// 用户已有成交记录（上次下单成功但结果未写入）则直接补记结果，不重复下单
+func (s *FlashSaleService) handleJob(ctx context.Context, job *flashSaleJob) bool {
+	status, _, err := s.store.Result(ctx, job.SessionID, job.UserID)
+	if err != nil {
+		log.Errorf("查询秒杀抢购结果失败 (sessionID: %d, userID: %d): %v", job.SessionID, job.UserID, err)
+		return false
+	}
+	if status != consts.FlashSaleResultPending {
+		log.Infof("秒杀下单任务已处理，跳过 (sessionID: %d, userID: %d, status: %s)", job.SessionID, job.UserID, status)
+		return true
+	}
+	record, err := s.flashSaleDao.GetUserOrder(ctx, job.SessionID, job.UserID)
+	if err == nil {
+		if err := s.store.Succeed(context.Background(), job.SessionID, job.UserID, record.OrderID); err != nil {
+			log.Errorf("补记秒杀下单结果失败 (sessionID: %d, userID: %d, orderID: %s): %v", job.SessionID, job.UserID, record.OrderID, err)
+			return false
+		}
+		scheduleUnpaidTimeout(ctx, s.unpaidQueue, record.OrderID, record.CreatedAt)
+		log.Infof("秒杀订单已存在，补记结果 (sessionID: %d, userID: %d, orderID: %s)", job.SessionID, job.UserID, record.OrderID)
+		return true
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Errorf("查询秒杀成交记录失败 (sessionID: %d, userID: %d): %v", job.SessionID, job.UserID, err)
+		return false
+	}
+
+	order, err := s.createOrder(ctx, job)
+	if err != nil {
+		log.Warnf("秒杀下单失败 (sessionID: %d, userID: %d): %v", job.SessionID, job.UserID, err)
+		if err := s.store.Fail(context.Background(), job.SessionID, job.UserID, err.Error()); err != nil {
+			log.Errorf("记录秒杀下单失败结果失败 (sessionID: %d, userID: %d): %v", job.SessionID, job.UserID, err)
+			return false
+		}
+		return true
+	}
+	scheduleUnpaidTimeout(ctx, s.unpaidQueue, order.OrderID, order.CreatedAt)
+	syncOrderProductIndex(ctx, s.orderDao, order.OrderID)
+	if err := s.store.Succeed(context.Background(), job.SessionID, job.UserID, order.OrderID); err != nil {
+		log.Errorf("记录秒杀下单结果失败 (sessionID: %d, userID: %d, orderID: %s): %v", job.SessionID, job.UserID, order.OrderID, err)
+		return false
+	}
+	log.Infof("秒杀订单已创建 (sessionID: %d, userID: %d, orderID: %s)", job.SessionID, job.UserID, order.OrderID)
+	return true
+}
+
+// createOrder 按秒杀价创建一件商品的待支付订单，库存扣减、超时关单与普通订单一致
+func (s *FlashSaleService) createOrder(ctx context.Context, job *flashSaleJob) (*model.Order, error) {
+	session, err := s.flashSaleDao.GetSession(ctx, job.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	address, err := loadShippingAddress(ctx, s.addressDao, job.UserID, job.AddressID)
+	if err != nil {
+		return nil, err
+	}
+	order, err := newOrderFromAddress(ctx, s.rates, job.UserID, address, job.Currency)
+	if err != nil {
+		return nil, err
+	}
+	items := []types.OrderItemReq{{ProductID: session.ProductID, SkuID: session.SkuID, Quantity: 1}}
+	if _, err := s.orderDao.CreateOrder(ctx, order, items, &flashSalePricer{sessionID: session.ID}); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// preloadSessions 预热所有未结束的已启用场次
+func (s *FlashSaleService) preloadSessions(ctx context.Context) {
+	sessions, err := s.flashSaleDao.ListUnfinishedSessions(ctx, time.Now())
+	if err != nil {
+		log.Errorf("加载秒杀场次失败: %v", err)
+		return
+	}
+	for i := range sessions {
+		s.preload(ctx, &sessions[i])
+	}
+}
+
+// preload 将场次剩余库存预热到 Redis，失败时等待下一轮预热
+func (s *FlashSaleService) preload(ctx context.Context, session *model.FlashSaleSession) {
+	if s.store == nil {
+		return
+	}
+	err := s.store.Preload(ctx, cache.FlashSaleStock{
+		SessionID: session.ID,
+		Stock:     session.Stock - session.Sold,
+		StartAt:   session.StartAt,
+		EndAt:     session.EndAt,
+	})
+	if err != nil {
+		log.Errorf("秒杀场次预热失败 (sessionID: %d): %v", session.ID, err)
+	}
+}
+
+// flashSalePricer 秒杀下单的 dao.OrderPricer 实现：记录成交并将订单项单价改为秒杀价
+type flashSalePricer struct {
+	sessionID uint
+}
+
+// Price 在下单事务中记录成交，并按订单汇率将秒杀价写入订单项
+func (p *flashSalePricer) Price(ctx context.Context, tx *gorm.DB, order *model.Order, items []model.OrderItem) (money.Amount, error) {
+	session, err := dao.NewFlashSaleDao(tx).RecordOrder(ctx, p.sessionID, order.UserID, order.OrderID)
+	if err != nil {
+		return 0, err
+	}
+	for i := range items {
+		items[i].Cost = session.Price.Convert(order.ExchangeRate)
+	}
+	return 0, nil
+}
+
+// validateFlashSaleSession 校验秒杀场次的价格、数量与时间，价格与数量以秒杀 SKU 为准
+func validateFlashSaleSession(session *model.FlashSaleSession, sku *model.ProductSku, now time.Time) error {
+	if session.Price <= 0 {
+		return errors.New("秒杀价必须大于 0")
+	}
+	if session.Price >= sku.Price {
+		return errors.New("秒杀价必须低于商品原价")
+	}
+	if session.Stock <= 0 {
+		return errors.New("投放数量必须大于 0")
+	}
+	if session.Stock > sku.Stock {
+		return errors.New("投放数量不能超过商品库存")
+	}
+	if !session.StartAt.Before(session.EndAt) {
+		return errors.New("结束时间必须晚于开始时间")
+	}
+	if !session.EndAt.After(now) {
+		return errors.New("结束时间必须晚于当前时间")
+	}
+	return nil
+}
+
+// buildFlashSaleSessionResp 将秒杀场次转换为响应结构，价格按 rate 从基础币种换算为 currency
+// 需预加载商品与 SKU，原价与图片以 SKU 为准
+func buildFlashSaleSessionResp(session *model.FlashSaleSession, remaining int, rate money.Rate, currency string) *types.FlashSaleSessionResp {
+	if remaining < 0 {
+		remaining = 0
+	}
+	picture := session.Sku.Picture
+	if picture == "" {
+		picture = session.Product.Picture
+	}
+	return &types.FlashSaleSessionResp{
+		ID:             session.ID,
+		ProductID:      session.ProductID,
+		SkuID:          session.SkuID,
+		SkuSpecs:       session.Sku.SpecKey,
+		ProductName:    session.Product.Name,
+		ProductPicture: picture,
+		Price:          session.Price.Convert(rate),
+		OriginalPrice:  session.Sku.Price.Convert(rate),
+		Currency:       currency,
+		Stock:          session.Stock,
+		Remaining:      remaining,
+		StartAt:        session.StartAt.Unix(),
+		EndAt:          session.EndAt.Unix(),
+		Enabled:        session.Enabled,
+	}
+}
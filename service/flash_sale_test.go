@@ -0,0 +1,59 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"douyin/pkg/utils/money"
+	"douyin/repository/db/model"
+)
+
+func TestValidateFlashSaleSession(t *testing.T) {
+	now := time.Now()
+	sku := &model.ProductSku{Price: 10000, Stock: 50}
+	newSession := func() *model.FlashSaleSession {
+		return &model.FlashSaleSession{
+			Price:   1990,
+			Stock:   20,
+			StartAt: now.Add(time.Hour),
+			EndAt:   now.Add(2 * time.Hour),
+		}
+	}
+	assert.NoError(t, validateFlashSaleSession(newSession(), sku, now))
+
+	notCheaper := newSession()
+	notCheaper.Price = 10000
+	assert.Error(t, validateFlashSaleSession(notCheaper, sku, now))
+
+	overStock := newSession()
+	overStock.Stock = 51
+	assert.Error(t, validateFlashSaleSession(overStock, sku, now))
+
+	badWindow := newSession()
+	badWindow.EndAt = badWindow.StartAt
+	assert.Error(t, validateFlashSaleSession(badWindow, sku, now))
+
+	finished := newSession()
+	finished.StartAt, finished.EndAt = now.Add(-2*time.Hour), now.Add(-time.Hour)
+	assert.Error(t, validateFlashSaleSession(finished, sku, now))
+}
+
+func TestBuildFlashSaleSessionResp(t *testing.T) {
+	session := &model.FlashSaleSession{
+		ID:      1,
+		SkuID:   11,
+		Price:   1000,
+		Stock:   10,
+		Product: model.Product{Name: "A", Picture: "a.png", Price: 4000},
+		Sku:     model.ProductSku{ID: 11, SpecKey: "颜色:红", Price: 5000},
+	}
+	resp := buildFlashSaleSessionResp(session, -1, money.Rate(2*money.RateScale), "EUR")
+	assert.Equal(t, money.Amount(2000), resp.Price)
+	assert.Equal(t, money.Amount(10000), resp.OriginalPrice, "原价以 SKU 为准")
+	assert.Equal(t, "颜色:红", resp.SkuSpecs)
+	assert.Equal(t, "a.png", resp.ProductPicture, "SKU 没有图片时使用商品图片")
+	assert.Equal(t, "EUR", resp.Currency)
+	assert.Equal(t, 0, resp.Remaining)
+}
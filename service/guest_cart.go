@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"douyin/config"
+	"douyin/consts"
+	"douyin/pkg/utils/encryption"
+	"douyin/pkg/utils/log"
+	"douyin/repository/cache"
+	"douyin/repository/db/dao"
+	"douyin/repository/db/model"
+	"douyin/types"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// guestCartTokenBytes 游客购物车令牌的随机字节数
+const guestCartTokenBytes = 16
+
+// ErrGuestCartUnavailable Redis 未初始化时游客购物车不可用
+var ErrGuestCartUnavailable = errors.New("游客购物车暂不可用")
+
+// GuestCartService 游客购物车服务
+// 未登录访客的购物车以 Redis 哈希保存（field 为 SKU ID，value 为数量），通过签名 Cookie 中的令牌定位；
+// 游客购物车不预占库存，只在加购时按可售数量校验，登录后合并到用户购物车时才开始预占
+type GuestCartService struct {
+	cartDao        *dao.CartDao
+	skuDao         *dao.ProductSkuDao
+	reservationDao *dao.StockReservationDao
+	rates          *ExchangeRateService
+}
+
+// NewGuestCartService 创建 GuestCartService 实例
+func NewGuestCartService(db *gorm.DB) *GuestCartService {
+	return &GuestCartService{
+		cartDao:        dao.NewCartDao(db),
+		skuDao:         dao.NewProductSkuDao(db),
+		reservationDao: dao.NewStockReservationDao(db),
+		rates:          NewExchangeRateService(db),
+	}
+}
+
+// NewGuestCartToken 生成新的游客购物车令牌，返回令牌与写入 Cookie 的签名值
+func NewGuestCartToken() (token, cookie string, err error) {
+	buf := make([]byte, guestCartTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(buf)
+	return token, token + "." + encryption.SignHMAC(config.GetGuestCartSecret(), []byte(token)), nil
+}
+
+// ParseGuestCartToken 校验 Cookie 值的签名并返回令牌，签名无效时返回 false
+func ParseGuestCartToken(cookie string) (string, bool) {
+	token, signature, found := strings.Cut(cookie, ".")
+	if !found || len(token) != guestCartTokenBytes*2 {
+		return "", false
+	}
+	if _, err := hex.DecodeString(token); err != nil {
+		return "", false
+	}
+	if !encryption.VerifyHMAC(config.GetGuestCartSecret(), signature, []byte(token)) {
+		return "", false
+	}
+	return token, true
+}
+
+// GetCart 获取游客购物车，商品信息与价格均为最新数据；token 为空时返回空购物车
+func (s *GuestCartService) GetCart(ctx context.Context, token, currency string) (*types.CartResp, error) {
+	items, err := s.loadItems(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	skuIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		skuIDs = append(skuIDs, item.SkuID)
+	}
+	skus, err := s.cartDao.ListCartSkus(ctx, skuIDs)
+	if err != nil {
+		return nil, err
+	}
+	// 游客没有自己的预占，所有已登录用户的预占都需要扣除
+	reserved, err := s.reservationDao.SumReservedByOthersBatch(ctx, skuIDs, 0)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := s.rates.DisplayQuote(ctx, 0, currency)
+	if err != nil {
+		return nil, err
+	}
+	return buildCartResp(items, skus, reserved, quote), nil
+}
+
+// AddItem 往游客购物车中添加商品，SKU 的确定规则见 dao.ProductSkuDao.ResolveSku；quantity 为增量，累加后的数量小于1时拒绝操作
+func (s *GuestCartService) AddItem(ctx context.Context, token string, productID, skuID uint, quantity int32) error {
+	rdb, err := guestCartRedis()
+	if err != nil {
+		return err
+	}
+	sku, err := s.resolveSku(ctx, productID, skuID)
+	if err != nil {
+		return err
+	}
+	key := cache.GuestCartKey(token)
+	current, err := rdb.HGet(ctx, key, strconv.FormatUint(uint64(sku.ID), 10)).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	next := int32(current) + quantity
+	if next < 1 {
+		return fmt.Errorf("AddItem: 最终商品数量小于1，操作非法")
+	}
+	return s.setQuantity(ctx, rdb, token, sku, next)
+}
+
+// SetQuantity 将游客购物车中的商品设置为指定数量，为 0 时移除该商品
+func (s *GuestCartService) SetQuantity(ctx context.Context, token string, productID, skuID uint, quantity int32) error {
+	rdb, err := guestCartRedis()
+	if err != nil {
+		return err
+	}
+	sku, err := s.resolveSku(ctx, productID, skuID)
+	if err != nil {
+		return err
+	}
+	if quantity == 0 {
+		return s.RemoveItems(ctx, token, []uint{sku.ID})
+	}
+	return s.setQuantity(ctx, rdb, token, sku, quantity)
+}
+
+// RemoveItems 从游客购物车中移除指定 SKU
+func (s *GuestCartService) RemoveItems(ctx context.Context, token string, skuIDs []uint) error {
+	rdb, err := guestCartRedis()
+	if err != nil {
+		return err
+	}
+	fields := make([]string, 0, len(skuIDs))
+	for _, id := range skuIDs {
+		fields = append(fields, strconv.FormatUint(uint64(id), 10))
+	}
+	return rdb.HDel(ctx, cache.GuestCartKey(token), fields...).Err()
+}
+
+// EmptyCart 清空游客购物车
+func (s *GuestCartService) EmptyCart(ctx context.Context, token string) error {
+	rdb, err := guestCartRedis()
+	if err != nil {
+		return err
+	}
+	return rdb.Del(ctx, cache.GuestCartKey(token)).Err()
+}
+
+// MergeIntoUser 登录成功后将游客购物车合并到用户购物车，合并规则见 CartDao.MergeItem
+// 每个商品单独合并并在成功后从游客购物车中删除，中途失败时已合并的商品不会在下次登录时重复累加；
+// 商品已下架或无可售库存时跳过并在结果中说明，全部处理完后删除游客购物车
+func (s *GuestCartService) MergeIntoUser(ctx context.Context, token string, userID uint) (*types.CartMergeResp, error) {
+	rdb, err := guestCartRedis()
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.loadItems(ctx, token)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+
+	skuIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		skuIDs = append(skuIDs, item.SkuID)
+	}
+	skus, err := s.cartDao.ListCartSkus(ctx, skuIDs)
+	if err != nil {
+		return nil, err
+	}
+	productIDs := make(map[uint]uint, len(skus))
+	for _, sku := range skus {
+		productIDs[sku.ID] = sku.ProductID
+	}
+
+	key := cache.GuestCartKey(token)
+	holdUntil := time.Now().Add(config.GetCartReservationTTL())
+	resp := &types.CartMergeResp{Items: make([]types.CartMergeItem, 0, len(items))}
+	for _, item := range items {
+		result := types.CartMergeItem{ProductID: productIDs[item.SkuID], SkuID: item.SkuID, Requested: item.Quantity}
+		previous, merged, err := s.cartDao.MergeItem(ctx, userID, item.SkuID, item.Quantity, holdUntil)
+		switch {
+		case errors.Is(err, dao.ErrCartProductNotFound), errors.Is(err, dao.ErrCartItemUnavailable):
+			result.Skipped = true
+			result.Reason = err.Error()
+		case err != nil:
+			return nil, err
+		default:
+			result.Previous = previous
+			result.Quantity = merged
+			if merged < previous+item.Quantity {
+				result.Adjusted = true
+				result.Reason = "超出单个商品数量上限或可售库存，已按可购买数量合并"
+			}
+		}
+		if err := rdb.HDel(ctx, key, strconv.FormatUint(uint64(item.SkuID), 10)).Err(); err != nil {
+			log.Warnf("删除已合并的游客购物车商品失败 (token: %s, skuID: %d): %v", token, item.SkuID, err)
+		}
+		resp.Items = append(resp.Items, result)
+	}
+	if err := rdb.Del(ctx, key).Err(); err != nil {
+		log.Warnf("删除游客购物车失败 (token: %s): %v", token, err)
+	}
+	log.Infof("游客购物车已合并到用户 %d 的购物车，共 %d 个商品", userID, len(resp.Items))
+	return resp, nil
+}
+
+// resolveSku 确定游客加购的 SKU，商品或 SKU 不存在时返回 dao.ErrCartProductNotFound
+func (s *GuestCartService) resolveSku(ctx context.Context, productID, skuID uint) (*model.ProductSku, error) {
+	sku, err := s.skuDao.ResolveSku(ctx, productID, skuID, false)
+	if errors.Is(err, dao.ErrSkuNotFound) {
+		return nil, dao.ErrCartProductNotFound
+	}
+	return sku, err
+}
+
+// setQuantity 按 SKU 可售数量校验后写入游客购物车，并顺延购物车的保留时长
+func (s *GuestCartService) setQuantity(ctx context.Context, rdb *redis.Client, token string, sku *model.ProductSku, quantity int32) error {
+	if quantity > consts.CartItemMaxQuantity {
+		return fmt.Errorf("单个商品最多购买 %d 件", consts.CartItemMaxQuantity)
+	}
+	reserved, err := s.reservationDao.SumReservedByOthers(ctx, sku.ID, 0)
+	if err != nil {
+		return err
+	}
+	if sku.Stock-reserved < int(quantity) {
+		return errors.New("库存不足: " + sku.Product.Name)
+	}
+
+	key := cache.GuestCartKey(token)
+	pipe := rdb.TxPipeline()
+	pipe.HSet(ctx, key, strconv.FormatUint(uint64(sku.ID), 10), quantity)
+	pipe.Expire(ctx, key, config.GetGuestCartTTL())
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// loadItems 读取游客购物车，按 SKU ID 排序以保证展示顺序稳定；ProductID 在查询 SKU 后由 buildCartResp 补全
+func (s *GuestCartService) loadItems(ctx context.Context, token string) ([]model.CartItem, error) {
+	if token == "" {
+		return nil, nil
+	}
+	rdb, err := guestCartRedis()
+	if err != nil {
+		return nil, err
+	}
+	fields, err := rdb.HGetAll(ctx, cache.GuestCartKey(token)).Result()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]model.CartItem, 0, len(fields))
+	for field, value := range fields {
+		skuID, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			continue
+		}
+		quantity, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || quantity < 1 {
+			continue
+		}
+		items = append(items, model.CartItem{SkuID: uint(skuID), Quantity: int32(quantity), Selected: true})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].SkuID < items[j].SkuID })
+	return items, nil
+}
+
+// guestCartRedis 返回 Redis 客户端，未初始化时返回 ErrGuestCartUnavailable
+func guestCartRedis() (*redis.Client, error) {
+	if cache.RedisClient == nil {
+		return nil, ErrGuestCartUnavailable
+	}
+	return cache.RedisClient, nil
+}
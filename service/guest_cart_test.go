@@ -0,0 +1,36 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"douyin/config"
+)
+
+func TestGuestCartToken(t *testing.T) {
+	previous := config.GlobalConfig
+	config.GlobalConfig = &config.Conf{EncryptSecret: &config.EncryptSecret{CartSecret: "CartSecretTest"}}
+	defer func() { config.GlobalConfig = previous }()
+
+	token, cookie, err := NewGuestCartToken()
+	require.NoError(t, err)
+	assert.Len(t, token, guestCartTokenBytes*2)
+
+	parsed, ok := ParseGuestCartToken(cookie)
+	assert.True(t, ok)
+	assert.Equal(t, token, parsed)
+
+	_, ok = ParseGuestCartToken(token)
+	assert.False(t, ok, "缺少签名")
+
+	other, _, err := NewGuestCartToken()
+	require.NoError(t, err)
+	_, ok = ParseGuestCartToken(other + cookie[len(token):])
+	assert.False(t, ok, "令牌被替换")
+
+	config.GlobalConfig.EncryptSecret.CartSecret = ""
+	_, ok = ParseGuestCartToken(cookie)
+	assert.False(t, ok, "未配置密钥")
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"douyin/consts"
+	"douyin/pkg/utils/log"
+	"douyin/repository/db/dao"
+	"douyin/repository/db/model"
+	"douyin/types"
+	"gorm.io/gorm"
+)
+
+// InventoryService 库存服务：维护仓库，办理入库与盘点调整，查询库存水位与流水，并对账
+// 下单占用、支付出库、取消释放与退货入库由订单、支付与售后流程在各自的事务中调用 dao.InventoryDao 完成，
+// 所有库存变动都会写入只追加的库存流水，对账时以流水为准核对库存水位，以各仓库可售数量之和核对 SKU 库存
+type InventoryService struct {
+	inventoryDao *dao.InventoryDao
+}
+
+// NewInventoryService 创建新的 InventoryService 实例
+func NewInventoryService(db *gorm.DB) *InventoryService {
+	return &InventoryService{
+		inventoryDao: dao.NewInventoryDao(db),
+	}
+}
+
+// CreateWarehouse 创建仓库，编码统一转为大写且不能重复
+func (s *InventoryService) CreateWarehouse(ctx context.Context, req *types.WarehouseCreateReq) (*types.WarehouseResp, error) {
+	warehouse := &model.Warehouse{
+		Code:     strings.ToUpper(strings.TrimSpace(req.Code)),
+		Name:     req.Name,
+		Address:  req.Address,
+		Priority: req.Priority,
+		Enabled:  true,
+	}
+	if err := s.inventoryDao.CreateWarehouse(ctx, warehouse); err != nil {
+		if errors.Is(err, dao.ErrWarehouseCodeExists) {
+			return nil, fmt.Errorf("仓库编码 %s 已存在", warehouse.Code)
+		}
+		log.Errorf("创建仓库失败 (code: %s): %v", warehouse.Code, err)
+		return nil, err
+	}
+	log.Infof("仓库已创建 (warehouseID: %d, code: %s)", warehouse.ID, warehouse.Code)
+	return buildWarehouseResp(warehouse), nil
+}
+
+// UpdateWarehouse 修改仓库名称、地址、优先级或启用状态
+func (s *InventoryService) UpdateWarehouse(ctx context.Context, req *types.WarehouseUpdateReq) (*types.WarehouseResp, error) {
+	warehouse, err := s.inventoryDao.GetWarehouse(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		warehouse.Name = *req.Name
+	}
+	if req.Address != nil {
+		warehouse.Address = *req.Address
+	}
+	if req.Priority != nil {
+		warehouse.Priority = *req.Priority
+	}
+	if req.Enabled != nil {
+		warehouse.Enabled = *req.Enabled
+	}
+	if err := s.inventoryDao.UpdateWarehouse(ctx, warehouse); err != nil {
+		log.Errorf("修改仓库失败 (warehouseID: %d): %v", req.ID, err)
+		return nil, err
+	}
+	return buildWarehouseResp(warehouse), nil
+}
+
+// ListWarehouses 按分配优先级列出全部仓库
+func (s *InventoryService) ListWarehouses(ctx context.Context) ([]*types.WarehouseResp, error) {
+	warehouses, err := s.inventoryDao.ListWarehouses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]*types.WarehouseResp, 0, len(warehouses))
+	for i := range warehouses {
+		resp = append(resp, buildWarehouseResp(&warehouses[i]))
+	}
+	return resp, nil
+}
+
+// Receive 仓库入库，增加在库数量与 SKU 可售库存
+func (s *InventoryService) Receive(ctx context.Context, adminID uint, req *types.InventoryReceiveReq) (*types.InventoryLevelResp, error) {
+	level, err := s.inventoryDao.Receive(ctx, req.WarehouseID, req.SkuID, req.Quantity, dao.InventoryMoveRef{
+		RefType: consts.InventoryRefManual,
+		RefID:   req.RefID,
+		Actor:   consts.OrderActorAdmin,
+		ActorID: adminID,
+		Remark:  req.Remark,
+	})
+	if err != nil {
+		log.Errorf("入库失败 (warehouseID: %d, skuID: %d): %v", req.WarehouseID, req.SkuID, err)
+		return nil, err
+	}
+	log.Infof("入库完成 (warehouseID: %d, skuID: %d, quantity: %d, adminID: %d)", req.WarehouseID, req.SkuID, req.Quantity, adminID)
+	syncProductIndex(ctx, level.ProductID)
+	return buildInventoryLevelResp(level), nil
+}
+
+// Adjust 盘点调整在库数量，调整后不能低于已被订单占用的数量
+func (s *InventoryService) Adjust(ctx context.Context, adminID uint, req *types.InventoryAdjustReq) (*types.InventoryLevelResp, error) {
+	level, err := s.inventoryDao.Adjust(ctx, req.WarehouseID, req.SkuID, req.Delta, dao.InventoryMoveRef{
+		RefType: consts.InventoryRefManual,
+		RefID:   req.RefID,
+		Actor:   consts.OrderActorAdmin,
+		ActorID: adminID,
+		Remark:  req.Remark,
+	})
+	if err != nil {
+		log.Errorf("库存调整失败 (warehouseID: %d, skuID: %d, delta: %d): %v", req.WarehouseID, req.SkuID, req.Delta, err)
+		return nil, err
+	}
+	log.Infof("库存调整完成 (warehouseID: %d, skuID: %d, delta: %d, adminID: %d)", req.WarehouseID, req.SkuID, req.Delta, adminID)
+	syncProductIndex(ctx, level.ProductID)
+	return buildInventoryLevelResp(level), nil
+}
+
+// ListLevels 分页查询库存水位
+func (s *InventoryService) ListLevels(ctx context.Context, req *types.InventoryLevelListReq) (*types.DataListResp, error) {
+	levels, total, err := s.inventoryDao.ListLevels(ctx, req.WarehouseID, req.SkuID, req.PageNum, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*types.InventoryLevelResp, 0, len(levels))
+	for i := range levels {
+		items = append(items, buildInventoryLevelResp(&levels[i]))
+	}
+	return &types.DataListResp{
+		Item:  items,
+		Total: total,
+	}, nil
+}
+
+// ListMovements 按时间倒序分页查询库存流水
+func (s *InventoryService) ListMovements(ctx context.Context, req *types.InventoryMovementListReq) (*types.DataListResp, error) {
+	movements, total, err := s.inventoryDao.ListMovements(ctx, &dao.InventoryMovementFilter{
+		WarehouseID: req.WarehouseID,
+		SkuID:       req.SkuID,
+		Type:        req.Type,
+		RefType:     req.RefType,
+		RefID:       req.RefID,
+	}, req.PageNum, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*types.InventoryMovementResp, 0, len(movements))
+	for i := range movements {
+		items = append(items, buildInventoryMovementResp(&movements[i]))
+	}
+	return &types.DataListResp{
+		Item:  items,
+		Total: total,
+	}, nil
+}
+
+// Reconcile 库存对账：库存水位应等于其流水变动之和，SKU 库存应等于各仓库可售数量之和
+// fixSkuStock 为 true 时按仓库库存重写不一致的 SKU 库存；库存水位与流水的差异需人工核实后通过盘点调整处理
+func (s *InventoryService) Reconcile(ctx context.Context, fixSkuStock bool) (*types.InventoryReconcileResp, error) {
+	levelChecks, err := s.inventoryDao.ListLevelChecks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	skuChecks, err := s.inventoryDao.ListSkuStockChecks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &types.InventoryReconcileResp{
+		CheckedLevels: len(levelChecks),
+		CheckedSkus:   len(skuChecks),
+		Discrepancies: findInventoryDiscrepancies(levelChecks, skuChecks),
+	}
+	for i := range resp.Discrepancies {
+		d := &resp.Discrepancies[i]
+		log.Warnf("库存对账差异 (kind: %s, warehouseID: %d, skuID: %d, field: %s, recorded: %d, expected: %d)",
+			d.Kind, d.WarehouseID, d.SkuID, d.Field, d.Recorded, d.Expected)
+		if !fixSkuStock || d.Kind != inventoryDiscrepancySku {
+			continue
+		}
+		if err := s.inventoryDao.SyncSkuStock(ctx, d.SkuID); err != nil {
+			log.Errorf("修正 SKU 库存失败 (skuID: %d): %v", d.SkuID, err)
+			continue
+		}
+		d.Fixed = true
+	}
+	return resp, nil
+}
+
+// 对账差异类型
+const (
+	inventoryDiscrepancyLevel = "level"
+	inventoryDiscrepancySku   = "sku"
+)
+
+// findInventoryDiscrepancies 比较库存水位与流水合计、SKU 库存与各仓库可售数量之和，返回全部不一致项
+func findInventoryDiscrepancies(levels []dao.InventoryLevelCheck, skus []dao.SkuStockCheck) []types.InventoryDiscrepancy {
+	discrepancies := make([]types.InventoryDiscrepancy, 0)
+	for _, level := range levels {
+		if level.OnHand != level.LedgerOnHand {
+			discrepancies = append(discrepancies, types.InventoryDiscrepancy{
+				Kind:        inventoryDiscrepancyLevel,
+				WarehouseID: level.WarehouseID,
+				SkuID:       level.SkuID,
+				Field:       "on_hand",
+				Recorded:    level.OnHand,
+				Expected:    level.LedgerOnHand,
+			})
+		}
+		if level.Reserved != level.LedgerReserved {
+			discrepancies = append(discrepancies, types.InventoryDiscrepancy{
+				Kind:        inventoryDiscrepancyLevel,
+				WarehouseID: level.WarehouseID,
+				SkuID:       level.SkuID,
+				Field:       "reserved",
+				Recorded:    level.Reserved,
+				Expected:    level.LedgerReserved,
+			})
+		}
+	}
+	for _, sku := range skus {
+		if sku.Stock != sku.LevelAvailable {
+			discrepancies = append(discrepancies, types.InventoryDiscrepancy{
+				Kind:     inventoryDiscrepancySku,
+				SkuID:    sku.SkuID,
+				Field:    "stock",
+				Recorded: sku.Stock,
+				Expected: sku.LevelAvailable,
+			})
+		}
+	}
+	return discrepancies
+}
+
+// buildWarehouseResp 将仓库模型转换为响应结构
+func buildWarehouseResp(warehouse *model.Warehouse) *types.WarehouseResp {
+	return &types.WarehouseResp{
+		ID:       warehouse.ID,
+		Code:     warehouse.Code,
+		Name:     warehouse.Name,
+		Address:  warehouse.Address,
+		Priority: warehouse.Priority,
+		Enabled:  warehouse.Enabled,
+	}
+}
+
+// buildInventoryLevelResp 将库存水位模型转换为响应结构
+func buildInventoryLevelResp(level *model.InventoryLevel) *types.InventoryLevelResp {
+	return &types.InventoryLevelResp{
+		WarehouseID: level.WarehouseID,
+		SkuID:       level.SkuID,
+		ProductID:   level.ProductID,
+		OnHand:      level.OnHand,
+		Reserved:    level.Reserved,
+		Available:   level.Available(),
+		UpdatedAt:   level.UpdatedAt.Unix(),
+	}
+}
+
+// buildInventoryMovementResp 将库存流水模型转换为响应结构
+func buildInventoryMovementResp(movement *model.InventoryMovement) *types.InventoryMovementResp {
+	return &types.InventoryMovementResp{
+		ID:             movement.ID,
+		WarehouseID:    movement.WarehouseID,
+		SkuID:          movement.SkuID,
+		ProductID:      movement.ProductID,
+		Type:           movement.Type,
+		OnHandChange:   movement.OnHandChange,
+		ReservedChange: movement.ReservedChange,
+		OnHandAfter:    movement.OnHandAfter,
+		ReservedAfter:  movement.ReservedAfter,
+		RefType:        movement.RefType,
+		RefID:          movement.RefID,
+		Actor:          movement.Actor,
+		ActorID:        movement.ActorID,
+		Remark:         movement.Remark,
+		CreatedAt:      movement.CreatedAt.Unix(),
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"douyin/repository/db/dao"
+)
+
+func TestFindInventoryDiscrepancies(t *testing.T) {
+	levels := []dao.InventoryLevelCheck{
+		{WarehouseID: 1, SkuID: 10, OnHand: 8, Reserved: 2, LedgerOnHand: 8, LedgerReserved: 2},
+		{WarehouseID: 2, SkuID: 10, OnHand: 5, Reserved: 1, LedgerOnHand: 3, LedgerReserved: 0},
+	}
+	skus := []dao.SkuStockCheck{
+		{SkuID: 10, Stock: 10, LevelAvailable: 10},
+		{SkuID: 11, Stock: 4, LevelAvailable: 0},
+	}
+
+	discrepancies := findInventoryDiscrepancies(levels, skus)
+	require.Len(t, discrepancies, 3)
+	assert.Equal(t, inventoryDiscrepancyLevel, discrepancies[0].Kind)
+	assert.Equal(t, uint(2), discrepancies[0].WarehouseID)
+	assert.Equal(t, "on_hand", discrepancies[0].Field)
+	assert.Equal(t, 5, discrepancies[0].Recorded)
+	assert.Equal(t, 3, discrepancies[0].Expected)
+	assert.Equal(t, "reserved", discrepancies[1].Field)
+	assert.Equal(t, inventoryDiscrepancySku, discrepancies[2].Kind)
+	assert.Equal(t, uint(11), discrepancies[2].SkuID)
+	assert.Equal(t, 0, discrepancies[2].Expected)
+
+	assert.Empty(t, findInventoryDiscrepancies(levels[:1], skus[:1]), "账实一致时没有差异")
+}
@@ -0,0 +1,383 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"douyin/config"
+	"douyin/consts"
+	"douyin/pkg/utils/log"
+	"douyin/repository/db/dao"
+	"douyin/repository/db/model"
+	"douyin/types"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrMerchantRequired 用户未开设店铺
+	ErrMerchantRequired = errors.New("请先开设店铺")
+	// ErrMerchantSuspended 店铺已被停用
+	ErrMerchantSuspended = errors.New("店铺已被停用，暂不能发布或修改商品")
+	// ErrProductForbidden 商品不属于当前用户的店铺
+	ErrProductForbidden = errors.New("无权操作该商品")
+)
+
+// MerchantService 商家服务：开设与维护店铺、查看店铺的商品与订单、货款结算
+// 商品在发布时归属于店主的店铺，订单项快照商品所属商家；订单支付成功后按商家写入待结算货款，售后退款时扣回，
+// 结算时将待结算明细的合计记入店主钱包
+type MerchantService struct {
+	merchantDao *dao.MerchantDao
+}
+
+// NewMerchantService 创建新的 MerchantService 实例
+func NewMerchantService(db *gorm.DB) *MerchantService {
+	return &MerchantService{
+		merchantDao: dao.NewMerchantDao(db),
+	}
+}
+
+// Register 为用户开设店铺，联系邮箱为空时使用店主账号的邮箱
+func (s *MerchantService) Register(ctx context.Context, userID uint, req *types.MerchantRegisterReq) (*types.MerchantResp, error) {
+	contactEmail := req.ContactEmail
+	if contactEmail == "" {
+		user, err := dao.NewUserDao(ctx).GetUserById(userID)
+		if err != nil {
+			log.Errorf("开设店铺时查询用户 %d 失败: %v", userID, err)
+			return nil, err
+		}
+		contactEmail = user.Email
+	}
+	if contactEmail == "" {
+		return nil, errors.New("请填写联系邮箱")
+	}
+
+	merchant := &model.Merchant{
+		UserID:       userID,
+		Name:         strings.TrimSpace(req.Name),
+		Description:  req.Description,
+		ContactEmail: contactEmail,
+		Status:       consts.MerchantStatusActive,
+	}
+	if err := s.merchantDao.CreateMerchant(ctx, merchant); err != nil {
+		if !errors.Is(err, dao.ErrMerchantExists) && !errors.Is(err, dao.ErrMerchantNameExists) {
+			log.Errorf("开设店铺失败 (userID: %d): %v", userID, err)
+		}
+		return nil, err
+	}
+	log.Infof("店铺已开设 (merchantID: %d, userID: %d, name: %s)", merchant.ID, userID, merchant.Name)
+	return buildMerchantResp(merchant), nil
+}
+
+// GetProfile 查询用户的店铺资料
+func (s *MerchantService) GetProfile(ctx context.Context, userID uint) (*types.MerchantResp, error) {
+	merchant, err := s.currentMerchant(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return buildMerchantResp(merchant), nil
+}
+
+// UpdateProfile 修改店铺名称、简介或联系邮箱
+func (s *MerchantService) UpdateProfile(ctx context.Context, userID uint, req *types.MerchantUpdateReq) (*types.MerchantResp, error) {
+	merchant, err := s.currentMerchant(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		merchant.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		merchant.Description = *req.Description
+	}
+	if req.ContactEmail != nil {
+		merchant.ContactEmail = *req.ContactEmail
+	}
+	if err := s.merchantDao.UpdateMerchant(ctx, merchant); err != nil {
+		if !errors.Is(err, dao.ErrMerchantNameExists) {
+			log.Errorf("修改店铺资料失败 (merchantID: %d): %v", merchant.ID, err)
+		}
+		return nil, err
+	}
+	return buildMerchantResp(merchant), nil
+}
+
+// ListProducts 分页查询店铺的商品，价格为基础币种
+func (s *MerchantService) ListProducts(ctx context.Context, userID uint, req *types.BasePage) (*types.DataListResp, error) {
+	merchant, err := s.currentMerchant(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	products, total, err := s.merchantDao.ListMerchantProducts(ctx, merchant.ID, req.PageNum, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]types.Product, 0, len(products))
+	for _, p := range products {
+		threshold := p.LowStockThreshold
+		items = append(items, types.Product{
+			ID:                uint32(p.ID),
+			Name:              p.Name,
+			Description:       p.Description,
+			Picture:           p.Picture,
+			MerchantID:        p.MerchantID,
+			Price:             p.Price,
+			Currency:          config.GetBaseCurrency(),
+			Stock:             p.Stock,
+			Version:           p.Version,
+			LowStockThreshold: &threshold,
+		})
+	}
+	return &types.DataListResp{
+		Item:  items,
+		Total: total,
+	}, nil
+}
+
+// ListOrders 分页查询店铺的子订单，每个子订单附带父订单的收货信息
+func (s *MerchantService) ListOrders(ctx context.Context, userID uint, req *types.MerchantOrderListReq) (*types.DataListResp, error) {
+	merchant, err := s.currentMerchant(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	subOrders, total, err := s.merchantDao.ListMerchantOrders(ctx, merchant.ID, req)
+	if err != nil {
+		log.Errorf("查询商家订单列表失败 (merchantID: %d): %v", merchant.ID, err)
+		return nil, err
+	}
+	items := make([]*types.OrderResp, 0, len(subOrders))
+	for i := range subOrders {
+		items = append(items, buildMerchantOrderResp(&subOrders[i]))
+	}
+	return &types.DataListResp{
+		Item:  items,
+		Total: total,
+	}, nil
+}
+
+// GetOrder 根据父订单ID查询店铺的子订单详情
+func (s *MerchantService) GetOrder(ctx context.Context, userID uint, orderID string) (*types.OrderResp, error) {
+	merchant, err := s.currentMerchant(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	subOrder, err := s.merchantDao.GetMerchantOrder(ctx, merchant.ID, orderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("订单不存在")
+		}
+		return nil, err
+	}
+	return buildMerchantOrderResp(subOrder), nil
+}
+
+// ListSettlements 分页查询店铺的结算明细
+func (s *MerchantService) ListSettlements(ctx context.Context, userID uint, req *types.MerchantSettlementListReq) (*types.DataListResp, error) {
+	merchant, err := s.currentMerchant(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	settlements, total, err := s.merchantDao.ListSettlements(ctx, merchant.ID, req.Status, req.PageNum, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*types.MerchantSettlementResp, 0, len(settlements))
+	for i := range settlements {
+		items = append(items, buildMerchantSettlementResp(&settlements[i]))
+	}
+	return &types.DataListResp{
+		Item:  items,
+		Total: total,
+	}, nil
+}
+
+// ListMerchants 管理员分页查询商家
+func (s *MerchantService) ListMerchants(ctx context.Context, req *types.MerchantListReq) (*types.DataListResp, error) {
+	merchants, total, err := s.merchantDao.ListMerchants(ctx, req.Status, req.PageNum, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*types.MerchantResp, 0, len(merchants))
+	for i := range merchants {
+		items = append(items, buildMerchantResp(&merchants[i]))
+	}
+	return &types.DataListResp{
+		Item:  items,
+		Total: total,
+	}, nil
+}
+
+// UpdateStatus 管理员启用或停用商家，停用后不能发布或修改商品，已产生的货款照常结算
+func (s *MerchantService) UpdateStatus(ctx context.Context, adminID uint, req *types.MerchantStatusReq) (*types.MerchantResp, error) {
+	merchant, err := s.merchantDao.GetMerchant(ctx, req.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+	merchant.Status = req.Status
+	if err := s.merchantDao.UpdateMerchant(ctx, merchant); err != nil {
+		log.Errorf("修改商家状态失败 (merchantID: %d): %v", merchant.ID, err)
+		return nil, err
+	}
+	log.Infof("商家状态已修改 (merchantID: %d, status: %s, adminID: %d)", merchant.ID, merchant.Status, adminID)
+	return buildMerchantResp(merchant), nil
+}
+
+// Settle 管理员发起货款结算：merchantID 不为 0 时只结算该商家，否则结算全部有待结算明细的商家
+// 每个商家在独立的事务中结算，某个商家失败（如扣回时余额不足）不影响其他商家
+func (s *MerchantService) Settle(ctx context.Context, merchantID uint) ([]types.MerchantSettleResult, error) {
+	merchantIDs := []uint{merchantID}
+	if merchantID == 0 {
+		var err error
+		if merchantIDs, err = s.merchantDao.ListPendingSettlementMerchantIDs(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	currency := config.GetBaseCurrency()
+	results := make([]types.MerchantSettleResult, 0, len(merchantIDs))
+	for _, id := range merchantIDs {
+		result := types.MerchantSettleResult{MerchantID: id, Currency: currency}
+		merchant, err := s.merchantDao.GetMerchant(ctx, id)
+		if err != nil {
+			if merchantID != 0 {
+				return nil, err
+			}
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		settled, err := s.merchantDao.SettleMerchant(ctx, merchant)
+		switch {
+		case err != nil:
+			log.Errorf("商家货款结算失败 (merchantID: %d): %v", id, err)
+			result.Error = err.Error()
+		case settled != nil:
+			log.Infof("商家货款已结算 (merchantID: %d, batchNo: %s, entries: %d, amount: %s %s)",
+				id, settled.BatchNo, settled.Entries, settled.Amount, currency)
+			result.BatchNo = settled.BatchNo
+			result.Entries = settled.Entries
+			result.Amount = settled.Amount
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// currentMerchant 查询当前用户的店铺，未开设时返回 ErrMerchantRequired
+func (s *MerchantService) currentMerchant(ctx context.Context, userID uint) (*model.Merchant, error) {
+	merchant, err := s.merchantDao.GetMerchantByUserID(ctx, userID)
+	if errors.Is(err, dao.ErrMerchantNotFound) {
+		return nil, ErrMerchantRequired
+	}
+	return merchant, err
+}
+
+// productMerchantForCreate 确定用户发布的商品所属的店铺：用户有正常营业的店铺时归属该店铺，
+// 没有店铺但拥有平台商品管理权限时作为平台自营商品（返回 0）
+func productMerchantForCreate(ctx context.Context, userID uint) (uint, error) {
+	merchant, err := dao.GetMerchantByUserID(ctx, userID)
+	if err == nil {
+		if merchant.Status != consts.MerchantStatusActive {
+			return 0, ErrMerchantSuspended
+		}
+		return merchant.ID, nil
+	}
+	if !errors.Is(err, dao.ErrMerchantNotFound) {
+		return 0, err
+	}
+	admin, err := dao.UserHasPermission(ctx, userID, consts.ProductManagePermission)
+	if err != nil {
+		return 0, err
+	}
+	if !admin {
+		return 0, fmt.Errorf("%w后再发布商品", ErrMerchantRequired)
+	}
+	return 0, nil
+}
+
+// checkProductOwnership 校验用户可以修改或删除商品：商品属于用户正常营业的店铺，或用户拥有平台商品管理权限
+func checkProductOwnership(ctx context.Context, userID, productID uint) error {
+	product, err := dao.GetProduct(uint32(productID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("商品不存在")
+		}
+		return err
+	}
+	admin, err := dao.UserHasPermission(ctx, userID, consts.ProductManagePermission)
+	if err != nil {
+		return err
+	}
+	if admin {
+		return nil
+	}
+
+	merchant, err := dao.GetMerchantByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, dao.ErrMerchantNotFound) {
+			return ErrProductForbidden
+		}
+		return err
+	}
+	return checkMerchantOwnsProduct(merchant, product)
+}
+
+// checkMerchantOwnsProduct 校验商品属于该店铺且店铺正常营业
+func checkMerchantOwnsProduct(merchant *model.Merchant, product *model.Product) error {
+	if product.MerchantID == 0 || product.MerchantID != merchant.ID {
+		return ErrProductForbidden
+	}
+	if merchant.Status != consts.MerchantStatusActive {
+		return ErrMerchantSuspended
+	}
+	return nil
+}
+
+// buildMerchantOrderResp 生成商家视角的订单响应：收货信息取自父订单，状态、金额与订单项为子订单的
+func buildMerchantOrderResp(subOrder *model.SubOrder) *types.OrderResp {
+	order := model.Order{OrderID: subOrder.OrderID, CreatedAt: subOrder.CreatedAt}
+	if subOrder.Order != nil {
+		order = *subOrder.Order
+	}
+	order.Status = subOrder.Status
+	order.DiscountAmount = subOrder.DiscountAmount
+	order.OrderItems = subOrder.OrderItems
+	order.SubOrders = nil
+	resp := buildOrderResp(&order)
+	resp.SubOrderID = subOrder.SubOrderID
+	return resp
+}
+
+// buildMerchantResp 将商家模型转换为响应结构
+func buildMerchantResp(merchant *model.Merchant) *types.MerchantResp {
+	return &types.MerchantResp{
+		ID:           merchant.ID,
+		UserID:       merchant.UserID,
+		Name:         merchant.Name,
+		Description:  merchant.Description,
+		ContactEmail: merchant.ContactEmail,
+		Status:       merchant.Status,
+		CreatedAt:    merchant.CreatedAt.Unix(),
+	}
+}
+
+// buildMerchantSettlementResp 将结算明细模型转换为响应结构
+func buildMerchantSettlementResp(settlement *model.MerchantSettlement) *types.MerchantSettlementResp {
+	resp := &types.MerchantSettlementResp{
+		ID:         settlement.ID,
+		Type:       settlement.Type,
+		Status:     settlement.Status,
+		OrderID:    settlement.OrderID,
+		RefundNo:   settlement.RefundNo,
+		Amount:     settlement.Amount,
+		Currency:   settlement.Currency,
+		BaseAmount: settlement.BaseAmount,
+		BatchNo:    settlement.BatchNo,
+		CreatedAt:  settlement.CreatedAt.Unix(),
+	}
+	if settlement.SettledAt != nil {
+		resp.SettledAt = settlement.SettledAt.Unix()
+	}
+	return resp
+}
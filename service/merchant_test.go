@@ -0,0 +1,52 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"douyin/consts"
+	"douyin/repository/db/model"
+)
+
+func TestCheckMerchantOwnsProduct(t *testing.T) {
+	merchant := &model.Merchant{ID: 7, Status: consts.MerchantStatusActive}
+
+	assert.NoError(t, checkMerchantOwnsProduct(merchant, &model.Product{ID: 1, MerchantID: 7}))
+	assert.ErrorIs(t, checkMerchantOwnsProduct(merchant, &model.Product{ID: 2, MerchantID: 8}), ErrProductForbidden)
+	assert.ErrorIs(t, checkMerchantOwnsProduct(merchant, &model.Product{ID: 3}), ErrProductForbidden, "平台自营商品只能由管理员修改")
+
+	merchant.Status = consts.MerchantStatusSuspended
+	assert.ErrorIs(t, checkMerchantOwnsProduct(merchant, &model.Product{ID: 1, MerchantID: 7}), ErrMerchantSuspended)
+}
+
+// TestBuildMerchantOrderResp 商家视角的订单为子订单，收货信息取自父订单
+func TestBuildMerchantOrderResp(t *testing.T) {
+	subOrder := &model.SubOrder{
+		SubOrderID:     "o-1-2",
+		OrderID:        "o-1",
+		MerchantID:     7,
+		Status:         consts.OrderTypeShipping,
+		Amount:         2150,
+		DiscountAmount: 150,
+		OrderItems: []model.OrderItem{
+			{ID: 3, SubOrderID: "o-1-2", MerchantID: 7, ProductID: 1, Cost: 1000, Quantity: 2, Discount: 120},
+			{ID: 4, SubOrderID: "o-1-2", MerchantID: 7, ProductID: 2, Cost: 300, Quantity: 1, Discount: 30},
+		},
+		Order: &model.Order{
+			OrderID:        "o-1",
+			Status:         consts.OrderTypePendingShipping,
+			DiscountAmount: 500, // 整单优惠，包含其他子订单的分摊部分
+			City:           "杭州",
+		},
+	}
+	resp := buildMerchantOrderResp(subOrder)
+	assert.Equal(t, "o-1", resp.OrderID)
+	assert.Equal(t, "o-1-2", resp.SubOrderID)
+	assert.Equal(t, consts.OrderTypeShipping, resp.Status)
+	assert.Equal(t, "杭州", resp.Address.City)
+	assert.Len(t, resp.Items, 2)
+	assert.EqualValues(t, 150, resp.DiscountAmount)
+	assert.EqualValues(t, 2150, resp.TotalAmount)
+	assert.Empty(t, resp.SubOrders)
+}
@@ -1,18 +1,37 @@
 package service
 
 import (
-	"douyin/pkg/utils/log"
 	"context"
+	"douyin/consts"
 	"douyin/pkg/utils/log"
+	"douyin/repository/cache"
 	"douyin/repository/db/dao"
+	"douyin/repository/db/model"
 	"douyin/types"
+	"errors"
+	"fmt"
 	"gorm.io/gorm"
 )
 
+// ErrIllegalOrderTransition 订单状态变更不符合状态机定义
+var ErrIllegalOrderTransition = errors.New("非法的订单状态变更")
+
+// buyerAllowedStatus 买家可以主动发起的目标状态，其余状态只能由支付、发货、退款等流程或系统任务驱动
+var buyerAllowedStatus = map[int]bool{
+	consts.OrderTypeCancelled: true,
+	consts.OrderTypeReceipt:   true,
+}
+
 // OrderService 订单服务
 type OrderService struct {
-	orderDao   *dao.OrderDao   // Renamed field for clarity
-	addressDao *dao.AddressDao // Added AddressDao
+	orderDao    *dao.OrderDao   // Renamed field for clarity
+	addressDao  *dao.AddressDao // Added AddressDao
+	subOrderDao *dao.SubOrderDao
+	shipmentDao *dao.ShipmentDao
+	rates       *ExchangeRateService
+	unpaidQueue *cache.DelayQueue     // 未支付订单超时关闭的延时队列，Redis 未初始化时为 nil
+	flashSales  *cache.FlashSaleStore // 秒杀库存与抢购结果，秒杀订单释放时归还名额，Redis 未初始化时为 nil
+	payments    *PaymentService       // 超时关单时查询处理中的支付单
 	// productDao *dao.ProductDao // Might be needed if product logic moves here
 }
 
@@ -31,47 +50,83 @@ func NewOrderService(db *gorm.DB) (*OrderService, error) {
 	log.Infof("订单服务使用的数据库连接成功")
 
 	return &OrderService{
-		orderDao:   dao.NewOrderDao(db),
-		addressDao: dao.NewAddressDao(db), // Initialize AddressDao
+		orderDao:    dao.NewOrderDao(db),
+		addressDao:  dao.NewAddressDao(db), // Initialize AddressDao
+		subOrderDao: dao.NewSubOrderDao(db),
+		shipmentDao: dao.NewShipmentDao(db),
+		rates:       NewExchangeRateService(db),
+		unpaidQueue: newUnpaidQueue(),
+		flashSales:  newFlashSaleStore(),
+		payments:    NewPaymentService(db),
 	}, nil
 }
 
-// CreateOrder handles the business logic for creating an order.
-// It fetches address details and then calls the order DAO.
-// The transactionality is currently handled within s.orderDao.CreateOrder.
-func (s *OrderService) CreateOrder(ctx context.Context, userID uint, addressID uint, items []types.OrderItemReq) (string, error) {
-	// Fetch address details using addressID
-	address, err := s.addressDao.GetAddressByID(ctx, userID, addressID)
+// CreateOrder 直接购买指定商品：校验收货地址后，在一个事务内创建订单、扣减库存、使用优惠券并生成待支付的支付单
+func (s *OrderService) CreateOrder(ctx context.Context, userID uint, req *types.CreateOrderReq) (*types.CheckoutResp, error) {
+	address, err := loadShippingAddress(ctx, s.addressDao, userID, req.AddressID)
+	if err != nil {
+		return nil, err
+	}
+	order, err := newOrderFromAddress(ctx, s.rates, userID, address, req.UserCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Service CreateOrder calling DAO with userID: %d, using addressID: %d", userID, req.AddressID)
+	payment, err := s.orderDao.CreateOrder(ctx, order, req.Items, newCouponPricer(req.CouponIDs))
+	if err != nil {
+		log.Errorf("创建订单失败 (userID: %d): %v", userID, err)
+		return nil, err
+	}
+	scheduleUnpaidTimeout(ctx, s.unpaidQueue, order.OrderID, order.CreatedAt)
+	syncOrderProductIndex(ctx, s.orderDao, order.OrderID)
+	return &types.CheckoutResp{
+		OrderID:        order.OrderID,
+		TransactionID:  payment.TransactionID,
+		TotalAmount:    payment.Amount,
+		DiscountAmount: order.DiscountAmount,
+	}, nil
+}
+
+// loadShippingAddress 查询属于用户的收货地址，下单和购物车结算共用
+func loadShippingAddress(ctx context.Context, addressDao *dao.AddressDao, userID, addressID uint) (*model.Address, error) {
+	address, err := addressDao.GetAddressByID(ctx, userID, addressID)
 	if err != nil {
 		log.Errorf("获取地址失败 (userID: %d, addressID: %d): %v", userID, addressID, err)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return "", errors.New("无效的地址ID或地址不属于该用户")
+			return nil, errors.New("无效的地址ID或地址不属于该用户")
 		}
-		return "", errors.New("获取地址信息时出错") // Generic error for other DB issues
+		return nil, errors.New("获取地址信息时出错") // Generic error for other DB issues
 	}
+	return address, nil
+}
 
-	// Adapt the service layer request (with AddressID and simplified items)
-	// to the DAO layer's expected types.CreateOrderReq structure.
-	daoOrderItems := make([]types.OrderItemReq, len(items))
-	for i, item := range items {
-		// Assuming the types.OrderItemReq for DAO is the same as for service input after previous refactor.
-		// If DAO expects a different structure or type for Quantity (e.g. int32 vs int), adjust here.
-		daoOrderItems[i] = types.OrderItemReq{
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity, // This now uses the updated types.OrderItemReq with int Quantity
+// newOrderFromAddress 根据收货地址构造待创建的订单，地址未填写邮箱时使用用户账号邮箱
+// 未指定币种时使用用户资料中的偏好币种，仍为空则使用基础币种；汇率在此取快照保存到订单
+func newOrderFromAddress(ctx context.Context, rates *ExchangeRateService, userID uint, address *model.Address, currency string) (*model.Order, error) {
+	email := address.Email
+	if email == "" || currency == "" {
+		if user, err := dao.NewUserDao(ctx).GetUserById(userID); err == nil {
+			if email == "" {
+				email = user.Email
+			}
+			if currency == "" {
+				currency = user.Currency
+			}
+		} else {
+			log.Warnf("获取用户信息失败 (userID: %d): %v", userID, err)
 		}
 	}
-
-	// Populate the DAO request DTO with fetched address details.
-	// UserCurrency might come from user profile or be determined by region/address.
-	// For now, using placeholder or from address if available.
-	userCurrency := "USD" // Placeholder, or determine from address.Country or user profile.
-	// if address.UserCurrency != "" { userCurrency = address.UserCurrency }
-
-
-	daoReq := &types.CreateOrderReq{ // This refers to the types.CreateOrderReq used by orderDao.CreateOrder
-		UserCurrency:  userCurrency,
-		Email:         address.Email, // Assuming Email is part of the address model, or fetch from user profile
+	quote, err := rates.Quote(ctx, currency)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Order{
+		UserID:        userID,
+		UserCurrency:  quote.Currency,
+		BaseCurrency:  quote.Base,
+		ExchangeRate:  quote.Rate,
+		Email:         email,
 		FirstName:     address.FirstName,
 		LastName:      address.LastName,
 		StreetAddress: address.StreetAddress,
@@ -79,21 +134,242 @@ func (s *OrderService) CreateOrder(ctx context.Context, userID uint, addressID u
 		State:         address.State,
 		Country:       address.Country,
 		ZipCode:       address.ZipCode,
-		OrderItems:    daoOrderItems,
+	}, nil
+}
+
+// UpdateOrder 买家修改订单：可修改收货地址（全部子订单发货前），或取消订单/确认子订单收货
+func (s *OrderService) UpdateOrder(ctx context.Context, userID uint, req *types.UpdateOrderReq) error {
+	order, err := s.orderDao.GetOrderByID(ctx, userID, req.OrderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("订单不存在")
+		}
+		log.Errorf("查询订单失败 (userID: %d, orderID: %s): %v", userID, req.OrderID, err)
+		return err
+	}
+
+	if req.StreetAddress != "" || req.City != "" || req.State != "" || req.Country != "" || req.ZipCode != "" {
+		if order.Status != consts.OrderTypeUnPaid && order.Status != consts.OrderTypePendingShipping {
+			return fmt.Errorf("订单当前状态为「%s」，无法修改收货地址", order.StatusText())
+		}
+		// 父订单待发货时可能已有子订单发货，收货地址对所有子订单生效，因此要求全部子订单都未发货
+		subOrders, err := s.subOrderDao.ListSubOrders(ctx, order.OrderID)
+		if err != nil {
+			return err
+		}
+		for _, sub := range subOrders {
+			if sub.Status != consts.OrderTypeUnPaid && sub.Status != consts.OrderTypePendingShipping {
+				return fmt.Errorf("子订单 %s 当前状态为「%s」，无法修改收货地址", sub.SubOrderID, sub.StatusText())
+			}
+		}
+		if err := s.orderDao.UpdateOrder(ctx, userID, req); err != nil {
+			log.Errorf("更新订单地址失败 (orderID: %s): %v", req.OrderID, err)
+			return err
+		}
 	}
 
-	// Call the existing transactional DAO method
-	log.Infof("Service CreateOrder calling DAO with userID: %d, using addressID: %d", userID, addressID)
-	return s.orderDao.CreateOrder(ctx, userID, daoReq)
+	if req.Status != 0 && req.Status != order.Status {
+		if !buyerAllowedStatus[req.Status] {
+			return fmt.Errorf("%w: 买家不能将订单变更为「%s」", ErrIllegalOrderTransition, consts.OrderTypeMap[req.Status])
+		}
+		if req.Status == consts.OrderTypeCancelled {
+			return s.releaseUnpaidOrder(ctx, order, consts.OrderTypeCancelled, consts.OrderActorUser, userID, req.Reason)
+		}
+		return s.confirmReceipt(ctx, order, req.SubOrderID, userID, req.Reason)
+	}
+	return nil
 }
 
-func (s *OrderService) UpdateOrder(ctx context.Context, userID uint, req *types.UpdateOrderReq) error {
-	// The DAO's UpdateOrder signature was: func (dao *OrderDao) UpdateOrder(userID uint, req *types.UpdateOrderReq) error
-	// It should be updated to accept context.
-	// Assuming it's updated to: func (dao *OrderDao) UpdateOrder(ctx context.Context, userID uint, req *types.UpdateOrderReq) error
-	// return s.orderDao.UpdateOrder(ctx, userID, req)
-
-	// If DAO not updated yet:
-	log.Warnf("Calling orderDao.UpdateOrder without context. Consider updating DAO if context propagation is needed.")
-	return s.orderDao.UpdateOrder(userID, req) 
+// ConfirmReceipt 买家确认收货，与 UpdateOrder 将状态改为已收货的处理相同
+func (s *OrderService) ConfirmReceipt(ctx context.Context, userID uint, req *types.ConfirmReceiptReq) error {
+	order, err := s.orderDao.GetOrderByID(ctx, userID, req.OrderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("订单不存在")
+		}
+		log.Errorf("查询订单失败 (userID: %d, orderID: %s): %v", userID, req.OrderID, err)
+		return err
+	}
+	return s.confirmReceipt(ctx, order, req.SubOrderID, userID, req.Reason)
+}
+
+// confirmReceipt 买家确认收货：指定子订单时只确认该子订单，否则确认全部待收货的子订单，父订单状态随子订单汇总
+func (s *OrderService) confirmReceipt(ctx context.Context, order *model.Order, subOrderID string, userID uint, reason string) error {
+	subOrders, err := s.subOrderDao.ListSubOrders(ctx, order.OrderID)
+	if err != nil {
+		return err
+	}
+	targets := make([]*model.SubOrder, 0, len(subOrders))
+	for i := range subOrders {
+		sub := &subOrders[i]
+		if subOrderID != "" && sub.SubOrderID == subOrderID {
+			targets = append(targets, sub)
+			break
+		}
+		if subOrderID == "" && sub.Status == consts.OrderTypeShipping {
+			targets = append(targets, sub)
+		}
+	}
+	if len(targets) == 0 {
+		if subOrderID != "" {
+			return errors.New("子订单不存在")
+		}
+		return fmt.Errorf("%w: 订单没有待收货的子订单", ErrIllegalOrderTransition)
+	}
+	for _, sub := range targets {
+		if err := confirmSubOrderReceipt(ctx, s.shipmentDao, sub, consts.OrderActorUser, userID, reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TransitOrderStatus 按状态机校验并执行订单状态流转，同时记录操作者和原因，处于相同状态的子订单随之流转
+// 支付、取消、超时关单等整单流程都应通过该方法修改订单状态
+func (s *OrderService) TransitOrderStatus(ctx context.Context, order *model.Order, to int, actor string, actorID uint, reason string) error {
+	if !order.CanTransitTo(to) {
+		return fmt.Errorf("%w: 「%s」->「%s」", ErrIllegalOrderTransition, order.StatusText(), consts.OrderTypeMap[to])
+	}
+	if err := s.orderDao.UpdateOrderStatus(ctx, order.OrderID, order.Status, to, actor, actorID, reason); err != nil {
+		log.Errorf("订单状态流转失败 (orderID: %s, %d -> %d): %v", order.OrderID, order.Status, to, err)
+		return err
+	}
+	log.Infof("订单状态流转成功 (orderID: %s, %d -> %d, actor: %s:%d)", order.OrderID, order.Status, to, actor, actorID)
+	order.Status = to
+	return nil
+}
+
+// TransitSubOrderStatus 按状态机校验并执行子订单状态流转，父订单状态由 dao 按全部子订单汇总更新
+// 发货、确认收货、售后退款等只涉及单个商家的流程都应通过该方法修改子订单状态
+func (s *OrderService) TransitSubOrderStatus(ctx context.Context, subOrder *model.SubOrder, to int, actor string, actorID uint, reason string) error {
+	if !subOrder.CanTransitTo(to) {
+		return fmt.Errorf("%w: 子订单「%s」->「%s」", ErrIllegalOrderTransition, subOrder.StatusText(), consts.OrderTypeMap[to])
+	}
+	if err := s.subOrderDao.UpdateSubOrderStatus(ctx, subOrder, subOrder.Status, to, actor, actorID, reason); err != nil {
+		log.Errorf("子订单状态流转失败 (subOrderID: %s, %d -> %d): %v", subOrder.SubOrderID, subOrder.Status, to, err)
+		return err
+	}
+	log.Infof("子订单状态流转成功 (subOrderID: %s, %d -> %d, actor: %s:%d)", subOrder.SubOrderID, subOrder.Status, to, actor, actorID)
+	subOrder.Status = to
+	return nil
+}
+
+// ListOrderHistory 获取买家订单的状态流转记录
+func (s *OrderService) ListOrderHistory(ctx context.Context, userID uint, orderID string) ([]types.OrderStatusHistoryResp, error) {
+	if _, err := s.orderDao.GetOrderByID(ctx, userID, orderID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("订单不存在")
+		}
+		return nil, err
+	}
+	histories, err := s.orderDao.ListOrderStatusHistory(ctx, orderID)
+	if err != nil {
+		log.Errorf("查询订单状态流转记录失败 (orderID: %s): %v", orderID, err)
+		return nil, err
+	}
+	resp := make([]types.OrderStatusHistoryResp, 0, len(histories))
+	for _, h := range histories {
+		resp = append(resp, types.OrderStatusHistoryResp{
+			SubOrderID:     h.SubOrderID,
+			FromStatus:     h.FromStatus,
+			FromStatusText: consts.OrderTypeMap[h.FromStatus],
+			ToStatus:       h.ToStatus,
+			ToStatusText:   consts.OrderTypeMap[h.ToStatus],
+			Actor:          h.Actor,
+			ActorID:        h.ActorID,
+			Reason:         h.Reason,
+			CreatedAt:      h.CreatedAt.Unix(),
+		})
+	}
+	return resp, nil
+}
+
+// ListOrders 分页查询买家订单
+func (s *OrderService) ListOrders(ctx context.Context, userID uint, req *types.OrderListReq) (*types.DataListResp, error) {
+	orders, total, err := s.orderDao.ListOrders(ctx, userID, req)
+	if err != nil {
+		log.Errorf("查询订单列表失败 (userID: %d): %v", userID, err)
+		return nil, err
+	}
+	items := make([]*types.OrderResp, 0, len(orders))
+	for i := range orders {
+		items = append(items, buildOrderResp(&orders[i]))
+	}
+	return &types.DataListResp{
+		Item:  items,
+		Total: total,
+	}, nil
+}
+
+// GetOrderDetail 查询买家订单详情
+func (s *OrderService) GetOrderDetail(ctx context.Context, userID uint, orderID string) (*types.OrderResp, error) {
+	order, err := s.orderDao.GetOrderDetail(ctx, userID, orderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("订单不存在")
+		}
+		log.Errorf("查询订单详情失败 (userID: %d, orderID: %s): %v", userID, orderID, err)
+		return nil, err
+	}
+	return buildOrderResp(order), nil
+}
+
+// buildOrderResp 将订单模型转换为响应结构，并汇总订单总金额；预加载了子订单时按子订单分组返回订单项
+func buildOrderResp(order *model.Order) *types.OrderResp {
+	resp := &types.OrderResp{
+		OrderID:        order.OrderID,
+		Status:         order.Status,
+		StatusText:     order.StatusText(),
+		UserCurrency:   order.UserCurrency,
+		BaseCurrency:   order.BaseCurrency,
+		ExchangeRate:   order.ExchangeRate,
+		DiscountAmount: order.DiscountAmount,
+		Email:          order.Email,
+		Address: types.Address{
+			StreetAddress: order.StreetAddress,
+			City:          order.City,
+			State:         order.State,
+			Country:       order.Country,
+			ZipCode:       order.ZipCode,
+		},
+		CreatedAt: order.CreatedAt.Unix(),
+		Items:     make([]types.OrderItemResp, 0, len(order.OrderItems)),
+	}
+	for _, item := range order.OrderItems {
+		resp.TotalAmount += item.Cost.Mul(int64(item.Quantity)) - item.Discount
+		resp.Items = append(resp.Items, buildOrderItemResp(&item))
+	}
+	for _, sub := range order.SubOrders {
+		subResp := types.SubOrderResp{
+			SubOrderID:     sub.SubOrderID,
+			MerchantID:     sub.MerchantID,
+			Status:         sub.Status,
+			StatusText:     sub.StatusText(),
+			TotalAmount:    sub.Amount,
+			DiscountAmount: sub.DiscountAmount,
+			Items:          make([]types.OrderItemResp, 0),
+		}
+		for i := range order.OrderItems {
+			if order.OrderItems[i].SubOrderID == sub.SubOrderID {
+				subResp.Items = append(subResp.Items, resp.Items[i])
+			}
+		}
+		resp.SubOrders = append(resp.SubOrders, subResp)
+	}
+	return resp
+}
+
+// buildOrderItemResp 将订单项模型转换为响应结构
+func buildOrderItemResp(item *model.OrderItem) types.OrderItemResp {
+	return types.OrderItemResp{
+		OrderItemID:    item.ID,
+		ProductID:      item.ProductID,
+		SkuID:          item.SkuID,
+		SkuSpecs:       item.SkuSpecs,
+		ProductName:    item.ProductName,
+		ProductPicture: item.ProductPicture,
+		Quantity:       item.Quantity,
+		Cost:           item.Cost,
+		Discount:       item.Discount,
+	}
 }
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"douyin/config"
+	"douyin/consts"
+	"douyin/pkg/utils/log"
+	"douyin/repository/cache"
+	"douyin/repository/db/dao"
+	"douyin/repository/db/model"
+	"gorm.io/gorm"
+)
+
+// newUnpaidQueue 创建未支付订单超时关闭的延时队列，Redis 未初始化时返回 nil
+func newUnpaidQueue() *cache.DelayQueue {
+	if cache.RedisClient == nil {
+		log.Warnf("Redis 未初始化，未支付订单将不会被自动关闭")
+		return nil
+	}
+	return cache.NewDelayQueue(cache.RedisClient, cache.OrderUnpaidDelayKey)
+}
+
+// scheduleUnpaidTimeout 将订单加入超时关闭延时队列，到期时间为 createdAt + 配置的超时时间
+// 入队失败不影响下单，服务重启时 ListenAndCloseUnpaid 会从数据库补齐
+func scheduleUnpaidTimeout(ctx context.Context, queue *cache.DelayQueue, orderID string, createdAt time.Time) {
+	if queue == nil {
+		return
+	}
+	runAt := createdAt.Add(config.GetOrderUnpaidTimeout())
+	if err := queue.Push(ctx, orderID, runAt); err != nil {
+		log.Errorf("订单 %s 加入超时关闭队列失败: %v", orderID, err)
+	}
+}
+
+// releaseUnpaidOrder 取消或关闭未支付订单，并归还库存、使支付单失效；秒杀订单提交后归还 Redis 中的名额
+func (s *OrderService) releaseUnpaidOrder(ctx context.Context, order *model.Order, to int, actor string, actorID uint, reason string) error {
+	if !order.CanTransitTo(to) {
+		return fmt.Errorf("%w: 「%s」->「%s」", ErrIllegalOrderTransition, order.StatusText(), consts.OrderTypeMap[to])
+	}
+	flashSale, err := s.orderDao.ReleaseUnpaidOrder(ctx, order.OrderID, to, actor, actorID, reason)
+	if err != nil {
+		if errors.Is(err, dao.ErrPaymentPending) {
+			return err
+		}
+		log.Errorf("释放未支付订单失败 (orderID: %s, -> %d): %v", order.OrderID, to, err)
+		return err
+	}
+	log.Infof("未支付订单已释放 (orderID: %s, -> %d, actor: %s:%d)", order.OrderID, to, actor, actorID)
+	order.Status = to
+	syncOrderProductIndex(ctx, s.orderDao, order.OrderID)
+	if flashSale != nil && s.flashSales != nil {
+		if err := s.flashSales.Release(ctx, flashSale.SessionID, flashSale.UserID, order.OrderID, "订单"+consts.OrderTypeMap[to]); err != nil {
+			log.Errorf("归还秒杀名额失败 (orderID: %s, sessionID: %d): %v", order.OrderID, flashSale.SessionID, err)
+		}
+	}
+	if s.unpaidQueue != nil {
+		if err := s.unpaidQueue.Remove(ctx, order.OrderID); err != nil {
+			log.Warnf("从超时关闭队列移除订单 %s 失败: %v", order.OrderID, err)
+		}
+	}
+	return nil
+}
+
+// CloseUnpaidOrder 超时关闭未支付订单，作为延时队列的回调执行
+// 订单不存在或已支付/已取消时直接返回，重复执行不会产生副作用；
+// 有处理中的支付时先向渠道查询结果，超过等待时限仍无结果的支付单失效待对账后关单，未到时限时返回错误由延时队列稍后重试
+func (s *OrderService) CloseUnpaidOrder(ctx context.Context, orderID string) error {
+	order, err := s.orderDao.GetOrderByOrderID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Warnf("超时关单：订单 %s 不存在，跳过", orderID)
+			return nil
+		}
+		return err
+	}
+	if order.Status != consts.OrderTypeUnPaid {
+		return nil
+	}
+	const reason = "超时未支付，系统自动关闭"
+	err = s.releaseUnpaidOrder(ctx, order, consts.OrderTypeClosed, consts.OrderActorSystem, 0, reason)
+	if errors.Is(err, dao.ErrPaymentPending) {
+		waiting, resolveErr := s.payments.ResolvePendingPayments(ctx, orderID)
+		if resolveErr != nil {
+			return resolveErr
+		}
+		if waiting {
+			// 渠道扣款结果未知且未到等待时限；返回错误使延时队列稍后重新投递
+			log.Infof("超时关单：订单 %s 有处理中的支付，稍后重试", orderID)
+			return err
+		}
+		// 处理中的支付已得到结果或已失效，重新关单；已支付成功时订单状态已变化，返回 ErrOrderStatusConflict
+		err = s.releaseUnpaidOrder(ctx, order, consts.OrderTypeClosed, consts.OrderActorSystem, 0, reason)
+	}
+	if errors.Is(err, dao.ErrOrderStatusConflict) {
+		// 读取之后订单被支付或取消，或已被其他实例关闭
+		return nil
+	}
+	return err
+}
+
+// ListenAndCloseUnpaid 持续消费超时关单队列，与 NotificationService.ListenAndSend 一样作为后台协程运行
+// 启动时会把数据库中仍未支付的订单重新入队，弥补 Redis 数据丢失或入队失败的情况
+func (s *OrderService) ListenAndCloseUnpaid(ctx context.Context) {
+	if s.unpaidQueue == nil {
+		log.Errorf("超时关单队列未初始化（Redis 不可用），worker 退出")
+		return
+	}
+
+	orders, err := s.orderDao.ListUnpaidOrders(ctx)
+	if err != nil {
+		log.Errorf("加载未支付订单失败: %v", err)
+	}
+	for _, order := range orders {
+		scheduleUnpaidTimeout(ctx, s.unpaidQueue, order.OrderID, order.CreatedAt)
+	}
+	log.Infof("已将 %d 个未支付订单加入超时关闭队列", len(orders))
+
+	s.unpaidQueue.Consume(ctx, config.GetOrderScanInterval(), s.CloseUnpaidOrder)
+}
@@ -5,179 +5,391 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
+	"douyin/config"
+	"douyin/consts"
+	"douyin/pkg/utils/log"
+	"douyin/repository/cache"
+	"douyin/repository/db/dao"
+	"douyin/repository/db/model"
+	"douyin/types"
 	"gorm.io/gorm"
+)
+
+// ErrIllegalPaymentTransition 支付单状态变更不符合状态机定义
+var ErrIllegalPaymentTransition = errors.New("非法的支付状态变更")
 
-	"github.com/CocaineCong/gin-mall/consts"
-	"github.com/CocaineCong/gin-mall/pkg/utils/ctl"
-	"github.com/CocaineCong/gin-mall/pkg/utils/log"
-	"github.com/CocaineCong/gin-mall/repository/db/dao"
-	"github.com/CocaineCong/gin-mall/repository/db/model"
-	"github.com/CocaineCong/gin-mall/types"
+var (
+	// mockProvider 模拟渠道的扣款记录保存在内存中，多个 PaymentService 实例（支付、退款）需共用同一个实例
+	mockProvider     *MockPaymentProvider
+	mockProviderOnce sync.Once
 )
 
-var PaymentSrvIns *PaymentSrv
-var PaymentSrvOnce sync.Once
+// PaymentService 支付服务，负责选择支付渠道、发起扣款并在扣款成功后推进订单状态
+type PaymentService struct {
+	db              *gorm.DB
+	paymentDao      *dao.PaymentDao
+	orderDao        *dao.OrderDao
+	providers       map[string]PaymentProviderFactory
+	defaultProvider string
+	unpaidQueue     *cache.DelayQueue
+	notifier        *NotificationService // 发送支付凭证邮件，Redis 未初始化时为 nil
+}
 
-// PaymentSrv 支付服务层对象
-type PaymentSrv struct {
+// NewPaymentService 创建新的 PaymentService 实例，并注册内置的余额支付与（按配置启用的）模拟支付渠道
+func NewPaymentService(db *gorm.DB) *PaymentService {
+	s := &PaymentService{
+		db:              db,
+		paymentDao:      dao.NewPaymentDao(db),
+		orderDao:        dao.NewOrderDao(db),
+		providers:       make(map[string]PaymentProviderFactory),
+		defaultProvider: config.GetPaymentDefaultProvider(),
+		unpaidQueue:     newUnpaidQueue(),
+	}
+	if cache.RedisClient != nil {
+		// 只用于入队，邮件由 main 中启动的 NotificationService worker 发送
+		s.notifier = NewNotificationService(cache.RedisClient, nil)
+	}
+	s.RegisterProvider(consts.PaymentProviderWallet, NewWalletPaymentProvider)
+	if conf := config.GlobalConfig; conf != nil && conf.Payment != nil && conf.Payment.MockEnabled {
+		mockProviderOnce.Do(func() {
+			mockProvider = NewMockPaymentProvider(conf.Payment.MockAutoCapture)
+		})
+		s.RegisterProvider(consts.PaymentProviderMock, func(*gorm.DB) PaymentProvider { return mockProvider })
+	}
+	return s
 }
 
-// GetPaymentSrv 单例模式获取支付服务实例
-func GetPaymentSrv() *PaymentSrv {
-	PaymentSrvOnce.Do(func() {
-		PaymentSrvIns = &PaymentSrv{}
-	})
-	return PaymentSrvIns
+// RegisterProvider 注册支付渠道，同名渠道会被覆盖
+func (s *PaymentService) RegisterProvider(name string, factory PaymentProviderFactory) {
+	s.providers[name] = factory
 }
 
-// PayDown 支付操作
-// 该操作包括：扣除买家金币、增加卖家金币、扣减商品库存、更新订单状态、生成买家商品记录等
-func (s *PaymentSrv) PayDown(ctx context.Context, req *types.PaymentDownReq) (resp interface{}, err error) {
-	// 获取当前用户信息（买家信息）
-	u, err := ctl.GetUserInfo(ctx)
+// provider 获取支付渠道实例，db 为本次调用使用的数据库连接（通常是事务）
+func (s *PaymentService) provider(name string, db *gorm.DB) (PaymentProvider, error) {
+	factory, ok := s.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPaymentProviderNotFound, name)
+	}
+	return factory(db), nil
+}
+
+// Pay 使用指定渠道支付订单
+// 先在事务中将支付单置为处理中并提交，再在事务外调用渠道扣款，最后在第二个事务中按交易ID写入扣款结果（与渠道回调相同的路径）；
+// 渠道扣款是外部副作用，不能随数据库事务回滚。本地渠道（LocalPaymentProvider，如余额支付）没有外部副作用，
+// 扣款与结果写入在同一事务中完成。同步扣款成功的渠道（余额、自动扣款的模拟渠道）会直接将订单置为待发货
+func (s *PaymentService) Pay(ctx context.Context, userID uint, req *types.PayReq) (*types.PaymentResp, error) {
+	order, err := s.orderDao.GetOrderByID(ctx, userID, req.OrderID)
 	if err != nil {
-		log.LogrusObj.Error("获取用户信息失败：", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("订单不存在")
+		}
 		return nil, err
 	}
+	if order.Status != consts.OrderTypeUnPaid {
+		return nil, fmt.Errorf("订单当前状态为「%s」，无法支付", order.StatusText())
+	}
 
-	// 开启事务处理支付流程
-	err = dao.NewOrderDao(ctx).Transaction(func(tx *gorm.DB) error {
-		uId := u.Id
+	providerName := req.Provider
+	if providerName == "" {
+		providerName = s.defaultProvider
+	}
+	provider, err := s.provider(providerName, s.db)
+	if err != nil {
+		return nil, err
+	}
+	_, local := provider.(LocalPaymentProvider)
 
-		// 获取订单信息（支付订单）
-		payment, err := dao.NewOrderDaoByDB(tx).GetOrderById(req.OrderId, uId)
+	var payment *model.Payment
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		paymentDao := dao.NewPaymentDao(tx)
+		payment, err = paymentDao.GetPaymentByOrderIDForUpdate(ctx, userID, req.OrderID)
 		if err != nil {
-			log.LogrusObj.Error("查询订单信息失败：", err)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("订单没有待支付的支付单")
+			}
 			return err
 		}
-
-		// 计算订单总金额：单价 * 数量
-		money := payment.Money
-		num := payment.Num
-		money = money * float64(num)
-
-		// 获取买家信息，并解密余额
-		userDao := dao.NewUserDaoByDB(tx)
-		user, err := userDao.GetUserById(uId)
-		if err != nil {
-			log.LogrusObj.Error("查询买家信息失败：", err)
-			return err
+		switch payment.Status {
+		case consts.PaymentStatusPaid:
+			return errors.New("订单已支付，请勿重复支付")
+		case consts.PaymentStatusPending:
+			return errors.New("支付处理中，请稍后查询支付结果")
 		}
-
-		moneyFloat, err := user.DecryptMoney(req.Key)
+		if !payment.CanTransitTo(consts.PaymentStatusPending) {
+			return errors.New("支付单已失效")
+		}
+		// 持有支付单行锁后再确认订单仍未支付；超时关单同样会锁定支付单，二者不会交错（见 dao.ExpireOrderPayments）
+		current, err := dao.NewOrderDao(tx).GetOrderByID(ctx, userID, req.OrderID)
 		if err != nil {
-			log.LogrusObj.Error("解密买家余额失败：", err)
 			return err
 		}
-
-		// 检查买家余额是否充足
-		if moneyFloat-money < 0.0 {
-			log.LogrusObj.Error("买家余额不足，当前余额：", moneyFloat, " 订单金额：", money)
-			return errors.New("金币不足")
+		if current.Status != consts.OrderTypeUnPaid {
+			return fmt.Errorf("订单当前状态为「%s」，无法支付", current.StatusText())
 		}
-
-		// 更新买家余额：扣除订单金额
-		newBuyerBalance := moneyFloat - money
-		finMoney := fmt.Sprintf("%f", newBuyerBalance)
-		user.Money = finMoney
-		user.Money, err = user.EncryptMoney(req.Key)
-		if err != nil {
-			log.LogrusObj.Error("加密买家余额失败：", err)
+		if local {
+			// 渠道使用本事务连接写库，扣款失败（如余额不足）时整个事务回滚，支付单保持原状态
+			txProvider, err := s.provider(providerName, tx)
+			if err != nil {
+				return err
+			}
+			result, err := txProvider.CreateCharge(ctx, newChargeRequest(payment))
+			if err != nil {
+				return err
+			}
+			return s.applyChargeResult(ctx, tx, payment, providerName, result, consts.OrderActorUser, userID)
+		}
+		if err := paymentDao.UpdatePaymentStatus(ctx, payment, consts.PaymentStatusPending, map[string]interface{}{
+			"provider":       providerName,
+			"provider_ref":   "",
+			"failure_reason": "",
+		}, "发起支付"); err != nil {
 			return err
 		}
+		payment.Status = consts.PaymentStatusPending
+		payment.Provider = providerName
+		payment.ProviderRef = ""
+		return nil
+	})
+	if err != nil {
+		log.Errorf("订单支付失败 (userID: %d, orderID: %s, provider: %s): %v", userID, req.OrderID, providerName, err)
+		return nil, err
+	}
+	if local {
+		log.Infof("订单支付请求完成 (orderID: %s, transactionID: %s, provider: %s, status: %s)",
+			payment.OrderID, payment.TransactionID, providerName, payment.Status)
+		s.afterPaid(ctx, payment)
+		return buildPaymentResp(payment), nil
+	}
 
-		err = userDao.UpdateUserById(uId, user)
+	result, chargeErr := provider.CreateCharge(ctx, newChargeRequest(payment))
+	if chargeErr != nil {
+		// 渠道调用失败时支付单置为失败，用户可以重新发起支付
+		result = &ChargeResult{Status: consts.PaymentStatusFailed, FailureReason: chargeErr.Error()}
+	}
+	applied, err := s.applyChargeByTransactionID(ctx, payment.TransactionID, providerName, result, consts.OrderActorUser, userID)
+	if chargeErr != nil {
 		if err != nil {
-			log.LogrusObj.Error("更新买家余额失败：", err)
-			return err
+			log.Errorf("记录支付失败结果出错 (transactionID: %s): %v", payment.TransactionID, err)
 		}
+		log.Errorf("订单支付失败 (userID: %d, orderID: %s, provider: %s): %v", userID, req.OrderID, providerName, chargeErr)
+		return nil, chargeErr
+	}
+	if err != nil {
+		// 渠道已受理或已扣款，但结果未能落库；支付单保持处理中，之后由查询或回调补齐
+		log.Errorf("写入扣款结果失败，需对账 (transactionID: %s, providerRef: %s, status: %s): %v",
+			payment.TransactionID, result.ProviderRef, result.Status, err)
+		return nil, err
+	}
 
-		// 获取卖家信息
-		boss, err := userDao.GetUserById(uint(req.BossID))
-		if err != nil {
-			log.LogrusObj.Error("查询卖家信息失败：", err)
-			return err
+	log.Infof("订单支付请求完成 (orderID: %s, transactionID: %s, provider: %s, status: %s)",
+		applied.OrderID, applied.TransactionID, providerName, applied.Status)
+	s.afterPaid(ctx, applied)
+	resp := buildPaymentResp(applied)
+	resp.PayURL = result.PayURL
+	return resp, nil
+}
+
+// newChargeRequest 根据支付单构造扣款参数
+func newChargeRequest(payment *model.Payment) *ChargeRequest {
+	return &ChargeRequest{
+		TransactionID: payment.TransactionID,
+		OrderID:       payment.OrderID,
+		UserID:        payment.UserID,
+		Amount:        payment.Amount,
+		Currency:      payment.Currency,
+		Description:   "订单支付 " + payment.OrderID,
+	}
+}
+
+// GetPayment 查询买家的支付单；处理中的支付单会先向渠道查询最新状态
+func (s *PaymentService) GetPayment(ctx context.Context, userID uint, transactionID string) (*types.PaymentResp, error) {
+	payment, err := s.paymentDao.GetPaymentByTransactionID(ctx, transactionID)
+	if err != nil || payment.UserID != userID {
+		if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("支付单不存在")
 		}
+		return nil, err
+	}
+	if payment.Status == consts.PaymentStatusPending {
+		if err := s.syncPendingPayment(ctx, payment); err != nil {
+			log.Warnf("同步支付单状态失败 (transactionID: %s): %v", transactionID, err)
+		}
+	}
+	return buildPaymentResp(payment), nil
+}
+
+// syncPendingPayment 向渠道查询处理中的支付单并落库
+func (s *PaymentService) syncPendingPayment(ctx context.Context, payment *model.Payment) error {
+	provider, err := s.provider(payment.Provider, s.db)
+	if err != nil {
+		return err
+	}
+	result, err := provider.QueryCharge(ctx, payment.TransactionID, payment.ProviderRef)
+	if err != nil {
+		return err
+	}
+	if result.Status == payment.Status {
+		return nil
+	}
+	applied, err := s.applyChargeByTransactionID(ctx, payment.TransactionID, payment.Provider, result, consts.OrderActorSystem, 0)
+	if err != nil {
+		return err
+	}
+	*payment = *applied
+	s.afterPaid(ctx, payment)
+	return nil
+}
 
-		// 解密卖家余额，并增加订单金额
-		bossBalance, err := boss.DecryptMoney(req.Key)
+// ResolvePendingPayments 超时关单遇到处理中的支付单时调用：先向渠道查询最新扣款结果，
+// 处理中超过 order.pendingPaymentTimeout 仍无结果的支付单置为失效并标记待对账，之后渠道才通知扣款成功时按迟到扣款自动退款
+// 返回订单下是否仍有未到等待时限的处理中支付单
+func (s *PaymentService) ResolvePendingPayments(ctx context.Context, orderID string) (bool, error) {
+	payments, err := s.paymentDao.ListPendingPaymentsByOrderID(ctx, orderID)
+	if err != nil {
+		return false, err
+	}
+	deadline := time.Now().Add(-config.GetOrderPendingPaymentTimeout())
+	waiting := false
+	for i := range payments {
+		payment := &payments[i]
+		if err := s.syncPendingPayment(ctx, payment); err != nil {
+			log.Warnf("同步处理中的支付单失败 (transactionID: %s): %v", payment.TransactionID, err)
+		}
+		if payment.Status != consts.PaymentStatusPending {
+			continue
+		}
+		if payment.UpdatedAt.After(deadline) {
+			waiting = true
+			continue
+		}
+		err := s.paymentDao.UpdatePaymentStatus(ctx, payment, consts.PaymentStatusExpired, map[string]interface{}{
+			"needs_reconcile": true,
+		}, "渠道超时未返回扣款结果，订单关闭，支付单失效待对账")
 		if err != nil {
-			log.LogrusObj.Error("解密卖家余额失败：", err)
-			return err
+			if errors.Is(err, dao.ErrPaymentStatusConflict) {
+				// 回调或查询恰好写入了结果，由下一次关单重新判断
+				waiting = true
+				continue
+			}
+			return false, err
 		}
+		payment.Status = consts.PaymentStatusExpired
+		payment.NeedsReconcile = true
+		log.Warnf("处理中的支付单超时未得到渠道结果，已失效待对账 (orderID: %s, transactionID: %s, providerRef: %s)",
+			orderID, payment.TransactionID, payment.ProviderRef)
+	}
+	return waiting, nil
+}
 
-		newBossBalance := bossBalance + money
-		finMoney = fmt.Sprintf("%f", newBossBalance)
-		boss.Money = finMoney
-		boss.Money, err = boss.EncryptMoney(req.Key)
+// applyChargeByTransactionID 在独立事务中按交易ID加锁读取支付单并写入渠道扣款结果，返回更新后的支付单
+func (s *PaymentService) applyChargeByTransactionID(ctx context.Context, transactionID, providerName string, result *ChargeResult, actor string, actorID uint) (*model.Payment, error) {
+	var payment *model.Payment
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		payment, err = dao.NewPaymentDao(tx).GetPaymentByTransactionIDForUpdate(ctx, transactionID)
 		if err != nil {
-			log.LogrusObj.Error("加密卖家余额失败：", err)
 			return err
 		}
+		return s.applyChargeResult(ctx, tx, payment, providerName, result, actor, actorID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
 
-		err = userDao.UpdateUserById(uint(req.BossID), boss)
-		if err != nil {
-			log.LogrusObj.Error("更新卖家余额失败：", err)
+// applyChargeResult 将渠道返回的扣款结果写入支付单，扣款成功时同时将订单从未支付流转为待发货，将订单占用的库存转为销售出库，并按商家写入待结算货款
+// 需在事务中调用，payment 会被更新为最新状态
+func (s *PaymentService) applyChargeResult(ctx context.Context, tx *gorm.DB, payment *model.Payment, providerName string, result *ChargeResult, actor string, actorID uint) error {
+	if result.Status == payment.Status {
+		if result.ProviderRef == payment.ProviderRef {
+			return nil
+		}
+		// 状态未变化（如异步渠道受理后仍为处理中），只记录渠道单号，便于之后查询与回调匹配
+		if err := dao.NewPaymentDao(tx).UpdatePaymentProvider(ctx, payment.TransactionID, providerName, result.ProviderRef); err != nil {
 			return err
 		}
+		payment.Provider = providerName
+		payment.ProviderRef = result.ProviderRef
+		return nil
+	}
+	if !payment.CanTransitTo(result.Status) {
+		return fmt.Errorf("%w: %s -> %s", ErrIllegalPaymentTransition, payment.Status, result.Status)
+	}
 
-		// 更新商品库存：扣减已购买数量
-		productDao := dao.NewProductDaoByDB(tx)
-		product, err := productDao.GetProductById(uint(req.ProductID))
-		if err != nil {
-			log.LogrusObj.Error("查询商品信息失败：", err)
+	updates := map[string]interface{}{
+		"provider":     providerName,
+		"provider_ref": result.ProviderRef,
+	}
+	now := time.Now()
+	reason := "支付渠道处理中"
+	switch result.Status {
+	case consts.PaymentStatusPaid:
+		updates["paid_at"] = now
+		reason = "支付成功"
+	case consts.PaymentStatusFailed:
+		updates["failure_reason"] = result.FailureReason
+		reason = "支付失败：" + result.FailureReason
+	}
+	if err := dao.NewPaymentDao(tx).UpdatePaymentStatus(ctx, payment, result.Status, updates, reason); err != nil {
+		return err
+	}
+
+	if result.Status == consts.PaymentStatusPaid {
+		if err := dao.NewOrderDao(tx).UpdateOrderStatus(ctx, payment.OrderID, consts.OrderTypeUnPaid, consts.OrderTypePendingShipping, actor, actorID, "支付成功"); err != nil {
 			return err
 		}
-		if product.Num < num {
-			log.LogrusObj.Error("商品库存不足，当前库存：", product.Num, " 购买数量：", num)
-			return errors.New("商品库存不足")
-		}
-		product.Num -= num
-		err = productDao.UpdateProduct(uint(req.ProductID), product)
-		if err != nil {
-			log.LogrusObj.Error("更新商品库存失败：", err)
+		if err := dao.NewOrderDao(tx).AddOrderProductSales(ctx, payment.OrderID); err != nil {
 			return err
 		}
-
-		// 更新订单状态为待发货
-		payment.Type = consts.OrderTypePendingShipping
-		err = dao.NewOrderDaoByDB(tx).UpdateOrderById(req.OrderId, uId, payment)
-		if err != nil {
-			log.LogrusObj.Error("更新订单状态失败：", err)
+		if err := dao.NewInventoryDao(tx).CommitOrderSale(ctx, payment.OrderID); err != nil {
 			return err
 		}
-
-		// 创建买家商品记录（将已购买的商品加入买家商品列表）
-		productUser := model.Product{
-			Name:          product.Name,
-			CategoryID:    product.CategoryID,
-			Title:         product.Title,
-			Info:          product.Info,
-			ImgPath:       product.ImgPath,
-			Price:         product.Price,
-			DiscountPrice: product.DiscountPrice,
-			Num:           num,
-			OnSale:        false,
-			BossID:        uId,
-			BossName:      user.UserName,
-			BossAvatar:    user.Avatar,
-		}
-
-		err = productDao.CreateProduct(&productUser)
-		if err != nil {
-			log.LogrusObj.Error("创建买家商品记录失败：", err)
+		if err := dao.NewMerchantDao(tx).CreateOrderSettlements(ctx, payment.OrderID); err != nil {
 			return err
 		}
+		payment.PaidAt = &now
+	}
+	if result.Status == consts.PaymentStatusFailed {
+		payment.FailureReason = result.FailureReason
+	}
+	payment.Status = result.Status
+	payment.Provider = providerName
+	payment.ProviderRef = result.ProviderRef
+	return nil
+}
 
-		return nil
-	})
-
-	if err != nil {
-		log.LogrusObj.Error("支付操作失败：", err)
-		return nil, err
+// afterPaid 支付成功后将订单移出超时关单队列，同步商品库存与销量到搜索索引，并发送支付凭证邮件
+func (s *PaymentService) afterPaid(ctx context.Context, payment *model.Payment) {
+	if payment.Status != consts.PaymentStatusPaid {
+		return
+	}
+	if s.unpaidQueue != nil {
+		if err := s.unpaidQueue.Remove(ctx, payment.OrderID); err != nil {
+			log.Warnf("从超时关闭队列移除订单 %s 失败: %v", payment.OrderID, err)
+		}
 	}
+	syncOrderProductIndex(ctx, s.orderDao, payment.OrderID)
+	s.enqueueReceiptEmail(ctx, payment)
+}
 
-	fmt.Println("支付操作成功") // 控制台输出中文提示
-	return
+// buildPaymentResp 将支付单模型转换为响应结构
+func buildPaymentResp(payment *model.Payment) *types.PaymentResp {
+	resp := &types.PaymentResp{
+		TransactionID: payment.TransactionID,
+		OrderID:       payment.OrderID,
+		Amount:        payment.Amount,
+		Currency:      payment.Currency,
+		Provider:      payment.Provider,
+		ProviderRef:   payment.ProviderRef,
+		Status:        payment.Status,
+		FailureReason: payment.FailureReason,
+		CreatedAt:     payment.CreatedAt.Unix(),
+	}
+	if payment.PaidAt != nil {
+		resp.PaidAt = payment.PaidAt.Unix()
+	}
+	return resp
 }
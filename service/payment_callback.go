@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"douyin/config"
+	"douyin/consts"
+	"douyin/pkg/utils/encryption"
+	"douyin/pkg/utils/log"
+	"douyin/pkg/utils/money"
+	"douyin/repository/db/dao"
+	"douyin/repository/db/model"
+	"gorm.io/gorm"
+)
+
+const (
+	// PaymentSignatureHeader 回调签名请求头，值为 hex(HMAC-SHA256(secret, timestamp + "." + body))
+	PaymentSignatureHeader = "X-Payment-Signature"
+	// PaymentTimestampHeader 回调签名时间戳（Unix 秒）请求头
+	PaymentTimestampHeader = "X-Payment-Timestamp"
+
+	// paymentCallbackMaxSkew 回调时间戳允许的最大偏差，超出视为重放
+	paymentCallbackMaxSkew = 5 * time.Minute
+)
+
+// ErrInvalidCallbackSignature 回调签名校验失败
+var ErrInvalidCallbackSignature = errors.New("支付回调签名校验失败")
+
+// HandleCallback 处理支付渠道的异步通知
+// 先校验 HMAC 签名，再交由渠道解析报文；同一交易同一状态的通知只处理一次，
+// 支付成功时支付单与订单在同一事务中更新为已支付，之后发送支付成功邮件。
+// 重复通知返回 nil，便于渠道停止重试。订单已取消或关闭后才收到的扣款成功通知同样返回 nil，
+// 支付单标记为待对账并向渠道自动退款（见 refundLateCapture）。
+func (s *PaymentService) HandleCallback(ctx context.Context, providerName string, header http.Header, body []byte) error {
+	if err := verifyCallbackSignature(header, body); err != nil {
+		log.Warnf("支付回调验签失败 (provider: %s): %v", providerName, err)
+		return err
+	}
+
+	provider, err := s.provider(providerName, s.db)
+	if err != nil {
+		return err
+	}
+	event, err := provider.VerifyCallback(ctx, header, body)
+	if err != nil {
+		log.Warnf("支付回调报文校验失败 (provider: %s): %v", providerName, err)
+		return err
+	}
+
+	var (
+		payment     *model.Payment
+		duplicate   bool
+		lateCapture bool
+	)
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		paymentDao := dao.NewPaymentDao(tx)
+		payment, err = paymentDao.GetPaymentByTransactionIDForUpdate(ctx, event.TransactionID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("支付单 %s 不存在", event.TransactionID)
+			}
+			return err
+		}
+		// 订单取消或关闭（支付单已失效）之后渠道才通知扣款成功，渠道单号可能与支付单最后记录的不同
+		lateCapture = event.Status == consts.PaymentStatusPaid && payment.Status == consts.PaymentStatusExpired
+		if !lateCapture {
+			if payment.Provider != providerName || payment.ProviderRef != event.ProviderRef {
+				return errors.New("回调渠道或渠道单号与支付单不一致")
+			}
+			if event.Status == consts.PaymentStatusPaid && event.Amount != payment.Amount {
+				return fmt.Errorf("回调金额 %s 与支付单金额 %s 不一致", event.Amount, payment.Amount)
+			}
+		}
+
+		created, err := paymentDao.RecordNotification(ctx, &model.PaymentNotification{
+			Provider:      providerName,
+			TransactionID: event.TransactionID,
+			Status:        event.Status,
+			ProviderRef:   event.ProviderRef,
+			Payload:       string(body),
+		})
+		if err != nil {
+			return err
+		}
+		if lateCapture {
+			// 通知照常记录并返回成功，支付单标记为待对账，提交后向渠道发起自动退款
+			duplicate = !created
+			if !created {
+				return nil
+			}
+			return paymentDao.FlagReconcile(ctx, payment, providerName, event.ProviderRef, "订单已取消或关闭后收到扣款成功通知，待自动退款")
+		}
+		if !created || payment.Status == event.Status {
+			duplicate = true
+			return nil
+		}
+
+		return s.applyChargeResult(ctx, tx, payment, providerName, &ChargeResult{
+			ProviderRef:   event.ProviderRef,
+			Status:        event.Status,
+			FailureReason: event.FailureReason,
+		}, consts.OrderActorSystem, 0)
+	})
+	if err != nil {
+		log.Errorf("处理支付回调失败 (provider: %s, transactionID: %s): %v", providerName, event.TransactionID, err)
+		return err
+	}
+	if lateCapture {
+		// 重复通知时若上次自动退款未成功（仍待对账）则再次尝试
+		if !duplicate || payment.NeedsReconcile {
+			s.refundLateCapture(ctx, payment, providerName, event.Amount)
+		}
+		return nil
+	}
+	if duplicate {
+		log.Infof("重复的支付回调已忽略 (provider: %s, transactionID: %s, status: %s)", providerName, event.TransactionID, event.Status)
+		return nil
+	}
+
+	log.Infof("支付回调处理成功 (provider: %s, transactionID: %s, status: %s)", providerName, event.TransactionID, event.Status)
+	s.afterPaid(ctx, payment)
+	return nil
+}
+
+// refundLateCapture 订单取消或关闭后渠道才扣款成功时，向渠道全额退回该笔扣款，成功后支付单流转为已退款并清除对账标记
+// 退款失败或仍在处理中时只记录日志，支付单保持待对账（needs_reconcile）由人工处理
+func (s *PaymentService) refundLateCapture(ctx context.Context, payment *model.Payment, providerName string, amount money.Amount) {
+	log.Warnf("订单 %s 已取消或关闭，渠道仍扣款成功，发起自动退款 (transactionID: %s, providerRef: %s, amount: %s)",
+		payment.OrderID, payment.TransactionID, payment.ProviderRef, amount)
+	provider, err := s.provider(providerName, s.db)
+	if err != nil {
+		log.Errorf("迟到扣款自动退款失败，需人工对账 (transactionID: %s): %v", payment.TransactionID, err)
+		return
+	}
+	result, err := provider.Refund(ctx, &RefundRequest{
+		RefundNo:      payment.TransactionID, // 迟到扣款只会整笔退回一次，以交易ID作为退款单号
+		TransactionID: payment.TransactionID,
+		ProviderRef:   payment.ProviderRef,
+		UserID:        payment.UserID,
+		Amount:        amount,
+		Currency:      payment.Currency,
+		Reason:        "订单已关闭，退回支付",
+	})
+	if err != nil || result.Status != consts.PaymentStatusRefunded {
+		if err == nil {
+			err = fmt.Errorf("渠道退款状态为 %s：%s", result.Status, result.FailureReason)
+		}
+		log.Errorf("迟到扣款自动退款未完成，需人工对账 (transactionID: %s): %v", payment.TransactionID, err)
+		return
+	}
+	if err := s.paymentDao.UpdatePaymentStatus(ctx, payment, consts.PaymentStatusRefunded, map[string]interface{}{
+		"refunded_amount": amount,
+		"needs_reconcile": false,
+	}, "订单已关闭，迟到的扣款已自动退回（"+result.RefundRef+"）"); err != nil {
+		log.Errorf("迟到扣款已退款但更新支付单失败，需人工对账 (transactionID: %s, refundRef: %s): %v", payment.TransactionID, result.RefundRef, err)
+		return
+	}
+	payment.Status = consts.PaymentStatusRefunded
+	payment.RefundedAmount = amount
+	payment.NeedsReconcile = false
+	log.Infof("迟到扣款已自动退回 (transactionID: %s, refundRef: %s)", payment.TransactionID, result.RefundRef)
+}
+
+// verifyCallbackSignature 校验回调签名与时间戳，密钥取自 EncryptSecret.PaymentSecret
+func verifyCallbackSignature(header http.Header, body []byte) error {
+	timestamp := header.Get(PaymentTimestampHeader)
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: 时间戳无效", ErrInvalidCallbackSignature)
+	}
+	if skew := time.Since(time.Unix(ts, 0)); skew > paymentCallbackMaxSkew || skew < -paymentCallbackMaxSkew {
+		return fmt.Errorf("%w: 时间戳已过期", ErrInvalidCallbackSignature)
+	}
+	signature := strings.ToLower(header.Get(PaymentSignatureHeader))
+	if !encryption.VerifyHMAC(config.GetPaymentCallbackSecret(), signature, []byte(timestamp+"."), body) {
+		return ErrInvalidCallbackSignature
+	}
+	return nil
+}
+
+// enqueueReceiptEmail 支付成功后通过邮件队列发送支付凭证，失败只记录日志
+func (s *PaymentService) enqueueReceiptEmail(ctx context.Context, payment *model.Payment) {
+	if s.notifier == nil {
+		return
+	}
+	order, err := s.orderDao.GetOrderDetail(ctx, payment.UserID, payment.OrderID)
+	if err != nil {
+		log.Errorf("发送支付凭证失败，查询订单 %s 出错: %v", payment.OrderID, err)
+		return
+	}
+	if order.Email == "" {
+		return
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "您的订单 %s 已支付成功。\n\n", order.OrderID)
+	for _, item := range order.OrderItems {
+		fmt.Fprintf(&body, "%s × %d  %s %s\n", item.ProductName, item.Quantity, item.Cost.Mul(int64(item.Quantity)), order.UserCurrency)
+	}
+	if order.DiscountAmount > 0 {
+		fmt.Fprintf(&body, "优惠券抵扣：-%s %s\n", order.DiscountAmount, order.UserCurrency)
+	}
+	fmt.Fprintf(&body, "\n实付金额：%s %s\n交易号：%s\n支付时间：%s\n",
+		payment.Amount, payment.Currency, payment.TransactionID, payment.PaidAt.Format("2006-01-02 15:04:05"))
+
+	job := EmailJob{
+		To:      []string{order.Email},
+		Subject: "支付成功通知 - 订单 " + order.OrderID,
+		Body:    body.String(),
+	}
+	if err := s.notifier.EnqueueEmail(ctx, job); err != nil {
+		log.Errorf("支付凭证邮件入队失败 (orderID: %s): %v", order.OrderID, err)
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"douyin/consts"
+	"douyin/pkg/utils/money"
+	"github.com/google/uuid"
+)
+
+// mockCharge 模拟渠道内存中的扣款记录
+type mockCharge struct {
+	transactionID string
+	amount        money.Amount
+	refunded      money.Amount
+	status        string
+}
+
+// mockCallbackPayload 模拟渠道回调报文
+type mockCallbackPayload struct {
+	TransactionID string       `json:"transaction_id"`
+	ProviderRef   string       `json:"provider_ref"`
+	Status        string       `json:"status"`
+	Amount        money.Amount `json:"amount"`
+	FailureReason string       `json:"failure_reason"`
+}
+
+// MockPaymentProvider 本地模拟/沙箱支付渠道，扣款与退款记录仅保存在内存中，服务重启后丢失
+// autoCapture 为 true 时扣款立即成功，否则返回处理中，等待回调通知最终结果
+type MockPaymentProvider struct {
+	autoCapture bool
+	mu          sync.Mutex
+	charges     map[string]*mockCharge
+	refunds     map[string]*RefundResult // 按退款单号保存成功的退款结果，重复退款直接返回
+}
+
+// NewMockPaymentProvider 创建模拟支付渠道
+func NewMockPaymentProvider(autoCapture bool) *MockPaymentProvider {
+	return &MockPaymentProvider{
+		autoCapture: autoCapture,
+		charges:     make(map[string]*mockCharge),
+		refunds:     make(map[string]*RefundResult),
+	}
+}
+
+// Name 渠道名称
+func (p *MockPaymentProvider) Name() string {
+	return consts.PaymentProviderMock
+}
+
+// CreateCharge 模拟发起扣款
+func (p *MockPaymentProvider) CreateCharge(ctx context.Context, req *ChargeRequest) (*ChargeResult, error) {
+	if req.Amount <= 0 {
+		return nil, errors.New("支付金额必须大于 0")
+	}
+	status := consts.PaymentStatusPending
+	if p.autoCapture {
+		status = consts.PaymentStatusPaid
+	}
+	ref := "mock_" + uuid.New().String()
+
+	p.mu.Lock()
+	p.charges[ref] = &mockCharge{transactionID: req.TransactionID, amount: req.Amount, status: status}
+	p.mu.Unlock()
+
+	result := &ChargeResult{ProviderRef: ref, Status: status}
+	if status == consts.PaymentStatusPending {
+		result.PayURL = fmt.Sprintf("/mock-pay/%s", ref)
+	}
+	return result, nil
+}
+
+// QueryCharge 查询模拟扣款状态，渠道单号为空时按交易ID查找
+func (p *MockPaymentProvider) QueryCharge(ctx context.Context, transactionID, providerRef string) (*ChargeResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if providerRef == "" {
+		for ref, charge := range p.charges {
+			if charge.transactionID == transactionID {
+				return &ChargeResult{ProviderRef: ref, Status: charge.status}, nil
+			}
+		}
+		return nil, ErrChargeNotFound
+	}
+	charge, ok := p.charges[providerRef]
+	if !ok || charge.transactionID != transactionID {
+		return nil, ErrChargeNotFound
+	}
+	return &ChargeResult{ProviderRef: providerRef, Status: charge.status}, nil
+}
+
+// Refund 模拟退款，累计退款金额不能超过扣款金额；同一退款单号已退款成功时返回首次的结果
+func (p *MockPaymentProvider) Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if result, ok := p.refunds[req.RefundNo]; ok {
+		return result, nil
+	}
+	charge, ok := p.charges[req.ProviderRef]
+	if !ok {
+		return nil, ErrChargeNotFound
+	}
+	if charge.status != consts.PaymentStatusPaid {
+		return &RefundResult{Status: consts.PaymentStatusFailed, FailureReason: "交易未支付成功，无法退款"}, nil
+	}
+	if req.Amount <= 0 || charge.refunded+req.Amount > charge.amount {
+		return &RefundResult{Status: consts.PaymentStatusFailed, FailureReason: "退款金额超出可退金额"}, nil
+	}
+	charge.refunded += req.Amount
+	result := &RefundResult{
+		RefundRef: "mock_refund_" + uuid.New().String(),
+		Status:    consts.PaymentStatusRefunded,
+	}
+	p.refunds[req.RefundNo] = result
+	return result, nil
+}
+
+// VerifyCallback 解析模拟渠道回调，并同步内存中的扣款状态
+func (p *MockPaymentProvider) VerifyCallback(ctx context.Context, header http.Header, body []byte) (*CallbackEvent, error) {
+	var payload mockCallbackPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("回调报文格式错误: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	charge, ok := p.charges[payload.ProviderRef]
+	if !ok {
+		return nil, ErrChargeNotFound
+	}
+	if charge.transactionID != payload.TransactionID {
+		return nil, errors.New("回调交易ID与渠道记录不一致")
+	}
+	if payload.Status != consts.PaymentStatusPaid && payload.Status != consts.PaymentStatusFailed {
+		return nil, fmt.Errorf("不支持的回调状态: %s", payload.Status)
+	}
+	charge.status = payload.Status
+
+	return &CallbackEvent{
+		TransactionID: payload.TransactionID,
+		ProviderRef:   payload.ProviderRef,
+		Status:        payload.Status,
+		Amount:        charge.amount,
+		FailureReason: payload.FailureReason,
+	}, nil
+}
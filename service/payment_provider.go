@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"douyin/pkg/utils/money"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrPaymentProviderNotFound 请求的支付渠道未注册或未启用
+	ErrPaymentProviderNotFound = errors.New("不支持的支付渠道")
+	// ErrPaymentCallbackNotSupported 支付渠道没有异步回调（如余额支付为同步扣款）
+	ErrPaymentCallbackNotSupported = errors.New("该支付渠道不支持回调通知")
+	// ErrChargeNotFound 支付渠道侧查询不到对应的扣款记录
+	ErrChargeNotFound = errors.New("支付渠道中不存在该交易")
+)
+
+// ChargeRequest 向支付渠道发起扣款的参数
+type ChargeRequest struct {
+	TransactionID string       // 本系统交易ID，渠道回调时原样带回
+	OrderID       string       // 订单ID
+	UserID        uint         // 付款用户
+	Amount        money.Amount // 扣款金额
+	Currency      string       // 币种
+	Description   string       // 扣款描述
+}
+
+// ChargeResult 扣款/查询结果，Status 取值为 consts.PaymentStatusPending / Paid / Failed
+type ChargeResult struct {
+	ProviderRef   string // 渠道侧交易单号
+	Status        string // 扣款状态
+	FailureReason string // 失败原因
+	PayURL        string // 需要用户跳转完成支付时的地址，同步扣款的渠道为空
+}
+
+// RefundRequest 向支付渠道发起退款的参数
+type RefundRequest struct {
+	RefundNo      string       // 本系统退款单号，作为渠道幂等键：同一退款单号重复发起只退款一次，返回首次的结果
+	TransactionID string       // 本系统交易ID
+	ProviderRef   string       // 原扣款的渠道单号
+	UserID        uint         // 收款用户
+	Amount        money.Amount // 退款金额，可小于原扣款金额（部分退款）
+	Currency      string       // 币种
+	Reason        string       // 退款原因
+}
+
+// RefundResult 退款结果，Status 取值为 consts.PaymentStatusPending / Refunded / Failed
+type RefundResult struct {
+	RefundRef     string // 渠道侧退款单号
+	Status        string // 退款状态
+	FailureReason string // 失败原因
+}
+
+// CallbackEvent 验签通过后的支付渠道异步通知
+type CallbackEvent struct {
+	TransactionID string       // 本系统交易ID
+	ProviderRef   string       // 渠道侧交易单号
+	Status        string       // 扣款状态
+	Amount        money.Amount // 渠道实际扣款金额
+	FailureReason string       // 失败原因
+}
+
+// PaymentProvider 支付渠道接口，新增渠道时实现该接口并通过 PaymentService.RegisterProvider 注册
+type PaymentProvider interface {
+	// Name 渠道名称，与 payments.provider 字段一致
+	Name() string
+	// CreateCharge 发起扣款；业务上的失败（如卡被拒）通过 ChargeResult.Status 返回，error 表示调用本身失败
+	CreateCharge(ctx context.Context, req *ChargeRequest) (*ChargeResult, error)
+	// QueryCharge 查询扣款状态，用于同步处理中的支付单；providerRef 为空（发起扣款后渠道单号未能落库）时按 transactionID 查询，
+	// 渠道侧不存在该交易时返回 ErrChargeNotFound
+	QueryCharge(ctx context.Context, transactionID, providerRef string) (*ChargeResult, error)
+	// Refund 对已成功的扣款发起全额或部分退款；业务上的失败通过 RefundResult.Status 返回，error 表示调用本身失败、结果未知，
+	// 调用方应以相同的 RefundNo 重试
+	Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error)
+	// VerifyCallback 校验并解析渠道的异步通知
+	VerifyCallback(ctx context.Context, header http.Header, body []byte) (*CallbackEvent, error)
+}
+
+// LocalPaymentProvider 只写本系统数据库、没有外部副作用的支付渠道（如余额支付）
+// 这类渠道的扣款在写入支付结果的同一事务中执行，扣款与支付单、订单状态一起提交或回滚
+type LocalPaymentProvider interface {
+	PaymentProvider
+	// Local 标记方法，无实际行为
+	Local()
+}
+
+// PaymentProviderFactory 根据数据库连接构造支付渠道
+// 外部渠道在数据库事务之外调用，扣款结果在之后的事务中按交易ID写入；
+// LocalPaymentProvider 在事务中调用，传入的 db 为该事务连接，渠道写库必须使用它以保证与支付单更新原子提交
+type PaymentProviderFactory func(db *gorm.DB) PaymentProvider
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"douyin/config"
+	"douyin/consts"
+	"douyin/repository/db/dao"
+	"douyin/repository/db/model"
+	"gorm.io/gorm"
+)
+
+// errWalletCurrency 钱包余额以基础币种记账，其他币种的订单不能使用余额支付或退回余额
+var errWalletCurrency = errors.New("余额支付仅支持基础币种订单")
+
+// WalletPaymentProvider 账户余额支付渠道，通过钱包复式记账扣减/返还余额，扣款同步完成
+// 实现 LocalPaymentProvider，扣款与支付单更新在同一事务中提交
+type WalletPaymentProvider struct {
+	walletDao *dao.WalletDao
+}
+
+// NewWalletPaymentProvider 创建余额支付渠道，db 可以是事务连接
+func NewWalletPaymentProvider(db *gorm.DB) PaymentProvider {
+	return &WalletPaymentProvider{
+		walletDao: dao.NewWalletDao(db),
+	}
+}
+
+// Name 渠道名称
+func (p *WalletPaymentProvider) Name() string {
+	return consts.PaymentProviderWallet
+}
+
+// Local 余额支付只写本系统数据库
+func (p *WalletPaymentProvider) Local() {}
+
+// walletChargeRef 由扣款流水的记账凭证号生成渠道单号
+func walletChargeRef(entry *model.WalletTransaction) string {
+	return "wallet_" + entry.TxnNo
+}
+
+// CreateCharge 扣减用户余额，余额不足时返回 dao.ErrInsufficientBalance
+// 扣款流水以交易ID为业务单号，同一交易只能扣款一次，重复扣款返回 dao.ErrWalletDuplicateRef
+func (p *WalletPaymentProvider) CreateCharge(ctx context.Context, req *ChargeRequest) (*ChargeResult, error) {
+	if req.Amount <= 0 {
+		return nil, errors.New("支付金额必须大于 0")
+	}
+	if req.Currency != config.GetBaseCurrency() {
+		return nil, errWalletCurrency
+	}
+	entry, err := p.walletDao.Post(ctx, &dao.WalletPosting{
+		UserID:         req.UserID,
+		Type:           consts.WalletTxnPurchase,
+		Amount:         -req.Amount.MinorUnits(),
+		CounterAccount: consts.WalletAccountSales,
+		RefID:          req.TransactionID,
+		Remark:         req.Description,
+		UniqueRef:      true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ChargeResult{
+		ProviderRef: walletChargeRef(entry),
+		Status:      consts.PaymentStatusPaid,
+	}, nil
+}
+
+// QueryCharge 以钱包流水为准：存在该交易的扣款流水即已支付，不存在返回 ErrChargeNotFound
+func (p *WalletPaymentProvider) QueryCharge(ctx context.Context, transactionID, providerRef string) (*ChargeResult, error) {
+	entry, err := p.walletDao.GetUserTransactionByRef(ctx, consts.WalletTxnPurchase, transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChargeNotFound
+		}
+		return nil, err
+	}
+	return &ChargeResult{ProviderRef: walletChargeRef(entry), Status: consts.PaymentStatusPaid}, nil
+}
+
+// Refund 将退款金额返还到用户余额
+// 退款流水以退款单号为业务单号，同一退款单号只入账一次，重复退款返回首次入账的结果
+func (p *WalletPaymentProvider) Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error) {
+	if req.Amount <= 0 {
+		return nil, errors.New("退款金额必须大于 0")
+	}
+	if req.Currency != config.GetBaseCurrency() {
+		return nil, errWalletCurrency
+	}
+	entry, err := p.walletDao.Post(ctx, &dao.WalletPosting{
+		UserID:         req.UserID,
+		Type:           consts.WalletTxnRefund,
+		Amount:         req.Amount.MinorUnits(),
+		CounterAccount: consts.WalletAccountSales,
+		RefID:          req.RefundNo,
+		Remark:         req.Reason,
+		UniqueRef:      true,
+	})
+	if errors.Is(err, dao.ErrWalletDuplicateRef) {
+		entry, err = p.walletDao.GetUserTransactionByRef(ctx, consts.WalletTxnRefund, req.RefundNo)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &RefundResult{
+		RefundRef: "wallet_refund_" + entry.TxnNo,
+		Status:    consts.PaymentStatusRefunded,
+	}, nil
+}
+
+// VerifyCallback 余额支付同步完成，没有回调通知
+func (p *WalletPaymentProvider) VerifyCallback(ctx context.Context, header http.Header, body []byte) (*CallbackEvent, error) {
+	return nil, ErrPaymentCallbackNotSupported
+}
@@ -3,6 +3,7 @@ package service
 
 import (
 	"context"
+	"douyin/config"
 	"douyin/repository/cache"
 	"douyin/repository/db/dao"
 	"douyin/repository/db/model"
@@ -16,34 +17,46 @@ import (
 )
 
 func CreateProduct(ctx context.Context, userID uint32, product *types.Product) error {
-	// 1. 验证用户身份是否有效，或者是否有权限创建商品
+	// 1. 验证用户身份是否有效，或者是否有权限创建商品：商品归属于用户的店铺，平台管理员可发布自营商品
 	if userID == 0 {
 		return errors.New("用户身份无效")
 	}
+	merchantID, err := productMerchantForCreate(ctx, uint(userID))
+	if err != nil {
+		return err
+	}
 
-	// 2. 将 types.Product 转换为 model.Product
+	// 2. 将 types.Product 转换为 model.Product，价格与库存由 SKU 汇总得出
+	specs, skus, err := buildProductSkus(product)
+	if err != nil {
+		return err
+	}
 	modelProduct := &model.Product{
 		Name:        product.Name,
 		Description: product.Description,
 		Picture:     product.Picture,
-		Price:       product.Price,
-		// Stock and Version will be set by GORM default or manually if needed
+		MerchantID:  merchantID,
+	}
+	if product.LowStockThreshold != nil {
+		modelProduct.LowStockThreshold = *product.LowStockThreshold
 	}
 
-	// 3. 调用DAO层，进行商品创建
-	err := dao.CreateProduct(modelProduct) // Assuming DAO CreateProduct handles context if necessary, or pass ctx
+	// 3. 调用DAO层，在一个事务内创建商品、规格与 SKU
+	err = dao.CreateProductWithSkus(ctx, modelProduct, specs, skus)
 	if err != nil {
 		log.Printf("创建商品失败：%v", err)
 		return err
 	}
 	fmt.Println("商品创建成功")
+	syncProductIndex(ctx, modelProduct.ID)
 	// Consider invalidating product list caches here
 	// For now, log or skip as per subtask instructions for list invalidation.
 	log.Println("Product list cache invalidation would be needed after creating a product.")
 	return nil
 }
 
-func GetProductByID(ctx context.Context, id uint32) (*types.Product, error) {
+// GetProductByID 查询商品详情，价格按 quote 换算为展示币种；quote 为 nil 时返回基础币种价格
+func GetProductByID(ctx context.Context, id uint32, quote *ExchangeQuote) (*types.Product, error) {
 	key := cache.ProductDetailKey(uint(id))
 	// Try to get from cache
 	cachedData, err := cache.RedisClient.Get(ctx, key).Result()
@@ -51,15 +64,19 @@ func GetProductByID(ctx context.Context, id uint32) (*types.Product, error) {
 		var productModel model.Product
 		if errUnmarshal := json.Unmarshal([]byte(cachedData), &productModel); errUnmarshal == nil {
 			// Cache hit and unmarshal success
-			return &types.Product{
+			typesProduct := &types.Product{
 				ID:          uint32(productModel.ID),
 				Name:        productModel.Name,
 				Description: productModel.Description,
 				Picture:     productModel.Picture,
+				MerchantID:  productModel.MerchantID,
 				Price:       productModel.Price,
 				Stock:       productModel.Stock, // Assuming types.Product also has Stock and Version
 				Version:     productModel.Version,
-			}, nil
+			}
+			localizeProductPrice(typesProduct, quote)
+			fillProductSkus(ctx, typesProduct, quote)
+			return typesProduct, nil
 		}
 		// Unmarshal failed, treat as cache miss and delete potentially corrupt cache entry
 		log.Printf("Error unmarshalling cached product for ID %d: %v. Deleting cache entry.", id, errUnmarshal)
@@ -94,16 +111,26 @@ func GetProductByID(ctx context.Context, id uint32) (*types.Product, error) {
 		Name:        product.Name,
 		Description: product.Description,
 		Picture:     product.Picture,
+		MerchantID:  product.MerchantID,
 		Price:       product.Price,
 		Stock:       product.Stock,
 		Version:     product.Version,
 	}
+	localizeProductPrice(typesProduct, quote)
+	fillProductSkus(ctx, typesProduct, quote)
 
 	return typesProduct, nil
 }
 
-func ListProducts(ctx context.Context, pageNum, pageSize int) ([]types.Product, int64, error) {
+// ListProducts 分页查询商品列表，价格按 quote 换算为展示币种；quote 为 nil 时返回基础币种价格
+// categoryID 不为 0 时按分类筛选，categoryIDs 为该分类及其全部子孙分类（见 CategoryService.Descendants）
+func ListProducts(ctx context.Context, pageNum, pageSize int, categoryID uint, categoryIDs []uint, quote *ExchangeQuote) ([]types.Product, int64, error) {
 	key := cache.ProductListKey(pageNum, pageSize)
+	if categoryID != 0 {
+		key = cache.ProductCategoryListKey(categoryID, pageNum, pageSize)
+	} else {
+		categoryIDs = nil
+	}
 	// Try to get from cache
 	cachedData, err := cache.RedisClient.Get(ctx, key).Result()
 	if err == nil {
@@ -120,10 +147,12 @@ func ListProducts(ctx context.Context, pageNum, pageSize int) ([]types.Product,
 					Name:        pModel.Name,
 					Description: pModel.Description,
 					Picture:     pModel.Picture,
+					MerchantID:  pModel.MerchantID,
 					Price:       pModel.Price,
 					Stock:       pModel.Stock,
 					Version:     pModel.Version,
 				})
+				localizeProductPrice(&typesProducts[len(typesProducts)-1], quote)
 			}
 			return typesProducts, res.Total, nil
 		}
@@ -135,7 +164,7 @@ func ListProducts(ctx context.Context, pageNum, pageSize int) ([]types.Product,
 	}
 
 	// Cache miss or error, fetch from DB
-	productsFromDB, total, dbErr := dao.ListProducts(pageNum, pageSize) // Assuming DAO ListProducts doesn't need context
+	productsFromDB, total, dbErr := dao.ListProducts(pageNum, pageSize, categoryIDs) // Assuming DAO ListProducts doesn't need context
 	if dbErr != nil {
 		log.Printf("获取商品列表失败：%v", dbErr)
 		return nil, 0, dbErr
@@ -164,39 +193,69 @@ func ListProducts(ctx context.Context, pageNum, pageSize int) ([]types.Product,
 			Name:        p.Name,
 			Description: p.Description,
 			Picture:     p.Picture,
+			MerchantID:  p.MerchantID,
 			Price:       p.Price,
 			Stock:       p.Stock,
 			Version:     p.Version,
 		})
+		localizeProductPrice(&result[len(result)-1], quote)
 	}
 	return result, total, nil
 }
 
+// localizeProductPrice 将商品的基础币种价格换算为展示币种；缓存中始终保存基础币种价格
+func localizeProductPrice(product *types.Product, quote *ExchangeQuote) {
+	if quote == nil {
+		product.Currency = config.GetBaseCurrency()
+		return
+	}
+	product.Price = quote.Convert(product.Price)
+	product.Currency = quote.Currency
+}
+
 func UpdateProduct(ctx context.Context, userID uint32, product *types.Product) error {
-	// 验证用户身份或权限
+	// 验证用户身份或权限：只能修改自己店铺的商品
 	if userID == 0 {
 		return errors.New("用户身份无效")
 	}
+	if err := checkProductOwnership(ctx, uint(userID), uint(product.ID)); err != nil {
+		return err
+	}
 
-	// 2. 将 types.Product 转换为 model.Product
+	// 2. 将 types.Product 转换为 model.Product；价格与库存由 SKU 汇总，只能通过 SKU 修改
+	specs, skus, err := updatedProductSkus(ctx, product)
+	if err != nil {
+		return err
+	}
 	modelProduct := &model.Product{
 		ID:          uint(product.ID),
 		Name:        product.Name,
 		Description: product.Description,
 		Picture:     product.Picture,
-		Price:       product.Price,
-		Stock:       product.Stock,   // Assuming types.Product carries this for updates
-		Version:     product.Version, // And version for optimistic locking if applicable at DAO
 	}
 
 	// 调用DAO层修改商品
-	err := dao.UpdateProduct(modelProduct) // Pass ctx if DAO method is updated
+	err = dao.UpdateProduct(modelProduct) // Pass ctx if DAO method is updated
 	if err != nil {
 		log.Printf("修改商品失败：%v", err)
 		return err
 	}
+	if product.LowStockThreshold != nil {
+		// Updates 会忽略零值，阈值单独更新以便设为 0 关闭预警
+		if err := dao.UpdateProductLowStockThreshold(ctx, uint(product.ID), *product.LowStockThreshold); err != nil {
+			log.Printf("修改商品低库存预警阈值失败：%v", err)
+			return err
+		}
+	}
+	if skus != nil {
+		if err := dao.ReplaceProductSkus(ctx, uint(product.ID), specs, skus); err != nil {
+			log.Printf("修改商品 SKU 失败：%v", err)
+			return err
+		}
+	}
 
 	fmt.Println("商品信息修改成功")
+	syncProductIndex(ctx, uint(product.ID))
 	// Invalidate product detail cache
 	detailKey := cache.ProductDetailKey(uint(product.ID))
 	if delErr := cache.RedisClient.Del(ctx, detailKey).Err(); delErr != nil {
@@ -208,10 +267,13 @@ func UpdateProduct(ctx context.Context, userID uint32, product *types.Product) e
 }
 
 func DeleteProduct(ctx context.Context, userID uint32, productID uint32) error {
-	// 验证用户身份或权限
+	// 验证用户身份或权限：只能删除自己店铺的商品
 	if userID == 0 {
 		return errors.New("用户身份无效")
 	}
+	if err := checkProductOwnership(ctx, uint(userID), uint(productID)); err != nil {
+		return err
+	}
 
 	// 调用DAO层删除商品
 	err := dao.DeleteProduct(productID) // Pass ctx if DAO method is updated
@@ -221,6 +283,7 @@ func DeleteProduct(ctx context.Context, userID uint32, productID uint32) error {
 	}
 
 	fmt.Println("商品删除成功")
+	removeProductIndex(ctx, uint(productID))
 	// Invalidate product detail cache
 	detailKey := cache.ProductDetailKey(uint(productID))
 	if delErr := cache.RedisClient.Del(ctx, detailKey).Err(); delErr != nil {
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"douyin/pkg/utils/log"
+	"douyin/repository/db/dao"
+	"douyin/repository/db/model"
+	"douyin/types"
+)
+
+// buildProductSkus 将创建/修改商品请求中的规格与 SKU 转换为模型并校验
+// 请求没有 SKU 时生成一个默认 SKU（没有规格，价格与库存取商品的 Price 与 Stock）；有规格时必须提供 SKU，且每个 SKU 的规格组合不能重复
+func buildProductSkus(product *types.Product) ([]model.ProductSpec, []model.ProductSku, error) {
+	if len(product.Skus) == 0 {
+		if len(product.Specs) > 0 {
+			return nil, nil, errors.New("有规格的商品需要提供 SKU")
+		}
+		if product.Price <= 0 {
+			return nil, nil, errors.New("商品价格必须大于 0")
+		}
+		if product.Stock < 0 {
+			return nil, nil, errors.New("商品库存不能为负数")
+		}
+		return nil, []model.ProductSku{{Price: product.Price, Stock: product.Stock}}, nil
+	}
+
+	specs := make([]model.ProductSpec, 0, len(product.Specs))
+	names := make(map[string]bool, len(product.Specs))
+	for i, spec := range product.Specs {
+		if names[spec.Name] {
+			return nil, nil, fmt.Errorf("规格「%s」重复", spec.Name)
+		}
+		names[spec.Name] = true
+		specs = append(specs, model.ProductSpec{Name: spec.Name, Values: spec.Values, Sort: i})
+	}
+
+	skus := make([]model.ProductSku, 0, len(product.Skus))
+	seen := make(map[string]bool, len(product.Skus))
+	for _, sku := range product.Skus {
+		key, err := model.SkuSpecKey(specs, sku.Specs)
+		if err != nil {
+			return nil, nil, err
+		}
+		if seen[key] {
+			return nil, nil, fmt.Errorf("SKU「%s」重复", key)
+		}
+		seen[key] = true
+		if sku.Price <= 0 {
+			return nil, nil, fmt.Errorf("SKU「%s」的价格必须大于 0", key)
+		}
+		if sku.Stock < 0 {
+			return nil, nil, fmt.Errorf("SKU「%s」的库存不能为负数", key)
+		}
+		skus = append(skus, model.ProductSku{
+			SpecKey: key,
+			Specs:   sku.Specs,
+			Price:   sku.Price,
+			Stock:   sku.Stock,
+			Picture: sku.Picture,
+		})
+	}
+	return specs, skus, nil
+}
+
+// updatedProductSkus 计算修改商品时需要写入的规格与 SKU，返回 nil 表示 SKU 保持不变
+// 请求带 SKU 时整体替换；只带非零的 Price/Stock 时修改默认 SKU，有多个规格的商品必须通过 SKU 修改价格与库存
+func updatedProductSkus(ctx context.Context, product *types.Product) ([]model.ProductSpec, []model.ProductSku, error) {
+	if len(product.Skus) > 0 {
+		return buildProductSkus(product)
+	}
+	if product.Price == 0 && product.Stock == 0 {
+		return nil, nil, nil
+	}
+	_, skus, err := dao.ListProductSkus(ctx, uint(product.ID))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(skus) != 1 || skus[0].SpecKey != "" {
+		return nil, nil, errors.New("商品有多个规格，请通过 skus 修改价格与库存")
+	}
+	sku := skus[0]
+	if product.Price != 0 {
+		sku.Price = product.Price
+	}
+	if product.Stock != 0 {
+		sku.Stock = product.Stock
+	}
+	if sku.Price <= 0 || sku.Stock < 0 {
+		return nil, nil, errors.New("商品价格必须大于 0，库存不能为负数")
+	}
+	return nil, []model.ProductSku{sku}, nil
+}
+
+// fillProductSkus 为商品详情填充规格与 SKU，SKU 价格按 quote 换算为展示币种；查询失败时只记录日志，不影响商品展示
+// SKU 库存变化频繁，不随商品详情缓存，每次从数据库读取
+func fillProductSkus(ctx context.Context, product *types.Product, quote *ExchangeQuote) {
+	specs, skus, err := dao.ListProductSkus(ctx, uint(product.ID))
+	if err != nil {
+		log.Warnf("查询商品规格失败 (productID: %d): %v", product.ID, err)
+		return
+	}
+	product.Specs = make([]types.ProductSpec, 0, len(specs))
+	for _, spec := range specs {
+		product.Specs = append(product.Specs, types.ProductSpec{Name: spec.Name, Values: spec.Values})
+	}
+	product.Skus = make([]types.ProductSku, 0, len(skus))
+	for _, sku := range skus {
+		price := sku.Price
+		if quote != nil {
+			price = quote.Convert(price)
+		}
+		product.Skus = append(product.Skus, types.ProductSku{
+			ID:      sku.ID,
+			Specs:   sku.Specs,
+			SpecKey: sku.SpecKey,
+			Price:   price,
+			Stock:   sku.Stock,
+			Picture: sku.Picture,
+		})
+	}
+}
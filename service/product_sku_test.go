@@ -0,0 +1,56 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"douyin/pkg/utils/money"
+	"douyin/types"
+)
+
+func TestBuildProductSkus(t *testing.T) {
+	// 没有规格的商品生成一个默认 SKU
+	specs, skus, err := buildProductSkus(&types.Product{Price: 1990, Stock: 8})
+	require.NoError(t, err)
+	assert.Empty(t, specs)
+	require.Len(t, skus, 1)
+	assert.Equal(t, "", skus[0].SpecKey)
+	assert.Equal(t, money.Amount(1990), skus[0].Price)
+	assert.Equal(t, 8, skus[0].Stock)
+
+	product := &types.Product{
+		Specs: []types.ProductSpec{
+			{Name: "颜色", Values: []string{"红", "蓝"}},
+			{Name: "尺码", Values: []string{"M", "L"}},
+		},
+		Skus: []types.ProductSku{
+			{Specs: map[string]string{"颜色": "红", "尺码": "M"}, Price: 5900, Stock: 3},
+			{Specs: map[string]string{"尺码": "L", "颜色": "蓝"}, Price: 6900, Stock: 0, Picture: "blue.png"},
+		},
+	}
+	specs, skus, err = buildProductSkus(product)
+	require.NoError(t, err)
+	require.Len(t, specs, 2)
+	assert.Equal(t, 1, specs[1].Sort)
+	require.Len(t, skus, 2)
+	assert.Equal(t, "颜色:红;尺码:M", skus[0].SpecKey)
+	assert.Equal(t, "颜色:蓝;尺码:L", skus[1].SpecKey)
+	assert.Equal(t, "blue.png", skus[1].Picture)
+
+	duplicated := *product
+	duplicated.Skus = append([]types.ProductSku{}, product.Skus[0], product.Skus[0])
+	_, _, err = buildProductSkus(&duplicated)
+	assert.Error(t, err, "规格组合重复")
+
+	noSkus := *product
+	noSkus.Skus = nil
+	_, _, err = buildProductSkus(&noSkus)
+	assert.Error(t, err, "有规格时必须提供 SKU")
+
+	free := *product
+	free.Skus = []types.ProductSku{{Specs: map[string]string{"颜色": "红", "尺码": "M"}, Price: 0, Stock: 1}}
+	_, _, err = buildProductSkus(&free)
+	assert.Error(t, err, "SKU 价格必须大于 0")
+}
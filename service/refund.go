@@ -0,0 +1,550 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"time"
+
+	"douyin/consts"
+	"douyin/pkg/utils/log"
+	"douyin/pkg/utils/money"
+	"douyin/pkg/utils/upload"
+	"douyin/repository/db/dao"
+	"douyin/repository/db/model"
+	"douyin/types"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefundService 售后服务：买家申请整单或按订单项退款/退货，管理员审核后原路退款或退回账户余额
+type RefundService struct {
+	db        *gorm.DB
+	refundDao *dao.RefundDao
+	orderDao  *dao.OrderDao
+	payments  *PaymentService
+	uploader  *upload.Client // 凭证图片上传，未配置 OSS 时为 nil
+}
+
+// NewRefundService 创建新的 RefundService 实例
+func NewRefundService(db *gorm.DB, uploader *upload.Client) *RefundService {
+	return &RefundService{
+		db:        db,
+		refundDao: dao.NewRefundDao(db),
+		orderDao:  dao.NewOrderDao(db),
+		payments:  NewPaymentService(db),
+		uploader:  uploader,
+	}
+}
+
+// ApplyRefund 买家对一个子订单申请售后，未指定退款项时对该子订单中尚未申请售后的全部商品申请退款
+func (s *RefundService) ApplyRefund(ctx context.Context, userID uint, req *types.RefundApplyReq, evidence []*multipart.FileHeader) (*types.RefundResp, error) {
+	refundType := req.Type
+	if refundType == "" {
+		refundType = consts.RefundTypeRefundOnly
+	}
+	if refundType != consts.RefundTypeRefundOnly && refundType != consts.RefundTypeReturn {
+		return nil, fmt.Errorf("不支持的售后类型: %s", refundType)
+	}
+	if len(evidence) > consts.RefundEvidenceMaxFiles {
+		return nil, fmt.Errorf("凭证图片最多上传 %d 张", consts.RefundEvidenceMaxFiles)
+	}
+
+	order, err := s.orderDao.GetOrderDetail(ctx, userID, req.OrderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("订单不存在")
+		}
+		return nil, err
+	}
+	subOrder, err := resolveRefundSubOrder(order, req.SubOrderID, req.ItemReq)
+	if err != nil {
+		return nil, err
+	}
+	if !consts.RefundableOrderStatus[subOrder.Status] {
+		return nil, fmt.Errorf("子订单当前状态为「%s」，无法申请售后", subOrder.StatusText())
+	}
+	subOrderItems := make([]model.OrderItem, 0, len(order.OrderItems))
+	for _, item := range order.OrderItems {
+		if item.SubOrderID == subOrder.SubOrderID {
+			subOrderItems = append(subOrderItems, item)
+		}
+	}
+
+	urls, err := s.uploadEvidence(order.OrderID, evidence)
+	if err != nil {
+		return nil, err
+	}
+
+	refund := &model.Refund{
+		RefundNo:   uuid.New().String(),
+		OrderID:    order.OrderID,
+		SubOrderID: subOrder.SubOrderID,
+		UserID:     userID,
+		Type:       refundType,
+		Reason:     req.Reason,
+		Evidence:   urls,
+		Status:     consts.RefundStatusPending,
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 锁住支付单，串行化同一订单的售后申请，避免并发申请超出可退数量
+		payment, err := dao.NewPaymentDao(tx).GetPaidPaymentByOrderIDForUpdate(ctx, order.OrderID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("订单没有可退款的支付记录")
+			}
+			return err
+		}
+		refund.TransactionID = payment.TransactionID
+
+		refunding, err := dao.NewRefundDao(tx).SumActiveRefundQuantity(ctx, order.OrderID)
+		if err != nil {
+			return err
+		}
+		refund.Items, refund.Amount, err = buildRefundItems(subOrderItems, refunding, req.ItemReq)
+		if err != nil {
+			return err
+		}
+		return dao.NewRefundDao(tx).CreateRefund(ctx, refund)
+	})
+	if err != nil {
+		log.Errorf("申请售后失败 (userID: %d, orderID: %s): %v", userID, req.OrderID, err)
+		return nil, err
+	}
+	log.Infof("售后申请已提交 (refundNo: %s, orderID: %s, amount: %s)", refund.RefundNo, refund.OrderID, refund.Amount)
+	return buildRefundResp(refund), nil
+}
+
+// resolveRefundSubOrder 确定售后申请所属的子订单：优先使用指定的子订单，其次取退款项所属的子订单（退款项须属于同一子订单），
+// 订单只有一个子订单时可以都不指定
+func resolveRefundSubOrder(order *model.Order, subOrderID string, reqItems []types.RefundItemReq) (*model.SubOrder, error) {
+	if subOrderID == "" && len(reqItems) > 0 {
+		for _, item := range order.OrderItems {
+			if item.ID == reqItems[0].OrderItemID {
+				subOrderID = item.SubOrderID
+				break
+			}
+		}
+		if subOrderID == "" {
+			return nil, fmt.Errorf("订单项 %d 不属于该订单", reqItems[0].OrderItemID)
+		}
+	}
+	if subOrderID == "" {
+		if len(order.SubOrders) != 1 {
+			return nil, errors.New("订单包含多个子订单，请指定要申请售后的子订单")
+		}
+		return &order.SubOrders[0], nil
+	}
+	for i := range order.SubOrders {
+		if order.SubOrders[i].SubOrderID == subOrderID {
+			return &order.SubOrders[i], nil
+		}
+	}
+	return nil, errors.New("子订单不存在")
+}
+
+// buildRefundItems 校验退款项并计算退款金额，refunding 为各订单项已在售后中的数量
+// 订单项分摊到的优惠按数量比例从退款中扣除，同一订单项分多次售后时各次扣除的优惠之和恰好等于该项的优惠总额
+func buildRefundItems(orderItems []model.OrderItem, refunding map[uint]int32, reqItems []types.RefundItemReq) ([]model.RefundItem, money.Amount, error) {
+	itemByID := make(map[uint]model.OrderItem, len(orderItems))
+	for _, item := range orderItems {
+		itemByID[item.ID] = item
+	}
+	if len(reqItems) == 0 {
+		for _, item := range orderItems {
+			if remain := item.Quantity - refunding[item.ID]; remain > 0 {
+				reqItems = append(reqItems, types.RefundItemReq{OrderItemID: item.ID, Quantity: remain})
+			}
+		}
+	}
+
+	var (
+		items  []model.RefundItem
+		amount money.Amount
+		seen   = make(map[uint]bool, len(reqItems))
+	)
+	for _, req := range reqItems {
+		orderItem, ok := itemByID[req.OrderItemID]
+		if !ok {
+			return nil, 0, fmt.Errorf("订单项 %d 不属于该订单", req.OrderItemID)
+		}
+		if seen[req.OrderItemID] {
+			return nil, 0, fmt.Errorf("订单项 %d 重复", req.OrderItemID)
+		}
+		seen[req.OrderItemID] = true
+		if remain := orderItem.Quantity - refunding[orderItem.ID]; req.Quantity > remain {
+			return nil, 0, fmt.Errorf("「%s」可申请售后的数量为 %d", orderItem.ProductName, remain)
+		}
+		itemAmount := orderItem.Cost.Mul(int64(req.Quantity)) - proratedDiscount(orderItem, refunding[orderItem.ID], req.Quantity)
+		items = append(items, model.RefundItem{
+			OrderItemID: orderItem.ID,
+			ProductID:   orderItem.ProductID,
+			SkuID:       orderItem.SkuID,
+			Quantity:    req.Quantity,
+			Amount:      itemAmount,
+		})
+		amount += itemAmount
+	}
+	if len(items) == 0 {
+		return nil, 0, errors.New("订单商品均已申请售后")
+	}
+	return items, amount, nil
+}
+
+// proratedDiscount 计算在已售后 done 件的基础上再售后 quantity 件时应扣除的优惠
+// 按累计数量向下取整计算，最后一件售后时补齐余数
+func proratedDiscount(item model.OrderItem, done, quantity int32) money.Amount {
+	if item.Discount == 0 || item.Quantity == 0 {
+		return 0
+	}
+	cumulative := func(n int32) money.Amount {
+		return item.Discount * money.Amount(n) / money.Amount(item.Quantity)
+	}
+	return cumulative(done+quantity) - cumulative(done)
+}
+
+// uploadEvidence 通过对象存储上传凭证图片，返回图片地址
+func (s *RefundService) uploadEvidence(orderID string, files []*multipart.FileHeader) ([]string, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	if s.uploader == nil {
+		return nil, errors.New("文件服务未初始化，暂不支持上传凭证")
+	}
+	urls := make([]string, 0, len(files))
+	for _, file := range files {
+		url, err := s.uploader.Upload("refunds/"+orderID, file)
+		if err != nil {
+			log.Errorf("上传售后凭证失败 (orderID: %s, file: %s): %v", orderID, file.Filename, err)
+			return nil, fmt.Errorf("上传凭证 %s 失败", file.Filename)
+		}
+		urls = append(urls, url)
+	}
+	return urls, nil
+}
+
+// ReviewRefund 管理员审核售后单
+// 通过时按退款方式分两种处理：
+// 退回余额时在同一事务中返还余额并写入退款结果；
+// 原路退回外部支付渠道时先将售后单置为退款处理中并提交，再在事务外以售后单号为幂等键调用渠道退款，最后在新的事务中写入退款结果。
+// 写入退款结果包括累加支付单退款金额、从商家待结算货款中扣回、退货商品重新入库，子订单退完时子订单流转为已退款，全部子订单退完时父订单随之流转为已退款。
+// 渠道明确退款失败时售后单退回待审核，可改为退回余额后重试；渠道调用出错或仍在处理中时售后单保持退款处理中，由 ListenAndRetryRefunds 重试。
+// 退货类售后应在确认收到退回商品后再审核通过。
+func (s *RefundService) ReviewRefund(ctx context.Context, reviewerID uint, req *types.RefundReviewReq) (*types.RefundResp, error) {
+	var refund *model.Refund
+	var payment *model.Payment
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		refundDao := dao.NewRefundDao(tx)
+		var err error
+		refund, err = refundDao.GetRefundByNoForUpdate(ctx, req.RefundNo)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("售后单不存在")
+			}
+			return err
+		}
+		if refund.Status != consts.RefundStatusPending {
+			return errors.New("售后单已处理")
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"reviewer_id": reviewerID,
+			"review_note": req.Note,
+			"reviewed_at": now,
+		}
+		refund.ReviewerID = reviewerID
+		refund.ReviewNote = req.Note
+		refund.ReviewedAt = &now
+		if !req.Approve {
+			if err := refundDao.UpdateRefundStatus(ctx, refund.ID, consts.RefundStatusPending, consts.RefundStatusRejected, updates); err != nil {
+				return err
+			}
+			refund.Status = consts.RefundStatusRejected
+		} else {
+			payment, err = s.executeRefund(ctx, tx, refund, req.RefundToWallet, updates)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("审核售后单失败 (refundNo: %s, reviewer: %d): %v", req.RefundNo, reviewerID, err)
+		return nil, err
+	}
+
+	if refund.Status == consts.RefundStatusProcessing {
+		if err := s.processRefund(ctx, refund, payment); err != nil {
+			log.Errorf("售后单渠道退款未完成 (refundNo: %s, reviewer: %d): %v", refund.RefundNo, reviewerID, err)
+			if refund.Status == consts.RefundStatusPending {
+				return nil, err
+			}
+		}
+	}
+	log.Infof("售后单审核完成 (refundNo: %s, status: %s, reviewer: %d)", refund.RefundNo, refund.Status, reviewerID)
+	s.afterRefunded(ctx, refund)
+	return buildRefundResp(refund), nil
+}
+
+// executeRefund 审核通过时执行退款，需在审核事务中调用，返回被退款的支付单
+// 退回余额时直接完成退款；原路退回外部渠道时只将售后单置为退款处理中，渠道退款由调用方在事务提交后通过 processRefund 发起
+func (s *RefundService) executeRefund(ctx context.Context, tx *gorm.DB, refund *model.Refund, toWallet bool, updates map[string]interface{}) (*model.Payment, error) {
+	payment, err := dao.NewPaymentDao(tx).GetPaidPaymentByOrderIDForUpdate(ctx, refund.OrderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("订单支付单状态异常，无法退款")
+		}
+		return nil, err
+	}
+
+	method := payment.Provider
+	if toWallet {
+		method = consts.PaymentProviderWallet
+	}
+	provider, err := s.payments.provider(method, tx)
+	if err != nil {
+		return nil, err
+	}
+	updates["refund_method"] = method
+	refund.RefundMethod = method
+
+	if _, local := provider.(LocalPaymentProvider); !local {
+		if err := dao.NewRefundDao(tx).UpdateRefundStatus(ctx, refund.ID, consts.RefundStatusPending, consts.RefundStatusProcessing, updates); err != nil {
+			return nil, err
+		}
+		refund.Status = consts.RefundStatusProcessing
+		return payment, nil
+	}
+
+	result, err := provider.Refund(ctx, newRefundRequest(refund, payment))
+	if err != nil {
+		return nil, fmt.Errorf("渠道退款失败: %w", err)
+	}
+	if result.Status != consts.PaymentStatusRefunded {
+		return nil, fmt.Errorf("渠道退款失败: %s", result.FailureReason)
+	}
+	return payment, s.applyRefund(ctx, tx, refund, payment, consts.RefundStatusPending, result.RefundRef, updates)
+}
+
+// newRefundRequest 由售后单与被退款的支付单构造渠道退款参数，以售后单号作为渠道幂等键
+func newRefundRequest(refund *model.Refund, payment *model.Payment) *RefundRequest {
+	return &RefundRequest{
+		RefundNo:      refund.RefundNo,
+		TransactionID: payment.TransactionID,
+		ProviderRef:   payment.ProviderRef,
+		UserID:        refund.UserID,
+		Amount:        refund.Amount,
+		Currency:      payment.Currency,
+		Reason:        refund.Reason,
+	}
+}
+
+// processRefund 对退款处理中的售后单调用渠道退款并写入结果，需在事务外调用
+// 渠道以售后单号去重，重复调用不会重复退款；渠道明确失败时售后单退回待审核，调用出错或渠道仍在处理中时保持退款处理中等待重试
+func (s *RefundService) processRefund(ctx context.Context, refund *model.Refund, payment *model.Payment) error {
+	provider, err := s.payments.provider(refund.RefundMethod, s.db)
+	if err != nil {
+		return err
+	}
+	result, err := provider.Refund(ctx, newRefundRequest(refund, payment))
+	if err != nil {
+		return fmt.Errorf("渠道退款调用失败，等待重试: %w", err)
+	}
+
+	switch result.Status {
+	case consts.PaymentStatusRefunded:
+		return s.completeRefund(ctx, refund, result.RefundRef)
+	case consts.PaymentStatusFailed:
+		if err := s.refundDao.UpdateRefundStatus(ctx, refund.ID, consts.RefundStatusProcessing, consts.RefundStatusPending, map[string]interface{}{
+			"refund_method": "",
+		}); err != nil {
+			return err
+		}
+		refund.Status = consts.RefundStatusPending
+		refund.RefundMethod = ""
+		return fmt.Errorf("渠道退款失败: %s", result.FailureReason)
+	default:
+		log.Infof("渠道退款处理中 (refundNo: %s, refundRef: %s)", refund.RefundNo, result.RefundRef)
+		return nil
+	}
+}
+
+// completeRefund 渠道退款成功后在新的事务中写入退款结果，售后单已不是退款处理中（已被其他请求写入）时返回 dao.ErrRefundStatusConflict
+func (s *RefundService) completeRefund(ctx context.Context, refund *model.Refund, refundRef string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		locked, err := dao.NewRefundDao(tx).GetRefundByNoForUpdate(ctx, refund.RefundNo)
+		if err != nil {
+			return err
+		}
+		if locked.Status != consts.RefundStatusProcessing {
+			return dao.ErrRefundStatusConflict
+		}
+		payment, err := dao.NewPaymentDao(tx).GetPaidPaymentByOrderIDForUpdate(ctx, locked.OrderID)
+		if err != nil {
+			return err
+		}
+		if err := s.applyRefund(ctx, tx, locked, payment, consts.RefundStatusProcessing, refundRef, map[string]interface{}{}); err != nil {
+			return err
+		}
+		refund.Status = locked.Status
+		refund.RefundRef = locked.RefundRef
+		return nil
+	})
+}
+
+// applyRefund 渠道退款成功后写入退款结果，需在事务中调用：
+// 售后单由 from 状态流转为已退款，累加支付单退款金额，从商家待结算货款中扣回，退货商品重新入库，并汇总子订单/父订单状态
+func (s *RefundService) applyRefund(ctx context.Context, tx *gorm.DB, refund *model.Refund, payment *model.Payment, from, refundRef string, updates map[string]interface{}) error {
+	if err := dao.NewPaymentDao(tx).AddRefundedAmount(ctx, payment, refund.Amount, "售后退款 "+refund.RefundNo); err != nil {
+		return err
+	}
+	updates["refund_ref"] = refundRef
+	refundDao := dao.NewRefundDao(tx)
+	if err := refundDao.UpdateRefundStatus(ctx, refund.ID, from, consts.RefundStatusRefunded, updates); err != nil {
+		return err
+	}
+	refund.Status = consts.RefundStatusRefunded
+	refund.RefundRef = refundRef
+	if err := dao.NewMerchantDao(tx).CreateRefundSettlements(ctx, refund); err != nil {
+		return err
+	}
+
+	if refund.Type == consts.RefundTypeReturn {
+		if err := refundDao.RestockRefundItems(ctx, refund, refund.ReviewerID); err != nil {
+			return err
+		}
+	}
+
+	return refundSubOrderIfSettled(ctx, tx, refund.SubOrderID, refund.ReviewerID)
+}
+
+// afterRefunded 退货类售后退款完成后同步订单商品索引
+func (s *RefundService) afterRefunded(ctx context.Context, refund *model.Refund) {
+	if refund.Status == consts.RefundStatusRefunded && refund.Type == consts.RefundTypeReturn {
+		syncOrderProductIndex(ctx, s.orderDao, refund.OrderID)
+	}
+}
+
+// ListenAndRetryRefunds 定期重试退款处理中的售后单，作为后台协程运行
+// 审核请求调用渠道出错、渠道异步退款或进程在调用渠道前后退出时，售后单停留在退款处理中，由该 worker 以相同售后单号重新发起退款直至得到结果
+func (s *RefundService) ListenAndRetryRefunds(ctx context.Context) {
+	ticker := time.NewTicker(consts.RefundRetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Infof("售后退款重试 worker 退出")
+			return
+		case now := <-ticker.C:
+			s.retryProcessingRefunds(ctx, now.Add(-consts.RefundRetryDelay))
+		}
+	}
+}
+
+// retryProcessingRefunds 重试一批最后更新早于 before 的退款处理中售后单
+func (s *RefundService) retryProcessingRefunds(ctx context.Context, before time.Time) {
+	refunds, err := s.refundDao.ListProcessingRefunds(ctx, before, consts.RefundRetryBatchSize)
+	if err != nil {
+		log.Errorf("查询退款处理中的售后单失败: %v", err)
+		return
+	}
+	paymentDao := dao.NewPaymentDao(s.db)
+	for i := range refunds {
+		refund := &refunds[i]
+		payment, err := paymentDao.GetPaidPaymentByOrderID(ctx, refund.OrderID)
+		if err != nil {
+			log.Errorf("重试售后退款失败，查询支付单出错 (refundNo: %s): %v", refund.RefundNo, err)
+			continue
+		}
+		if err := s.processRefund(ctx, refund, payment); err != nil {
+			if errors.Is(err, dao.ErrRefundStatusConflict) {
+				continue
+			}
+			log.Errorf("重试售后退款未完成 (refundNo: %s): %v", refund.RefundNo, err)
+			continue
+		}
+		if refund.Status == consts.RefundStatusRefunded {
+			log.Infof("售后单重试退款成功 (refundNo: %s, refundRef: %s)", refund.RefundNo, refund.RefundRef)
+			s.afterRefunded(ctx, refund)
+		}
+	}
+}
+
+// refundSubOrderIfSettled 子订单已全部退款时流转为已退款，父订单状态随之汇总（全部子订单退完时父订单为已退款），需在退款事务中调用
+func refundSubOrderIfSettled(ctx context.Context, tx *gorm.DB, subOrderID string, reviewerID uint) error {
+	subOrderDao := dao.NewSubOrderDao(tx)
+	subOrder, err := subOrderDao.GetSubOrder(ctx, subOrderID)
+	if err != nil {
+		return err
+	}
+	refunded, err := dao.NewRefundDao(tx).SumRefundedAmount(ctx, subOrderID)
+	if err != nil {
+		return err
+	}
+	if refunded < subOrder.Amount || !subOrder.CanTransitTo(consts.OrderTypeRefunded) {
+		return nil
+	}
+	return subOrderDao.UpdateSubOrderStatus(ctx, subOrder, subOrder.Status, consts.OrderTypeRefunded, consts.OrderActorAdmin, reviewerID, "售后退款完成")
+}
+
+// GetRefund 查询买家的售后单
+func (s *RefundService) GetRefund(ctx context.Context, userID uint, refundNo string) (*types.RefundResp, error) {
+	refund, err := s.refundDao.GetRefundByNo(ctx, refundNo)
+	if err != nil || refund.UserID != userID {
+		if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("售后单不存在")
+		}
+		return nil, err
+	}
+	return buildRefundResp(refund), nil
+}
+
+// ListRefunds 分页查询售后单，userID 为 0 时查询全部（管理端）
+func (s *RefundService) ListRefunds(ctx context.Context, userID uint, req *types.RefundListReq) (*types.DataListResp, error) {
+	refunds, total, err := s.refundDao.ListRefunds(ctx, userID, req)
+	if err != nil {
+		log.Errorf("查询售后单列表失败 (userID: %d): %v", userID, err)
+		return nil, err
+	}
+	items := make([]*types.RefundResp, 0, len(refunds))
+	for i := range refunds {
+		items = append(items, buildRefundResp(&refunds[i]))
+	}
+	return &types.DataListResp{
+		Item:  items,
+		Total: total,
+	}, nil
+}
+
+// buildRefundResp 将售后单模型转换为响应结构
+func buildRefundResp(refund *model.Refund) *types.RefundResp {
+	resp := &types.RefundResp{
+		RefundNo:     refund.RefundNo,
+		OrderID:      refund.OrderID,
+		SubOrderID:   refund.SubOrderID,
+		Type:         refund.Type,
+		Reason:       refund.Reason,
+		Evidence:     refund.Evidence,
+		Amount:       refund.Amount,
+		Status:       refund.Status,
+		ReviewNote:   refund.ReviewNote,
+		RefundMethod: refund.RefundMethod,
+		CreatedAt:    refund.CreatedAt.Unix(),
+		Items:        make([]types.RefundItemResp, 0, len(refund.Items)),
+	}
+	if refund.ReviewedAt != nil {
+		resp.ReviewedAt = refund.ReviewedAt.Unix()
+	}
+	for _, item := range refund.Items {
+		resp.Items = append(resp.Items, types.RefundItemResp{
+			OrderItemID: item.OrderItemID,
+			ProductID:   item.ProductID,
+			SkuID:       item.SkuID,
+			Quantity:    item.Quantity,
+			Amount:      item.Amount,
+		})
+	}
+	return resp
+}
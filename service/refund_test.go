@@ -0,0 +1,95 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"douyin/pkg/utils/money"
+	"douyin/repository/db/model"
+	"douyin/types"
+)
+
+func TestBuildRefundItems(t *testing.T) {
+	orderItems := []model.OrderItem{
+		{ID: 1, ProductID: 10, Quantity: 2, Cost: 999, ProductName: "A"},
+		{ID: 2, ProductID: 20, Quantity: 1, Cost: 500, ProductName: "B"},
+	}
+
+	t.Run("整单退款只包含未申请售后的数量", func(t *testing.T) {
+		items, amount, err := buildRefundItems(orderItems, map[uint]int32{1: 1}, nil)
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.Equal(t, int32(1), items[0].Quantity)
+		assert.Equal(t, money.Amount(1499), amount)
+	})
+
+	t.Run("按订单项部分退款", func(t *testing.T) {
+		items, amount, err := buildRefundItems(orderItems, nil, []types.RefundItemReq{{OrderItemID: 1, Quantity: 2}})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, uint(10), items[0].ProductID)
+		assert.Equal(t, money.Amount(1998), amount)
+	})
+
+	t.Run("超出可退数量", func(t *testing.T) {
+		_, _, err := buildRefundItems(orderItems, map[uint]int32{2: 1}, []types.RefundItemReq{{OrderItemID: 2, Quantity: 1}})
+		assert.Error(t, err)
+	})
+
+	t.Run("订单项不属于订单", func(t *testing.T) {
+		_, _, err := buildRefundItems(orderItems, nil, []types.RefundItemReq{{OrderItemID: 3, Quantity: 1}})
+		assert.Error(t, err)
+	})
+
+	t.Run("全部已申请售后", func(t *testing.T) {
+		_, _, err := buildRefundItems(orderItems, map[uint]int32{1: 2, 2: 1}, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("按数量比例扣除优惠", func(t *testing.T) {
+		discounted := []model.OrderItem{{ID: 1, ProductID: 10, Quantity: 3, Cost: 1000, Discount: 100, ProductName: "A"}}
+		_, first, err := buildRefundItems(discounted, nil, []types.RefundItemReq{{OrderItemID: 1, Quantity: 1}})
+		require.NoError(t, err)
+		assert.Equal(t, money.Amount(967), first)
+		_, second, err := buildRefundItems(discounted, map[uint]int32{1: 1}, []types.RefundItemReq{{OrderItemID: 1, Quantity: 1}})
+		require.NoError(t, err)
+		assert.Equal(t, money.Amount(967), second)
+		_, last, err := buildRefundItems(discounted, map[uint]int32{1: 2}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, money.Amount(966), last)
+		assert.Equal(t, money.Amount(2900), first+second+last)
+	})
+}
+
+func TestResolveRefundSubOrder(t *testing.T) {
+	order := &model.Order{
+		OrderID: "o-1",
+		OrderItems: []model.OrderItem{
+			{ID: 1, SubOrderID: "o-1-1"},
+			{ID: 2, SubOrderID: "o-1-2"},
+		},
+		SubOrders: []model.SubOrder{{SubOrderID: "o-1-1"}, {SubOrderID: "o-1-2"}},
+	}
+
+	sub, err := resolveRefundSubOrder(order, "o-1-2", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "o-1-2", sub.SubOrderID)
+
+	sub, err = resolveRefundSubOrder(order, "", []types.RefundItemReq{{OrderItemID: 2, Quantity: 1}})
+	require.NoError(t, err)
+	assert.Equal(t, "o-1-2", sub.SubOrderID, "未指定子订单时取退款项所属的子订单")
+
+	_, err = resolveRefundSubOrder(order, "", nil)
+	assert.Error(t, err, "多个子订单时必须指定")
+	_, err = resolveRefundSubOrder(order, "", []types.RefundItemReq{{OrderItemID: 9, Quantity: 1}})
+	assert.Error(t, err)
+	_, err = resolveRefundSubOrder(order, "o-2-1", nil)
+	assert.Error(t, err)
+
+	order.SubOrders = order.SubOrders[:1]
+	sub, err = resolveRefundSubOrder(order, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "o-1-1", sub.SubOrderID)
+}
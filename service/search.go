@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"douyin/config"
+	"douyin/consts"
+	"douyin/pkg/utils/log"
+	"douyin/pkg/utils/money"
+	"douyin/repository/db/dao"
+	"douyin/repository/db/model"
+	"douyin/types"
+	"gorm.io/gorm"
+)
+
+// ErrSearchUnavailable 搜索后端未初始化
+var ErrSearchUnavailable = errors.New("商品搜索服务暂不可用")
+
+// SearchService 商品搜索后端接口，新增后端时实现该接口并在 NewSearchService 中按配置选择
+type SearchService interface {
+	// Search 按条件分页搜索商品，返回的商品价格为基础币种
+	Search(ctx context.Context, filter *dao.ProductSearchFilter) ([]model.Product, int64, error)
+	// IndexProducts 将商品的最新信息同步到索引，已删除的商品从索引中移除；直接查询商品表的后端无需同步
+	IndexProducts(ctx context.Context, productIDs []uint) error
+	// DeleteProduct 从索引中删除商品
+	DeleteProduct(ctx context.Context, productID uint) error
+}
+
+// productSearch 全局商品搜索后端，由 InitSearchService 初始化；为 nil 时搜索不可用，商品变更也不会同步索引
+var productSearch SearchService
+
+// NewSearchService 根据配置 search.backend 创建商品搜索后端
+func NewSearchService(db *gorm.DB) (SearchService, error) {
+	switch backend := config.GetSearchBackend(); backend {
+	case consts.SearchBackendMySQL:
+		return NewMySQLSearchService(db), nil
+	case consts.SearchBackendES:
+		username, password := config.GetEsBasicAuth()
+		return NewEsSearchService(db, config.GetEsAddress(), config.GetEsIndex(), username, password), nil
+	default:
+		return nil, fmt.Errorf("不支持的商品搜索后端: %s", backend)
+	}
+}
+
+// InitSearchService 初始化全局商品搜索后端；使用 ElasticSearch 时会在索引不存在时创建索引
+func InitSearchService(ctx context.Context, db *gorm.DB) error {
+	search, err := NewSearchService(db)
+	if err != nil {
+		return err
+	}
+	if es, ok := search.(*EsSearchService); ok {
+		if err := es.EnsureIndex(ctx); err != nil {
+			return err
+		}
+	}
+	productSearch = search
+	log.Infof("商品搜索后端已初始化 (backend: %s)", config.GetSearchBackend())
+	return nil
+}
+
+// SearchProducts 按关键词、价格区间、分类和库存搜索商品，价格按 quote 换算为展示币种；quote 为 nil 时返回基础币种价格
+// categoryIDs 为 req.CategoryID 及其全部子孙分类（见 CategoryService.Descendants）
+func SearchProducts(ctx context.Context, req *types.ProductSearchReq, categoryIDs []uint, quote *ExchangeQuote) ([]types.Product, int64, error) {
+	if productSearch == nil {
+		return nil, 0, ErrSearchUnavailable
+	}
+	filter, err := buildProductSearchFilter(req, categoryIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	products, total, err := productSearch.Search(ctx, filter)
+	if err != nil {
+		log.Errorf("搜索商品失败 (keyword: %s): %v", req.Keyword, err)
+		return nil, 0, err
+	}
+	result := make([]types.Product, 0, len(products))
+	for _, p := range products {
+		result = append(result, types.Product{
+			ID:          uint32(p.ID),
+			Name:        p.Name,
+			Description: p.Description,
+			Picture:     p.Picture,
+			MerchantID:  p.MerchantID,
+			Price:       p.Price,
+			Stock:       p.Stock,
+			Version:     p.Version,
+		})
+		localizeProductPrice(&result[len(result)-1], quote)
+	}
+	return result, total, nil
+}
+
+// buildProductSearchFilter 将搜索请求转换为搜索条件，校验价格区间
+func buildProductSearchFilter(req *types.ProductSearchReq, categoryIDs []uint) (*dao.ProductSearchFilter, error) {
+	filter := &dao.ProductSearchFilter{
+		Keyword:  strings.TrimSpace(req.Keyword),
+		InStock:  req.InStock,
+		Sort:     req.Sort,
+		PageNum:  req.PageNum,
+		PageSize: req.PageSize,
+	}
+	if req.CategoryID != 0 {
+		filter.CategoryIDs = categoryIDs
+	}
+	var err error
+	if req.MinPrice != "" {
+		if filter.MinPrice, err = money.Parse(req.MinPrice); err != nil || filter.MinPrice < 0 {
+			return nil, fmt.Errorf("最低价格格式错误: %s", req.MinPrice)
+		}
+	}
+	if req.MaxPrice != "" {
+		if filter.MaxPrice, err = money.Parse(req.MaxPrice); err != nil || filter.MaxPrice < 0 {
+			return nil, fmt.Errorf("最高价格格式错误: %s", req.MaxPrice)
+		}
+	}
+	if filter.MinPrice > 0 && filter.MaxPrice > 0 && filter.MinPrice > filter.MaxPrice {
+		return nil, errors.New("最低价格不能高于最高价格")
+	}
+	return filter, nil
+}
+
+// syncProductIndex 商品变更后增量同步搜索索引，失败只记录日志，不影响商品本身的写入
+func syncProductIndex(ctx context.Context, productIDs ...uint) {
+	if productSearch == nil || len(productIDs) == 0 {
+		return
+	}
+	if err := productSearch.IndexProducts(ctx, productIDs); err != nil {
+		log.Warnf("同步商品搜索索引失败 (productIDs: %v): %v", productIDs, err)
+	}
+}
+
+// removeProductIndex 商品删除后从搜索索引中移除，失败只记录日志
+func removeProductIndex(ctx context.Context, productID uint) {
+	if productSearch == nil {
+		return
+	}
+	if err := productSearch.DeleteProduct(ctx, productID); err != nil {
+		log.Warnf("删除商品搜索索引失败 (productID: %d): %v", productID, err)
+	}
+}
+
+// syncOrderProductIndex 同步订单商品的库存与销量到搜索索引
+// 下单占用库存、取消或关闭释放库存、支付成功、退货入库后调用
+func syncOrderProductIndex(ctx context.Context, orderDao *dao.OrderDao, orderID string) {
+	if productSearch == nil {
+		return
+	}
+	productIDs, err := orderDao.ListOrderProductIDs(ctx, orderID)
+	if err != nil {
+		log.Warnf("查询订单 %s 的商品失败，未同步搜索索引: %v", orderID, err)
+		return
+	}
+	syncProductIndex(ctx, productIDs...)
+}
+
+// MySQLSearchService 基于商品表 FULLTEXT 索引（ngram 分词）的商品搜索，直接查询商品表，无需同步索引
+type MySQLSearchService struct {
+	searchDao *dao.ProductSearchDao
+}
+
+// NewMySQLSearchService 创建新的 MySQLSearchService 实例
+func NewMySQLSearchService(db *gorm.DB) *MySQLSearchService {
+	return &MySQLSearchService{
+		searchDao: dao.NewProductSearchDao(db),
+	}
+}
+
+// Search 按条件分页搜索商品
+func (s *MySQLSearchService) Search(ctx context.Context, filter *dao.ProductSearchFilter) ([]model.Product, int64, error) {
+	return s.searchDao.Search(ctx, filter)
+}
+
+// IndexProducts 商品表即索引，无需同步
+func (s *MySQLSearchService) IndexProducts(ctx context.Context, productIDs []uint) error {
+	return nil
+}
+
+// DeleteProduct 商品表即索引，无需同步
+func (s *MySQLSearchService) DeleteProduct(ctx context.Context, productID uint) error {
+	return nil
+}
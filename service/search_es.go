@@ -0,0 +1,340 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"douyin/consts"
+	"douyin/pkg/utils/log"
+	"douyin/pkg/utils/money"
+	"douyin/repository/db/dao"
+	"douyin/repository/db/model"
+	"gorm.io/gorm"
+)
+
+// esMaxResultWindow ElasticSearch 默认的 index.max_result_window，from + size 超过该值的分页会被拒绝
+const esMaxResultWindow = 10000
+
+// esProductMapping 商品索引的字段映射；名称与描述使用默认分词器（中文按单字切分），安装 IK 插件后可改为 ik_max_word
+const esProductMapping = `{
+	"mappings": {
+		"properties": {
+			"id":           {"type": "long"},
+			"name":         {"type": "text"},
+			"description":  {"type": "text"},
+			"picture":      {"type": "keyword", "index": false},
+			"price":        {"type": "scaled_float", "scaling_factor": 100},
+			"stock":        {"type": "integer"},
+			"sales":        {"type": "integer"},
+			"version":      {"type": "integer", "index": false},
+			"category_ids": {"type": "long"},
+			"created_at":   {"type": "date"}
+		}
+	}
+}`
+
+// esProductDocument 商品在索引中的文档
+type esProductDocument struct {
+	ID          uint         `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Picture     string       `json:"picture"`
+	Price       money.Amount `json:"price"` // 基础币种，序列化为两位小数的数字
+	Stock       int          `json:"stock"`
+	Sales       int          `json:"sales"`
+	Version     int          `json:"version"`
+	CategoryIDs []uint       `json:"category_ids"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// EsSearchService 基于 ElasticSearch 的商品搜索
+// 只用到索引、批量写入和查询几个 REST 接口，因此直接通过 net/http 调用；商品变更时由 IndexProducts / DeleteProduct 增量同步
+type EsSearchService struct {
+	client             *http.Client
+	address            string
+	index              string
+	username           string
+	password           string
+	searchDao          *dao.ProductSearchDao
+	productCategoryDao *dao.ProductCategoryDao
+}
+
+// NewEsSearchService 创建新的 EsSearchService 实例，address 形如 http://127.0.0.1:9200，username 为空时不使用认证
+func NewEsSearchService(db *gorm.DB, address, index, username, password string) *EsSearchService {
+	return &EsSearchService{
+		client:             &http.Client{Timeout: 5 * time.Second},
+		address:            strings.TrimRight(address, "/"),
+		index:              index,
+		username:           username,
+		password:           password,
+		searchDao:          dao.NewProductSearchDao(db),
+		productCategoryDao: dao.NewProductCategoryDao(db),
+	}
+}
+
+// EnsureIndex 索引不存在时按 esProductMapping 创建索引
+func (s *EsSearchService) EnsureIndex(ctx context.Context) error {
+	status, _, err := s.do(ctx, http.MethodHead, "/"+s.index, "", nil)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusOK {
+		return nil
+	}
+	status, body, err := s.do(ctx, http.MethodPut, "/"+s.index, "application/json", strings.NewReader(esProductMapping))
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("创建商品索引失败 (status: %d): %s", status, body)
+	}
+	log.Infof("已创建商品搜索索引 %s", s.index)
+	return nil
+}
+
+// Search 按条件分页搜索商品
+func (s *EsSearchService) Search(ctx context.Context, filter *dao.ProductSearchFilter) ([]model.Product, int64, error) {
+	if filter.PageNum*filter.PageSize > esMaxResultWindow {
+		return nil, 0, fmt.Errorf("搜索结果最多查看前 %d 条，请缩小搜索范围", esMaxResultWindow)
+	}
+	query, err := json.Marshal(buildEsSearchQuery(filter))
+	if err != nil {
+		return nil, 0, err
+	}
+	status, body, err := s.do(ctx, http.MethodPost, "/"+s.index+"/_search", "application/json", bytes.NewReader(query))
+	if err != nil {
+		return nil, 0, err
+	}
+	if status != http.StatusOK {
+		return nil, 0, fmt.Errorf("搜索商品失败 (status: %d): %s", status, body)
+	}
+
+	var result struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				Source esProductDocument `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, 0, fmt.Errorf("解析搜索结果失败: %w", err)
+	}
+	products := make([]model.Product, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		doc := hit.Source
+		products = append(products, model.Product{
+			ID:          doc.ID,
+			CreatedAt:   doc.CreatedAt,
+			Name:        doc.Name,
+			Description: doc.Description,
+			Picture:     doc.Picture,
+			Price:       doc.Price,
+			Stock:       doc.Stock,
+			Sales:       doc.Sales,
+			Version:     doc.Version,
+		})
+	}
+	return products, result.Hits.Total.Value, nil
+}
+
+// IndexProducts 从数据库读取商品及其分类，通过 _bulk 接口写入索引；数据库中已不存在的商品从索引中删除
+func (s *EsSearchService) IndexProducts(ctx context.Context, productIDs []uint) error {
+	products, err := s.searchDao.GetProducts(ctx, productIDs)
+	if err != nil {
+		return err
+	}
+	relations, err := s.productCategoryDao.ListByProducts(ctx, productIDs)
+	if err != nil {
+		return err
+	}
+	categories := make(map[uint][]uint, len(products))
+	for _, r := range relations {
+		categories[r.ProductID] = append(categories[r.ProductID], r.CategoryID)
+	}
+
+	var bulk bytes.Buffer
+	found := make(map[uint]bool, len(products))
+	for _, p := range products {
+		found[p.ID] = true
+		doc, err := json.Marshal(buildEsProductDocument(&p, categories[p.ID]))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&bulk, `{"index":{"_id":"%d"}}`+"\n%s\n", p.ID, doc)
+	}
+	for _, id := range productIDs {
+		if !found[id] {
+			fmt.Fprintf(&bulk, `{"delete":{"_id":"%d"}}`+"\n", id)
+		}
+	}
+
+	status, body, err := s.do(ctx, http.MethodPost, "/"+s.index+"/_bulk", "application/x-ndjson", &bulk)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("同步商品索引失败 (status: %d): %s", status, body)
+	}
+	return checkEsBulkResponse(body)
+}
+
+// DeleteProduct 从索引中删除商品，文档不存在时视为成功
+func (s *EsSearchService) DeleteProduct(ctx context.Context, productID uint) error {
+	path := "/" + s.index + "/_doc/" + strconv.FormatUint(uint64(productID), 10)
+	status, body, err := s.do(ctx, http.MethodDelete, path, "", nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK && status != http.StatusNotFound {
+		return fmt.Errorf("删除商品索引失败 (status: %d): %s", status, body)
+	}
+	return nil
+}
+
+// do 发送请求并返回状态码与响应体
+func (s *EsSearchService) do(ctx context.Context, method, path, contentType string, body io.Reader) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.address+path, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("请求 ElasticSearch 失败: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, data, nil
+}
+
+// buildEsProductDocument 将商品模型转换为索引文档
+func buildEsProductDocument(p *model.Product, categoryIDs []uint) *esProductDocument {
+	if categoryIDs == nil {
+		categoryIDs = []uint{}
+	}
+	return &esProductDocument{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Picture:     p.Picture,
+		Price:       p.Price,
+		Stock:       p.Stock,
+		Sales:       p.Sales,
+		Version:     p.Version,
+		CategoryIDs: categoryIDs,
+		CreatedAt:   p.CreatedAt,
+	}
+}
+
+// buildEsSearchQuery 将搜索条件转换为 _search 请求体：关键词参与相关度打分，其余条件放在 filter 中不影响打分
+func buildEsSearchQuery(filter *dao.ProductSearchFilter) map[string]interface{} {
+	var must []interface{}
+	if filter.Keyword != "" {
+		must = append(must, map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":  filter.Keyword,
+				"fields": []string{"name^3", "description"},
+			},
+		})
+	} else {
+		must = append(must, map[string]interface{}{"match_all": map[string]interface{}{}})
+	}
+
+	filters := make([]interface{}, 0)
+	if filter.MinPrice > 0 || filter.MaxPrice > 0 {
+		price := make(map[string]interface{})
+		if filter.MinPrice > 0 {
+			price["gte"] = filter.MinPrice
+		}
+		if filter.MaxPrice > 0 {
+			price["lte"] = filter.MaxPrice
+		}
+		filters = append(filters, map[string]interface{}{"range": map[string]interface{}{"price": price}})
+	}
+	if len(filter.CategoryIDs) > 0 {
+		filters = append(filters, map[string]interface{}{"terms": map[string]interface{}{"category_ids": filter.CategoryIDs}})
+	}
+	if filter.InStock {
+		filters = append(filters, map[string]interface{}{"range": map[string]interface{}{"stock": map[string]interface{}{"gt": 0}}})
+	}
+
+	return map[string]interface{}{
+		"from":             (filter.PageNum - 1) * filter.PageSize,
+		"size":             filter.PageSize,
+		"track_total_hits": true,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must":   must,
+				"filter": filters,
+			},
+		},
+		"sort": esSearchSort(filter),
+	}
+}
+
+// esSearchSort 返回排序规则，与 MySQL 后端一致：相同时按商品ID倒序，未指定排序时有关键词按相关度，否则按上架时间倒序
+func esSearchSort(filter *dao.ProductSearchFilter) []interface{} {
+	var primary interface{}
+	switch filter.Sort {
+	case consts.ProductSortPriceAsc:
+		primary = map[string]string{"price": "asc"}
+	case consts.ProductSortPriceDesc:
+		primary = map[string]string{"price": "desc"}
+	case consts.ProductSortSales:
+		primary = map[string]string{"sales": "desc"}
+	case consts.ProductSortNewest:
+		primary = map[string]string{"created_at": "desc"}
+	default:
+		if filter.Keyword != "" {
+			primary = map[string]string{"_score": "desc"}
+		} else {
+			primary = map[string]string{"created_at": "desc"}
+		}
+	}
+	return []interface{}{primary, map[string]string{"id": "desc"}}
+}
+
+// checkEsBulkResponse 检查 _bulk 响应中是否有失败的条目，删除不存在的文档不算失败
+func checkEsBulkResponse(body []byte) error {
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID     string          `json:"_id"`
+			Status int             `json:"status"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("解析批量写入结果失败: %w", err)
+	}
+	if !result.Errors {
+		return nil
+	}
+	for _, item := range result.Items {
+		for action, r := range item {
+			if r.Status < 300 || (action == "delete" && r.Status == http.StatusNotFound) {
+				continue
+			}
+			return fmt.Errorf("同步商品 %s 到索引失败 (%s, status: %d): %s", r.ID, action, r.Status, r.Error)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"douyin/consts"
+	"douyin/pkg/utils/money"
+	"douyin/repository/db/dao"
+	"douyin/types"
+)
+
+func TestBuildProductSearchFilter(t *testing.T) {
+	req := &types.ProductSearchReq{
+		BasePage:   types.BasePage{PageNum: 2, PageSize: 20},
+		Keyword:    "  手机 ",
+		MinPrice:   "9.90",
+		MaxPrice:   "100",
+		CategoryID: 1,
+		InStock:    true,
+		Sort:       consts.ProductSortSales,
+	}
+	filter, err := buildProductSearchFilter(req, []uint{1, 2, 4})
+	require.NoError(t, err)
+	assert.Equal(t, "手机", filter.Keyword)
+	assert.Equal(t, money.Amount(990), filter.MinPrice)
+	assert.Equal(t, money.Amount(10000), filter.MaxPrice)
+	assert.Equal(t, []uint{1, 2, 4}, filter.CategoryIDs)
+	assert.True(t, filter.InStock)
+	assert.Equal(t, 2, filter.PageNum)
+	assert.Equal(t, 20, filter.PageSize)
+
+	// 未指定分类时忽略 categoryIDs
+	filter, err = buildProductSearchFilter(&types.ProductSearchReq{}, []uint{1})
+	require.NoError(t, err)
+	assert.Empty(t, filter.CategoryIDs)
+	assert.Zero(t, filter.MinPrice)
+
+	_, err = buildProductSearchFilter(&types.ProductSearchReq{MinPrice: "abc"}, nil)
+	assert.Error(t, err, "价格格式错误")
+	_, err = buildProductSearchFilter(&types.ProductSearchReq{MinPrice: "-1"}, nil)
+	assert.Error(t, err, "价格不能为负")
+	_, err = buildProductSearchFilter(&types.ProductSearchReq{MinPrice: "20", MaxPrice: "10"}, nil)
+	assert.Error(t, err, "最低价格高于最高价格")
+}
+
+func TestBuildEsSearchQuery(t *testing.T) {
+	filter := &dao.ProductSearchFilter{
+		Keyword:     "耳机",
+		MinPrice:    990,
+		CategoryIDs: []uint{3},
+		InStock:     true,
+		PageNum:     3,
+		PageSize:    10,
+	}
+	data, err := json.Marshal(buildEsSearchQuery(filter))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"from": 20,
+		"size": 10,
+		"track_total_hits": true,
+		"query": {"bool": {
+			"must": [{"multi_match": {"query": "耳机", "fields": ["name^3", "description"]}}],
+			"filter": [
+				{"range": {"price": {"gte": 9.90}}},
+				{"terms": {"category_ids": [3]}},
+				{"range": {"stock": {"gt": 0}}}
+			]
+		}},
+		"sort": [{"_score": "desc"}, {"id": "desc"}]
+	}`, string(data))
+
+	data, err = json.Marshal(buildEsSearchQuery(&dao.ProductSearchFilter{PageNum: 1, PageSize: 10}))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"from": 0,
+		"size": 10,
+		"track_total_hits": true,
+		"query": {"bool": {"must": [{"match_all": {}}], "filter": []}},
+		"sort": [{"created_at": "desc"}, {"id": "desc"}]
+	}`, string(data))
+}
+
+func TestEsSearchSort(t *testing.T) {
+	cases := map[string]string{
+		consts.ProductSortPriceAsc:  `[{"price":"asc"},{"id":"desc"}]`,
+		consts.ProductSortPriceDesc: `[{"price":"desc"},{"id":"desc"}]`,
+		consts.ProductSortSales:     `[{"sales":"desc"},{"id":"desc"}]`,
+		consts.ProductSortNewest:    `[{"created_at":"desc"},{"id":"desc"}]`,
+	}
+	for sort, want := range cases {
+		// 指定排序时关键词不影响排序
+		data, err := json.Marshal(esSearchSort(&dao.ProductSearchFilter{Keyword: "手机", Sort: sort}))
+		require.NoError(t, err)
+		assert.JSONEq(t, want, string(data), sort)
+	}
+}
+
+func TestCheckEsBulkResponse(t *testing.T) {
+	assert.NoError(t, checkEsBulkResponse([]byte(`{"errors":false,"items":[{"index":{"_id":"1","status":201}}]}`)))
+	assert.NoError(t, checkEsBulkResponse([]byte(`{"errors":true,"items":[
+		{"index":{"_id":"1","status":200}},
+		{"delete":{"_id":"2","status":404}}
+	]}`)), "删除不存在的文档不算失败")
+	assert.Error(t, checkEsBulkResponse([]byte(`{"errors":true,"items":[
+		{"index":{"_id":"1","status":400,"error":{"type":"mapper_parsing_exception"}}}
+	]}`)))
+	assert.Error(t, checkEsBulkResponse([]byte(`not json`)))
+}
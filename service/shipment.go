@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"douyin/config"
+	"douyin/consts"
+	"douyin/pkg/utils/log"
+	"douyin/repository/db/dao"
+	"douyin/repository/db/model"
+	"douyin/types"
+	"gorm.io/gorm"
+)
+
+// ShipmentService 发货与物流服务：商家（或管理员）为子订单发货、买家查看物流、定期刷新物流轨迹并超时自动确认收货
+// 子订单发货时创建发货单并流转为已发货；买家确认收货或发货超过 shipping.autoConfirmDays 天后流转为已收货
+type ShipmentService struct {
+	shipmentDao *dao.ShipmentDao
+	subOrderDao *dao.SubOrderDao
+	orderDao    *dao.OrderDao
+	merchantDao *dao.MerchantDao
+	tracker     CarrierTracker // 物流轨迹查询，未配置时为 nil
+}
+
+// NewShipmentService 创建新的 ShipmentService 实例，物流轨迹查询实现按配置选择
+func NewShipmentService(db *gorm.DB) *ShipmentService {
+	tracker, err := NewCarrierTracker()
+	if err != nil {
+		log.Errorf("初始化物流轨迹查询失败，将不刷新物流轨迹: %v", err)
+	}
+	return &ShipmentService{
+		shipmentDao: dao.NewShipmentDao(db),
+		subOrderDao: dao.NewSubOrderDao(db),
+		orderDao:    dao.NewOrderDao(db),
+		merchantDao: dao.NewMerchantDao(db),
+		tracker:     tracker,
+	}
+}
+
+// ShipMerchantOrder 商家为订单中属于自己店铺的子订单发货
+func (s *ShipmentService) ShipMerchantOrder(ctx context.Context, userID uint, req *types.ShipOrderReq) (*types.ShipmentResp, error) {
+	merchant, err := s.merchantDao.GetMerchantByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, dao.ErrMerchantNotFound) {
+			return nil, ErrMerchantRequired
+		}
+		return nil, err
+	}
+	subOrder, err := s.merchantDao.GetMerchantOrder(ctx, merchant.ID, req.OrderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("订单不存在")
+		}
+		return nil, err
+	}
+	return s.ship(ctx, subOrder, req.Carrier, req.TrackingNo, consts.OrderActorMerchant, userID)
+}
+
+// AdminShipOrder 管理员为子订单发货，用于平台自营商品或代商家发货
+func (s *ShipmentService) AdminShipOrder(ctx context.Context, adminID uint, req *types.AdminShipOrderReq) (*types.ShipmentResp, error) {
+	subOrder, err := s.subOrderDao.GetSubOrder(ctx, req.SubOrderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("子订单不存在")
+		}
+		return nil, err
+	}
+	return s.ship(ctx, subOrder, req.Carrier, req.TrackingNo, consts.OrderActorAdmin, adminID)
+}
+
+// ship 校验子订单待发货后创建发货单并将子订单流转为已发货
+func (s *ShipmentService) ship(ctx context.Context, subOrder *model.SubOrder, carrier, trackingNo, actor string, actorID uint) (*types.ShipmentResp, error) {
+	carrier = strings.ToUpper(strings.TrimSpace(carrier))
+	trackingNo = strings.TrimSpace(trackingNo)
+	if carrier == "" || trackingNo == "" {
+		return nil, errors.New("请填写快递公司与快递单号")
+	}
+	if !subOrder.CanTransitTo(consts.OrderTypeShipping) {
+		return nil, fmt.Errorf("%w: 子订单当前状态为「%s」，无法发货", ErrIllegalOrderTransition, subOrder.StatusText())
+	}
+
+	shipment := model.NewShipment(subOrder, carrier, trackingNo, time.Now(), config.GetShippingAutoConfirmDays())
+	reason := fmt.Sprintf("发货：%s %s", carrier, trackingNo)
+	if err := s.shipmentDao.CreateShipment(ctx, shipment, subOrder, actor, actorID, reason); err != nil {
+		log.Errorf("子订单发货失败 (subOrderID: %s): %v", subOrder.SubOrderID, err)
+		return nil, err
+	}
+	subOrder.Status = consts.OrderTypeShipping
+	log.Infof("子订单已发货 (subOrderID: %s, carrier: %s, trackingNo: %s, actor: %s:%d)",
+		subOrder.SubOrderID, carrier, trackingNo, actor, actorID)
+	return buildShipmentResp(shipment), nil
+}
+
+// ListOrderShipments 查询买家订单的发货单；配置了物流轨迹查询时，先刷新尚未签收的发货单，刷新失败时返回已保存的轨迹
+func (s *ShipmentService) ListOrderShipments(ctx context.Context, userID uint, orderID string) ([]*types.ShipmentResp, error) {
+	if _, err := s.orderDao.GetOrderByID(ctx, userID, orderID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("订单不存在")
+		}
+		return nil, err
+	}
+	shipments, err := s.shipmentDao.ListOrderShipments(ctx, orderID)
+	if err != nil {
+		log.Errorf("查询订单发货单失败 (orderID: %s): %v", orderID, err)
+		return nil, err
+	}
+	resp := make([]*types.ShipmentResp, 0, len(shipments))
+	for i := range shipments {
+		shipment := &shipments[i]
+		if shipment.ReceivedAt == nil && shipment.Status != consts.ShipmentStatusDelivered {
+			if err := s.refreshTracking(ctx, shipment); err != nil {
+				log.Warnf("刷新物流轨迹失败 (shipmentID: %d): %v", shipment.ID, err)
+			}
+		}
+		resp = append(resp, buildShipmentResp(shipment))
+	}
+	return resp, nil
+}
+
+// ListenAndTrack 定期刷新未签收发货单的物流轨迹，并为到期未确认收货的子订单自动确认收货，作为后台协程运行
+// 自动确认以子订单状态为条件更新，多实例部署时每个子订单只会被确认一次
+func (s *ShipmentService) ListenAndTrack(ctx context.Context) {
+	ticker := time.NewTicker(config.GetShippingTrackInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Infof("物流跟踪 worker 退出")
+			return
+		case <-ticker.C:
+			s.refreshPendingTracking(ctx)
+			s.autoConfirmDue(ctx)
+		}
+	}
+}
+
+// refreshPendingTracking 刷新一批待收货且尚未签收的发货单的物流轨迹
+func (s *ShipmentService) refreshPendingTracking(ctx context.Context) {
+	if s.tracker == nil {
+		return
+	}
+	shipments, err := s.shipmentDao.ListTrackingShipments(ctx, consts.ShipmentTrackBatchSize)
+	if err != nil {
+		log.Errorf("查询待刷新物流轨迹的发货单失败: %v", err)
+		return
+	}
+	for i := range shipments {
+		if err := s.refreshTracking(ctx, &shipments[i]); err != nil {
+			log.Warnf("刷新物流轨迹失败 (shipmentID: %d): %v", shipments[i].ID, err)
+		}
+	}
+}
+
+// refreshTracking 查询发货单的物流轨迹并保存，未配置物流轨迹查询时不做任何处理
+func (s *ShipmentService) refreshTracking(ctx context.Context, shipment *model.Shipment) error {
+	if s.tracker == nil {
+		return nil
+	}
+	info, err := s.tracker.Track(ctx, shipment.Carrier, shipment.TrackingNo)
+	if err != nil {
+		return err
+	}
+	if shipment.ApplyTracking(info.Status, info.Events, time.Now()) {
+		log.Infof("物流状态已更新 (shipmentID: %d, subOrderID: %s, status: %s)", shipment.ID, shipment.SubOrderID, shipment.Status)
+	}
+	return s.shipmentDao.UpdateTracking(ctx, shipment)
+}
+
+// autoConfirmDue 为一批发货超过自动确认期限仍未确认收货的子订单确认收货
+func (s *ShipmentService) autoConfirmDue(ctx context.Context) {
+	shipments, err := s.shipmentDao.ListAutoConfirmDue(ctx, time.Now(), consts.ShipmentTrackBatchSize)
+	if err != nil {
+		log.Errorf("查询待自动确认收货的发货单失败: %v", err)
+		return
+	}
+	reason := fmt.Sprintf("发货超过 %d 天未确认收货，系统自动确认", config.GetShippingAutoConfirmDays())
+	for _, shipment := range shipments {
+		subOrder, err := s.subOrderDao.GetSubOrder(ctx, shipment.SubOrderID)
+		if err != nil {
+			log.Errorf("查询子订单失败 (subOrderID: %s): %v", shipment.SubOrderID, err)
+			continue
+		}
+		if err := confirmSubOrderReceipt(ctx, s.shipmentDao, subOrder, consts.OrderActorSystem, 0, reason); err != nil {
+			// 买家同时确认收货或子订单已退款时状态冲突，无需处理
+			log.Warnf("自动确认收货失败 (subOrderID: %s): %v", shipment.SubOrderID, err)
+		}
+	}
+}
+
+// confirmSubOrderReceipt 按状态机校验后将子订单流转为已收货，并记录发货单的确认收货时间
+// 买家确认收货与超时自动确认共用
+func confirmSubOrderReceipt(ctx context.Context, shipmentDao *dao.ShipmentDao, subOrder *model.SubOrder, actor string, actorID uint, reason string) error {
+	if !subOrder.CanTransitTo(consts.OrderTypeReceipt) {
+		return fmt.Errorf("%w: 子订单「%s」->「%s」", ErrIllegalOrderTransition, subOrder.StatusText(), consts.OrderTypeMap[consts.OrderTypeReceipt])
+	}
+	if err := shipmentDao.ConfirmReceipt(ctx, subOrder, actor, actorID, reason, time.Now()); err != nil {
+		log.Errorf("子订单确认收货失败 (subOrderID: %s): %v", subOrder.SubOrderID, err)
+		return err
+	}
+	log.Infof("子订单已确认收货 (subOrderID: %s, actor: %s:%d)", subOrder.SubOrderID, actor, actorID)
+	subOrder.Status = consts.OrderTypeReceipt
+	return nil
+}
+
+// buildShipmentResp 将发货单模型转换为响应结构
+func buildShipmentResp(shipment *model.Shipment) *types.ShipmentResp {
+	resp := &types.ShipmentResp{
+		ID:            shipment.ID,
+		OrderID:       shipment.OrderID,
+		SubOrderID:    shipment.SubOrderID,
+		MerchantID:    shipment.MerchantID,
+		Carrier:       shipment.Carrier,
+		TrackingNo:    shipment.TrackingNo,
+		Status:        shipment.Status,
+		ShippedAt:     shipment.ShippedAt.Unix(),
+		AutoConfirmAt: shipment.AutoConfirmAt.Unix(),
+		Events:        make([]types.TrackingEventResp, 0, len(shipment.Events)),
+		Items:         make([]types.ShipmentItemResp, 0, len(shipment.Items)),
+	}
+	if shipment.DeliveredAt != nil {
+		resp.DeliveredAt = shipment.DeliveredAt.Unix()
+	}
+	if shipment.ReceivedAt != nil {
+		resp.ReceivedAt = shipment.ReceivedAt.Unix()
+	}
+	for _, event := range shipment.Events {
+		resp.Events = append(resp.Events, types.TrackingEventResp{
+			Time:        event.Time.Unix(),
+			Location:    event.Location,
+			Description: event.Description,
+		})
+	}
+	for _, item := range shipment.Items {
+		resp.Items = append(resp.Items, types.ShipmentItemResp{
+			OrderItemID: item.OrderItemID,
+			ProductID:   item.ProductID,
+			SkuID:       item.SkuID,
+			Quantity:    item.Quantity,
+		})
+	}
+	return resp
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"douyin/consts"
+	"douyin/repository/db/model"
+)
+
+func TestFileCarrierTracker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tracking.json")
+	writeTracking := func(content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	tracker := NewFileCarrierTracker(path)
+	ctx := context.Background()
+
+	writeTracking(`{"SF":{"SF100":{"status":"IN_TRANSIT","events":[{"time":"2024-05-01T10:00:00+08:00","location":"深圳","description":"快件已揽收"}]}}}`)
+	info, err := tracker.Track(ctx, "SF", "SF100")
+	require.NoError(t, err)
+	assert.Equal(t, consts.ShipmentStatusInTransit, info.Status)
+	require.Len(t, info.Events, 1)
+	assert.Equal(t, "深圳", info.Events[0].Location)
+
+	_, err = tracker.Track(ctx, "SF", "SF404")
+	assert.ErrorIs(t, err, ErrTrackingNotFound)
+	_, err = tracker.Track(ctx, "YTO", "SF100")
+	assert.ErrorIs(t, err, ErrTrackingNotFound)
+
+	// 每次查询重新读取文件，修改文件即可模拟物流进展
+	writeTracking(`{"SF":{"SF100":{"status":"DELIVERED","events":[
+		{"time":"2024-05-01T10:00:00+08:00","location":"深圳","description":"快件已揽收"},
+		{"time":"2024-05-02T14:40:00+08:00","location":"杭州","description":"快件已签收"}]}}}`)
+	info, err = tracker.Track(ctx, "SF", "SF100")
+	require.NoError(t, err)
+	shipment := &model.Shipment{Status: consts.ShipmentStatusInTransit}
+	assert.True(t, shipment.ApplyTracking(info.Status, info.Events, time.Now()))
+	require.NotNil(t, shipment.DeliveredAt)
+	assert.True(t, shipment.DeliveredAt.Equal(time.Date(2024, 5, 2, 6, 40, 0, 0, time.UTC)))
+
+	writeTracking(`not json`)
+	_, err = tracker.Track(ctx, "SF", "SF100")
+	assert.Error(t, err)
+
+	_, err = NewFileCarrierTracker(filepath.Join(t.TempDir(), "missing.json")).Track(ctx, "SF", "SF100")
+	assert.Error(t, err)
+}
+
+func TestBuildShipmentResp(t *testing.T) {
+	shippedAt := time.Unix(1714528800, 0)
+	subOrder := &model.SubOrder{
+		SubOrderID: "o-1-1",
+		OrderID:    "o-1",
+		OrderItems: []model.OrderItem{{ID: 3, ProductID: 8, Quantity: 2}},
+	}
+	shipment := model.NewShipment(subOrder, "SF", "SF100", shippedAt, 7)
+	received := shippedAt.Add(48 * time.Hour)
+	shipment.ReceivedAt = &received
+
+	resp := buildShipmentResp(shipment)
+	assert.Equal(t, "o-1-1", resp.SubOrderID)
+	assert.Equal(t, consts.ShipmentStatusShipped, resp.Status)
+	assert.Equal(t, shippedAt.Unix(), resp.ShippedAt)
+	assert.Equal(t, shippedAt.AddDate(0, 0, 7).Unix(), resp.AutoConfirmAt)
+	assert.Equal(t, received.Unix(), resp.ReceivedAt)
+	assert.Zero(t, resp.DeliveredAt)
+	assert.Empty(t, resp.Events)
+	require.Len(t, resp.Items, 1)
+	assert.Equal(t, int32(2), resp.Items[0].Quantity)
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"douyin/config"
+	"douyin/consts"
+	"douyin/repository/db/model"
+)
+
+// ErrTrackingNotFound 物流轨迹查询不到该快递单号
+var ErrTrackingNotFound = errors.New("查询不到该快递单号的物流信息")
+
+// TrackingInfo 物流轨迹查询结果
+type TrackingInfo struct {
+	Status string                `json:"status"` // 物流状态，取值见 consts.ShipmentStatus*
+	Events []model.TrackingEvent `json:"events"` // 物流轨迹，按时间先后排列
+}
+
+// CarrierTracker 物流轨迹查询接口，接入快递公司或快递聚合平台时实现该接口并在 NewCarrierTracker 中按配置选择
+type CarrierTracker interface {
+	// Track 查询快递单的物流状态与轨迹；单号不存在时返回 ErrTrackingNotFound
+	Track(ctx context.Context, carrier, trackingNo string) (*TrackingInfo, error)
+}
+
+// NewCarrierTracker 根据配置 shipping.tracker 创建物流轨迹查询实现，未配置时返回 nil（不查询物流轨迹，只按期限自动确认收货）
+func NewCarrierTracker() (CarrierTracker, error) {
+	switch tracker := config.GetShippingTracker(); tracker {
+	case "":
+		return nil, nil
+	case consts.CarrierTrackerFile:
+		return NewFileCarrierTracker(config.GetShippingTrackingFile()), nil
+	default:
+		return nil, fmt.Errorf("不支持的物流轨迹查询实现: %s", tracker)
+	}
+}
+
+// FileCarrierTracker 从本地 JSON 文件读取物流轨迹的模拟实现，用于开发与测试
+// 文件按「快递公司编码 -> 快递单号 -> 轨迹」组织，每次查询都重新读取文件，修改文件即可模拟物流进展
+type FileCarrierTracker struct {
+	path string
+}
+
+// NewFileCarrierTracker 创建读取 path 文件的 FileCarrierTracker
+func NewFileCarrierTracker(path string) *FileCarrierTracker {
+	return &FileCarrierTracker{path: path}
+}
+
+// Track 从文件中查询快递单的物流轨迹
+func (t *FileCarrierTracker) Track(ctx context.Context, carrier, trackingNo string) (*TrackingInfo, error) {
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		return nil, fmt.Errorf("读取物流轨迹文件失败: %w", err)
+	}
+	var carriers map[string]map[string]*TrackingInfo
+	if err := json.Unmarshal(data, &carriers); err != nil {
+		return nil, fmt.Errorf("解析物流轨迹文件失败: %w", err)
+	}
+	info, ok := carriers[carrier][trackingNo]
+	if !ok || info == nil {
+		return nil, ErrTrackingNotFound
+	}
+	return info, nil
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"douyin/config"
+	"douyin/consts"
+	"douyin/pkg/utils/log"
+	"douyin/repository/cache"
+	"douyin/repository/db/dao"
+	"douyin/repository/db/model"
+	"douyin/types"
+	"gorm.io/gorm"
+)
+
+// ErrRestockInStock 订阅到货提醒时 SKU 仍有库存
+var ErrRestockInStock = errors.New("商品有货，可直接购买")
+
+// StockNotificationService 库存提醒服务：处理低库存预警与到货提醒订阅
+// 库存变动时 dao 在同一事务中写入库存事件，ListenAndNotify 轮询事件并通过 NotificationService 将邮件入队
+type StockNotificationService struct {
+	notificationDao *dao.StockNotificationDao
+	skuDao          *dao.ProductSkuDao
+	merchantDao     *dao.MerchantDao
+	notifier        *NotificationService // 邮件入队，Redis 未初始化时为 nil
+}
+
+// NewStockNotificationService 创建新的 StockNotificationService 实例
+func NewStockNotificationService(db *gorm.DB) *StockNotificationService {
+	s := &StockNotificationService{
+		notificationDao: dao.NewStockNotificationDao(db),
+		skuDao:          dao.NewProductSkuDao(db),
+		merchantDao:     dao.NewMerchantDao(db),
+	}
+	if cache.RedisClient != nil {
+		// 只用于入队，邮件由 main 中启动的 NotificationService worker 发送
+		s.notifier = NewNotificationService(cache.RedisClient, nil)
+	}
+	return s
+}
+
+// Subscribe 订阅缺货 SKU 的到货提醒，提醒发送到用户当前的邮箱；重复订阅会刷新邮箱并重新等待提醒
+func (s *StockNotificationService) Subscribe(ctx context.Context, userID uint, req *types.RestockSubscribeReq) (*types.RestockSubscriptionResp, error) {
+	sku, err := s.skuDao.ResolveSku(ctx, req.ProductID, req.SkuID, false)
+	if err != nil {
+		return nil, err
+	}
+	if sku.Stock > 0 {
+		return nil, ErrRestockInStock
+	}
+	user, err := dao.NewUserDao(ctx).GetUserById(userID)
+	if err != nil {
+		log.Errorf("订阅到货提醒时查询用户 %d 失败: %v", userID, err)
+		return nil, err
+	}
+	if user.Email == "" {
+		return nil, errors.New("请先绑定邮箱再订阅到货提醒")
+	}
+
+	subscription := &model.RestockSubscription{
+		UserID:    userID,
+		SkuID:     sku.ID,
+		ProductID: sku.ProductID,
+		Email:     user.Email,
+	}
+	if err := s.notificationDao.Subscribe(ctx, subscription); err != nil {
+		log.Errorf("订阅到货提醒失败 (userID: %d, skuID: %d): %v", userID, sku.ID, err)
+		return nil, err
+	}
+	log.Infof("用户 %d 已订阅 SKU %d 的到货提醒", userID, sku.ID)
+	return buildRestockSubscriptionResp(subscription), nil
+}
+
+// Unsubscribe 取消到货提醒
+func (s *StockNotificationService) Unsubscribe(ctx context.Context, userID, skuID uint) error {
+	found, err := s.notificationDao.Unsubscribe(ctx, userID, skuID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("未订阅该商品的到货提醒")
+	}
+	return nil
+}
+
+// ListSubscriptions 分页查询用户的到货提醒订阅
+func (s *StockNotificationService) ListSubscriptions(ctx context.Context, userID uint, req *types.BasePage) (*types.DataListResp, error) {
+	subscriptions, total, err := s.notificationDao.ListUserSubscriptions(ctx, userID, req.PageNum, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*types.RestockSubscriptionResp, 0, len(subscriptions))
+	for i := range subscriptions {
+		items = append(items, buildRestockSubscriptionResp(&subscriptions[i]))
+	}
+	return &types.DataListResp{
+		Item:  items,
+		Total: total,
+	}, nil
+}
+
+// ListenAndNotify 定期处理库存事件，与 CartService.ListenAndReleaseExpired 一样作为后台协程运行
+// 事件先标记为已处理再发送邮件，多实例部署时每个事件只会被一个实例处理；邮件入队失败只记录日志，不重试
+func (s *StockNotificationService) ListenAndNotify(ctx context.Context) {
+	ticker := time.NewTicker(config.GetInventoryNotifyInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Infof("库存提醒 worker 退出")
+			return
+		case <-ticker.C:
+			s.processPendingEvents(ctx)
+		}
+	}
+}
+
+// processPendingEvents 处理一批未处理的库存事件
+func (s *StockNotificationService) processPendingEvents(ctx context.Context) {
+	events, err := s.notificationDao.ListPendingEvents(ctx, consts.StockEventBatchSize)
+	if err != nil {
+		log.Errorf("查询库存事件失败: %v", err)
+		return
+	}
+	for i := range events {
+		event := &events[i]
+		claimed, err := s.notificationDao.ClaimEvent(ctx, event.ID)
+		if err != nil {
+			log.Errorf("标记库存事件 %d 失败: %v", event.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		switch event.Type {
+		case consts.StockEventLowStock:
+			s.sendLowStockAlert(ctx, event)
+		case consts.StockEventBackInStock:
+			s.sendRestockNotices(ctx, event)
+		default:
+			log.Warnf("未知的库存事件类型 %s (eventID: %d)", event.Type, event.ID)
+		}
+	}
+}
+
+// sendLowStockAlert 将低库存预警邮件发送给商品所属商家的联系邮箱，平台自营商品发送给配置的收件人
+func (s *StockNotificationService) sendLowStockAlert(ctx context.Context, event *model.StockEvent) {
+	product, err := dao.GetProduct(uint32(event.ProductID))
+	if err != nil {
+		log.Errorf("发送低库存预警失败，查询商品 %d 出错: %v", event.ProductID, err)
+		return
+	}
+	log.Warnf("商品库存低于预警阈值 (productID: %d, stock: %d, threshold: %d)", product.ID, event.Stock, event.Threshold)
+
+	recipients := config.GetInventoryAlertEmails()
+	if product.MerchantID != 0 {
+		merchant, err := s.merchantDao.GetMerchant(ctx, product.MerchantID)
+		if err != nil {
+			log.Errorf("发送低库存预警失败，查询商家 %d 出错: %v", product.MerchantID, err)
+			return
+		}
+		recipients = lowStockRecipients(merchant, recipients)
+	}
+	if len(recipients) == 0 || s.notifier == nil {
+		return
+	}
+	if err := s.notifier.EnqueueEmail(ctx, buildLowStockEmail(product, event, recipients)); err != nil {
+		log.Errorf("低库存预警邮件入队失败 (productID: %d): %v", product.ID, err)
+	}
+}
+
+// lowStockRecipients 商家商品的低库存预警收件人：商家有联系邮箱时只发给商家，否则退回配置的收件人
+func lowStockRecipients(merchant *model.Merchant, fallback []string) []string {
+	if merchant.ContactEmail == "" {
+		return fallback
+	}
+	return []string{merchant.ContactEmail}
+}
+
+// sendRestockNotices 向等待提醒的订阅用户发送到货邮件；处理时 SKU 已再次售罄则保留订阅，等待下一次到货
+func (s *StockNotificationService) sendRestockNotices(ctx context.Context, event *model.StockEvent) {
+	skus, err := s.skuDao.ListSkusByIDs(ctx, []uint{event.SkuID})
+	if err != nil {
+		log.Errorf("发送到货提醒失败，查询 SKU %d 出错: %v", event.SkuID, err)
+		return
+	}
+	if len(skus) == 0 || skus[0].Stock <= 0 {
+		return
+	}
+	sku := &skus[0]
+
+	subscriptions, err := s.notificationDao.ListPendingSubscriptions(ctx, sku.ID)
+	if err != nil {
+		log.Errorf("查询 SKU %d 的到货提醒订阅失败: %v", sku.ID, err)
+		return
+	}
+	if s.notifier == nil {
+		return
+	}
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+		if err := s.notifier.EnqueueEmail(ctx, buildRestockEmail(sku, subscription.Email)); err != nil {
+			log.Errorf("到货提醒邮件入队失败 (subscriptionID: %d): %v", subscription.ID, err)
+			continue
+		}
+		if err := s.notificationDao.MarkNotified(ctx, subscription.ID); err != nil {
+			log.Errorf("记录到货提醒已发送失败 (subscriptionID: %d): %v", subscription.ID, err)
+		}
+	}
+	log.Infof("SKU %d 到货，已通知 %d 位订阅用户", sku.ID, len(subscriptions))
+}
+
+// skuDisplayName 返回带规格描述的商品名称，如「杯子（颜色:白）」
+func skuDisplayName(sku *model.ProductSku) string {
+	if sku.SpecKey == "" {
+		return sku.Product.Name
+	}
+	return sku.Product.Name + "（" + sku.SpecKey + "）"
+}
+
+// buildLowStockEmail 生成低库存预警邮件
+func buildLowStockEmail(product *model.Product, event *model.StockEvent, recipients []string) EmailJob {
+	return EmailJob{
+		To:      recipients,
+		Subject: fmt.Sprintf("低库存预警 - %s", product.Name),
+		Body: fmt.Sprintf("商品「%s」（ID: %d）的总库存已降至 %d 件，低于预警阈值 %d 件，请及时补货。\n\n触发时间：%s\n",
+			product.Name, product.ID, event.Stock, event.Threshold, event.CreatedAt.Format("2006-01-02 15:04:05")),
+	}
+}
+
+// buildRestockEmail 生成到货提醒邮件
+func buildRestockEmail(sku *model.ProductSku, email string) EmailJob {
+	name := skuDisplayName(sku)
+	return EmailJob{
+		To:      []string{email},
+		Subject: fmt.Sprintf("到货提醒 - %s", name),
+		Body: fmt.Sprintf("您关注的商品「%s」已到货，当前单价 %s %s，库存有限，欢迎选购。\n\n商品ID：%d\nSKU ID：%d\n",
+			name, sku.Price, config.GetBaseCurrency(), sku.ProductID, sku.ID),
+	}
+}
+
+// buildRestockSubscriptionResp 将到货提醒订阅转换为响应结构
+func buildRestockSubscriptionResp(subscription *model.RestockSubscription) *types.RestockSubscriptionResp {
+	resp := &types.RestockSubscriptionResp{
+		ID:        subscription.ID,
+		ProductID: subscription.ProductID,
+		SkuID:     subscription.SkuID,
+		Email:     subscription.Email,
+		CreatedAt: subscription.CreatedAt.Unix(),
+	}
+	if subscription.NotifiedAt != nil {
+		resp.NotifiedAt = subscription.NotifiedAt.Unix()
+	}
+	return resp
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"douyin/consts"
+	"douyin/repository/db/model"
+)
+
+func TestBuildStockNotificationEmails(t *testing.T) {
+	product := &model.Product{ID: 1, Name: "杯子"}
+	event := &model.StockEvent{
+		Type:      consts.StockEventLowStock,
+		ProductID: 1,
+		Stock:     3,
+		Threshold: 5,
+		CreatedAt: time.Date(2024, 5, 1, 8, 0, 0, 0, time.Local),
+	}
+	alert := buildLowStockEmail(product, event, []string{"ops@example.com", "buyer@example.com"})
+	assert.Equal(t, []string{"ops@example.com", "buyer@example.com"}, alert.To)
+	assert.Equal(t, "低库存预警 - 杯子", alert.Subject)
+	assert.Contains(t, alert.Body, "已降至 3 件，低于预警阈值 5 件")
+
+	sku := &model.ProductSku{ID: 11, ProductID: 1, SpecKey: "颜色:白", Price: 1050, Product: *product}
+	notice := buildRestockEmail(sku, "user@example.com")
+	assert.Equal(t, []string{"user@example.com"}, notice.To)
+	assert.Equal(t, "到货提醒 - 杯子（颜色:白）", notice.Subject)
+	assert.Contains(t, notice.Body, "10.50")
+
+	sku.SpecKey = ""
+	assert.Equal(t, "杯子", skuDisplayName(sku), "默认 SKU 不显示规格")
+}
+
+func TestLowStockRecipients(t *testing.T) {
+	fallback := []string{"ops@example.com"}
+	assert.Equal(t, []string{"shop@example.com"}, lowStockRecipients(&model.Merchant{ContactEmail: "shop@example.com"}, fallback))
+	assert.Equal(t, fallback, lowStockRecipients(&model.Merchant{}, fallback))
+}
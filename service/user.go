@@ -10,13 +10,16 @@ import (
 	"sync"
 	"time"
 
+	"douyin/config"
 	"douyin/consts"
 	"douyin/pkg/utils/ctl"
 	"douyin/pkg/utils/jwt"
 	"douyin/pkg/utils/log"
+	"douyin/pkg/utils/money"
 	"douyin/repository/db/dao"
 	"douyin/repository/db/model"
 	"douyin/types"
+	"gorm.io/gorm"
 )
 
 var UserSrvIns *UserSrv   // 全局用户服务实例
@@ -190,9 +193,29 @@ func (s *UserSrv) UserUpdate(ctx context.Context, req *types.UserUpdateReq) (res
 		log.LogrusObj.Error("查询用户失败：", err)
 		return nil, err
 	}
-	// 仅更新允许修改的字段
-	user.UserName = req.UserName
-	user.Email = req.Email
+	// 仅更新允许修改且请求中填写了的字段
+	if req.UserName != "" {
+		user.UserName = req.UserName
+	}
+	if req.Email != "" {
+		user.Email = req.Email
+	}
+	if req.Currency != "" {
+		currency, err := normalizeCurrency(req.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if currency != config.GetBaseCurrency() {
+			if _, err := userDao.GetExchangeRate(currency); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+				}
+				log.LogrusObj.Error("查询汇率失败：", err)
+				return nil, err
+			}
+		}
+		user.Currency = currency
+	}
 	user.UpdatedAt = time.Now()
 	if err = userDao.UpdateUserById(req.UserId, user); err != nil {
 		log.LogrusObj.Error("更新用户信息失败：", err)
@@ -204,7 +227,7 @@ func (s *UserSrv) UserUpdate(ctx context.Context, req *types.UserUpdateReq) (res
 
 // UserInfoShow 获取用户身份信息业务逻辑
 // 返回内容：[用户ID, 用户名, 用户邮箱, 用户余额, 创建时间, 更新时间]
-// 余额直接返回，不进行加解密处理
+// 余额取自钱包（wallets 表），以两位小数字符串返回
 func (s *UserSrv) UserInfoShow(ctx context.Context, req *types.UserInfoShowReq) (resp interface{}, err error) {
 	u, err := ctl.GetUserInfo(ctx)
 	if err != nil {
@@ -217,11 +240,17 @@ func (s *UserSrv) UserInfoShow(ctx context.Context, req *types.UserInfoShowReq)
 		log.LogrusObj.Error("查询用户失败：", err)
 		return nil, err
 	}
+	balance, err := userDao.GetWalletBalance(user.ID)
+	if err != nil {
+		log.LogrusObj.Error("查询钱包余额失败：", err)
+		return nil, err
+	}
 	userResp := &types.UserIdentityInfo{
 		UserID:   user.ID,
 		UserName: user.UserName,
 		Email:    user.Email,
-		Money:    user.Money,
+		Money:    money.Amount(balance).String(),
+		Currency: user.Currency,
 		CreateAt: user.CreatedAt.Unix(),
 		UpdateAt: user.UpdatedAt.Unix(),
 	}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"douyin/consts"
+	"douyin/pkg/utils/log"
+	"douyin/pkg/utils/money"
+	"douyin/repository/db/dao"
+	"douyin/repository/db/model"
+	"douyin/types"
+	"gorm.io/gorm"
+)
+
+// WalletService 钱包服务，余额与流水均以分为单位记账
+type WalletService struct {
+	walletDao *dao.WalletDao
+}
+
+// NewWalletService 创建新的 WalletService 实例
+func NewWalletService(db *gorm.DB) *WalletService {
+	return &WalletService{
+		walletDao: dao.NewWalletDao(db),
+	}
+}
+
+// ListTransactions 分页查询用户钱包流水及当前余额
+func (s *WalletService) ListTransactions(ctx context.Context, userID uint, req *types.WalletTransactionListReq) (*types.WalletTransactionListResp, error) {
+	balance, err := s.walletDao.GetBalance(ctx, userID)
+	if err != nil {
+		log.Errorf("查询钱包余额失败 (userID: %d): %v", userID, err)
+		return nil, err
+	}
+	entries, total, err := s.walletDao.ListTransactions(ctx, userID, req)
+	if err != nil {
+		log.Errorf("查询钱包流水失败 (userID: %d): %v", userID, err)
+		return nil, err
+	}
+
+	items := make([]types.WalletTransactionResp, 0, len(entries))
+	for i := range entries {
+		item, err := walletTransactionResp(&entries[i])
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return &types.WalletTransactionListResp{
+		Balance: money.Amount(balance),
+		DataListResp: types.DataListResp{
+			Item:  items,
+			Total: total,
+		},
+	}, nil
+}
+
+// TopUp 登记外部资金到账，用户钱包入账、外部资金账户出账
+// 同一用户同一到账凭证号只入账一次，重复提交返回 dao.ErrWalletDuplicateRef
+func (s *WalletService) TopUp(ctx context.Context, operatorID uint, req *types.WalletTopUpReq) (*types.WalletTransactionResp, error) {
+	if req.Amount <= 0 {
+		return nil, errors.New("充值金额必须大于 0")
+	}
+	remark := req.Remark
+	if remark == "" {
+		remark = "充值"
+	}
+	entry, err := s.walletDao.Post(ctx, &dao.WalletPosting{
+		UserID:         req.UserID,
+		Type:           consts.WalletTxnTopUp,
+		Amount:         req.Amount.MinorUnits(),
+		CounterAccount: consts.WalletAccountExternal,
+		RefID:          req.RefID,
+		Remark:         remark,
+		UniqueRef:      true,
+	})
+	if err != nil {
+		log.Errorf("钱包充值失败 (userID: %d, refID: %s, operatorID: %d): %v", req.UserID, req.RefID, operatorID, err)
+		return nil, err
+	}
+	log.Infof("钱包充值成功 (userID: %d, amount: %s, refID: %s, operatorID: %d)", req.UserID, req.Amount, req.RefID, operatorID)
+	return walletTransactionResp(entry)
+}
+
+// Adjust 管理员人工调整用户余额，对方账户为人工调整账户；扣减后余额不能小于 0
+// 备注中记录操作人，便于审计
+func (s *WalletService) Adjust(ctx context.Context, adminID uint, req *types.WalletAdjustReq) (*types.WalletTransactionResp, error) {
+	if req.Amount == 0 {
+		return nil, errors.New("调整金额不能为 0")
+	}
+	entry, err := s.walletDao.Post(ctx, &dao.WalletPosting{
+		UserID:         req.UserID,
+		Type:           consts.WalletTxnAdjustment,
+		Amount:         req.Amount.MinorUnits(),
+		CounterAccount: consts.WalletAccountAdjust,
+		RefID:          req.RefID,
+		Remark:         fmt.Sprintf("%s（操作人：%d）", req.Remark, adminID),
+	})
+	if err != nil {
+		log.Errorf("钱包余额调整失败 (userID: %d, adminID: %d): %v", req.UserID, adminID, err)
+		return nil, err
+	}
+	log.Infof("钱包余额已调整 (userID: %d, amount: %s, adminID: %d)", req.UserID, req.Amount, adminID)
+	return walletTransactionResp(entry)
+}
+
+// walletTransactionResp 将用户钱包一侧的流水转换为响应结构
+func walletTransactionResp(entry *model.WalletTransaction) (*types.WalletTransactionResp, error) {
+	balanceAfter, err := dao.DecryptWalletAmount(entry.BalanceAfter)
+	if err != nil {
+		log.Errorf("解密钱包流水余额失败 (txnNo: %s): %v", entry.TxnNo, err)
+		return nil, err
+	}
+	return &types.WalletTransactionResp{
+		TxnNo:        entry.TxnNo,
+		Type:         entry.Type,
+		Amount:       money.Amount(entry.Amount),
+		BalanceAfter: money.Amount(balanceAfter),
+		RefID:        entry.RefID,
+		Remark:       entry.Remark,
+		CreatedAt:    entry.CreatedAt.Unix(),
+	}, nil
+}
@@ -1,5 +1,7 @@
 package types
 
+import "douyin/pkg/utils/money"
+
 // CreateCartReq 创建购物车请求参数 (UserID will be from JWT)
 type CreateCartReq struct {
 	// UserID is removed, will be extracted from JWT claims in handler
@@ -16,8 +18,90 @@ type EmptyCartReq struct {
 }
 
 // AddItemReq 添加商品到购物车请求参数
+// 有多个规格的商品必须指定 SkuID；只有默认 SKU 的商品可以只传 ProductID
 type AddItemReq struct {
 	// UserID is removed, will be extracted from JWT claims in handler
-	ProductID uint `json:"product_id" binding:"required,gt=0"`
-	Quantity  int  `json:"quantity" binding:"required,gt=0,lte=100"` // Max 100 per add
+	ProductID uint `json:"product_id" binding:"required_without=SkuID,omitempty,gt=0"`
+	SkuID     uint `json:"sku_id" binding:"omitempty,gt=0"`
+	Quantity  int  `json:"quantity" binding:"required,ne=0,gte=-100,lte=100"` // 数量增量，负数表示减少，单次最多 ±100
+}
+
+// RemoveCartItemReq 从购物车中移除商品请求参数
+type RemoveCartItemReq struct {
+	SkuIDs []uint `json:"sku_ids" binding:"required,min=1,dive,gt=0"` // 要移除的 SKU ID 列表
+}
+
+// SetCartItemQuantityReq 设置购物车商品数量请求参数，SKU 的指定方式同 AddItemReq
+type SetCartItemQuantityReq struct {
+	ProductID uint `json:"product_id" binding:"required_without=SkuID,omitempty,gt=0"`
+	SkuID     uint `json:"sku_id" binding:"omitempty,gt=0"`
+	Quantity  int  `json:"quantity" binding:"gte=0,lte=999"` // 设置后的绝对数量，为 0 时移除该商品
+}
+
+// SelectCartItemsReq 勾选/取消勾选购物车商品请求参数
+type SelectCartItemsReq struct {
+	SkuIDs   []uint `json:"sku_ids" binding:"omitempty,dive,gt=0"` // 为空时作用于整个购物车
+	Selected bool   `json:"selected"`                              // true 勾选，false 取消勾选
+}
+
+// CartItemResp 购物车行信息，商品名称、图片、价格与库存均为查询时的最新数据
+type CartItemResp struct {
+	ProductID     uint          `json:"product_id"`         // 商品ID
+	SkuID         uint          `json:"sku_id"`             // SKU ID
+	SkuSpecs      string        `json:"sku_specs"`          // SKU 规格描述，默认 SKU 为空
+	ProductName   string        `json:"product_name"`       // 商品名称
+	Picture       string        `json:"picture"`            // SKU 图片，未设置时为商品图片
+	Price         money.Amount  `json:"price"`              // 当前单价（展示币种）
+	Quantity      int32         `json:"quantity"`           // 购物车中的数量
+	Selected      bool          `json:"selected"`           // 是否勾选结算
+	Available     int           `json:"available"`          // 当前可售数量（SKU 库存扣除其他用户的预占）
+	InStock       bool          `json:"in_stock"`           // 可售数量是否满足购物车数量
+	Invalid       bool          `json:"invalid"`            // 商品或 SKU 已下架或不存在
+	Subtotal      money.Amount  `json:"subtotal"`           // 行小计 = 单价 × 数量
+	SnapshotPrice money.Amount  `json:"snapshot_price"`     // 加入购物车时的单价（展示币种），游客购物车为 0
+	Warnings      []CartWarning `json:"warnings,omitempty"` // 加入购物车后发生的变化，需提示用户确认
+}
+
+// CartWarning 购物车行提示
+type CartWarning struct {
+	ProductID     uint         `json:"product_id"`
+	SkuID         uint         `json:"sku_id"`
+	Type          string       `json:"type"`                     // 提示类型，见 consts.CartWarning*
+	Message       string       `json:"message"`                  // 提示文案
+	SnapshotPrice money.Amount `json:"snapshot_price,omitempty"` // 加入购物车时的单价，价格变动时返回
+	CurrentPrice  money.Amount `json:"current_price,omitempty"`  // 当前单价，价格变动时返回
+	Available     int          `json:"available,omitempty"`      // 当前可售数量，库存不足时返回
+}
+
+// CartResp 获取购物车响应
+type CartResp struct {
+	Items            []CartItemResp `json:"items"`
+	Currency         string         `json:"currency"`          // 展示币种
+	TotalQuantity    int64          `json:"total_quantity"`    // 购物车商品总件数
+	TotalAmount      money.Amount   `json:"total_amount"`      // 所有有效商品的小计之和
+	SelectedQuantity int64          `json:"selected_quantity"` // 已勾选的商品件数
+	SelectedAmount   money.Amount   `json:"selected_amount"`   // 已勾选有效商品的小计之和，即结算金额
+	HasWarnings      bool           `json:"has_warnings"`      // 是否存在需要用户确认的变化
+}
+
+// GuestCartGetReq 获取游客购物车请求参数
+type GuestCartGetReq struct {
+	Currency string `json:"currency" form:"currency"` // 展示币种，为空时使用基础币种
+}
+
+// CartMergeItem 游客购物车单个商品的合并结果
+type CartMergeItem struct {
+	ProductID uint   `json:"product_id"`
+	SkuID     uint   `json:"sku_id"`
+	Requested int32  `json:"requested"`        // 游客购物车中的数量
+	Previous  int32  `json:"previous"`         // 合并前用户购物车中的数量
+	Quantity  int32  `json:"quantity"`         // 合并后用户购物车中的数量
+	Adjusted  bool   `json:"adjusted"`         // 是否因单行上限或库存不足而少于两者之和
+	Skipped   bool   `json:"skipped"`          // 商品已下架或无可售库存，未合并
+	Reason    string `json:"reason,omitempty"` // 调整或跳过的原因
+}
+
+// CartMergeResp 登录时游客购物车合并结果
+type CartMergeResp struct {
+	Items []CartMergeItem `json:"items"`
 }
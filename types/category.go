@@ -3,8 +3,11 @@ package types
 
 // 商品类别
 type Category struct {
-	ID   uint32 `json:"id"`   // 分类ID
-	Name string `json:"name"` // 分类名称
+	ID       uint32      `json:"id"`                 // 分类ID
+	Name     string      `json:"name"`               // 分类名称
+	ParentID uint32      `json:"parent_id"`          // 父分类ID，0 表示顶级分类
+	Sort     int         `json:"sort"`               // 同级排序，越小越靠前
+	Children []*Category `json:"children,omitempty"` // 子分类
 }
 
 // 商品分类关联
@@ -12,3 +15,29 @@ type ProductCategory struct {
 	ProductID  uint32 `json:"product_id"`  // 商品ID
 	CategoryID uint32 `json:"category_id"` // 分类ID
 }
+
+// CategoryCreateReq 管理员创建分类请求参数
+type CategoryCreateReq struct {
+	Name     string `json:"name" binding:"required,max=50"`
+	ParentID uint   `json:"parent_id"` // 父分类ID，0 表示顶级分类
+	Sort     int    `json:"sort"`
+}
+
+// CategoryUpdateReq 管理员修改分类请求参数，未传的字段保持不变
+type CategoryUpdateReq struct {
+	ID       uint    `json:"id" binding:"required,gt=0"`
+	Name     *string `json:"name" binding:"omitempty,min=1,max=50"`
+	ParentID *uint   `json:"parent_id"` // 移动到新的父分类下，0 表示移为顶级分类
+	Sort     *int    `json:"sort"`
+}
+
+// CategoryDeleteReq 管理员删除分类请求参数
+type CategoryDeleteReq struct {
+	ID uint `json:"id" binding:"required,gt=0"`
+}
+
+// ProductCategoryAssignReq 管理员设置商品所属分类请求参数，整体替换商品原有的分类
+type ProductCategoryAssignReq struct {
+	ProductID   uint   `json:"product_id" binding:"required,gt=0"`
+	CategoryIDs []uint `json:"category_ids" binding:"max=20,dive,gt=0"` // 为空时清除商品的全部分类
+}
@@ -1,13 +1,20 @@
 package types
 
-// CheckoutReq 结算请求参数
+import "douyin/pkg/utils/money"
+
+// CheckoutReq 结算请求参数，结算当前用户购物车中勾选的商品
 type CheckoutReq struct {
-	UserCurrency  string         `json:"user_currency" binding:"required"`  // 用户货币
-	Email         string         `json:"email" binding:"required"`          // 用户邮箱
-	StreetAddress string         `json:"street_address" binding:"required"` // 街道地址
-	City          string         `json:"city" binding:"required"`           // 城市
-	State         string         `json:"state" binding:"required"`          // 省/州
-	Country       string         `json:"country" binding:"required"`        // 国家
-	ZipCode       string         `json:"zip_code" binding:"required"`       // 邮政编码
-	OrderItems    []OrderItemReq `json:"order_items" binding:"required"`    // 订单项
+	AddressID      uint   `json:"address_id" binding:"required,gt=0"`             // 收货地址ID
+	UserCurrency   string `json:"user_currency"`                                  // 用户货币，为空时使用用户偏好币种或基础币种
+	CouponIDs      []uint `json:"coupon_ids" binding:"omitempty,max=5,dive,gt=0"` // 使用的用户优惠券ID（可选）
+	ConfirmChanges bool   `json:"confirm_changes"`                                // 用户已确认购物车中的价格变动，为 false 且存在变动时拒绝结算并返回变动明细
+}
+
+// CheckoutResp 结算/下单响应
+type CheckoutResp struct {
+	OrderID        string        `json:"order_id"`           // 订单ID
+	TransactionID  string        `json:"transaction_id"`     // 待支付的交易ID
+	TotalAmount    money.Amount  `json:"total_amount"`       // 应付金额（已扣除优惠）
+	DiscountAmount money.Amount  `json:"discount_amount"`    // 优惠券抵扣总额
+	Warnings       []CartWarning `json:"warnings,omitempty"` // 用户已确认的价格变动，仅购物车结算返回
 }
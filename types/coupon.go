@@ -0,0 +1,76 @@
+package types
+
+import "douyin/pkg/utils/money"
+
+// CouponTemplateCreateReq 管理员创建优惠券模板请求参数，金额均为基础币种
+type CouponTemplateCreateReq struct {
+	Name          string       `json:"name" binding:"required,max=100"`
+	Type          string       `json:"type" binding:"required,oneof=FIXED PERCENT THRESHOLD"` // 类型，取值见 consts.CouponType*
+	Value         money.Amount `json:"value"`                                                 // 立减/满减金额
+	Percent       int          `json:"percent" binding:"gte=0,lt=100"`                        // 折扣券减免的百分比
+	Threshold     money.Amount `json:"threshold"`                                             // 使用门槛，0 表示无门槛
+	MaxDiscount   money.Amount `json:"max_discount"`                                          // 折扣券最高优惠金额，0 表示不限
+	CategoryID    uint         `json:"category_id"`                                           // 适用分类，0 表示全场通用
+	Stackable     bool         `json:"stackable"`                                             // 是否可与其他可叠加券同时使用
+	TotalQuantity int          `json:"total_quantity" binding:"gte=0"`                        // 发行总量，0 表示不限
+	PerUserLimit  int          `json:"per_user_limit" binding:"gte=0"`                        // 每人限领张数，0 按 1 处理
+	ClaimStartAt  int64        `json:"claim_start_at" binding:"required"`                     // 领取开始时间（Unix 时间戳）
+	ClaimEndAt    int64        `json:"claim_end_at" binding:"required"`                       // 领取结束时间（Unix 时间戳）
+	ValidDays     int          `json:"valid_days" binding:"gte=0"`                            // 领取后有效天数，0 表示以 expires_at 为准
+	ExpiresAt     int64        `json:"expires_at" binding:"required"`                         // 使用截止时间（Unix 时间戳）
+}
+
+// CouponTemplateStatusReq 管理员启用/停用优惠券模板请求参数
+type CouponTemplateStatusReq struct {
+	TemplateID uint `json:"template_id" binding:"required,gt=0"`
+	Enabled    bool `json:"enabled"`
+}
+
+// CouponTemplateListReq 优惠券模板列表查询参数
+type CouponTemplateListReq struct {
+	BasePage
+}
+
+// CouponClaimReq 领取优惠券请求参数
+type CouponClaimReq struct {
+	TemplateID uint `json:"template_id" binding:"required,gt=0"`
+}
+
+// UserCouponListReq 我的优惠券列表查询参数
+type UserCouponListReq struct {
+	BasePage
+	Status string `form:"status"` // 状态（可选），取值见 consts.UserCouponStatus*
+}
+
+// CouponTemplateResp 优惠券模板信息，金额为基础币种
+type CouponTemplateResp struct {
+	ID              uint         `json:"id"`
+	Name            string       `json:"name"`
+	Type            string       `json:"type"`
+	Value           money.Amount `json:"value"`
+	Percent         int          `json:"percent"`
+	Threshold       money.Amount `json:"threshold"`
+	MaxDiscount     money.Amount `json:"max_discount"`
+	CategoryID      uint         `json:"category_id"`
+	Stackable       bool         `json:"stackable"`
+	TotalQuantity   int          `json:"total_quantity"`
+	ClaimedQuantity int          `json:"claimed_quantity"`
+	PerUserLimit    int          `json:"per_user_limit"`
+	ClaimStartAt    int64        `json:"claim_start_at"`
+	ClaimEndAt      int64        `json:"claim_end_at"`
+	ValidDays       int          `json:"valid_days"`
+	ExpiresAt       int64        `json:"expires_at"`
+	Enabled         bool         `json:"enabled"`
+}
+
+// UserCouponResp 用户优惠券信息
+type UserCouponResp struct {
+	ID             uint               `json:"id"`
+	Status         string             `json:"status"`
+	ClaimedAt      int64              `json:"claimed_at"`
+	ExpiresAt      int64              `json:"expires_at"`
+	UsedAt         int64              `json:"used_at,omitempty"`
+	OrderID        string             `json:"order_id,omitempty"`
+	DiscountAmount money.Amount       `json:"discount_amount,omitempty"` // 在订单中抵扣的金额（订单币种）
+	Template       CouponTemplateResp `json:"template"`
+}
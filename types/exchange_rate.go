@@ -0,0 +1,28 @@
+package types
+
+import "douyin/pkg/utils/money"
+
+// ExchangeRateItem 单个币种的汇率
+type ExchangeRateItem struct {
+	Currency string     `json:"currency" binding:"required,len=3"` // 目标币种，ISO 4217 代码
+	Rate     money.Rate `json:"rate" binding:"required"`           // 1 单位基础币种可兑换的目标币种数量
+}
+
+// ExchangeRateUpdateReq 管理员批量更新汇率请求参数
+type ExchangeRateUpdateReq struct {
+	Rates []ExchangeRateItem `json:"rates" binding:"required,min=1,dive"`
+}
+
+// ExchangeRateResp 汇率信息
+type ExchangeRateResp struct {
+	Currency  string     `json:"currency"`
+	Rate      money.Rate `json:"rate"`
+	Source    string     `json:"source"`     // 数据来源：file / admin
+	UpdatedAt int64      `json:"updated_at"` // 更新时间（Unix 时间戳）
+}
+
+// ExchangeRateListResp 汇率列表，附带基础币种
+type ExchangeRateListResp struct {
+	Base  string             `json:"base"`
+	Rates []ExchangeRateResp `json:"rates"`
+}
@@ -0,0 +1,63 @@
+package types
+
+import "douyin/pkg/utils/money"
+
+// FlashSaleCreateReq 管理员创建秒杀场次请求参数，秒杀价为基础币种
+type FlashSaleCreateReq struct {
+	ProductID uint         `json:"product_id" binding:"required_without=SkuID,omitempty,gt=0"`
+	SkuID     uint         `json:"sku_id" binding:"omitempty,gt=0"` // 秒杀 SKU，有多个规格的商品必须指定
+	Price     money.Amount `json:"price" binding:"required"`        // 秒杀价
+	Stock     int          `json:"stock" binding:"required,gt=0"`   // 投放数量，不能超过 SKU 库存
+	StartAt   int64        `json:"start_at" binding:"required"`     // 开始时间（Unix 时间戳）
+	EndAt     int64        `json:"end_at" binding:"required"`       // 结束时间（Unix 时间戳）
+}
+
+// FlashSaleStatusReq 管理员启用/停用秒杀场次请求参数
+type FlashSaleStatusReq struct {
+	SessionID uint `json:"session_id" binding:"required,gt=0"`
+	Enabled   bool `json:"enabled"`
+}
+
+// FlashSaleListReq 秒杀场次列表查询参数
+type FlashSaleListReq struct {
+	BasePage
+	Currency string `form:"currency"` // 展示币种（可选），为空时使用基础币种
+}
+
+// FlashSaleBuyReq 秒杀抢购请求参数，抢到名额后订单由后台异步创建
+type FlashSaleBuyReq struct {
+	SessionID    uint   `json:"session_id" binding:"required,gt=0"`
+	AddressID    uint   `json:"address_id" binding:"required,gt=0"`
+	UserCurrency string `json:"user_currency"` // 订单币种（可选），规则同创建订单
+}
+
+// FlashSaleResultReq 秒杀结果查询参数
+type FlashSaleResultReq struct {
+	SessionID uint `form:"session_id" binding:"required,gt=0"`
+}
+
+// FlashSaleSessionResp 秒杀场次信息
+type FlashSaleSessionResp struct {
+	ID             uint         `json:"id"`
+	ProductID      uint         `json:"product_id"`
+	SkuID          uint         `json:"sku_id"`
+	SkuSpecs       string       `json:"sku_specs"` // SKU 规格描述
+	ProductName    string       `json:"product_name"`
+	ProductPicture string       `json:"product_picture"`
+	Price          money.Amount `json:"price"`          // 秒杀价
+	OriginalPrice  money.Amount `json:"original_price"` // SKU 原价
+	Currency       string       `json:"currency"`       // 价格币种
+	Stock          int          `json:"stock"`          // 投放数量
+	Remaining      int          `json:"remaining"`      // 剩余可抢数量
+	StartAt        int64        `json:"start_at"`
+	EndAt          int64        `json:"end_at"`
+	Enabled        bool         `json:"enabled"`
+}
+
+// FlashSaleResultResp 秒杀抢购结果
+type FlashSaleResultResp struct {
+	SessionID uint   `json:"session_id"`
+	Status    string `json:"status"`             // 取值见 consts.FlashSaleResult*
+	OrderID   string `json:"order_id,omitempty"` // 下单成功时的订单ID，需在超时前完成支付
+	Message   string `json:"message,omitempty"`  // 下单失败的原因
+}
@@ -0,0 +1,116 @@
+package types
+
+// WarehouseCreateReq 管理员创建仓库请求参数
+type WarehouseCreateReq struct {
+	Code     string `json:"code" binding:"required,max=32"`
+	Name     string `json:"name" binding:"required,max=100"`
+	Address  string `json:"address" binding:"max=255"`
+	Priority int    `json:"priority"` // 分配优先级，数值小的优先
+}
+
+// WarehouseUpdateReq 管理员修改仓库请求参数，未传的字段保持不变
+type WarehouseUpdateReq struct {
+	ID       uint    `json:"id" binding:"required,gt=0"`
+	Name     *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Address  *string `json:"address" binding:"omitempty,max=255"`
+	Priority *int    `json:"priority"`
+	Enabled  *bool   `json:"enabled"` // 停用后不再参与下单分配
+}
+
+// WarehouseResp 仓库信息
+type WarehouseResp struct {
+	ID       uint   `json:"id"`
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Address  string `json:"address"`
+	Priority int    `json:"priority"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// InventoryReceiveReq 入库请求参数
+type InventoryReceiveReq struct {
+	WarehouseID uint   `json:"warehouse_id" binding:"required,gt=0"`
+	SkuID       uint   `json:"sku_id" binding:"required,gt=0"`
+	Quantity    int    `json:"quantity" binding:"required,gt=0"`
+	RefID       string `json:"ref_id" binding:"max=64"` // 入库单号（可选）
+	Remark      string `json:"remark" binding:"max=255"`
+}
+
+// InventoryAdjustReq 盘点调整请求参数
+type InventoryAdjustReq struct {
+	WarehouseID uint   `json:"warehouse_id" binding:"required,gt=0"`
+	SkuID       uint   `json:"sku_id" binding:"required,gt=0"`
+	Delta       int    `json:"delta" binding:"required,ne=0"` // 在库数量变动，正数为盘盈、负数为盘亏
+	RefID       string `json:"ref_id" binding:"max=64"`       // 盘点单号（可选）
+	Remark      string `json:"remark" binding:"required,max=255"`
+}
+
+// InventoryLevelListReq 库存水位查询参数
+type InventoryLevelListReq struct {
+	BasePage
+	WarehouseID uint `form:"warehouse_id"`
+	SkuID       uint `form:"sku_id"`
+}
+
+// InventoryLevelResp 仓库中某个 SKU 的库存水位
+type InventoryLevelResp struct {
+	WarehouseID uint  `json:"warehouse_id"`
+	SkuID       uint  `json:"sku_id"`
+	ProductID   uint  `json:"product_id"`
+	OnHand      int   `json:"on_hand"`   // 在库数量
+	Reserved    int   `json:"reserved"`  // 被未支付订单占用的数量
+	Available   int   `json:"available"` // 可售数量
+	UpdatedAt   int64 `json:"updated_at"`
+}
+
+// InventoryMovementListReq 库存流水查询参数
+type InventoryMovementListReq struct {
+	BasePage
+	WarehouseID uint   `form:"warehouse_id"`
+	SkuID       uint   `form:"sku_id"`
+	Type        string `form:"type"`     // 流水类型，取值见 consts.InventoryMove*
+	RefType     string `form:"ref_type"` // 单据类型，取值见 consts.InventoryRef*
+	RefID       string `form:"ref_id"`   // 单据号，如订单ID
+}
+
+// InventoryMovementResp 库存流水
+type InventoryMovementResp struct {
+	ID             uint   `json:"id"`
+	WarehouseID    uint   `json:"warehouse_id"`
+	SkuID          uint   `json:"sku_id"`
+	ProductID      uint   `json:"product_id"`
+	Type           string `json:"type"`
+	OnHandChange   int    `json:"on_hand_change"`
+	ReservedChange int    `json:"reserved_change"`
+	OnHandAfter    int    `json:"on_hand_after"`
+	ReservedAfter  int    `json:"reserved_after"`
+	RefType        string `json:"ref_type"`
+	RefID          string `json:"ref_id"`
+	Actor          string `json:"actor"`
+	ActorID        uint   `json:"actor_id"`
+	Remark         string `json:"remark"`
+	CreatedAt      int64  `json:"created_at"`
+}
+
+// InventoryReconcileReq 库存对账请求参数
+type InventoryReconcileReq struct {
+	FixSkuStock bool `json:"fix_sku_stock"` // 是否按仓库库存重写不一致的 SKU 缓存库存
+}
+
+// InventoryDiscrepancy 一条对账差异
+type InventoryDiscrepancy struct {
+	Kind        string `json:"kind"` // level：库存水位与流水合计不一致；sku：SKU 缓存库存与各仓库可售数量之和不一致
+	WarehouseID uint   `json:"warehouse_id,omitempty"`
+	SkuID       uint   `json:"sku_id"`
+	Field       string `json:"field"`    // 不一致的字段：on_hand、reserved 或 stock
+	Recorded    int    `json:"recorded"` // 当前记录的数量
+	Expected    int    `json:"expected"` // 按流水或仓库库存计算的数量
+	Fixed       bool   `json:"fixed"`
+}
+
+// InventoryReconcileResp 库存对账结果
+type InventoryReconcileResp struct {
+	CheckedLevels int                    `json:"checked_levels"`
+	CheckedSkus   int                    `json:"checked_skus"`
+	Discrepancies []InventoryDiscrepancy `json:"discrepancies"`
+}
@@ -0,0 +1,84 @@
+package types
+
+import "douyin/pkg/utils/money"
+
+// MerchantRegisterReq 开设店铺请求参数
+type MerchantRegisterReq struct {
+	Name         string `json:"name" binding:"required,max=100"`
+	Description  string `json:"description" binding:"max=500"`
+	ContactEmail string `json:"contact_email" binding:"omitempty,email,max=255"` // 联系邮箱，为空时使用店主账号的邮箱
+}
+
+// MerchantUpdateReq 修改店铺资料请求参数，未传的字段保持不变
+type MerchantUpdateReq struct {
+	Name         *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Description  *string `json:"description" binding:"omitempty,max=500"`
+	ContactEmail *string `json:"contact_email" binding:"omitempty,email,max=255"`
+}
+
+// MerchantResp 店铺资料
+type MerchantResp struct {
+	ID           uint   `json:"id"`
+	UserID       uint   `json:"user_id"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	ContactEmail string `json:"contact_email"`
+	Status       string `json:"status"` // 取值见 consts.MerchantStatus*
+	CreatedAt    int64  `json:"created_at"`
+}
+
+// MerchantListReq 管理员查询商家列表参数
+type MerchantListReq struct {
+	BasePage
+	Status string `form:"status"` // 状态（可选），取值见 consts.MerchantStatus*
+}
+
+// MerchantStatusReq 管理员启用/停用商家请求参数
+type MerchantStatusReq struct {
+	MerchantID uint   `json:"merchant_id" binding:"required,gt=0"`
+	Status     string `json:"status" binding:"required,oneof=ACTIVE SUSPENDED"`
+}
+
+// MerchantOrderListReq 商家订单列表查询参数
+type MerchantOrderListReq struct {
+	BasePage
+	Status    int   `form:"status" json:"status"`         // 订单状态（可选），取值见 consts.OrderType*
+	StartTime int64 `form:"start_time" json:"start_time"` // 下单时间起（可选，Unix 时间戳，包含）
+	EndTime   int64 `form:"end_time" json:"end_time"`     // 下单时间止（可选，Unix 时间戳，不包含）
+}
+
+// MerchantSettlementListReq 商家结算明细查询参数
+type MerchantSettlementListReq struct {
+	BasePage
+	Status string `form:"status"` // 状态（可选），取值见 consts.SettlementStatus*
+}
+
+// MerchantSettlementResp 一条结算明细
+type MerchantSettlementResp struct {
+	ID         uint         `json:"id"`
+	Type       string       `json:"type"` // 取值见 consts.SettlementType*
+	Status     string       `json:"status"`
+	OrderID    string       `json:"order_id"`
+	RefundNo   string       `json:"refund_no,omitempty"`
+	Amount     money.Amount `json:"amount"`      // 订单币种金额，扣回为负数
+	Currency   string       `json:"currency"`    // 订单币种
+	BaseAmount money.Amount `json:"base_amount"` // 结算到钱包的基础币种金额
+	BatchNo    string       `json:"batch_no,omitempty"`
+	SettledAt  int64        `json:"settled_at,omitempty"`
+	CreatedAt  int64        `json:"created_at"`
+}
+
+// MerchantSettleReq 管理员发起结算请求参数
+type MerchantSettleReq struct {
+	MerchantID uint `json:"merchant_id"` // 只结算该商家，为 0 时结算全部有待结算明细的商家
+}
+
+// MerchantSettleResult 一个商家的结算结果
+type MerchantSettleResult struct {
+	MerchantID uint         `json:"merchant_id"`
+	BatchNo    string       `json:"batch_no,omitempty"`
+	Entries    int          `json:"entries"` // 本次结算的明细条数
+	Amount     money.Amount `json:"amount"`  // 记入商家钱包的基础币种金额
+	Currency   string       `json:"currency"`
+	Error      string       `json:"error,omitempty"` // 结算失败的原因，如扣回时店主钱包余额不足
+}
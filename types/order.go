@@ -1,16 +1,21 @@
 // types/order.go
 package types
 
+import "douyin/pkg/utils/money"
+
 // CreateOrderReq 创建订单请求参数
 type CreateOrderReq struct {
 	Items     []OrderItemReq `json:"items" binding:"required,dive"`      // dive validates each item in slice
 	AddressID uint           `json:"address_id" binding:"required,gt=0"` // Assuming AddressID is for shipping
-	// UserCurrency, Email, FirstName, etc. might be associated with AddressID or fetched for the user
+	// 用户货币，为空时使用用户偏好币种或基础币种；Email、姓名等收货信息从 AddressID 对应的地址中获取
+	UserCurrency string `json:"user_currency"`
+	CouponIDs    []uint `json:"coupon_ids" binding:"omitempty,max=5,dive,gt=0"` // 使用的用户优惠券ID（可选）
 }
 
-// OrderItemReq 订单项请求参数
+// OrderItemReq 订单项请求参数，有多个规格的商品必须指定 SkuID，只有默认 SKU 的商品可以只传 ProductID
 type OrderItemReq struct {
-	ProductID uint `json:"product_id" binding:"required,gt=0"`
+	ProductID uint `json:"product_id" binding:"required_without=SkuID,omitempty,gt=0"`
+	SkuID     uint `json:"sku_id" binding:"omitempty,gt=0"`
 	Quantity  int  `json:"quantity" binding:"required,gt=0,lte=100"`
 }
 
@@ -23,5 +28,71 @@ type UpdateOrderReq struct {
 	State         string `json:"state"`                       // 省/州（可选）
 	Country       string `json:"country"`                     // 国家（可选）
 	ZipCode       string `json:"zip_code"`                    // 邮政编码（可选）
-	Status        string `json:"status"`                      // 订单状态（可选）
+	Status        int    `json:"status"`                      // 目标订单状态（可选），取值见 consts.OrderType*
+	SubOrderID    string `json:"sub_order_id"`                // 确认收货的子订单ID（可选），为空时确认全部待收货的子订单
+	Reason        string `json:"reason"`                      // 状态变更原因（可选）
+}
+
+// OrderStatusHistoryResp 订单状态流转记录响应
+type OrderStatusHistoryResp struct {
+	SubOrderID     string `json:"sub_order_id,omitempty"` // 子订单ID，父订单的流转记录为空
+	FromStatus     int    `json:"from_status"`            // 变更前状态
+	FromStatusText string `json:"from_status_text"`       // 变更前状态描述
+	ToStatus       int    `json:"to_status"`              // 变更后状态
+	ToStatusText   string `json:"to_status_text"`         // 变更后状态描述
+	Actor          string `json:"actor"`                  // 操作者类型：user/admin/merchant/system
+	ActorID        uint   `json:"actor_id"`               // 操作者ID
+	Reason         string `json:"reason"`                 // 变更原因
+	CreatedAt      int64  `json:"created_at"`             // 变更时间（Unix 时间戳）
+}
+
+// OrderListReq 订单列表查询请求参数
+type OrderListReq struct {
+	BasePage
+	Status    int    `form:"status" json:"status"`         // 订单状态（可选），取值见 consts.OrderType*
+	StartTime int64  `form:"start_time" json:"start_time"` // 下单时间起（可选，Unix 时间戳，包含）
+	EndTime   int64  `form:"end_time" json:"end_time"`     // 下单时间止（可选，Unix 时间戳，不包含）
+	Keyword   string `form:"keyword" json:"keyword"`       // 订单号或商品名称关键字（可选）
+}
+
+// OrderItemResp 订单项响应，商品名称与图片为下单时的快照
+type OrderItemResp struct {
+	OrderItemID    uint         `json:"order_item_id"`   // 订单项ID，申请售后时使用
+	ProductID      uint         `json:"product_id"`      // 商品ID
+	SkuID          uint         `json:"sku_id"`          // SKU ID
+	SkuSpecs       string       `json:"sku_specs"`       // SKU 规格描述
+	ProductName    string       `json:"product_name"`    // 商品名称
+	ProductPicture string       `json:"product_picture"` // 商品图片
+	Quantity       int32        `json:"quantity"`        // 购买数量
+	Cost           money.Amount `json:"cost"`            // 下单时单价
+	Discount       money.Amount `json:"discount"`        // 该订单项分摊到的优惠总额
+}
+
+// OrderResp 订单响应
+type OrderResp struct {
+	OrderID        string          `json:"order_id"`               // 订单ID
+	Status         int             `json:"status"`                 // 订单状态
+	StatusText     string          `json:"status_text"`            // 订单状态描述
+	UserCurrency   string          `json:"user_currency"`          // 用户货币
+	BaseCurrency   string          `json:"base_currency"`          // 商品定价的基础币种
+	ExchangeRate   money.Rate      `json:"exchange_rate"`          // 下单时的汇率快照
+	TotalAmount    money.Amount    `json:"total_amount"`           // 订单应付金额（已扣除优惠）
+	DiscountAmount money.Amount    `json:"discount_amount"`        // 优惠券抵扣总额
+	Email          string          `json:"email"`                  // 联系邮箱
+	Address        Address         `json:"address"`                // 收货地址
+	CreatedAt      int64           `json:"created_at"`             // 下单时间（Unix 时间戳）
+	Items          []OrderItemResp `json:"items"`                  // 订单项
+	SubOrderID     string          `json:"sub_order_id,omitempty"` // 商家视角的订单为子订单，此时为子订单ID，状态、金额与订单项均为该子订单的
+	SubOrders      []SubOrderResp  `json:"sub_orders,omitempty"`   // 按商家拆分的子订单，只在买家视角返回
+}
+
+// SubOrderResp 子订单响应，每个子订单独立发货、收货与售后
+type SubOrderResp struct {
+	SubOrderID     string          `json:"sub_order_id"`    // 子订单ID
+	MerchantID     uint            `json:"merchant_id"`     // 商家ID，0 表示平台自营
+	Status         int             `json:"status"`          // 子订单状态
+	StatusText     string          `json:"status_text"`     // 子订单状态描述
+	TotalAmount    money.Amount    `json:"total_amount"`    // 子订单实付金额
+	DiscountAmount money.Amount    `json:"discount_amount"` // 子订单分摊到的优惠
+	Items          []OrderItemResp `json:"items"`           // 子订单的订单项
 }
@@ -0,0 +1,24 @@
+package types
+
+import "douyin/pkg/utils/money"
+
+// PayReq 发起支付请求参数
+type PayReq struct {
+	OrderID  string `json:"order_id" binding:"required"` // 订单ID
+	Provider string `json:"provider"`                    // 支付渠道（mock / wallet），为空时使用默认渠道
+}
+
+// PaymentResp 支付单信息
+type PaymentResp struct {
+	TransactionID string       `json:"transaction_id"`
+	OrderID       string       `json:"order_id"`
+	Amount        money.Amount `json:"amount"`
+	Currency      string       `json:"currency"`
+	Provider      string       `json:"provider"`
+	ProviderRef   string       `json:"provider_ref"`
+	Status        string       `json:"status"`
+	FailureReason string       `json:"failure_reason,omitempty"`
+	PayURL        string       `json:"pay_url,omitempty"` // 需要跳转支付渠道完成支付时返回
+	PaidAt        int64        `json:"paid_at,omitempty"`
+	CreatedAt     int64        `json:"created_at"`
+}
@@ -1,19 +1,63 @@
 // types/product.go
 package types
 
+import "douyin/pkg/utils/money"
+
 // 商品
 type Product struct {
-	ID          uint32   `json:"id"`          // 商品ID
-	Name        string   `json:"name"`        // 商品名称
-	Description string   `json:"description"` // 商品描述
-	Picture     string   `json:"picture"`     // 商品图片
-	Price       float64  `json:"price"`       // 商品价格
-	Stock       int      `json:"stock"`       // 商品库存
-	Version     int      `json:"version"`     // 版本号
-	Categories  []string `json:"categories"`  // 商品分类
+	ID          uint32        `json:"id"`                                             // 商品ID
+	Name        string        `json:"name"`                                           // 商品名称
+	Description string        `json:"description"`                                    // 商品描述
+	Picture     string        `json:"picture"`                                        // 商品图片
+	MerchantID  uint          `json:"merchant_id"`                                    // 所属商家ID，0 表示平台自营；创建/修改时忽略
+	Price       money.Amount  `json:"price"`                                          // 商品价格（SKU 最低价），查询时为按展示币种换算后的价格
+	Currency    string        `json:"currency"`                                       // 价格币种
+	Stock       int           `json:"stock"`                                          // 商品库存（SKU 库存之和）
+	Version     int           `json:"version"`                                        // 版本号
+	Categories  []string      `json:"categories"`                                     // 商品分类
+	Specs       []ProductSpec `json:"specs,omitempty" binding:"omitempty,max=5,dive"` // 规格属性，没有规格的商品为空；只在商品详情中返回
+	Skus        []ProductSku  `json:"skus,omitempty" binding:"omitempty,dive"`        // SKU 列表，只在商品详情中返回；创建/修改时为空表示只有一个默认 SKU，价格与库存取 Price 与 Stock
+	// LowStockThreshold 低库存预警阈值，总库存降至该值及以下时发送预警邮件，0 表示不预警；修改时不传表示保持不变，不在查询结果中返回
+	LowStockThreshold *int `json:"low_stock_threshold,omitempty" binding:"omitempty,gte=0"`
+}
+
+// ProductSpec 商品规格属性及其可选值，如「颜色」:「红、蓝」
+type ProductSpec struct {
+	Name   string   `json:"name" binding:"required,max=50"`
+	Values []string `json:"values" binding:"required,min=1,dive,required,max=50"`
+}
+
+// ProductSku 商品 SKU，在每个规格上取一个值，拥有独立的价格、库存与图片
+type ProductSku struct {
+	ID      uint              `json:"id"`                                   // SKU ID，创建/修改时忽略，按规格取值匹配已有 SKU
+	Specs   map[string]string `json:"specs"`                                // 规格名称到取值的映射
+	SpecKey string            `json:"spec_key"`                             // 规格描述，如「颜色:红;尺码:L」，由服务端生成
+	Price   money.Amount      `json:"price" binding:"required"`             // 价格，查询时为按展示币种换算后的价格
+	Stock   int               `json:"stock" binding:"gte=0"`                // 库存
+	Picture string            `json:"picture" binding:"omitempty,max=1000"` // SKU 图片，为空时使用商品图片
 }
 
 // 查询商品请求
 type GetProductReq struct {
-	ID uint32 `json:"id"` // 商品ID
+	ID       uint32 `json:"id"`       // 商品ID
+	Currency string `json:"currency"` // 展示币种（可选），为空时使用用户偏好币种或基础币种
+}
+
+// ProductListReq 商品列表查询请求
+type ProductListReq struct {
+	BasePage
+	Currency   string `form:"currency" json:"currency"`       // 展示币种（可选），为空时使用用户偏好币种或基础币种
+	CategoryID uint   `form:"category_id" json:"category_id"` // 分类筛选（可选），包含该分类的全部子孙分类
+}
+
+// ProductSearchReq 商品搜索请求
+type ProductSearchReq struct {
+	BasePage
+	Keyword    string `form:"keyword" json:"keyword" binding:"max=100"`                                     // 关键词，匹配商品名称和描述
+	MinPrice   string `form:"min_price" json:"min_price"`                                                   // 最低价格（基础币种，如 "9.90"），为空表示不限
+	MaxPrice   string `form:"max_price" json:"max_price"`                                                   // 最高价格（基础币种），为空表示不限
+	CategoryID uint   `form:"category_id" json:"category_id"`                                               // 分类筛选（可选），包含该分类的全部子孙分类
+	InStock    bool   `form:"in_stock" json:"in_stock"`                                                     // 只看有货
+	Sort       string `form:"sort" json:"sort" binding:"omitempty,oneof=price_asc price_desc sales newest"` // 排序方式，为空时有关键词按相关度，否则按上架时间倒序
+	Currency   string `form:"currency" json:"currency"`                                                     // 展示币种（可选），为空时使用用户偏好币种或基础币种
 }
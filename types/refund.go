@@ -0,0 +1,60 @@
+package types
+
+import "douyin/pkg/utils/money"
+
+// RefundItemReq 申请售后的订单项及数量
+type RefundItemReq struct {
+	OrderItemID uint  `json:"order_item_id" binding:"required,gt=0"`
+	Quantity    int32 `json:"quantity" binding:"required,gt=0"`
+}
+
+// RefundApplyReq 申请售后请求参数（multipart/form-data，凭证图片通过 evidence 字段上传）
+type RefundApplyReq struct {
+	OrderID    string          `form:"order_id" binding:"required"`       // 订单ID
+	SubOrderID string          `form:"sub_order_id"`                      // 子订单ID，订单只有一个子订单或已指定退款项时可为空
+	Type       string          `form:"type"`                              // 售后类型：REFUND_ONLY（默认）/ RETURN
+	Reason     string          `form:"reason" binding:"required,max=500"` // 申请原因
+	Items      string          `form:"items"`                             // 退款项 JSON 数组，如 [{"order_item_id":1,"quantity":1}]，为空表示子订单中尚未售后的商品全部退款
+	ItemReq    []RefundItemReq `form:"-"`                                 // 由 Items 解析得到
+}
+
+// RefundReviewReq 管理员审核售后请求参数
+type RefundReviewReq struct {
+	RefundNo       string `json:"refund_no" binding:"required"`
+	Approve        bool   `json:"approve"` // true 通过并退款，false 拒绝
+	Note           string `json:"note" binding:"max=500"`
+	RefundToWallet bool   `json:"refund_to_wallet"` // 不走原支付渠道，直接退回账户余额
+}
+
+// RefundListReq 售后单列表查询参数
+type RefundListReq struct {
+	BasePage
+	Status  string `form:"status"`
+	OrderID string `form:"order_id"`
+}
+
+// RefundItemResp 售后单中的退款项
+type RefundItemResp struct {
+	OrderItemID uint         `json:"order_item_id"`
+	ProductID   uint         `json:"product_id"`
+	SkuID       uint         `json:"sku_id"`
+	Quantity    int32        `json:"quantity"`
+	Amount      money.Amount `json:"amount"`
+}
+
+// RefundResp 售后单信息
+type RefundResp struct {
+	RefundNo     string           `json:"refund_no"`
+	OrderID      string           `json:"order_id"`
+	SubOrderID   string           `json:"sub_order_id"`
+	Type         string           `json:"type"`
+	Reason       string           `json:"reason"`
+	Evidence     []string         `json:"evidence"`
+	Amount       money.Amount     `json:"amount"`
+	Status       string           `json:"status"`
+	ReviewNote   string           `json:"review_note"`
+	RefundMethod string           `json:"refund_method"`
+	CreatedAt    int64            `json:"created_at"`
+	ReviewedAt   int64            `json:"reviewed_at,omitempty"`
+	Items        []RefundItemResp `json:"items"`
+}
@@ -0,0 +1,54 @@
+package types
+
+// ShipOrderReq 商家发货请求参数，发货对象为订单中属于该商家的子订单
+type ShipOrderReq struct {
+	OrderID    string `json:"order_id" binding:"required"`           // 父订单ID
+	Carrier    string `json:"carrier" binding:"required,max=32"`     // 快递公司编码，如 SF、YTO
+	TrackingNo string `json:"tracking_no" binding:"required,max=64"` // 快递单号
+}
+
+// AdminShipOrderReq 管理员发货请求参数，用于平台自营或代商家发货
+type AdminShipOrderReq struct {
+	SubOrderID string `json:"sub_order_id" binding:"required"`       // 子订单ID
+	Carrier    string `json:"carrier" binding:"required,max=32"`     // 快递公司编码
+	TrackingNo string `json:"tracking_no" binding:"required,max=64"` // 快递单号
+}
+
+// ConfirmReceiptReq 买家确认收货请求参数
+type ConfirmReceiptReq struct {
+	OrderID    string `json:"order_id" binding:"required"` // 父订单ID
+	SubOrderID string `json:"sub_order_id"`                // 子订单ID，为空时确认全部待收货的子订单
+	Reason     string `json:"reason" binding:"max=255"`    // 备注
+}
+
+// TrackingEventResp 一条物流轨迹
+type TrackingEventResp struct {
+	Time        int64  `json:"time"`
+	Location    string `json:"location"`
+	Description string `json:"description"`
+}
+
+// ShipmentItemResp 发货单中的订单项
+type ShipmentItemResp struct {
+	OrderItemID uint  `json:"order_item_id"`
+	ProductID   uint  `json:"product_id"`
+	SkuID       uint  `json:"sku_id"`
+	Quantity    int32 `json:"quantity"`
+}
+
+// ShipmentResp 发货单信息
+type ShipmentResp struct {
+	ID            uint                `json:"id"`
+	OrderID       string              `json:"order_id"`
+	SubOrderID    string              `json:"sub_order_id"`
+	MerchantID    uint                `json:"merchant_id"`
+	Carrier       string              `json:"carrier"`
+	TrackingNo    string              `json:"tracking_no"`
+	Status        string              `json:"status"` // 物流状态：SHIPPED / IN_TRANSIT / DELIVERED
+	ShippedAt     int64               `json:"shipped_at"`
+	DeliveredAt   int64               `json:"delivered_at,omitempty"`
+	ReceivedAt    int64               `json:"received_at,omitempty"`
+	AutoConfirmAt int64               `json:"auto_confirm_at"` // 到该时间仍未确认收货时自动确认
+	Events        []TrackingEventResp `json:"events"`
+	Items         []ShipmentItemResp  `json:"items"`
+}
@@ -0,0 +1,22 @@
+package types
+
+// RestockSubscribeReq 订阅到货提醒请求参数，商品只有一个 SKU 时可不传 sku_id
+type RestockSubscribeReq struct {
+	ProductID uint `json:"product_id" binding:"required,gt=0"`
+	SkuID     uint `json:"sku_id"`
+}
+
+// RestockUnsubscribeReq 取消到货提醒请求参数
+type RestockUnsubscribeReq struct {
+	SkuID uint `json:"sku_id" binding:"required,gt=0"`
+}
+
+// RestockSubscriptionResp 到货提醒订阅
+type RestockSubscriptionResp struct {
+	ID         uint   `json:"id"`
+	ProductID  uint   `json:"product_id"`
+	SkuID      uint   `json:"sku_id"`
+	Email      string `json:"email"`       // 提醒发送到的邮箱
+	NotifiedAt int64  `json:"notified_at"` // 到货提醒发送时间，0 表示仍在等待到货
+	CreatedAt  int64  `json:"created_at"`
+}
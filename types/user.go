@@ -16,9 +16,10 @@ type UserRegisterReq struct {
 
 // UserTokenData 用户令牌数据结构，登录成功后返回 token 数据
 type UserTokenData struct {
-	User         interface{} `json:"user"`          // 用户信息对象
-	AccessToken  string      `json:"access_token"`  // 访问令牌
-	RefreshToken string      `json:"refresh_token"` // 刷新令牌
+	User         interface{}    `json:"user"`                 // 用户信息对象
+	AccessToken  string         `json:"access_token"`         // 访问令牌
+	RefreshToken string         `json:"refresh_token"`        // 刷新令牌
+	CartMerge    *CartMergeResp `json:"cart_merge,omitempty"` // 登录时游客购物车的合并结果
 }
 
 // UserLoginReq 用户登录请求结构体
@@ -37,6 +38,7 @@ type UserUpdateReq struct {
 	UserId   uint   `json:"user_id"`                    // 用户ID（必传，用于标识要更新的用户）
 	UserName string `form:"user_name" json:"user_name" binding:"omitempty,min=3,max=20" validate:"omitempty,alphanum"` // 新的用户名
 	Email    string `form:"email" json:"email" binding:"omitempty,email"`         // 新的邮箱
+	Currency string `form:"currency" json:"currency" binding:"omitempty,len=3"`   // 偏好币种（ISO 4217 代码）
 }
 
 // UserInfoShowReq 用户信息展示请求结构体
@@ -51,6 +53,7 @@ type UserIdentityInfo struct {
 	UserName string `json:"user_name"` // 用户名
 	Email    string `json:"email"`     // 用户邮箱
 	Money    string `json:"money"`     // 余额（直接展示，不进行加解密）
+	Currency string `json:"currency"`  // 偏好币种，为空表示使用基础币种
 	CreateAt int64  `json:"create_at"` // 创建时间（Unix 时间戳）
 	UpdateAt int64  `json:"update_at"` // 更新时间（Unix 时间戳）
 }
@@ -0,0 +1,42 @@
+package types
+
+import "douyin/pkg/utils/money"
+
+// WalletTransactionListReq 钱包流水查询参数
+type WalletTransactionListReq struct {
+	BasePage
+	Type string `form:"type"` // 流水类型：TOPUP / PURCHASE / REFUND / ADJUSTMENT，为空查询全部
+}
+
+// WalletTransactionResp 钱包流水
+type WalletTransactionResp struct {
+	TxnNo        string       `json:"txn_no"`
+	Type         string       `json:"type"`
+	Amount       money.Amount `json:"amount"`        // 变动金额，正数入账、负数出账
+	BalanceAfter money.Amount `json:"balance_after"` // 变动后余额
+	RefID        string       `json:"ref_id"`
+	Remark       string       `json:"remark"`
+	CreatedAt    int64        `json:"created_at"`
+}
+
+// WalletTransactionListResp 钱包流水列表，附带当前余额
+type WalletTransactionListResp struct {
+	Balance money.Amount `json:"balance"`
+	DataListResp
+}
+
+// WalletTopUpReq 充值入账请求参数，外部资金（银行转账、线下收款等）到账后由财务登记
+type WalletTopUpReq struct {
+	UserID uint         `json:"user_id" binding:"required,gt=0"`
+	Amount money.Amount `json:"amount" binding:"required,gt=0"`   // 充值金额（基础币种）
+	RefID  string       `json:"ref_id" binding:"required,max=64"` // 外部到账凭证号，同一用户同一凭证只入账一次
+	Remark string       `json:"remark" binding:"max=255"`
+}
+
+// WalletAdjustReq 管理员人工调整余额请求参数
+type WalletAdjustReq struct {
+	UserID uint         `json:"user_id" binding:"required,gt=0"`
+	Amount money.Amount `json:"amount" binding:"required,ne=0"`    // 调整金额，正数增加、负数扣减
+	RefID  string       `json:"ref_id" binding:"max=64"`           // 调整单号（可选）
+	Remark string       `json:"remark" binding:"required,max=200"` // 调整原因，记账时附加操作人
+}